@@ -0,0 +1,350 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/migration"
+)
+
+// Интеграционные тесты поднимают полный HTTP-стек API поверх одноразовых
+// PostgreSQL и Redis:
+//
+//	go test -tags integration ./cmd/api
+//
+// Источники баз перебираются по порядку:
+//   - INTEGRATION_DB_URL / INTEGRATION_REDIS_URL — уже запущенные серверы.
+//     В PostgreSQL создаётся временная база, которая удаляется после тестов;
+//   - локальные initdb/pg_ctl и redis-server — запускаются во временном каталоге;
+//   - docker — контейнеры postgres:16-alpine и redis:7-alpine.
+//
+// Если ничего из этого нет, тесты пропускаются, а с INTEGRATION_REQUIRED=1
+// завершаются ошибкой. INTEGRATION_VERBOSE=1 включает журнал сервисов.
+
+// errNoBackend — не найден ни один способ запустить PostgreSQL или Redis
+var errNoBackend = errors.New("нет PostgreSQL/Redis для интеграционных тестов")
+
+// env — окружение, общее для всех тестов пакета
+var env *integrationEnv
+
+type integrationEnv struct {
+	dbURL    string
+	db       *pgxpool.Pool
+	baseURL  string
+	client   *http.Client
+	grpcAddr string
+	// notifications — файл FileNotifier с сообщениями пользователям
+	notifications string
+
+	cleanup []func()
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	if os.Getenv("INTEGRATION_VERBOSE") == "" {
+		log.SetOutput(io.Discard)
+	}
+
+	// Тесты регистрируют сотни пользователей и выполняют тысячи переводов
+	// с одного адреса, лимиты по умолчанию здесь только мешают
+	for _, name := range []string{"RATE_LIMIT_AUTH", "RATE_LIMIT_READS", "RATE_LIMIT_MONEY", "RATE_LIMIT_PASSWORD_RESET"} {
+		if os.Getenv(name) == "" {
+			os.Setenv(name, "1000000/1s")
+		}
+	}
+
+	e, err := startIntegrationEnv()
+	if e != nil {
+		defer e.close()
+	}
+	if err != nil {
+		if errors.Is(err, errNoBackend) && os.Getenv("INTEGRATION_REQUIRED") == "" {
+			fmt.Fprintf(os.Stderr, "Интеграционные тесты пропущены: %v\n", err)
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Ошибка подготовки окружения: %v\n", err)
+		return 1
+	}
+
+	env = e
+	return m.Run()
+}
+
+func startIntegrationEnv() (*integrationEnv, error) {
+	e := &integrationEnv{}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	adminURL, err := e.startPostgres(ctx)
+	if err != nil {
+		return e, err
+	}
+	if e.dbURL, err = e.createDatabase(ctx, adminURL); err != nil {
+		return e, err
+	}
+
+	redisAddr, err := e.startRedis(ctx)
+	if err != nil {
+		return e, err
+	}
+
+	if err := migration.New(e.dbURL).Up(ctx); err != nil {
+		return e, fmt.Errorf("миграции: %w", err)
+	}
+
+	if e.db, err = pgxpool.New(ctx, e.dbURL); err != nil {
+		return e, fmt.Errorf("подключение к базе данных: %w", err)
+	}
+	e.onCleanup(e.db.Close)
+
+	version, err := migration.CheckVersion(ctx, e.db)
+	if err != nil {
+		return e, err
+	}
+
+	redisCache := cache.NewRedisCache(redisAddr)
+	e.onCleanup(func() { _ = redisCache.Close() })
+	if err := waitFor(ctx, func() error { return redisCache.Ping(ctx) }); err != nil {
+		return e, fmt.Errorf("подключение к Redis: %w", err)
+	}
+
+	// Сообщения пользователям (коды сброса пароля) тесты читают из файла
+	notificationsDir, err := os.MkdirTemp("", "bank-notifications-")
+	if err != nil {
+		return e, err
+	}
+	e.onCleanup(func() { _ = os.RemoveAll(notificationsDir) })
+	e.notifications = filepath.Join(notificationsDir, "messages.jsonl")
+	os.Setenv("NOTIFIER", "file")
+	os.Setenv("NOTIFIER_FILE", e.notifications)
+
+	srv := newServer(e.db, redisCache, version)
+	e.onCleanup(srv.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return e, err
+	}
+	httpServer := &fasthttp.Server{Handler: srv.Handler, Concurrency: 4096}
+	go func() { _ = httpServer.Serve(listener) }()
+	e.onCleanup(func() { _ = httpServer.Shutdown() })
+
+	e.baseURL = "http://" + listener.Addr().String()
+
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return e, err
+	}
+	go func() { _ = srv.GRPC.Serve(grpcListener) }()
+	e.grpcAddr = grpcListener.Addr().String()
+	e.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: 256},
+	}
+	return e, nil
+}
+
+// onCleanup регистрирует действие для close; выполняются в обратном порядке
+func (e *integrationEnv) onCleanup(fn func()) {
+	e.cleanup = append(e.cleanup, fn)
+}
+
+func (e *integrationEnv) close() {
+	for i := len(e.cleanup) - 1; i >= 0; i-- {
+		e.cleanup[i]()
+	}
+}
+
+// startPostgres возвращает строку подключения к серверу, в котором можно
+// создавать базы
+func (e *integrationEnv) startPostgres(ctx context.Context) (string, error) {
+	if dbURL := os.Getenv("INTEGRATION_DB_URL"); dbURL != "" {
+		return dbURL, nil
+	}
+
+	if initdb, err := exec.LookPath("initdb"); err == nil {
+		return e.startLocalPostgres(ctx, filepath.Dir(initdb))
+	}
+
+	if _, err := exec.LookPath("docker"); err == nil {
+		addr, err := e.startContainer(ctx, "5432/tcp",
+			"-e", "POSTGRES_HOST_AUTH_METHOD=trust", "postgres:16-alpine", "-c", "fsync=off")
+		if err != nil {
+			return "", err
+		}
+		return "postgres://postgres@" + addr + "/postgres?sslmode=disable", nil
+	}
+
+	return "", fmt.Errorf("%w: задайте INTEGRATION_DB_URL или установите PostgreSQL либо docker", errNoBackend)
+}
+
+func (e *integrationEnv) startLocalPostgres(ctx context.Context, binDir string) (string, error) {
+	dataDir, err := os.MkdirTemp("", "bank-it-pg-")
+	if err != nil {
+		return "", err
+	}
+	e.onCleanup(func() { _ = os.RemoveAll(dataDir) })
+
+	port, err := freePort()
+	if err != nil {
+		return "", err
+	}
+
+	initdb := exec.CommandContext(ctx, filepath.Join(binDir, "initdb"),
+		"-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync")
+	if out, err := initdb.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("initdb: %w\n%s", err, out)
+	}
+
+	pgCtl := filepath.Join(binDir, "pg_ctl")
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -c fsync=off -c max_connections=200", port, dataDir)
+	start := exec.CommandContext(ctx, pgCtl, "-D", dataDir, "-o", options, "-l", filepath.Join(dataDir, "server.log"), "-w", "start")
+	if out, err := start.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("pg_ctl start: %w\n%s", err, out)
+	}
+	e.onCleanup(func() {
+		_ = exec.Command(pgCtl, "-D", dataDir, "-m", "immediate", "stop").Run()
+	})
+
+	return fmt.Sprintf("postgres://postgres@127.0.0.1:%d/postgres?sslmode=disable", port), nil
+}
+
+// createDatabase создаёт временную базу на сервере adminURL и возвращает
+// строку подключения к ней. База удаляется при close.
+func (e *integrationEnv) createDatabase(ctx context.Context, adminURL string) (string, error) {
+	var admin *pgx.Conn
+	err := waitFor(ctx, func() (err error) {
+		admin, err = pgx.Connect(ctx, adminURL)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("подключение к PostgreSQL: %w", err)
+	}
+	defer admin.Close(context.Background())
+
+	name := "bank_it_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		return "", fmt.Errorf("создание базы %s: %w", name, err)
+	}
+	e.onCleanup(func() {
+		conn, err := pgx.Connect(context.Background(), adminURL)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		_, _ = conn.Exec(context.Background(), "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)")
+	})
+
+	dbURL, err := url.Parse(adminURL)
+	if err != nil {
+		return "", err
+	}
+	dbURL.Path = "/" + name
+	return dbURL.String(), nil
+}
+
+// startRedis возвращает адрес Redis в формате host:port
+func (e *integrationEnv) startRedis(ctx context.Context) (string, error) {
+	if addr := os.Getenv("INTEGRATION_REDIS_URL"); addr != "" {
+		return addr, nil
+	}
+
+	if redisServer, err := exec.LookPath("redis-server"); err == nil {
+		port, err := freePort()
+		if err != nil {
+			return "", err
+		}
+		cmd := exec.Command(redisServer, "--port", fmt.Sprint(port), "--bind", "127.0.0.1",
+			"--save", "", "--appendonly", "no")
+		if err := cmd.Start(); err != nil {
+			return "", fmt.Errorf("redis-server: %w", err)
+		}
+		e.onCleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		})
+		return fmt.Sprintf("127.0.0.1:%d", port), nil
+	}
+
+	if _, err := exec.LookPath("docker"); err == nil {
+		return e.startContainer(ctx, "6379/tcp", "redis:7-alpine")
+	}
+
+	return "", fmt.Errorf("%w: задайте INTEGRATION_REDIS_URL или установите redis-server либо docker", errNoBackend)
+}
+
+// startContainer запускает контейнер с портом port, опубликованным на
+// случайном локальном порту, и возвращает его адрес
+func (e *integrationEnv) startContainer(ctx context.Context, port string, args ...string) (string, error) {
+	runArgs := append([]string{"run", "-d", "--rm", "-p", "127.0.0.1::" + strings.TrimSuffix(port, "/tcp")}, args...)
+	out, err := exec.CommandContext(ctx, "docker", runArgs...).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker run %s: %w", args[len(args)-1], commandError(err))
+	}
+	id := strings.TrimSpace(string(out))
+	e.onCleanup(func() { _ = exec.Command("docker", "rm", "-f", id).Run() })
+
+	out, err = exec.CommandContext(ctx, "docker", "port", id, port).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker port: %w", commandError(err))
+	}
+	// Вывод может содержать и IPv6-адрес, берём первую строку
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return addr, nil
+}
+
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitFor повторяет fn, пока она не выполнится успешно или не истечёт ctx.
+// Серверы в контейнерах принимают подключения не сразу после запуска.
+func waitFor(ctx context.Context, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,1337 @@
+//go:build integration
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+// testPassword удовлетворяет политике паролей (services.ValidatePassword)
+const testPassword = "Integration-Pass-2024!"
+
+// systemAccountID — системный счёт банка, на который зачисляются комиссии
+const systemAccountID = "00000000000001"
+
+type apiUser struct {
+	name  string
+	id    string
+	token string
+}
+
+// call выполняет запрос к API и разбирает JSON-ответ в out (если он не nil)
+func call(t testing.TB, method, path, token string, body, out any) int {
+	t.Helper()
+
+	status, err := doRequest(method, path, token, body, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+// doRequest — call без testing.TB для горутин, где нельзя вызывать t.Fatal
+func doRequest(method, path, token string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("кодирование запроса %s %s: %w", method, path, err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, env.baseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("запрос %s %s: %w", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := env.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("запрос %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("чтение ответа %s %s: %w", method, path, err)
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return 0, fmt.Errorf("разбор ответа %s %s (%d): %w\n%s", method, path, resp.StatusCode, err, data)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// expect выполняет запрос и проверяет код ответа
+func expect(t testing.TB, status int, method, path, token string, body, out any) {
+	t.Helper()
+
+	var raw json.RawMessage
+	if got := call(t, method, path, token, body, &raw); got != status {
+		t.Fatalf("%s %s: код %d, ожидался %d: %s", method, path, got, status, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("разбор ответа %s %s: %v", method, path, err)
+		}
+	}
+}
+
+// expectProblem проверяет ответ об ошибке: код ответа, стабильный код
+// проблемы и идентификатор запроса
+func expectProblem(t testing.TB, status int, code apierror.Code, method, path, token string, body any) {
+	t.Helper()
+
+	var problem apierror.Problem
+	expect(t, status, method, path, token, body, &problem)
+	if problem.Code != code || problem.Status != status {
+		t.Fatalf("%s %s: проблема %q (%d), ожидалась %q", method, path, problem.Code, problem.Status, code)
+	}
+	if problem.RequestID == "" {
+		t.Errorf("%s %s: в ответе нет request_id", method, path)
+	}
+}
+
+// newUser регистрирует пользователя с уникальным именем и входит под ним
+func newUser(t testing.TB, prefix string) *apiUser {
+	t.Helper()
+
+	user := &apiUser{name: prefix + "-" + uuid.NewString()[:8]}
+	credentials := map[string]string{"name": user.name, "password": testPassword}
+	expect(t, http.StatusCreated, "POST", "/v1/register", "", credentials, nil)
+
+	var login struct {
+		Token  string `json:"token"`
+		UserID string `json:"user_id"`
+	}
+	expect(t, http.StatusOK, "POST", "/v1/login", "", credentials, &login)
+	if login.Token == "" {
+		t.Fatalf("вход %s: пустой токен", user.name)
+	}
+
+	user.id = login.UserID
+	user.token = login.Token
+	return user
+}
+
+func (u *apiUser) login(t testing.TB) int {
+	t.Helper()
+	return call(t, "POST", "/v1/login", "", map[string]string{"name": u.name, "password": testPassword}, nil)
+}
+
+func (u *apiUser) createAccount(t testing.TB) string {
+	t.Helper()
+
+	var account struct {
+		ID      string  `json:"id"`
+		Balance float64 `json:"balance"`
+	}
+	expect(t, http.StatusCreated, "POST", "/v1/accounts", u.token, nil, &account)
+	if account.ID == "" {
+		t.Fatalf("создание счёта: пустой ID")
+	}
+	return account.ID
+}
+
+func (u *apiUser) balance(t testing.TB, accountID string) float64 {
+	t.Helper()
+
+	var account struct {
+		Balance float64 `json:"balance"`
+	}
+	expect(t, http.StatusOK, "GET", "/v1/accounts/"+accountID, u.token, nil, &account)
+	return account.Balance
+}
+
+// checkAPIBalance проверяет, что API отдаёт баланс cents. Кеш после
+// операций сбрасывается асинхронно через пул воркеров, поэтому короткое
+// время допускается старое значение.
+func (u *apiUser) checkAPIBalance(t testing.TB, accountID string, cents int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := u.balance(t, accountID)
+		if int64(math.Round(got*100)) == cents {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("счёт %s через API: %.2f, ожидалось %d коп.", accountID, got, cents)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// dbBalance читает баланс в копейках напрямую из базы, минуя кеш API
+func dbBalance(t testing.TB, accountID string) int64 {
+	t.Helper()
+
+	var cents int64
+	err := env.db.QueryRow(context.Background(),
+		`SELECT (balance * 100)::bigint FROM accounts WHERE id = $1`, accountID).Scan(&cents)
+	if err != nil {
+		t.Fatalf("баланс счёта %s: %v", accountID, err)
+	}
+	return cents
+}
+
+// totalMoney — сумма балансов всех счетов банка в копейках
+func totalMoney(t testing.TB) int64 {
+	t.Helper()
+
+	var cents int64
+	err := env.db.QueryRow(context.Background(),
+		`SELECT COALESCE(SUM(balance * 100), 0)::bigint FROM accounts`).Scan(&cents)
+	if err != nil {
+		t.Fatalf("сумма балансов: %v", err)
+	}
+	return cents
+}
+
+// raiseBankLimits снимает потолки банка на время теста
+func raiseBankLimits(t testing.TB) {
+	t.Helper()
+
+	ctx := context.Background()
+	var singleMax, dailyMax, monthlyMax float64
+	var dailyCountMax int
+	err := env.db.QueryRow(ctx, `
+		SELECT single_max, daily_max, monthly_max, daily_count_max
+		FROM spending_limits WHERE scope = 'bank'`).Scan(&singleMax, &dailyMax, &monthlyMax, &dailyCountMax)
+	if err != nil {
+		t.Fatalf("потолки банка: %v", err)
+	}
+
+	if _, err := env.db.Exec(ctx, `
+		UPDATE spending_limits
+		SET single_max = 1000000000, daily_max = 1000000000, monthly_max = 1000000000, daily_count_max = 1000000
+		WHERE scope = 'bank'`); err != nil {
+		t.Fatalf("повышение потолков банка: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := env.db.Exec(ctx, `
+			UPDATE spending_limits
+			SET single_max = $1, daily_max = $2, monthly_max = $3, daily_count_max = $4
+			WHERE scope = 'bank'`, singleMax, dailyMax, monthlyMax, dailyCountMax); err != nil {
+			t.Errorf("восстановление потолков банка: %v", err)
+		}
+	})
+}
+
+func countTransactions(t testing.TB, accountID string) int {
+	t.Helper()
+
+	var count int
+	err := env.db.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM transactions WHERE from_account_id = $1 OR to_account_id = $1`, accountID).Scan(&count)
+	if err != nil {
+		t.Fatalf("число транзакций счёта %s: %v", accountID, err)
+	}
+	return count
+}
+
+func TestHealth(t *testing.T) {
+	expect(t, http.StatusOK, "GET", "/health/live", "", nil, nil)
+	expect(t, http.StatusOK, "GET", "/health/ready", "", nil, nil)
+}
+
+// TestUserJourney проходит весь путь клиента: регистрация, вход, счета,
+// перевод, платёж, история, закрытие счёта и удаление пользователя
+func TestUserJourney(t *testing.T) {
+	alice := newUser(t, "alice")
+	merchant := newUser(t, "merchant")
+
+	current := alice.createAccount(t)
+	savings := alice.createAccount(t)
+	shop := merchant.createAccount(t)
+
+	if got := alice.balance(t, current); got != 100 {
+		t.Fatalf("начальный баланс: %.2f, ожидалось 100.00", got)
+	}
+
+	// Чужой счёт недоступен
+	expectProblem(t, http.StatusForbidden, apierror.CodeAccessDenied, "GET", "/v1/accounts/"+shop, alice.token, nil)
+	expectProblem(t, http.StatusUnauthorized, apierror.CodeUnauthorized, "GET", "/v1/accounts", "", nil)
+
+	systemBefore := dbBalance(t, systemAccountID)
+
+	// Перевод 10.00 между своими счетами: комиссия 1%
+	expect(t, http.StatusCreated, "POST", "/v1/transactions/transfer", alice.token,
+		map[string]any{"from_account_id": current, "to_account_id": savings, "amount": 10}, nil)
+
+	// Платёж 20.00 продавцу: комиссия 3%
+	var payment struct {
+		Transaction struct {
+			ID         string  `json:"id"`
+			FeeAmount  float64 `json:"fee_amount"`
+			TotalDebit float64 `json:"total_debit"`
+		} `json:"transaction"`
+	}
+	expect(t, http.StatusCreated, "POST", "/v1/transactions/payment", alice.token,
+		map[string]any{"from_account_id": current, "to_account_id": shop, "amount": 20}, &payment)
+	if math.Round(payment.Transaction.FeeAmount*100) != 60 || math.Round(payment.Transaction.TotalDebit*100) != 20_60 {
+		t.Fatalf("платёж: комиссия %.2f, списано %.2f", payment.Transaction.FeeAmount, payment.Transaction.TotalDebit)
+	}
+
+	// Перевод сверх остатка отклоняется и ничего не меняет
+	expectProblem(t, http.StatusConflict, apierror.CodeInsufficientBalance, "POST", "/v1/transactions/transfer", alice.token,
+		map[string]any{"from_account_id": current, "to_account_id": savings, "amount": 1000})
+
+	// Балансы через API (с кешем) совпадают с базой
+	checks := []struct {
+		user    *apiUser
+		account string
+		want    int64
+	}{
+		{alice, current, 100_00 - 10_10 - 20_60},
+		{alice, savings, 110_00},
+		{merchant, shop, 120_00},
+	}
+	for _, check := range checks {
+		if got := dbBalance(t, check.account); got != check.want {
+			t.Errorf("счёт %s в базе: %d коп., ожидалось %d", check.account, got, check.want)
+		}
+		check.user.checkAPIBalance(t, check.account, check.want)
+	}
+	if got := dbBalance(t, systemAccountID) - systemBefore; got != 10+60 {
+		t.Errorf("комиссии на системном счёте: %d коп., ожидалось 70", got)
+	}
+
+	var history struct {
+		Transactions []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"transactions"`
+		Total int `json:"total"`
+	}
+	expect(t, http.StatusOK, "GET", "/v1/transactions?account_id="+current, alice.token, nil, &history)
+	if history.Total != 2 || len(history.Transactions) != 2 {
+		t.Fatalf("история счёта: %d записей, ожидалось 2", history.Total)
+	}
+	expect(t, http.StatusOK, "GET", "/v1/transactions/"+payment.Transaction.ID, alice.token, nil, nil)
+
+	// Закрытие счёта переводит остаток на системный счёт
+	systemBefore = dbBalance(t, systemAccountID)
+	expect(t, http.StatusOK, "DELETE", "/v1/accounts/"+savings, alice.token, nil, nil)
+	if got := dbBalance(t, systemAccountID) - systemBefore; got != 110_00 {
+		t.Errorf("остаток закрытого счёта на системном счёте: %d коп., ожидалось 11000", got)
+	}
+	expectProblem(t, http.StatusConflict, apierror.CodeAccountClosed, "POST", "/v1/transactions/transfer", alice.token,
+		map[string]any{"from_account_id": savings, "to_account_id": current, "amount": 1})
+
+	deleteUserWithHistory(t, alice, current, merchant, shop)
+}
+
+// deleteUserWithHistory удаляет пользователя, у которого есть транзакции,
+// с выплатой остатка на счёт payee. История операций — учётные данные банка
+// и должна пережить удаление.
+func deleteUserWithHistory(t *testing.T, user *apiUser, accountID string, payee *apiUser, payoutAccountID string) {
+	t.Helper()
+
+	before := countTransactions(t, accountID)
+	remaining := dbBalance(t, accountID)
+	payeeBefore := dbBalance(t, payoutAccountID)
+
+	var deleted struct {
+		Payouts []struct {
+			Type   string  `json:"type"`
+			Amount float64 `json:"amount"`
+		} `json:"payouts"`
+	}
+	expect(t, http.StatusOK, "DELETE", "/v1/users/me", user.token,
+		map[string]string{"payout_account_id": payoutAccountID}, &deleted)
+	if len(deleted.Payouts) != 1 || deleted.Payouts[0].Type != "payout" || int64(math.Round(deleted.Payouts[0].Amount*100)) != remaining {
+		t.Errorf("выплаты при удалении: %+v, ожидался перевод %d коп.", deleted.Payouts, remaining)
+	}
+
+	// Транзакции остаются, к ним добавляется выплата остатка
+	if got := countTransactions(t, accountID); got != before+1 {
+		t.Fatalf("после удаления пользователя %d транзакций, ожидалось %d", got, before+1)
+	}
+	if got := dbBalance(t, accountID); got != 0 {
+		t.Errorf("баланс закрытого счёта: %d коп., ожидался 0", got)
+	}
+	payee.checkAPIBalance(t, payoutAccountID, payeeBefore+remaining)
+
+	if status := user.login(t); status != http.StatusUnauthorized {
+		t.Errorf("вход удалённого пользователя: код %d, ожидался 401", status)
+	}
+	expectProblem(t, http.StatusUnauthorized, apierror.CodeInvalidToken, "GET", "/v1/accounts", user.token, nil)
+}
+
+// TestErrorResponses проверяет модель ошибок: problem+json со стабильным
+// кодом, идентификатор запроса, совпадающий с заголовком X-Request-ID, и
+// язык сообщений по Accept-Language
+func TestErrorResponses(t *testing.T) {
+	user := newUser(t, "errors")
+
+	expectProblem(t, http.StatusUnauthorized, apierror.CodeInvalidToken, "GET", "/v1/accounts", "not-a-jwt", nil)
+	expectProblem(t, http.StatusNotFound, apierror.CodeRouteNotFound, "GET", "/no-such-route", "", nil)
+	expectProblem(t, http.StatusBadRequest, apierror.CodeValidationFailed, "GET", "/v1/transactions/not-a-uuid", user.token, nil)
+	expectProblem(t, http.StatusNotFound, apierror.CodeTransactionNotFound, "GET", "/v1/transactions/"+uuid.NewString(), user.token, nil)
+	expectProblem(t, http.StatusUnauthorized, apierror.CodeInvalidCredentials, "POST", "/v1/login", "",
+		map[string]string{"name": user.name, "password": "wrong-" + testPassword})
+
+	req, err := http.NewRequest("GET", env.baseURL+"/v1/accounts/00000000000000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.token)
+	req.Header.Set("X-Request-ID", "integration-request-42")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,ru;q=0.5")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var problem apierror.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("разбор ответа: %v", err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != apierror.ContentType {
+		t.Errorf("Content-Type: %q, ожидался %q", ct, apierror.ContentType)
+	}
+	if got := resp.Header.Get("X-Request-ID"); got != "integration-request-42" || problem.RequestID != got {
+		t.Errorf("X-Request-ID: заголовок %q, в теле %q", got, problem.RequestID)
+	}
+	if problem.Instance != "/v1/accounts/00000000000000" {
+		t.Errorf("instance: %q", problem.Instance)
+	}
+	if problem.Code != apierror.CodeAccountNotFound || problem.Title != "Account not found" || resp.Header.Get("Content-Language") != "en" {
+		t.Errorf("ответ на английском: код %q, заголовок %q, Content-Language %q",
+			problem.Code, problem.Title, resp.Header.Get("Content-Language"))
+	}
+}
+
+// TestAPIVersions проверяет версии API: прежние пути без версии работают
+// как /v1 и помечены устаревшими, /v2 отдаёт суммы строками
+func TestAPIVersions(t *testing.T) {
+	user := newUser(t, "versions")
+	from := user.createAccount(t)
+	to := user.createAccount(t)
+
+	req, err := http.NewRequest("GET", env.baseURL+"/accounts/"+from, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /accounts/{id} без версии: код %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Deprecation") == "" || resp.Header.Get("Sunset") == "" {
+		t.Errorf("нет заголовков Deprecation/Sunset: %v", resp.Header)
+	}
+	if link := resp.Header.Get("Link"); link != "</v1/accounts/"+from+">; rel=\"successor-version\"" {
+		t.Errorf("Link: %q", link)
+	}
+
+	var account struct {
+		Balance          string `json:"balance"`
+		AvailableBalance string `json:"available_balance"`
+	}
+	expect(t, http.StatusOK, "GET", "/v2/accounts/"+to, user.token, nil, &account)
+	if account.Balance != "100.00" || account.AvailableBalance != "100.00" {
+		t.Errorf("счёт v2: %+v", account)
+	}
+
+	var transfer struct {
+		Transaction struct {
+			Amount     string `json:"amount"`
+			FeeAmount  string `json:"fee_amount"`
+			TotalDebit string `json:"total_debit"`
+		} `json:"transaction"`
+	}
+	expect(t, http.StatusCreated, "POST", "/v2/transactions/transfer", user.token,
+		map[string]any{"from_account_id": from, "to_account_id": to, "amount": "20.00"}, &transfer)
+	if got := transfer.Transaction; got.Amount != "20.00" || got.FeeAmount != "0.20" || got.TotalDebit != "20.20" {
+		t.Errorf("перевод v2: %+v", got)
+	}
+	expectProblem(t, http.StatusBadRequest, apierror.CodeValidationFailed, "POST", "/v2/transactions/transfer", user.token,
+		map[string]any{"from_account_id": from, "to_account_id": to, "amount": 20})
+	user.checkAPIBalance(t, from, 100_00-20_20)
+}
+
+// TestGRPC проверяет, что gRPC API работает с теми же данными, что и HTTP:
+// счета из HTTP видны в gRPC, а перевод через gRPC — в балансе HTTP API
+func TestGRPC(t *testing.T) {
+	conn, err := grpc.NewClient(env.grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	user := newUser(t, "grpc")
+	from := user.createAccount(t)
+	to := user.createAccount(t)
+
+	login, err := bankv1.NewAuthServiceClient(conn).Login(context.Background(), &bankv1.LoginRequest{Name: user.name, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+login.GetToken())
+
+	accounts, err := bankv1.NewAccountServiceClient(conn).ListAccounts(ctx, &bankv1.ListAccountsRequest{})
+	if err != nil {
+		t.Fatalf("ListAccounts: %v", err)
+	}
+	if len(accounts.GetAccounts()) != 2 {
+		t.Errorf("счета через gRPC: %v", accounts.GetAccounts())
+	}
+
+	transactions := bankv1.NewTransactionServiceClient(conn)
+	transfer, err := transactions.Transfer(ctx, &bankv1.TransferRequest{FromAccountId: from, ToAccountId: to, Amount: "30.00"})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if transfer.GetTotalDebit() != "30.30" {
+		t.Errorf("перевод через gRPC: %v", transfer)
+	}
+	user.checkAPIBalance(t, from, 100_00-30_30)
+
+	_, err = transactions.Transfer(ctx, &bankv1.TransferRequest{FromAccountId: from, ToAccountId: to, Amount: "1000.00"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("перевод сверх баланса: %v, ожидался FailedPrecondition", err)
+	}
+
+	stream, err := transactions.StreamHistory(ctx, &bankv1.StreamHistoryRequest{AccountId: to})
+	if err != nil {
+		t.Fatalf("StreamHistory: %v", err)
+	}
+	var received int
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if item.GetId() != transfer.GetId() {
+			t.Errorf("лишняя транзакция в истории: %v", item)
+		}
+		received++
+	}
+	if received != 1 {
+		t.Errorf("история через gRPC: %d транзакций, ожидалась 1", received)
+	}
+}
+
+// sseEvent — событие потока GET /v1/events
+type sseEvent struct {
+	ID   string
+	Type string
+	Data struct {
+		Transaction *struct {
+			ID     string `json:"id"`
+			Amount string `json:"amount"`
+		} `json:"transaction"`
+		Balance *struct {
+			AccountID string `json:"account_id"`
+			Balance   string `json:"balance"`
+		} `json:"balance"`
+	}
+}
+
+// openEvents подключается к потоку событий и возвращает канал событий.
+// Возврат происходит после строки retry: подписка уже зарегистрирована.
+func openEvents(t *testing.T, token, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", env.baseURL+"/v1/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		resp.Body.Close()
+		t.Fatalf("GET /v1/events: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	if line, err := reader.ReadString('\n'); err != nil || !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("начало потока: %q, %v", line, err)
+	}
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		var event sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "" && event.ID != "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("поток событий закрыт")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("нет события за 5 секунд")
+	}
+	return sseEvent{}
+}
+
+// TestEventStream проверяет события о входящем переводе и возобновление
+// потока по Last-Event-ID после обрыва
+func TestEventStream(t *testing.T) {
+	alice := newUser(t, "events-alice")
+	bob := newUser(t, "events-bob")
+	from := alice.createAccount(t)
+	to := bob.createAccount(t)
+
+	transfer := func(amount float64) string {
+		var result struct {
+			Transaction struct {
+				ID string `json:"id"`
+			} `json:"transaction"`
+		}
+		expect(t, http.StatusCreated, "POST", "/v1/transactions/transfer", alice.token,
+			map[string]any{"from_account_id": from, "to_account_id": to, "amount": amount}, &result)
+		return result.Transaction.ID
+	}
+
+	stream := openEvents(t, bob.token, "")
+	first := transfer(10)
+
+	completed := nextEvent(t, stream)
+	if completed.Type != "transaction.completed" || completed.Data.Transaction == nil || completed.Data.Transaction.ID != first {
+		t.Fatalf("первое событие: %+v", completed)
+	}
+	balance := nextEvent(t, stream)
+	if balance.Type != "balance.changed" || balance.Data.Balance == nil || balance.Data.Balance.Balance != "110.00" {
+		t.Fatalf("событие баланса: %+v", balance)
+	}
+
+	// Пока клиент отключён, приходит ещё один перевод
+	second := transfer(5)
+	resumed := openEvents(t, bob.token, balance.ID)
+
+	completed = nextEvent(t, resumed)
+	if completed.Data.Transaction == nil || completed.Data.Transaction.ID != second {
+		t.Fatalf("после возобновления: %+v, ожидался перевод %s", completed, second)
+	}
+	if balance := nextEvent(t, resumed); balance.Data.Balance == nil || balance.Data.Balance.Balance != "115.00" {
+		t.Fatalf("баланс после возобновления: %+v", balance)
+	}
+}
+
+// TestUserProfile проверяет профиль, уникальность контактов и смену
+// пароля, которая завершает остальные сессии
+func TestUserProfile(t *testing.T) {
+	alice := newUser(t, "profile-alice")
+	bob := newUser(t, "profile-bob")
+
+	expectProblem(t, http.StatusBadRequest, apierror.CodeWeakPassword, "POST", "/v1/register", "",
+		map[string]string{"name": "weak-" + uuid.NewString()[:8], "password": "Qwerty2024!"})
+
+	type profile struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"display_name"`
+		Email       string `json:"email"`
+		Phone       string `json:"phone"`
+	}
+	var got profile
+	expect(t, http.StatusOK, "GET", "/v1/users/me", alice.token, nil, &got)
+	if got.ID != alice.id || got.Name != alice.name || got.Email != "" {
+		t.Fatalf("профиль: %+v", got)
+	}
+
+	email := alice.name + "@Example.com"
+	expect(t, http.StatusOK, "PATCH", "/v1/users/me", alice.token,
+		map[string]any{"display_name": "Алиса", "email": email, "phone": "+7 916 000-00-01"}, &got)
+	if got.DisplayName != "Алиса" || got.Email != strings.ToLower(email) || got.Phone != "+79160000001" {
+		t.Fatalf("профиль после изменения: %+v", got)
+	}
+	expectProblem(t, http.StatusConflict, apierror.CodeEmailTaken, "PATCH", "/v1/users/me", bob.token,
+		map[string]any{"email": strings.ToUpper(email)})
+	expectProblem(t, http.StatusBadRequest, apierror.CodeInvalidPhone, "PATCH", "/v1/users/me", bob.token,
+		map[string]any{"phone": "8-916-000-00-01"})
+
+	// Вторая сессия alice, например на другом устройстве
+	var other struct {
+		Token string `json:"token"`
+	}
+	expect(t, http.StatusOK, "POST", "/v1/login", "", map[string]string{"name": alice.name, "password": testPassword}, &other)
+
+	const newPassword = "Changed-Pass-2025?"
+	expectProblem(t, http.StatusForbidden, apierror.CodeWrongPassword, "POST", "/v1/users/me/password", alice.token,
+		map[string]string{"current_password": "wrong-" + testPassword, "new_password": newPassword})
+	expectProblem(t, http.StatusBadRequest, apierror.CodeWeakPassword, "POST", "/v1/users/me/password", alice.token,
+		map[string]string{"current_password": testPassword, "new_password": "password2025"})
+
+	var changed struct {
+		Token string `json:"token"`
+	}
+	expect(t, http.StatusOK, "POST", "/v1/users/me/password", alice.token,
+		map[string]string{"current_password": testPassword, "new_password": newPassword}, &changed)
+
+	for _, token := range []string{alice.token, other.Token} {
+		expectProblem(t, http.StatusUnauthorized, apierror.CodeInvalidToken, "GET", "/v1/users/me", token, nil)
+	}
+	expect(t, http.StatusOK, "GET", "/v1/users/me", changed.Token, nil, &got)
+	expect(t, http.StatusOK, "POST", "/v1/login", "", map[string]string{"name": alice.name, "password": newPassword}, nil)
+	if status := alice.login(t); status != http.StatusUnauthorized {
+		t.Errorf("вход со старым паролем: %d, ожидался 401", status)
+	}
+}
+
+// resetCode ждёт сообщение со сбросом пароля для userID в файле
+// уведомлений и возвращает код из него
+func resetCode(t *testing.T, userID string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(env.notifications)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			var msg struct {
+				UserID string `json:"user_id"`
+				Body   string `json:"body"`
+			}
+			if json.Unmarshal([]byte(lines[i]), &msg) != nil || msg.UserID != userID {
+				continue
+			}
+			// Код стоит в тексте отдельной строкой
+			for _, line := range strings.Split(msg.Body, "\n") {
+				if len(line) == 43 && !strings.Contains(line, " ") {
+					return line
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("сообщение со сбросом пароля для %s не получено", userID)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	user := newUser(t, "reset")
+
+	// Ответ для неизвестного пользователя не отличается от ответа для известного
+	var unknown, known struct {
+		Message string `json:"message"`
+	}
+	expect(t, http.StatusAccepted, "POST", "/v1/auth/password-reset/request", "",
+		map[string]string{"login": "nobody-" + uuid.NewString()[:8]}, &unknown)
+	expect(t, http.StatusAccepted, "POST", "/v1/auth/password-reset/request", "",
+		map[string]string{"login": user.name}, &known)
+	if unknown.Message != known.Message {
+		t.Errorf("ответы различаются: %q и %q", unknown.Message, known.Message)
+	}
+
+	code := resetCode(t, user.id)
+	const newPassword = "Reset-Pass-2025?"
+	expectProblem(t, http.StatusBadRequest, apierror.CodeInvalidResetToken, "POST", "/v1/auth/password-reset/confirm", "",
+		map[string]string{"token": strings.Repeat("A", len(code)), "new_password": newPassword})
+	expectProblem(t, http.StatusBadRequest, apierror.CodeWeakPassword, "POST", "/v1/auth/password-reset/confirm", "",
+		map[string]string{"token": code, "new_password": "password2025"})
+	expect(t, http.StatusOK, "POST", "/v1/auth/password-reset/confirm", "",
+		map[string]string{"token": code, "new_password": newPassword}, nil)
+
+	// Код одноразовый, прежние JWT и пароль больше не действуют
+	expectProblem(t, http.StatusBadRequest, apierror.CodeInvalidResetToken, "POST", "/v1/auth/password-reset/confirm", "",
+		map[string]string{"token": code, "new_password": "Another-Pass-2026?"})
+	expectProblem(t, http.StatusUnauthorized, apierror.CodeInvalidToken, "GET", "/v1/users/me", user.token, nil)
+	if status := user.login(t); status != http.StatusUnauthorized {
+		t.Errorf("вход со старым паролем: %d, ожидался 401", status)
+	}
+	expect(t, http.StatusOK, "POST", "/v1/login", "", map[string]string{"name": user.name, "password": newPassword}, nil)
+}
+
+type notificationList struct {
+	Notifications []struct {
+		ID      string `json:"id"`
+		Kind    string `json:"kind"`
+		Subject string `json:"subject"`
+	} `json:"notifications"`
+	Total  int `json:"total"`
+	Unread int `json:"unread"`
+}
+
+// waitNotifications ждёт, пока во входящих пользователя появится count
+// уведомлений: они доставляются асинхронно через пул воркеров
+func waitNotifications(t *testing.T, user *apiUser, count int) notificationList {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var list notificationList
+		expect(t, http.StatusOK, "GET", "/v1/notifications", user.token, nil, &list)
+		if list.Total >= count {
+			if list.Total > count {
+				t.Fatalf("уведомлений %s: %d, ожидалось %d", user.name, list.Total, count)
+			}
+			return list
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("уведомлений %s: %d, ожидалось %d", user.name, list.Total, count)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	alice := newUser(t, "notify-alice")
+	bob := newUser(t, "notify-bob")
+	aliceAccount := alice.createAccount(t)
+	bobAccount := bob.createAccount(t)
+
+	var preferences struct {
+		EmailEnabled        bool    `json:"email_enabled"`
+		IncomingTransfer    bool    `json:"incoming_transfer"`
+		LowBalanceThreshold float64 `json:"low_balance_threshold"`
+		Language            string  `json:"language"`
+	}
+	expect(t, http.StatusOK, "GET", "/v1/users/me/notification-preferences", alice.token, nil, &preferences)
+	if !preferences.EmailEnabled || !preferences.IncomingTransfer || preferences.LowBalanceThreshold != 10 || preferences.Language != "ru" {
+		t.Fatalf("настройки по умолчанию: %+v", preferences)
+	}
+	expectProblem(t, http.StatusBadRequest, apierror.CodeValidationFailed, "PATCH", "/v1/users/me/notification-preferences", alice.token,
+		map[string]any{"language": "de"})
+	expect(t, http.StatusOK, "PATCH", "/v1/users/me/notification-preferences", alice.token,
+		map[string]any{"low_balance_threshold": 60}, &preferences)
+	if preferences.LowBalanceThreshold != 60 || !preferences.EmailEnabled {
+		t.Fatalf("настройки после изменения: %+v", preferences)
+	}
+	expect(t, http.StatusOK, "PATCH", "/v1/users/me/notification-preferences", bob.token,
+		map[string]any{"language": "en"}, nil)
+
+	// Остаток alice опускается до 49.50 — ниже порога, bob получает поступление
+	expect(t, http.StatusCreated, "POST", "/v1/transactions/transfer", alice.token,
+		map[string]any{"from_account_id": aliceAccount, "to_account_id": bobAccount, "amount": 50}, nil)
+
+	bobInbox := waitNotifications(t, bob, 1)
+	if bobInbox.Notifications[0].Kind != "incoming_transfer" || bobInbox.Notifications[0].Subject != "Incoming transfer of 50.00" {
+		t.Errorf("уведомление bob: %+v", bobInbox.Notifications[0])
+	}
+	aliceInbox := waitNotifications(t, alice, 1)
+	if aliceInbox.Notifications[0].Kind != "low_balance" || aliceInbox.Unread != 1 {
+		t.Errorf("входящие alice: %+v", aliceInbox)
+	}
+
+	// Уведомление видно только владельцу
+	expectProblem(t, http.StatusNotFound, apierror.CodeNotificationNotFound, "POST",
+		"/v1/notifications/"+aliceInbox.Notifications[0].ID+"/read", bob.token, nil)
+	expect(t, http.StatusOK, "POST", "/v1/notifications/"+aliceInbox.Notifications[0].ID+"/read", alice.token, nil, nil)
+	var unread notificationList
+	expect(t, http.StatusOK, "GET", "/v1/notifications?unread=true", alice.token, nil, &unread)
+	if unread.Total != 0 || unread.Unread != 0 {
+		t.Errorf("непрочитанные alice: %+v", unread)
+	}
+
+	var marked struct {
+		Marked int `json:"marked"`
+	}
+	expect(t, http.StatusOK, "POST", "/v1/notifications/read-all", bob.token, nil, &marked)
+	if marked.Marked != 1 {
+		t.Errorf("отмечено прочитанными: %d, ожидалось 1", marked.Marked)
+	}
+
+	// Пользователю с адресом письмо уходит через почтовый канал (файл в тестах)
+	email := alice.name + "@example.com"
+	expect(t, http.StatusOK, "PATCH", "/v1/users/me", bob.token, map[string]any{"email": "b" + email}, nil)
+	expect(t, http.StatusCreated, "POST", "/v1/transactions/transfer", alice.token,
+		map[string]any{"from_account_id": aliceAccount, "to_account_id": bobAccount, "amount": 1}, nil)
+	waitNotifications(t, bob, 2)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, err := os.ReadFile(env.notifications)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatal(err)
+		}
+		delivered := false
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, `"kind":"incoming_transfer"`) && strings.Contains(line, `"email":"b`+email+`"`) {
+				delivered = true
+			}
+		}
+		if delivered {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("письмо о поступлении не отправлено")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestDeleteUserWithoutTransactions(t *testing.T) {
+	user := newUser(t, "short-lived")
+	account := user.createAccount(t)
+
+	// Остаток без счёта выплаты не даёт удалить пользователя
+	expectProblem(t, http.StatusConflict, apierror.CodeBalanceNotZero, "DELETE", "/v1/users/me", user.token, nil)
+
+	// Закрытый счёт отдаёт остаток банку, после чего удаление проходит
+	expect(t, http.StatusOK, "DELETE", "/v1/accounts/"+account, user.token, nil, nil)
+	expect(t, http.StatusOK, "DELETE", "/v1/users/me", user.token, nil, nil)
+
+	if status := user.login(t); status != http.StatusUnauthorized {
+		t.Fatalf("вход удалённого пользователя: код %d, ожидался 401", status)
+	}
+}
+
+// TestConcurrentTransfersConserveMoney выполняет тысячи параллельных
+// переводов между счетами разных пользователей, в том числе встречных.
+// Деньги не появляются и не исчезают: сумма балансов всех счетов, включая
+// системный с комиссиями, не меняется, и ни один баланс не уходит в минус.
+func TestConcurrentTransfersConserveMoney(t *testing.T) {
+	const (
+		users           = 10
+		accountsPerUser = 3
+		transfers       = 3000
+		workers         = 64
+	)
+
+	type account struct {
+		owner *apiUser
+		id    string
+	}
+	var accounts []account
+	for i := 0; i < users; i++ {
+		user := newUser(t, fmt.Sprintf("concurrent-%d", i))
+		for j := 0; j < accountsPerUser; j++ {
+			accounts = append(accounts, account{owner: user, id: user.createAccount(t)})
+		}
+	}
+
+	// Потолок банка — 50 операций в сутки на пользователя: с ним большинство
+	// из тысяч переводов отклонялось бы лимитом, а не нехваткой средств
+	raiseBankLimits(t)
+
+	totalBefore := totalMoney(t)
+
+	jobs := make(chan int)
+	var succeeded, rejected atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, seed))
+
+			for range jobs {
+				from := accounts[rng.IntN(len(accounts))]
+				to := accounts[rng.IntN(len(accounts))]
+				for to.id == from.id {
+					to = accounts[rng.IntN(len(accounts))]
+				}
+				// Целые суммы: комиссия 1% выражается в целых копейках
+				body := map[string]any{"from_account_id": from.id, "to_account_id": to.id, "amount": 1 + rng.IntN(15)}
+
+				var problem apierror.Problem
+				status, err := doRequest("POST", "/v1/transactions/transfer", from.owner.token, body, &problem)
+				switch {
+				case err != nil:
+					t.Error(err)
+				case status == http.StatusCreated:
+					succeeded.Add(1)
+				case status == http.StatusConflict && problem.Code == apierror.CodeInsufficientBalance:
+					// Недостаточно средств — единственный допустимый отказ
+					rejected.Add(1)
+				default:
+					t.Errorf("перевод %s -> %s: код %d, проблема %q", from.id, to.id, status, problem.Code)
+				}
+			}
+		}(uint64(w))
+	}
+	for i := 0; i < transfers; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	t.Logf("переводов: %d выполнено, %d отклонено", succeeded.Load(), rejected.Load())
+	if succeeded.Load() == 0 {
+		t.Fatal("ни один перевод не выполнен")
+	}
+
+	if totalAfter := totalMoney(t); totalAfter != totalBefore {
+		t.Fatalf("сумма балансов изменилась: было %d коп., стало %d коп.", totalBefore, totalAfter)
+	}
+
+	ids := make([]string, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.id
+	}
+	var completed int64
+	err := env.db.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM transactions WHERE type = 'transfer' AND from_account_id = ANY($1)`,
+		ids).Scan(&completed)
+	if err != nil {
+		t.Fatalf("число переводов: %v", err)
+	}
+	if completed != succeeded.Load() {
+		t.Errorf("в базе %d переводов, API подтвердил %d", completed, succeeded.Load())
+	}
+
+	for _, a := range accounts {
+		cents := dbBalance(t, a.id)
+		if cents < 0 {
+			t.Errorf("отрицательный баланс счёта %s: %d коп.", a.id, cents)
+		}
+		// Кеш инвалидирован: API отдаёт актуальный баланс
+		a.owner.checkAPIBalance(t, a.id, cents)
+	}
+}
+
+// authorize создаёт авторизацию через API и возвращает её ID
+func (u *apiUser) authorize(t testing.TB, from, to string, amount float64) string {
+	t.Helper()
+
+	var auth struct {
+		ID string `json:"id"`
+	}
+	expect(t, http.StatusCreated, "POST", "/v1/authorizations", u.token,
+		map[string]any{"from_account_id": from, "to_account_id": to, "amount": amount}, &auth)
+	return auth.ID
+}
+
+// dbHeld читает холд счёта в копейках напрямую из базы
+func dbHeld(t testing.TB, accountID string) int64 {
+	t.Helper()
+
+	var cents int64
+	err := env.db.QueryRow(context.Background(),
+		`SELECT (held_amount * 100)::bigint FROM accounts WHERE id = $1`, accountID).Scan(&cents)
+	if err != nil {
+		t.Fatalf("холд счёта %s: %v", accountID, err)
+	}
+	return cents
+}
+
+func authorizationStatus(t testing.TB, id string) string {
+	t.Helper()
+
+	var status string
+	err := env.db.QueryRow(context.Background(),
+		`SELECT status FROM authorizations WHERE id = $1`, id).Scan(&status)
+	if err != nil {
+		t.Fatalf("статус авторизации %s: %v", id, err)
+	}
+	return status
+}
+
+// TestAuthorizationExpirySkipsLocked проверяет, что снятие просроченных
+// холдов не ждёт авторизацию, заблокированную параллельным capture или
+// release, а обрабатывает её при следующем проходе. Фоновое снятие сервера
+// может успеть раньше теста, поэтому итог проверяется по базе.
+func TestAuthorizationExpirySkipsLocked(t *testing.T) {
+	ctx := context.Background()
+	payer := newUser(t, "expiry-payer")
+	shop := newUser(t, "expiry-shop")
+	from := payer.createAccount(t)
+	to := shop.createAccount(t)
+
+	locked := payer.authorize(t, from, to, 20)
+	free := payer.authorize(t, from, to, 10)
+	if held := dbHeld(t, from); held != 3090 {
+		t.Fatalf("холд до истечения: %d коп., ожидалось 3090", held)
+	}
+
+	if _, err := env.db.Exec(ctx,
+		`UPDATE authorizations SET expires_at = NOW() - INTERVAL '1 second' WHERE id = ANY($1)`,
+		[]string{locked, free}); err != nil {
+		t.Fatalf("сдвиг срока действия: %v", err)
+	}
+
+	expectProblem(t, http.StatusConflict, apierror.CodeAuthorizationExpired,
+		"POST", "/v1/authorizations/"+free+"/capture", shop.token, nil)
+
+	// Держим блокировку, как её держал бы capture в соседнем экземпляре API
+	tx, err := env.db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("начало транзакции: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `SELECT id FROM authorizations WHERE id = $1 FOR UPDATE`, locked); err != nil {
+		t.Fatalf("блокировка авторизации: %v", err)
+	}
+
+	repo := repository.NewAuthorizationRepository(env.db)
+	expireCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	expired, err := repo.ExpireDue(expireCtx, 100)
+	if err != nil {
+		t.Fatalf("ExpireDue при заблокированной авторизации: %v", err)
+	}
+	for _, a := range expired {
+		if a.ID == locked {
+			t.Errorf("заблокированная авторизация %s снята", locked)
+		}
+	}
+
+	if status := authorizationStatus(t, free); status != "expired" {
+		t.Errorf("свободная авторизация: статус %s, ожидался expired", status)
+	}
+	if status := authorizationStatus(t, locked); status != "active" {
+		t.Errorf("заблокированная авторизация: статус %s, ожидался active", status)
+	}
+	if held := dbHeld(t, from); held != 2060 {
+		t.Errorf("холд после первого прохода: %d коп., ожидалось 2060", held)
+	}
+
+	if err := tx.Rollback(ctx); err != nil {
+		t.Fatalf("снятие блокировки: %v", err)
+	}
+	if _, err := repo.ExpireDue(ctx, 100); err != nil {
+		t.Fatalf("ExpireDue: %v", err)
+	}
+
+	if status := authorizationStatus(t, locked); status != "expired" {
+		t.Errorf("авторизация после снятия блокировки: статус %s, ожидался expired", status)
+	}
+	if held := dbHeld(t, from); held != 0 {
+		t.Errorf("холд после второго прохода: %d коп., ожидалось 0", held)
+	}
+	if cents := dbBalance(t, from); cents != 10000 {
+		t.Errorf("баланс плательщика: %d коп., ожидалось 10000", cents)
+	}
+}
+
+// TestSpendingUsageUTCDay проверяет, что сутки и месяц лимитов считаются по
+// UTC, даже если часовой пояс сессии БД другой. Переводы вставляются прямо в
+// базу за секунду до начала суток UTC и ровно в их начало; балансы меняются
+// вместе с ними, чтобы сверка сходилась.
+func TestSpendingUsageUTCDay(t *testing.T) {
+	ctx := context.Background()
+	user := newUser(t, "limits-utc")
+	from := user.createAccount(t)
+	to := user.createAccount(t)
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	transfers := []struct {
+		amount    float64
+		createdAt time.Time
+	}{
+		{7, dayStart.Add(-time.Second)},
+		{11, dayStart},
+	}
+	for _, transfer := range transfers {
+		if _, err := env.db.Exec(ctx, `
+			INSERT INTO transactions (
+				id, type, from_account_id, to_account_id,
+				amount, fee_percent, fee_amount, total_debit,
+				fee_account_id, status, created_at
+			) VALUES ($1, 'transfer', $2, $3, $4, 0, 0, $4, $5, 'completed', $6)`,
+			uuid.NewString(), from, to, transfer.amount, systemAccountID, transfer.createdAt); err != nil {
+			t.Fatalf("вставка перевода: %v", err)
+		}
+		if _, err := env.db.Exec(ctx, `
+			UPDATE accounts SET balance = balance + CASE WHEN id = $1 THEN -$3::numeric ELSE $3::numeric END
+			WHERE id IN ($1, $2)`, from, to, transfer.amount); err != nil {
+			t.Fatalf("изменение балансов: %v", err)
+		}
+	}
+
+	// Владивосток (UTC+10): местные сутки начинаются в 14:00 UTC, и подсчёт
+	// по часовому поясу сессии захватил бы или потерял один из переводов
+	config := env.db.Config()
+	config.ConnConfig.RuntimeParams["timezone"] = "Asia/Vladivostok"
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("пул с часовым поясом: %v", err)
+	}
+	defer pool.Close()
+
+	state, err := repository.NewSpendingLimitRepository(pool).GetState(ctx, user.id, from)
+	if err != nil {
+		t.Fatalf("GetState: %v", err)
+	}
+
+	wantMonthly := 11.0
+	if dayStart.Day() != 1 {
+		// Перевод за секунду до суток — ещё в этом месяце
+		wantMonthly += 7
+	}
+	for name, usage := range map[string]models.SpendingUsage{"счёта": state.AccountUsage, "пользователя": state.UserUsage} {
+		if usage.Daily != 11 || usage.DailyCount != 1 || usage.Monthly != wantMonthly {
+			t.Errorf("использование %s: %+v, ожидалось за сутки 11 (1 операция), за месяц %.2f", name, usage, wantMonthly)
+		}
+	}
+}
+
+// TestReconciliationFindsDiscrepancy проверяет отчёт сверки на известном
+// расхождении: баланс счёта меняется в базе в обход транзакций
+func TestReconciliationFindsDiscrepancy(t *testing.T) {
+	ctx := context.Background()
+	user := newUser(t, "reconcile")
+	accountID := user.createAccount(t)
+
+	if _, err := env.db.Exec(ctx, `UPDATE accounts SET balance = balance + 12.34 WHERE id = $1`, accountID); err != nil {
+		t.Fatalf("изменение баланса: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := env.db.Exec(ctx, `UPDATE accounts SET balance = balance - 12.34 WHERE id = $1`, accountID); err != nil {
+			t.Errorf("восстановление баланса: %v", err)
+		}
+	})
+
+	repo := repository.NewReconciliationRepository(env.db)
+	report, err := repo.Run(ctx, "cli")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Status != "completed" || report.FinishedAt == nil || report.AccountsChecked < 2 {
+		t.Fatalf("отчёт: %+v", report)
+	}
+
+	// Остальные тесты не меняют балансы в обход транзакций, поэтому
+	// расхождение в отчёте одно
+	want := models.BalanceDiscrepancy{
+		AccountID:       accountID,
+		AccountStatus:   "active",
+		ExpectedBalance: 100,
+		ActualBalance:   112.34,
+		Difference:      12.34,
+	}
+	if report.DiscrepancyCount != 1 || len(report.Discrepancies) != 1 || report.Discrepancies[0] != want {
+		t.Fatalf("расхождения: %+v, ожидалось %+v", report.Discrepancies, want)
+	}
+	if report.TotalDifference != 12.34 {
+		t.Errorf("итоговая разница %.2f, ожидалось 12.34", report.TotalDifference)
+	}
+
+	saved, err := repo.GetByID(ctx, report.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if saved.Status != "completed" || saved.DiscrepancyCount != 1 || len(saved.Discrepancies) != 1 || saved.Discrepancies[0] != want {
+		t.Errorf("сохранённый отчёт: %+v", saved)
+	}
+}
+
+// TestReconciliationInProgress проверяет, что сверка не запускается, пока
+// advisory lock держит другой экземпляр API или CLI
+func TestReconciliationInProgress(t *testing.T) {
+	ctx := context.Background()
+	conn, err := env.db.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("соединение: %v", err)
+	}
+	defer conn.Release()
+
+	// Ключ совпадает с repository.reconciliationLockKey
+	const lockKey = 7_340_001
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		t.Fatalf("блокировка сверки: %v", err)
+	}
+	unlocked := false
+	defer func() {
+		if !unlocked {
+			conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+		}
+	}()
+
+	var before int
+	if err := env.db.QueryRow(ctx, `SELECT COUNT(*) FROM reconciliation_reports`).Scan(&before); err != nil {
+		t.Fatalf("число отчётов: %v", err)
+	}
+
+	repo := repository.NewReconciliationRepository(env.db)
+	if _, err := repo.Run(ctx, "cli"); !errors.Is(err, repository.ErrReconciliationInProgress) {
+		t.Fatalf("сверка при чужой блокировке: ошибка %v, ожидалась %v", err, repository.ErrReconciliationInProgress)
+	}
+
+	var after int
+	if err := env.db.QueryRow(ctx, `SELECT COUNT(*) FROM reconciliation_reports`).Scan(&after); err != nil {
+		t.Fatalf("число отчётов: %v", err)
+	}
+	if after != before {
+		t.Errorf("отклонённая сверка создала отчёт: было %d, стало %d", before, after)
+	}
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+		t.Fatalf("снятие блокировки: %v", err)
+	}
+	unlocked = true
+
+	report, err := repo.Run(ctx, "cli")
+	if err != nil {
+		t.Fatalf("сверка после снятия блокировки: %v", err)
+	}
+	if report.Status != "completed" {
+		t.Errorf("статус %s, ожидался completed", report.Status)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"bank-prototype/internal/migration"
+	"bank-prototype/internal/utils"
+)
+
+// runMigrateCommand управляет схемой базы данных: ./main migrate up|down [N]|goto V|force V|status.
+// Миграции встроены в бинарник, каталог migrations не нужен.
+func runMigrateCommand(args []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status, err := migration.New(databaseURL()).Exec(ctx, args)
+	if err != nil {
+		if errors.Is(err, migration.ErrInvalidCommand) {
+			fmt.Fprintf(os.Stderr, "%v\n\nИспользование:\n%s\n", err, migration.Usage)
+			return 64
+		}
+		utils.LogError("Migration", "Ошибка миграции", err)
+		return 1
+	}
+
+	printMigrationStatus(status)
+	return 0
+}
+
+func printMigrationStatus(status *migration.Status) {
+	fmt.Printf("Версия схемы: %d (ожидается %d)\n", status.Version, status.Expected)
+	if status.Dirty {
+		fmt.Printf("Миграция %d применена не полностью: исправьте схему и выполните migrate force %d\n",
+			status.Version, status.Version)
+	}
+	if len(status.Pending) > 0 {
+		fmt.Printf("Неприменённые миграции: %v\n", status.Pending)
+	}
+}
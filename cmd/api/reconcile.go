@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/utils"
+)
+
+// Коды выхода подкоманды reconcile
+const (
+	reconcileExitOK            = 0
+	reconcileExitError         = 1
+	reconcileExitDiscrepancies = 2
+)
+
+// runReconcileCommand выполняет разовую сверку балансов: ./main reconcile [--json] [--timeout 10m].
+// Возвращает 2, если найдены расхождения, чтобы сверку можно было
+// запускать из cron или CI и реагировать на ненулевой код выхода.
+func runReconcileCommand(args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "вывести отчёт в формате JSON")
+	timeout := flags.Duration("timeout", 10*time.Minute, "максимальное время сверки")
+	if err := flags.Parse(args); err != nil {
+		return reconcileExitError
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	dbpool, err := pgxpool.New(ctx, databaseURL())
+	if err != nil {
+		utils.LogError("Reconcile", "Ошибка подключения к базе данных", err)
+		return reconcileExitError
+	}
+	defer dbpool.Close()
+
+	service := services.NewReconciliationService(repository.NewReconciliationRepository(dbpool), nil)
+
+	report, err := service.Run(ctx, services.ReconciliationTriggerCLI)
+	if err != nil {
+		utils.LogError("Reconcile", "Сверка завершилась ошибкой", err)
+		return reconcileExitError
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(report)
+	} else {
+		fmt.Printf("Отчёт %s: проверено счетов %d, расхождений %d (сумма %.2f)\n",
+			report.ID, report.AccountsChecked, report.DiscrepancyCount, report.TotalDifference)
+		for _, d := range report.Discrepancies {
+			fmt.Printf("  %s [%s]: ожидалось %.2f, фактически %.2f, разница %+.2f\n",
+				d.AccountID, d.AccountStatus, d.ExpectedBalance, d.ActualBalance, d.Difference)
+		}
+	}
+
+	if report.DiscrepancyCount > 0 {
+		return reconcileExitDiscrepancies
+	}
+	return reconcileExitOK
+}
@@ -0,0 +1,578 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+
+	"bank-prototype/internal/buildinfo"
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/events"
+	"bank-prototype/internal/grpcapi"
+	"bank-prototype/internal/handlers"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/middleware"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/notifications"
+	"bank-prototype/internal/openapi"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+)
+
+// Пути без префикса версии устарели с выходом /v1 и будут удалены после
+// legacySunset
+var (
+	legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	legacySunset     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// server — собранный стек API: сервисы, фоновые планировщики, HTTP-маршрутизатор
+// и gRPC-сервер. Вынесен из main, чтобы интеграционные тесты поднимали
+// тот же стек, что и продакшен, поверх своих PostgreSQL и Redis.
+type server struct {
+	Handler fasthttp.RequestHandler
+	GRPC    *grpc.Server
+
+	closers []func()
+}
+
+// newServer собирает API поверх готовых подключений. Подключения остаются
+// во владении вызывающего кода, Close их не закрывает.
+func newServer(dbpool *pgxpool.Pool, redisCache *cache.RedisCache, expectedMigrationVersion uint) *server {
+	srv := &server{}
+
+	// Redis нужен в любом режиме (rate limiter, инвалидации), а кеш сервисов
+	// выбирается переменной CACHE_MODE
+	serviceCache := newServiceCache(redisCache)
+	if serviceCache != cache.Cache(redisCache) {
+		srv.onClose(func() {
+			_ = serviceCache.Close()
+		})
+	}
+
+	// Инициализация Worker Pool
+	utils.LogInfo("WorkerPool", "Инициализация пула воркеров...")
+	workerPool := worker.NewWorkerPool(10, 1000, 3) // 10 воркеров, очередь на 1000 задач, 3 повтора
+	workerPool.Start()
+	srv.onClose(func() {
+		utils.LogInfo("WorkerPool", "Остановка пула воркеров...")
+		if err := workerPool.Shutdown(30 * time.Second); err != nil {
+			utils.LogError("WorkerPool", "Ошибка остановки пула", err)
+		}
+	})
+
+	userRepo := repository.NewUserRepository(dbpool)
+	accountRepo := repository.NewAccountRepository(dbpool)
+	transactionRepo := repository.NewTransactionRepository(dbpool)
+	scheduledTransferRepo := repository.NewScheduledTransferRepository(dbpool)
+	authorizationRepo := repository.NewAuthorizationRepository(dbpool)
+	spendingLimitRepo := repository.NewSpendingLimitRepository(dbpool)
+	batchRepo := repository.NewBatchRepository(dbpool)
+	reconciliationRepo := repository.NewReconciliationRepository(dbpool)
+	passwordResetRepo := repository.NewPasswordResetRepository(dbpool)
+	notificationRepo := repository.NewNotificationRepository(dbpool)
+
+	authService := services.NewAuthService("your_jwt_secret_change_me_in_production", time.Hour*24)
+	authService.SetUserStore(userRepo, serviceCache) // токены завершённых сессий не принимаются
+	userService := services.NewUserService(userRepo, accountRepo, authService, serviceCache, workerPool)
+	// Почта общая для сброса пароля и уведомлений
+	mailer := newNotifier()
+	passwordResetService := services.NewPasswordResetService(userRepo, passwordResetRepo, authService, mailer, workerPool,
+		envDuration("PASSWORD_RESET_TTL", services.DefaultPasswordResetTTL))
+	accountService := services.NewAccountService(accountRepo, serviceCache)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, serviceCache)
+	transactionService.SetWorkerPool(workerPool) // Устанавливаем worker pool
+	scheduledTransferService := services.NewScheduledTransferService(scheduledTransferRepo, accountRepo, transactionService, workerPool)
+	authorizationService := services.NewAuthorizationService(authorizationRepo, accountRepo, serviceCache, workerPool)
+	spendingLimitService := services.NewSpendingLimitService(spendingLimitRepo, accountRepo)
+	batchService := services.NewBatchService(batchRepo, transactionRepo, accountRepo, transactionService, workerPool)
+	reconciliationService := services.NewReconciliationService(reconciliationRepo, workerPool)
+	notificationService := services.NewNotificationService(notificationRepo, userRepo, accountRepo, serviceCache, workerPool)
+	notificationService.SetChannel(notifications.ChannelEmail, mailer)
+	if sms := newSMSNotifier(); sms != nil {
+		notificationService.SetChannel(notifications.ChannelSMS, sms)
+	}
+	scheduledTransferService.SetNotifications(notificationService)
+	retentionService := services.NewRetentionService(userRepo, envDuration("DATA_RETENTION_PERIOD", services.DefaultRetentionPeriod), workerPool)
+
+	// Планировщик регулярных переводов, снятие просроченных холдов, сверка
+	// балансов и удаление данных с истёкшим сроком хранения
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	srv.onClose(stopScheduler)
+	go scheduledTransferService.RunScheduler(schedulerCtx, envDuration("SCHEDULER_POLL_INTERVAL", 30*time.Second))
+	go authorizationService.RunExpirer(schedulerCtx, envDuration("AUTHORIZATION_EXPIRY_INTERVAL", time.Minute))
+	go reconciliationService.RunScheduled(schedulerCtx, envDuration("RECONCILIATION_INTERVAL", 24*time.Hour))
+	go retentionService.RunScheduled(schedulerCtx, envDuration("RETENTION_INTERVAL", 24*time.Hour))
+
+	// События в реальном времени: публикуют сервисы, раздаёт брокер через
+	// Redis pub/sub, поэтому клиент получает их на любом экземпляре API.
+	// Те же события превращаются в уведомления пользователям.
+	eventBroker := events.NewBroker(redisCache.Client())
+	go eventBroker.Run(schedulerCtx)
+	eventPublisher := services.CombinePublishers(eventBroker, notificationService)
+	transactionService.SetEventPublisher(eventPublisher)
+	authorizationService.SetEventPublisher(eventPublisher)
+	userService.SetEventPublisher(eventPublisher)
+
+	authMiddleware := middleware.NewAuthMiddleware(authService)
+	rateLimiter := middleware.NewRateLimiter(redisCache.Client(), middleware.DefaultRateLimitPolicies)
+
+	healthHandler := handlers.NewHealthHandler(dbpool, redisCache, workerPool, expectedMigrationVersion)
+	authHandler := handlers.NewAuthHandler(authService, userRepo)
+	userHandler := handlers.NewUserHandler(userService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	scheduledTransferHandler := handlers.NewScheduledTransferHandler(scheduledTransferService)
+	authorizationHandler := handlers.NewAuthorizationHandler(authorizationService)
+	limitHandler := handlers.NewLimitHandler(spendingLimitService)
+	batchHandler := handlers.NewBatchHandler(batchService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	eventHandler := handlers.NewEventHandler(eventBroker)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+
+	// Маршруты проверяются в порядке регистрации. Тот же список описывает
+	// API в /openapi.json, поэтому новый маршрут сразу попадает в документ.
+	router := openapi.NewRouter(openapi.Info{
+		Title:       "Bank Prototype API",
+		Version:     buildinfo.Version,
+		Description: "Счета, переводы, платежи, авторизации и регулярные переводы. Язык сообщений (ru, en) — по заголовку Accept-Language",
+	}, func(route *openapi.Route, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		if route.RateGroup != "" {
+			next = rateLimiter.Limit(route.RateGroup, next)
+		}
+		switch route.Auth {
+		case openapi.AuthAdmin:
+			next = authMiddleware.RequireAuth(authMiddleware.RequireAdmin(next))
+		case openapi.AuthUser:
+			next = authMiddleware.RequireAuth(next)
+		}
+		return next
+	})
+
+	idNotFound := map[int]any{fasthttp.StatusForbidden: nil, fasthttp.StatusNotFound: nil}
+	// Идентификаторы всего, кроме счетов, — UUID: неверный формат отклоняется
+	// с 400 до обращения к базе
+	uuidID := []openapi.Param{{Name: "id", Schema: &openapi.Schema{Type: openapi.Types{"string"}, Format: "uuid"}}}
+
+	// Служебные
+	router.Handle(openapi.Route{
+		Method: "GET", Path: "/health", OperationID: "health", Tag: "health",
+		Summary:   "Процесс жив (синоним /health/live)",
+		Responses: map[int]any{fasthttp.StatusOK: handlers.HealthResponse{}},
+		Handler:   healthHandler.Live,
+	})
+	router.Handle(openapi.Route{
+		Method: "GET", Path: "/health/live", OperationID: "healthLive", Tag: "health",
+		Summary:   "Процесс жив",
+		Responses: map[int]any{fasthttp.StatusOK: handlers.HealthResponse{}},
+		Handler:   healthHandler.Live,
+	})
+	router.Handle(openapi.Route{
+		Method: "GET", Path: "/health/ready", OperationID: "healthReady", Tag: "health",
+		Summary: "Экземпляр готов принимать трафик",
+		Responses: map[int]any{
+			fasthttp.StatusOK:                 handlers.HealthResponse{},
+			fasthttp.StatusServiceUnavailable: handlers.HealthResponse{},
+		},
+		Handler: healthHandler.Ready,
+	})
+	router.Handle(openapi.Route{
+		Method: "GET", Path: "/openapi.json", OperationID: "openapi", Tag: "health",
+		Summary:   "Описание API в формате OpenAPI 3.1",
+		Responses: map[int]any{fasthttp.StatusOK: map[string]any{}},
+		Handler:   router.ServeSpec,
+	})
+
+	// Публичное API — под /v1. Прежние пути без версии остаются синонимами
+	// с заголовками Deprecation и Sunset до legacySunset.
+	v1 := router.Group("/v1").WithLegacyAliases(openapi.Deprecation{Since: legacyDeprecated, Sunset: legacySunset})
+
+	// Маршруты, появившиеся после выхода /v1, синонимов без версии не имеют
+	v1Only := router.Group("/v1")
+
+	// Пользователи
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/register", OperationID: "register", Tag: "users",
+		Summary: "Регистрация", RateGroup: middleware.RateGroupAuth,
+		Body:      models.RegisterRequest{},
+		Responses: map[int]any{fasthttp.StatusCreated: models.RegisterResponse{}, fasthttp.StatusConflict: nil},
+		Handler:   authHandler.RegisterHandler,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/login", OperationID: "login", Tag: "users",
+		Summary: "Вход и получение JWT", RateGroup: middleware.RateGroupAuth,
+		Body:      models.LoginRequest{},
+		Responses: map[int]any{fasthttp.StatusOK: models.LoginResponse{}, fasthttp.StatusUnauthorized: nil},
+		Handler:   authHandler.LoginHandler,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "POST", Path: "/auth/password-reset/request", OperationID: "requestPasswordReset", Tag: "users",
+		Summary: "Запрос кода сброса пароля по имени или email; ответ не зависит от наличия пользователя", RateGroup: middleware.RateGroupPasswordReset,
+		Body:      models.PasswordResetRequest{},
+		Responses: map[int]any{fasthttp.StatusAccepted: models.PasswordResetResponse{}},
+		Handler:   passwordResetHandler.Request,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "POST", Path: "/auth/password-reset/confirm", OperationID: "confirmPasswordReset", Tag: "users",
+		Summary: "Новый пароль по одноразовому коду; все сессии завершаются", RateGroup: middleware.RateGroupPasswordReset,
+		Body:      models.PasswordResetConfirmRequest{},
+		Responses: map[int]any{fasthttp.StatusOK: models.PasswordResetResponse{}, fasthttp.StatusBadRequest: nil},
+		Handler:   passwordResetHandler.Confirm,
+	})
+	v1.Handle(openapi.Route{
+		Method: "DELETE", Path: "/users/me", OperationID: "deleteCurrentUser", Tag: "users",
+		Summary: "Удаление пользователя: закрытие счетов, выплата остатков и обезличивание", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupAuth,
+		Body: models.DeleteUserRequest{}, BodyOptional: true,
+		Responses: map[int]any{fasthttp.StatusOK: models.DeleteUserResponse{}, fasthttp.StatusNotFound: nil, fasthttp.StatusConflict: nil},
+		Handler:   userHandler.DeleteUser,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "GET", Path: "/users/me", OperationID: "getCurrentUser", Tag: "users",
+		Summary: "Профиль пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.UserProfile{}, fasthttp.StatusNotFound: nil},
+		Handler:   userHandler.Profile,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "PATCH", Path: "/users/me", OperationID: "updateCurrentUser", Tag: "users",
+		Summary: "Изменение профиля: отображаемое имя, email, телефон", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupAuth,
+		Body:      models.UpdateProfileRequest{},
+		Responses: map[int]any{fasthttp.StatusOK: models.UserProfile{}, fasthttp.StatusConflict: nil},
+		Handler:   userHandler.UpdateProfile,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "POST", Path: "/users/me/password", OperationID: "changePassword", Tag: "users",
+		Summary: "Смена пароля; остальные сессии завершаются", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupAuth,
+		Body:      models.ChangePasswordRequest{},
+		Responses: map[int]any{fasthttp.StatusOK: models.ChangePasswordResponse{}, fasthttp.StatusForbidden: nil},
+		Handler:   userHandler.ChangePassword,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "GET", Path: "/users/me/notification-preferences", OperationID: "getNotificationPreferences", Tag: "notifications",
+		Summary: "Настройки уведомлений: каналы, виды уведомлений, порог остатка и язык", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.NotificationPreferences{}},
+		Handler:   notificationHandler.Preferences,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "PATCH", Path: "/users/me/notification-preferences", OperationID: "updateNotificationPreferences", Tag: "notifications",
+		Summary: "Изменение настроек уведомлений", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupAuth,
+		Body:      models.UpdateNotificationPreferencesRequest{},
+		Responses: map[int]any{fasthttp.StatusOK: models.NotificationPreferences{}},
+		Handler:   notificationHandler.UpdatePreferences,
+	})
+	v1.Handle(openapi.Route{
+		Method: "PUT", Path: "/users/me/limits", OperationID: "updateUserLimits", Tag: "limits",
+		Summary: "Лимиты расходов пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.SpendingLimits{},
+		Responses: map[int]any{fasthttp.StatusOK: models.SpendingLimits{}, fasthttp.StatusNotFound: nil},
+		Handler:   limitHandler.UpdateUserLimits,
+	})
+
+	// Счета
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/accounts", OperationID: "createAccount", Tag: "accounts",
+		Summary: "Открытие счёта", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Responses: map[int]any{fasthttp.StatusCreated: models.AccountResponse{}, fasthttp.StatusConflict: nil},
+		Handler:   accountHandler.CreateAccount,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/accounts", OperationID: "listAccounts", Tag: "accounts",
+		Summary: "Счета пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.AccountListResponse{}},
+		Handler:   accountHandler.GetAccounts,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/accounts/{id}/limits", OperationID: "getAccountLimits", Tag: "limits",
+		Summary: "Лимиты счёта и остаток", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.AccountLimitsResponse{}),
+		Handler:   limitHandler.GetAccountLimits,
+	})
+	v1.Handle(openapi.Route{
+		Method: "PUT", Path: "/accounts/{id}/limits", OperationID: "updateAccountLimits", Tag: "limits",
+		Summary: "Изменение лимитов счёта", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.SpendingLimits{},
+		Responses: with(idNotFound, fasthttp.StatusOK, models.AccountLimitsResponse{}),
+		Handler:   limitHandler.UpdateAccountLimits,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/accounts/{id}", OperationID: "getAccount", Tag: "accounts",
+		Summary: "Счёт", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.AccountResponse{}, fasthttp.StatusGone, nil),
+		Handler:   accountHandler.GetAccountByID,
+	})
+	v1.Handle(openapi.Route{
+		Method: "DELETE", Path: "/accounts/{id}", OperationID: "closeAccount", Tag: "accounts",
+		Summary: "Закрытие счёта, остаток переводится на системный счёт", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.CloseAccountResponse{}, fasthttp.StatusConflict, nil, fasthttp.StatusGone, nil),
+		Handler:   accountHandler.DeleteAccount,
+	})
+
+	// Транзакции
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/transfer", OperationID: "transfer", Tag: "transactions",
+		Summary: "Перевод между счетами (комиссия 1%)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.TransferRequest{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.TransactionResult{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.Transfer,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/payment", OperationID: "payment", Tag: "transactions",
+		Summary: "Платёж (комиссия 3%)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.PaymentRequest{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.TransactionResult{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.Payment,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/batch", OperationID: "createBatch", Tag: "transactions",
+		Summary: "Пакет переводов: JSON, CSV или загрузка файла", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Query: []openapi.Param{
+			{Name: "from_account_id", Description: "Счёт списания для тела text/csv"},
+			{Name: "mode", Description: "Режим пакета для тела text/csv", Schema: openapi.String("all_or_nothing", "best_effort")},
+		},
+		Body:      models.BatchTransferRequest{},
+		BodyTypes: []string{"text/csv", "multipart/form-data"},
+		Responses: with(idNotFound,
+			fasthttp.StatusCreated, models.TransferBatch{},
+			fasthttp.StatusAccepted, models.TransferBatch{},
+			fasthttp.StatusConflict, nil),
+		Handler: batchHandler.Create,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/transactions/batch/{id}", OperationID: "getBatch", Tag: "transactions",
+		PathParams: uuidID,
+		Summary:    "Пакет переводов с позициями", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.TransferBatch{}),
+		Handler:   batchHandler.GetByID,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/transactions", OperationID: "listTransactions", Tag: "transactions",
+		Summary: "История транзакций", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Query: []openapi.Param{
+			{Name: "account_id", Description: "Только транзакции этого счёта"},
+		},
+		Responses: with(idNotFound, fasthttp.StatusOK, models.TransactionListResponse{}),
+		Handler:   transactionHandler.GetHistory,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/{id}/refund", OperationID: "refundTransaction", Tag: "transactions",
+		PathParams: uuidID,
+		Summary:    "Возврат по транзакции (полный или частичный)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body: models.RefundRequest{}, BodyOptional: true,
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.RefundResult{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.Refund,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/admin/transactions/{id}/reversal", OperationID: "reverseTransaction", Tag: "admin",
+		PathParams: uuidID,
+		Summary:    "Сторнирование транзакции", Auth: openapi.AuthAdmin, RateGroup: middleware.RateGroupMoney,
+		Body: models.RefundRequest{}, BodyOptional: true,
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.RefundResult{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.Reverse,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/admin/reconciliation/run", OperationID: "runReconciliation", Tag: "admin",
+		Summary: "Запуск сверки балансов", Auth: openapi.AuthAdmin, RateGroup: middleware.RateGroupMoney,
+		Responses: map[int]any{fasthttp.StatusOK: models.ReconciliationReport{}, fasthttp.StatusConflict: nil},
+		Handler:   reconciliationHandler.Run,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/admin/reconciliation/reports", OperationID: "listReconciliationReports", Tag: "admin",
+		Summary: "Отчёты сверки", Auth: openapi.AuthAdmin, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.ReconciliationReportListResponse{}},
+		Handler:   reconciliationHandler.List,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/admin/reconciliation/reports/{id}", OperationID: "getReconciliationReport", Tag: "admin",
+		PathParams: uuidID,
+		Summary:    "Отчёт сверки с расхождениями", Auth: openapi.AuthAdmin, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.ReconciliationReport{}, fasthttp.StatusNotFound: nil},
+		Handler:   reconciliationHandler.GetByID,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/transactions/{id}", OperationID: "getTransaction", Tag: "transactions",
+		PathParams: uuidID,
+		Summary:    "Транзакция с возвратами", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.Transaction{}),
+		Handler:   transactionHandler.GetByID,
+	})
+
+	// Регулярные переводы
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/scheduled-transfers", OperationID: "createScheduledTransfer", Tag: "scheduled-transfers",
+		Summary: "Создание регулярного перевода", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.CreateScheduledTransferRequest{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.ScheduledTransfer{}, fasthttp.StatusConflict, nil),
+		Handler:   scheduledTransferHandler.Create,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/scheduled-transfers", OperationID: "listScheduledTransfers", Tag: "scheduled-transfers",
+		Summary: "Регулярные переводы пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.ScheduledTransferListResponse{}},
+		Handler:   scheduledTransferHandler.List,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/scheduled-transfers/{id}", OperationID: "getScheduledTransfer", Tag: "scheduled-transfers",
+		PathParams: uuidID,
+		Summary:    "Регулярный перевод с историей запусков", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.ScheduledTransferResponse{}),
+		Handler:   scheduledTransferHandler.GetByID,
+	})
+	v1.Handle(openapi.Route{
+		Method: "PATCH", Path: "/scheduled-transfers/{id}", OperationID: "updateScheduledTransfer", Tag: "scheduled-transfers",
+		PathParams: uuidID,
+		Summary:    "Изменение или приостановка регулярного перевода", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.UpdateScheduledTransferRequest{},
+		Responses: with(idNotFound, fasthttp.StatusOK, models.ScheduledTransfer{}, fasthttp.StatusConflict, nil),
+		Handler:   scheduledTransferHandler.Update,
+	})
+	v1.Handle(openapi.Route{
+		Method: "DELETE", Path: "/scheduled-transfers/{id}", OperationID: "cancelScheduledTransfer", Tag: "scheduled-transfers",
+		PathParams: uuidID,
+		Summary:    "Отмена регулярного перевода", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.CancelScheduledTransferResponse{}, fasthttp.StatusConflict, nil),
+		Handler:   scheduledTransferHandler.Cancel,
+	})
+
+	// Авторизации (холды)
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/authorizations", OperationID: "createAuthorization", Tag: "authorizations",
+		Summary: "Резервирование средств", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.CreateAuthorizationRequest{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.Authorization{}, fasthttp.StatusConflict, nil),
+		Handler:   authorizationHandler.Create,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/authorizations", OperationID: "listAuthorizations", Tag: "authorizations",
+		Summary: "Авторизации пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.AuthorizationListResponse{}},
+		Handler:   authorizationHandler.List,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/authorizations/{id}/capture", OperationID: "captureAuthorization", Tag: "authorizations",
+		PathParams: uuidID,
+		Summary:    "Списание зарезервированных средств", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body: models.CaptureAuthorizationRequest{}, BodyOptional: true,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.CaptureAuthorizationResponse{}, fasthttp.StatusConflict, nil),
+		Handler:   authorizationHandler.Capture,
+	})
+	v1.Handle(openapi.Route{
+		Method: "POST", Path: "/authorizations/{id}/release", OperationID: "releaseAuthorization", Tag: "authorizations",
+		PathParams: uuidID,
+		Summary:    "Отмена резервирования", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.Authorization{}, fasthttp.StatusConflict, nil),
+		Handler:   authorizationHandler.Release,
+	})
+	v1.Handle(openapi.Route{
+		Method: "GET", Path: "/authorizations/{id}", OperationID: "getAuthorization", Tag: "authorizations",
+		PathParams: uuidID,
+		Summary:    "Авторизация", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.Authorization{}),
+		Handler:   authorizationHandler.GetByID,
+	})
+
+	// События в реальном времени
+	v1Only.Handle(openapi.Route{
+		Method: "GET", Path: "/events", OperationID: "streamEvents", Tag: "events",
+		Summary: "Поток событий о транзакциях и балансах (Server-Sent Events)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Query: []openapi.Param{
+			{Name: "last_event_id", Description: "ID последнего полученного события, если клиент не может передать заголовок Last-Event-ID"},
+		},
+		Responses: map[int]any{fasthttp.StatusOK: openapi.EventStream{Event: models.Event{}}},
+		Handler:   eventHandler.Stream,
+	})
+
+	// Входящие уведомления
+	v1Only.Handle(openapi.Route{
+		Method: "GET", Path: "/notifications", OperationID: "listNotifications", Tag: "notifications",
+		Summary: "Последние уведомления, новые первыми", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Query: []openapi.Param{
+			{Name: "unread", Description: "Только непрочитанные", Schema: &openapi.Schema{Type: openapi.Types{"boolean"}}},
+		},
+		Responses: map[int]any{fasthttp.StatusOK: models.NotificationListResponse{}},
+		Handler:   notificationHandler.List,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "POST", Path: "/notifications/read-all", OperationID: "markAllNotificationsRead", Tag: "notifications",
+		Summary: "Отметить все уведомления прочитанными", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.MarkNotificationsReadResponse{}},
+		Handler:   notificationHandler.MarkAllRead,
+	})
+	v1Only.Handle(openapi.Route{
+		Method: "POST", Path: "/notifications/{id}/read", OperationID: "markNotificationRead", Tag: "notifications",
+		PathParams: uuidID,
+		Summary:    "Отметить уведомление прочитанным", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.MarkNotificationsReadResponse{}, fasthttp.StatusNotFound: nil},
+		Handler:   notificationHandler.MarkRead,
+	})
+
+	// API v2: суммы — десятичные строки, даты — RFC 3339. Обработчики v2
+	// вызывают те же сервисы, что и v1; остальные операции пока только в v1.
+	v2 := router.Group("/v2")
+	v2.Handle(openapi.Route{
+		Method: "GET", Path: "/accounts", OperationID: "listAccounts", Tag: "accounts",
+		Summary: "Счета пользователя", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: map[int]any{fasthttp.StatusOK: models.AccountListResponseV2{}},
+		Handler:   accountHandler.GetAccountsV2,
+	})
+	v2.Handle(openapi.Route{
+		Method: "GET", Path: "/accounts/{id}", OperationID: "getAccount", Tag: "accounts",
+		Summary: "Счёт", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupReads,
+		Responses: with(idNotFound, fasthttp.StatusOK, models.AccountV2{}, fasthttp.StatusGone, nil),
+		Handler:   accountHandler.GetAccountByIDV2,
+	})
+	v2.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/transfer", OperationID: "transfer", Tag: "transactions",
+		Summary: "Перевод между счетами (комиссия 1%)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.TransferRequestV2{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.TransactionResultV2{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.TransferV2,
+	})
+	v2.Handle(openapi.Route{
+		Method: "POST", Path: "/transactions/payment", OperationID: "payment", Tag: "transactions",
+		Summary: "Платёж (комиссия 3%)", Auth: openapi.AuthUser, RateGroup: middleware.RateGroupMoney,
+		Body:      models.TransferRequestV2{},
+		Responses: with(idNotFound, fasthttp.StatusCreated, models.TransactionResultV2{}, fasthttp.StatusConflict, nil),
+		Handler:   transactionHandler.PaymentV2,
+	})
+
+	srv.Handler = tracing.Middleware(i18n.Middleware(router.Handler))
+
+	// gRPC API для внутренних сервисов — те же сервисы на отдельном порту.
+	// Останавливается первым, до пула воркеров и планировщиков.
+	srv.GRPC = grpcapi.NewServer(authService, userRepo, accountService, transactionService, rateLimiter)
+	srv.onClose(srv.GRPC.GracefulStop)
+
+	return srv
+}
+
+// onClose регистрирует действие для Close
+func (s *server) onClose(fn func()) {
+	s.closers = append(s.closers, fn)
+}
+
+// Close останавливает планировщики, пул воркеров и кеш сервисов в порядке,
+// обратном запуску
+func (s *server) Close() {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+	s.closers = nil
+}
+
+// with дополняет набор ответов парами «код, модель»
+func with(base map[int]any, pairs ...any) map[int]any {
+	responses := make(map[int]any, len(base)+len(pairs)/2)
+	for status, model := range base {
+		responses[status] = model
+	}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		responses[pairs[i].(int)] = pairs[i+1]
+	}
+	return responses
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+type accountDetails struct {
+	Account      models.Account                `json:"account"`
+	Owner        string                        `json:"owner"`
+	Limits       *models.AccountLimitsResponse `json:"limits,omitempty"`
+	Transactions []models.Transaction          `json:"transactions"`
+}
+
+func runAccount(ctx context.Context, app *app, args []string) error {
+	fs := flag.NewFlagSet("account", flag.ContinueOnError)
+	limit := fs.Int("transactions", 10, "сколько последних транзакций показать")
+	positional, err := parseFlags(app, fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("%w: укажите операцию (show, freeze, unfreeze, close) и номер счёта", errUsage)
+	}
+	operation, accountID := positional[0], positional[1]
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	accountService := services.NewAccountService(accountRepo, app.redis(ctx))
+
+	switch operation {
+	case "show":
+		return showAccount(ctx, app, db, accountRepo, accountID, *limit)
+
+	case "freeze", "unfreeze":
+		var account *models.Account
+		if operation == "freeze" {
+			account, err = accountService.Freeze(ctx, accountID)
+		} else {
+			account, err = accountService.Unfreeze(ctx, accountID)
+		}
+		if err != nil {
+			return err
+		}
+		return app.print(account, func(w io.Writer) {
+			fmt.Fprintf(w, "Счёт %s: статус %s\n", account.ID, account.Status)
+		})
+
+	case "close":
+		account, err := accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		// Закрытие от имени владельца: остаток переводится на системный счёт
+		if err := accountService.DeleteAccount(ctx, accountID, account.UserID); err != nil {
+			return err
+		}
+		account.Status = "closed"
+		return app.print(account, func(w io.Writer) {
+			fmt.Fprintf(w, "Счёт %s закрыт, остаток %.2f переведён на системный счёт\n", account.ID, account.Balance)
+		})
+
+	default:
+		return fmt.Errorf("%w: неизвестная операция account %s", errUsage, operation)
+	}
+}
+
+func showAccount(ctx context.Context, app *app, db *pgxpool.Pool, accountRepo *repository.AccountRepository, accountID string, limit int) error {
+	account, err := accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	details := accountDetails{Account: *account}
+
+	if owner, err := repository.NewUserRepository(db).GetByID(ctx, account.UserID); err == nil {
+		details.Owner = owner.Name
+	}
+
+	if account.Status != "closed" {
+		limitService := services.NewSpendingLimitService(repository.NewSpendingLimitRepository(db), accountRepo)
+		if limits, err := limitService.GetAccountLimits(ctx, accountID, account.UserID); err == nil {
+			details.Limits = limits
+		}
+	}
+
+	transactions, err := repository.NewTransactionRepository(db).GetByAccountID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+	}
+	details.Transactions = transactions
+
+	return app.print(details, func(w io.Writer) {
+		fmt.Fprintf(w, "Счёт:        %s\n", account.ID)
+		fmt.Fprintf(w, "Владелец:    %s (%s)\n", details.Owner, account.UserID)
+		fmt.Fprintf(w, "Статус:      %s\n", account.Status)
+		fmt.Fprintf(w, "Баланс:      %.2f (зарезервировано %.2f, доступно %.2f)\n",
+			account.Balance, account.HeldAmount, account.AvailableBalance())
+		fmt.Fprintf(w, "Открыт:      %s\n", account.CreatedAt.Format("2006-01-02 15:04:05"))
+
+		if details.Limits != nil {
+			fmt.Fprintf(w, "Использовано сегодня: %.2f (%d операций), за месяц: %.2f\n",
+				details.Limits.Account.Used.Daily, details.Limits.Account.Used.DailyCount, details.Limits.Account.Used.Monthly)
+		}
+
+		fmt.Fprintf(w, "\nПоследние транзакции (%d):\n", len(transactions))
+		for _, t := range transactions {
+			printTransactionLine(w, t)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+const (
+	exportFormatCSV   = "csv"
+	exportFormatJSONL = "jsonl"
+)
+
+var (
+	accountCSVHeader     = []string{"id", "user_id", "balance", "held_amount", "status", "created_at"}
+	transactionCSVHeader = []string{
+		"id", "type", "from_account_id", "to_account_id", "amount", "fee_percent", "fee_amount",
+		"total_debit", "fee_account_id", "status", "created_at", "original_transaction_id",
+		"refunded_amount", "refunded_fee_amount",
+	}
+)
+
+// runExport выгружает данные построчно: CSV или JSON Lines (по объекту на строку).
+// Строки читаются из базы курсором, поэтому объём выгрузки не ограничен памятью.
+func runExport(ctx context.Context, app *app, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "csv или jsonl (по умолчанию csv, с --json — jsonl)")
+	output := fs.String("output", "", "файл для выгрузки (по умолчанию stdout)")
+	from := fs.String("from", "", "начало периода, YYYY-MM-DD или RFC3339 (включительно)")
+	to := fs.String("to", "", "конец периода, YYYY-MM-DD или RFC3339 (не включительно)")
+	positional, err := parseFlags(app, fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: укажите accounts или transactions", errUsage)
+	}
+
+	if *format == "" {
+		*format = exportFormatCSV
+		if app.json {
+			*format = exportFormatJSONL
+		}
+	}
+	if *format != exportFormatCSV && *format != exportFormatJSONL {
+		return fmt.Errorf("%w: формат должен быть csv или jsonl", errUsage)
+	}
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = app.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	buffered := bufio.NewWriter(out)
+	writer := newRecordWriter(buffered, *format)
+
+	switch positional[0] {
+	case "accounts":
+		err = writer.header(accountCSVHeader)
+		if err == nil {
+			err = repository.NewAccountRepository(db).Export(ctx, func(a models.Account) error {
+				return writer.write(a, []string{
+					a.ID, a.UserID, formatMoney(a.Balance), formatMoney(a.HeldAmount),
+					a.Status, a.CreatedAt.Format(time.RFC3339),
+				})
+			})
+		}
+
+	case "transactions":
+		start, end, parseErr := exportPeriod(*from, *to)
+		if parseErr != nil {
+			return parseErr
+		}
+		err = writer.header(transactionCSVHeader)
+		if err == nil {
+			err = repository.NewTransactionRepository(db).Export(ctx, start, end, func(t models.Transaction) error {
+				originalID := ""
+				if t.OriginalTransactionID != nil {
+					originalID = *t.OriginalTransactionID
+				}
+				return writer.write(t, []string{
+					t.ID, t.Type, t.FromAccountID, t.ToAccountID, formatMoney(t.Amount),
+					strconv.Itoa(t.FeePercent), formatMoney(t.FeeAmount), formatMoney(t.TotalDebit),
+					t.FeeAccountID, t.Status, t.CreatedAt.Format(time.RFC3339), originalID,
+					formatMoney(t.RefundedAmount), formatMoney(t.RefundedFeeAmount),
+				})
+			})
+		}
+
+	default:
+		return fmt.Errorf("%w: неизвестный вид выгрузки %s", errUsage, positional[0])
+	}
+
+	if err != nil {
+		return err
+	}
+	if err := writer.flush(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "Выгружено записей: %d → %s\n", writer.count, *output)
+	}
+	return nil
+}
+
+// recordWriter пишет записи в выбранном формате
+type recordWriter struct {
+	csv   *csv.Writer
+	json  *json.Encoder
+	count int
+}
+
+func newRecordWriter(w io.Writer, format string) *recordWriter {
+	if format == exportFormatJSONL {
+		return &recordWriter{json: json.NewEncoder(w)}
+	}
+	return &recordWriter{csv: csv.NewWriter(w)}
+}
+
+func (r *recordWriter) header(columns []string) error {
+	if r.csv == nil {
+		return nil
+	}
+	return r.csv.Write(columns)
+}
+
+func (r *recordWriter) write(v any, row []string) error {
+	r.count++
+	if r.csv == nil {
+		return r.json.Encode(v)
+	}
+	return r.csv.Write(row)
+}
+
+func (r *recordWriter) flush() error {
+	if r.csv == nil {
+		return nil
+	}
+	r.csv.Flush()
+	return r.csv.Error()
+}
+
+// exportPeriod разбирает границы периода; без --from выгрузка идёт с начала истории,
+// без --to — до текущего момента
+func exportPeriod(from, to string) (time.Time, time.Time, error) {
+	start := time.Unix(0, 0).UTC()
+	end := time.Now()
+
+	if from != "" {
+		parsed, err := parseExportTime(from)
+		if err != nil {
+			return start, end, fmt.Errorf("%w: некорректная дата --from: %v", errUsage, err)
+		}
+		start = parsed
+	}
+	if to != "" {
+		parsed, err := parseExportTime(to)
+		if err != nil {
+			return start, end, fmt.Errorf("%w: некорректная дата --to: %v", errUsage, err)
+		}
+		end = parsed
+	}
+
+	if !end.After(start) {
+		return start, end, fmt.Errorf("%w: --to должна быть позже --from", errUsage)
+	}
+	return start, end, nil
+}
+
+func parseExportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func formatMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
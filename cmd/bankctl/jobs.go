@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+// jobsListLimit — сколько задач каждого вида выводит jobs list
+const jobsListLimit = 100
+
+func runJobs(ctx context.Context, app *app, args []string) error {
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", 15*time.Minute, "считать пакет зависшим, если он не завершён за это время")
+	positional, err := parseFlags(app, fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: укажите list или replay", errUsage)
+	}
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch positional[0] {
+	case "list":
+		return listJobs(ctx, app, db, *olderThan)
+
+	case "replay":
+		if len(positional) < 3 {
+			return fmt.Errorf("%w: bankctl jobs replay scheduled_transfer|transfer_batch ID", errUsage)
+		}
+		return replayJob(ctx, app, db, positional[1], positional[2])
+
+	default:
+		return fmt.Errorf("%w: неизвестная операция jobs %s", errUsage, positional[0])
+	}
+}
+
+func listJobs(ctx context.Context, app *app, db *pgxpool.Pool, olderThan time.Duration) error {
+	scheduledService, batchService := jobServices(app.redis(ctx), db)
+
+	scheduled, err := scheduledService.ListFailed(ctx, jobsListLimit)
+	if err != nil {
+		return err
+	}
+	batches, err := batchService.ListStale(ctx, olderThan, jobsListLimit)
+	if err != nil {
+		return err
+	}
+
+	jobs := append(scheduled, batches...)
+	if jobs == nil {
+		jobs = []models.FailedJob{}
+	}
+
+	return app.print(jobs, func(w io.Writer) {
+		if len(jobs) == 0 {
+			fmt.Fprintln(w, "Неудачных задач нет")
+			return
+		}
+		for _, job := range jobs {
+			message := "-"
+			if job.Error != nil {
+				message = *job.Error
+			}
+			fmt.Fprintf(w, "%-18s %s  %-10s %s  %s\n",
+				job.Kind, job.ID, job.Status, job.FailedAt.Format("2006-01-02 15:04:05"), message)
+		}
+	})
+}
+
+func replayJob(ctx context.Context, app *app, db *pgxpool.Pool, kind, id string) error {
+	scheduledService, batchService := jobServices(app.redis(ctx), db)
+
+	switch kind {
+	case "scheduled_transfer":
+		st, err := scheduledService.Replay(ctx, id)
+		if err != nil {
+			return err
+		}
+		return app.print(st, func(w io.Writer) {
+			fmt.Fprintf(w, "Регулярный перевод %s возобновлён, планировщик API выполнит его на ближайшем проходе\n", st.ID)
+		})
+
+	case "transfer_batch":
+		batch, err := batchService.Resume(ctx, id)
+		if err != nil {
+			return err
+		}
+		return app.print(batch, func(w io.Writer) {
+			fmt.Fprintf(w, "Пакет %s: %s (успешно %d, с ошибкой %d из %d)\n",
+				batch.ID, batch.Status, batch.SucceededItems, batch.FailedItems, batch.TotalItems)
+		})
+
+	default:
+		return fmt.Errorf("%w: неизвестный вид задачи %s", errUsage, kind)
+	}
+}
+
+// jobServices собирает сервисы так же, как API, но без Worker Pool:
+// перезапущенные задачи выполняются синхронно в процессе утилиты
+func jobServices(apiCache cache.Cache, db *pgxpool.Pool) (*services.ScheduledTransferService, *services.BatchService) {
+	accountRepo := repository.NewAccountRepository(db)
+	transactionRepo := repository.NewTransactionRepository(db)
+
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, apiCache)
+
+	scheduledService := services.NewScheduledTransferService(
+		repository.NewScheduledTransferRepository(db), accountRepo, transactionService, nil)
+	batchService := services.NewBatchService(
+		repository.NewBatchRepository(db), transactionRepo, accountRepo, transactionService, nil)
+
+	return scheduledService, batchService
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"bank-prototype/internal/migration"
+)
+
+func runMigrate(ctx context.Context, app *app, args []string) error {
+	positional, err := parseFlags(app, flag.NewFlagSet("migrate", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+
+	status, err := migration.New(databaseURL()).Exec(ctx, positional)
+	if errors.Is(err, migration.ErrInvalidCommand) {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	return app.print(status, func(w io.Writer) {
+		fmt.Fprintf(w, "Версия схемы: %d (ожидается %d)\n", status.Version, status.Expected)
+		if status.Dirty {
+			fmt.Fprintf(w, "Миграция %d применена не полностью: исправьте схему и выполните migrate force %d\n",
+				status.Version, status.Version)
+		}
+		if len(status.Pending) > 0 {
+			fmt.Fprintf(w, "Неприменённые миграции: %v\n", status.Pending)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+// errDiscrepancies — сверка выполнена, но найдены расхождения
+var errDiscrepancies = errors.New("найдены расхождения")
+
+func runReconcile(ctx context.Context, app *app, args []string) error {
+	positional, err := parseFlags(app, flag.NewFlagSet("reconcile", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("%w: reconcile не принимает аргументов", errUsage)
+	}
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+
+	service := services.NewReconciliationService(repository.NewReconciliationRepository(db), nil)
+	report, err := service.Run(ctx, services.ReconciliationTriggerCLI)
+	if err != nil {
+		return err
+	}
+
+	err = app.print(report, func(w io.Writer) {
+		fmt.Fprintf(w, "Отчёт %s: проверено счетов %d, расхождений %d (сумма %.2f)\n",
+			report.ID, report.AccountsChecked, report.DiscrepancyCount, report.TotalDifference)
+		for _, d := range report.Discrepancies {
+			fmt.Fprintf(w, "  %s [%s]: ожидалось %.2f, фактически %.2f, разница %+.2f\n",
+				d.AccountID, d.AccountStatus, d.ExpectedBalance, d.ActualBalance, d.Difference)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if report.DiscrepancyCount > 0 {
+		return errDiscrepancies
+	}
+	return nil
+}
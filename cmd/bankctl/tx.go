@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+func runTx(ctx context.Context, app *app, args []string) error {
+	positional, err := parseFlags(app, flag.NewFlagSet("tx", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "show" {
+		return fmt.Errorf("%w: bankctl tx show ID", errUsage)
+	}
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+
+	transactionService := services.NewTransactionService(
+		repository.NewTransactionRepository(db),
+		repository.NewAccountRepository(db),
+		nil,
+	)
+
+	transaction, err := transactionService.LookupTransaction(ctx, positional[1])
+	if err != nil {
+		return err
+	}
+
+	return app.print(transaction, func(w io.Writer) {
+		fmt.Fprintf(w, "Транзакция: %s\n", transaction.ID)
+		fmt.Fprintf(w, "Тип:        %s\n", transaction.Type)
+		fmt.Fprintf(w, "Статус:     %s\n", transaction.Status)
+		fmt.Fprintf(w, "Со счёта:   %s\n", transaction.FromAccountID)
+		fmt.Fprintf(w, "На счёт:    %s\n", transaction.ToAccountID)
+		fmt.Fprintf(w, "Сумма:      %.2f + комиссия %.2f (%d%%) = %.2f\n",
+			transaction.Amount, transaction.FeeAmount, transaction.FeePercent, transaction.TotalDebit)
+		fmt.Fprintf(w, "Создана:    %s\n", transaction.CreatedAt.Format("2006-01-02 15:04:05"))
+		if transaction.OriginalTransactionID != nil {
+			fmt.Fprintf(w, "Исходная:   %s\n", *transaction.OriginalTransactionID)
+		}
+		if transaction.RefundedAmount > 0 || transaction.RefundedFeeAmount > 0 {
+			fmt.Fprintf(w, "Возвращено: %.2f (комиссии %.2f)\n", transaction.RefundedAmount, transaction.RefundedFeeAmount)
+		}
+
+		if len(transaction.Refunds) > 0 {
+			fmt.Fprintf(w, "\nВозвраты:\n")
+			for _, refund := range transaction.Refunds {
+				printTransactionLine(w, refund)
+			}
+		}
+	})
+}
+
+func printTransactionLine(w io.Writer, t models.Transaction) {
+	fmt.Fprintf(w, "  %s  %-10s %s → %s  %10.2f  %s\n",
+		t.CreatedAt.Format("2006-01-02 15:04:05"), t.Type, t.FromAccountID, t.ToAccountID, t.Amount, t.ID)
+}
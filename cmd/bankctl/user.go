@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+type userResult struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func runUser(ctx context.Context, app *app, args []string) error {
+	fs := flag.NewFlagSet("user", flag.ContinueOnError)
+	name := fs.String("name", "", "имя пользователя")
+	password := fs.String("password", "", "пароль (по умолчанию из BANKCTL_PASSWORD)")
+	positional, err := parseFlags(app, fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("%w: укажите create-admin или promote", errUsage)
+	}
+
+	db, err := app.pool(ctx)
+	if err != nil {
+		return err
+	}
+	userRepo := repository.NewUserRepository(db)
+
+	switch positional[0] {
+	case "create-admin":
+		if *password == "" {
+			*password = os.Getenv("BANKCTL_PASSWORD")
+		}
+		if *name == "" || *password == "" {
+			return fmt.Errorf("%w: укажите --name и пароль", errUsage)
+		}
+		// Те же требования, что и при регистрации через API
+		if err := services.ValidatePassword(*password, *name); err != nil {
+			return err
+		}
+
+		// Секрет JWT для хеширования пароля не используется
+		authService := services.NewAuthService("", time.Hour)
+		passwordHash, err := authService.HashPassword(*password)
+		if err != nil {
+			return err
+		}
+
+		user := &models.User{Name: *name, PasswordHash: passwordHash, Role: models.RoleAdmin}
+		if err := userRepo.Create(ctx, user); err != nil {
+			return fmt.Errorf("создание пользователя %s: %w", *name, err)
+		}
+
+		return app.print(userResult{ID: user.ID, Name: user.Name, Role: user.Role}, func(w io.Writer) {
+			fmt.Fprintf(w, "Администратор %s создан (ID: %s)\n", user.Name, user.ID)
+		})
+
+	case "promote":
+		if len(positional) < 2 {
+			return fmt.Errorf("%w: укажите имя пользователя", errUsage)
+		}
+		target := positional[1]
+
+		if err := userRepo.SetRole(ctx, target, models.RoleAdmin); err != nil {
+			return fmt.Errorf("назначение роли пользователю %s: %w", target, err)
+		}
+
+		// API берёт роль из кеша сессий, а не из токена: без сброса ключа
+		// новая роль действует не позже чем через минуту
+		user, err := userRepo.GetByName(ctx, target)
+		if err != nil {
+			return fmt.Errorf("получение пользователя %s: %w", target, err)
+		}
+		if apiCache := app.redis(ctx); apiCache != nil {
+			if err := apiCache.Delete(ctx, cache.UserSessionKey(user.ID)); err != nil {
+				fmt.Fprintf(os.Stderr, "Предупреждение: кеш сессий не сброшен (%v), роль действует не позже чем через минуту\n", err)
+			}
+		}
+
+		return app.print(userResult{ID: user.ID, Name: target, Role: models.RoleAdmin}, func(w io.Writer) {
+			fmt.Fprintf(w, "Пользователю %s выдана роль admin\n", target)
+		})
+
+	default:
+		return fmt.Errorf("%w: неизвестная операция user %s", errUsage, positional[0])
+	}
+}
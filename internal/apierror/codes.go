@@ -0,0 +1,118 @@
+package apierror
+
+import "bank-prototype/internal/i18n"
+
+// Code — стабильный машиночитаемый код ошибки. Коды не переименовываются:
+// клиенты сравнивают их напрямую. Новый код добавляется вместе с заголовками
+// во всех каталогах i18n.
+type Code string
+
+// Ошибки запроса и доступа
+const (
+	CodeValidationFailed     Code = "validation_failed"
+	CodeMalformedRequest     Code = "malformed_request"
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeRouteNotFound        Code = "route_not_found"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeInvalidToken         Code = "invalid_token"
+	CodeInvalidCredentials   Code = "invalid_credentials"
+	CodeAdminRequired        Code = "admin_required"
+	CodeAccessDenied         Code = "access_denied"
+	CodeRateLimited          Code = "rate_limited"
+	CodeInternal             Code = "internal_error"
+)
+
+// Пользователи и счета
+const (
+	CodeUserNotFound         Code = "user_not_found"
+	CodeUserExists           Code = "user_exists"
+	CodeAccountNotFound      Code = "account_not_found"
+	CodeAccountClosed        Code = "account_closed"
+	CodeAccountFrozen        Code = "account_frozen"
+	CodeAccountNotFrozen     Code = "account_not_frozen"
+	CodeAccountHasHolds      Code = "account_has_holds"
+	CodeAccountLimitReached  Code = "account_limit_reached"
+	CodeAccountStatusChanged Code = "account_status_changed"
+	CodeBalanceNotZero       Code = "balance_not_zero"
+	CodeInvalidPayoutAccount Code = "invalid_payout_account"
+)
+
+// Профиль и пароль
+const (
+	CodeWeakPassword      Code = "weak_password"
+	CodeWrongPassword     Code = "wrong_password"
+	CodeInvalidEmail      Code = "invalid_email"
+	CodeInvalidPhone      Code = "invalid_phone"
+	CodeEmailTaken        Code = "email_taken"
+	CodePhoneTaken        Code = "phone_taken"
+	CodeInvalidResetToken Code = "invalid_reset_token"
+)
+
+// Деньги и лимиты
+const (
+	CodeInsufficientBalance   Code = "insufficient_balance"
+	CodeInvalidAmount         Code = "invalid_amount"
+	CodeSelfTransfer          Code = "self_transfer"
+	CodeSpendingLimitExceeded Code = "spending_limit_exceeded"
+	CodeInvalidLimit          Code = "invalid_limit"
+	CodeLimitAboveCeiling     Code = "limit_above_ceiling"
+)
+
+// Транзакции и возвраты
+const (
+	CodeTransactionNotFound      Code = "transaction_not_found"
+	CodeTransactionFailed        Code = "transaction_failed"
+	CodeTransactionNotRefundable Code = "transaction_not_refundable"
+	CodeRefundExceedsAmount      Code = "refund_exceeds_amount"
+	CodeRefundOnlyPayments       Code = "refund_only_payments"
+	CodeFeeRefundForbidden       Code = "fee_refund_forbidden"
+)
+
+// Пакеты переводов
+const (
+	CodeBatchNotFound     Code = "batch_not_found"
+	CodeInvalidBatchMode  Code = "invalid_batch_mode"
+	CodeEmptyBatch        Code = "empty_batch"
+	CodeBatchTooLarge     Code = "batch_too_large"
+	CodeBatchNotResumable Code = "batch_not_resumable"
+	CodeBatchCancelled    Code = "batch_cancelled"
+)
+
+// Регулярные переводы
+const (
+	CodeScheduledTransferNotFound  Code = "scheduled_transfer_not_found"
+	CodeScheduledTransferFinished  Code = "scheduled_transfer_finished"
+	CodeScheduledTransferNotPaused Code = "scheduled_transfer_not_paused"
+	CodeInvalidSchedule            Code = "invalid_schedule"
+	CodeInvalidCronExpression      Code = "invalid_cron_expression"
+	CodeInvalidScheduleWindow      Code = "invalid_schedule_window"
+	CodeInvalidTransactionType     Code = "invalid_transaction_type"
+	CodeInvalidFundsPolicy         Code = "invalid_funds_policy"
+	CodeInvalidScheduleStatus      Code = "invalid_schedule_status"
+)
+
+// Авторизации (холды)
+const (
+	CodeAuthorizationNotFound   Code = "authorization_not_found"
+	CodeAuthorizationNotActive  Code = "authorization_not_active"
+	CodeAuthorizationExpired    Code = "authorization_expired"
+	CodeInvalidAuthorizationTTL Code = "invalid_authorization_ttl"
+	CodeCaptureExceedsAmount    Code = "capture_exceeds_amount"
+)
+
+// Сверка балансов
+const (
+	CodeReconciliationReportNotFound Code = "reconciliation_report_not_found"
+	CodeReconciliationInProgress     Code = "reconciliation_in_progress"
+)
+
+// Уведомления
+const (
+	CodeNotificationNotFound Code = "notification_not_found"
+)
+
+// Title возвращает заголовок проблемы для кода на языке lang. Заголовки
+// хранятся в каталоге i18n под ключом, совпадающим с кодом.
+func (c Code) Title(lang i18n.Lang) string {
+	return i18n.T(lang, string(c))
+}
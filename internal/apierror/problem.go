@@ -0,0 +1,80 @@
+// Package apierror — единая модель ошибок HTTP API в формате RFC 7807
+// (application/problem+json). Каждая ошибка несёт стабильный код, по
+// которому клиент принимает решение, не разбирая текст сообщения, и
+// идентификатор запроса для поиска в журнале и трейсах.
+package apierror
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/tracing"
+)
+
+// ContentType — тип содержимого ответов с ошибкой
+const ContentType = "application/problem+json"
+
+// typePrefix — префикс URI типа проблемы. Тип однозначно определяется
+// кодом: urn:bank-prototype:problem:insufficient_balance.
+const typePrefix = "urn:bank-prototype:problem:"
+
+// FieldError — нарушение в одной части запроса
+type FieldError struct {
+	In      string `json:"in"`              // body, query, path или header
+	Field   string `json:"field,omitempty"` // путь к полю: items[2].amount
+	Message string `json:"message"`
+}
+
+// Problem — тело ответа с ошибкой
+type Problem struct {
+	Type      string       `json:"type" validate:"required"`
+	Title     string       `json:"title" validate:"required"`
+	Status    int          `json:"status" validate:"required"`
+	Code      Code         `json:"code" validate:"required"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// New возвращает проблему с кодом code. Заголовок берётся из каталога
+// по коду, detail дополняет его подробностями конкретного случая и должен
+// быть уже на языке запроса.
+func New(status int, code Code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + string(code),
+		Title:  code.Title(i18n.Default),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// WithErrors добавляет список нарушений по полям
+func (p *Problem) WithErrors(errs []FieldError) *Problem {
+	p.Errors = errs
+	return p
+}
+
+// Write отправляет проблему клиенту на языке запроса. Instance — путь
+// запроса, request_id совпадает с заголовком X-Request-ID ответа.
+func (p *Problem) Write(ctx *fasthttp.RequestCtx) {
+	p.Title = p.Code.Title(i18n.FromRequest(ctx))
+	if strings.EqualFold(p.Detail, p.Title) {
+		p.Detail = ""
+	}
+	p.Instance = string(ctx.Path())
+	p.RequestID = tracing.RequestID(ctx)
+
+	ctx.SetStatusCode(p.Status)
+	ctx.SetContentType(ContentType)
+	_ = json.NewEncoder(ctx).Encode(p)
+}
+
+// Write отправляет проблему с кодом code
+func Write(ctx *fasthttp.RequestCtx, status int, code Code, detail string) {
+	New(status, code, detail).Write(ctx)
+}
@@ -0,0 +1,39 @@
+package buildinfo
+
+import (
+	"runtime"
+	"time"
+)
+
+// Значения подставляются при сборке:
+//
+//	go build -ldflags "-X bank-prototype/internal/buildinfo.Version=1.2.0 \
+//	  -X bank-prototype/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X bank-prototype/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+var startedAt = time.Now()
+
+// Info содержит сведения о сборке и запущенном процессе
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Uptime    string `json:"uptime"`
+}
+
+// Get возвращает сведения о текущей сборке
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+	}
+}
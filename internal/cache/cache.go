@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// ErrMiss — ключа нет в кеше или истёк его TTL
+var ErrMiss = errors.New("ключ не найден в кеше")
+
+// Cache — хранилище строк по ключу с TTL. Сервисы зависят только от него,
+// поэтому реализацию можно выбрать при запуске: Redis, память процесса
+// или двухуровневый кеш. nil означает работу без кеша.
+type Cache interface {
+	// Get возвращает значение или ErrMiss
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// GetJSON читает значение и декодирует его в dest
+func GetJSON(ctx context.Context, c Cache, key string, dest interface{}) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), dest)
+}
+
+// SetJSON сохраняет value в JSON
+func SetJSON(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, string(data), ttl)
+}
+
+const (
+	AccountInfoTTL    = 60 * time.Second
+	UserAccountsTTL   = 300 * time.Second
+	AccountVersionTTL = 24 * time.Hour
+
+	// ttlJitter — доля TTL, на которую он случайно сдвигается, чтобы ключи,
+	// записанные одновременно, не истекали одновременно
+	ttlJitter = 0.1
+)
+
+// AccountVersionKey хранит текущую версию снимка счёта. Любое изменение
+// счёта удаляет этот ключ, и следующее чтение получает новую версию —
+// снимки, записанные под старой версией, больше не читаются. Так запрос,
+// прочитавший счёт до перевода и сохранивший его после инвалидации,
+// не может вернуть устаревший баланс.
+func AccountVersionKey(accountID string) string {
+	return "account:version:" + accountID
+}
+
+// AccountInfoKey — снимок счёта версии version
+func AccountInfoKey(accountID, version string) string {
+	return "account:info:" + accountID + ":" + version
+}
+
+func UserAccountsKey(userID string) string {
+	return "user:accounts:" + userID
+}
+
+// UserSessionKey — текущие версия сессий и роль пользователя (см. AuthService.Authenticate)
+func UserSessionKey(userID string) string {
+	return "user:session:" + userID
+}
+
+// LowBalanceNotifiedKey отмечает, что о низком остатке счёта уже
+// сообщено; ключ удаляется, когда остаток поднимается выше порога
+func LowBalanceNotifiedKey(accountID string) string {
+	return "notify:low_balance:" + accountID
+}
+
+// AccountVersion возвращает текущую версию снимка счёта, создавая новую,
+// если ключа версии нет
+func AccountVersion(ctx context.Context, c Cache, accountID string) (string, error) {
+	version, err := c.Get(ctx, AccountVersionKey(accountID))
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, ErrMiss) {
+		return "", err
+	}
+
+	version = strconv.FormatUint(rand.Uint64(), 36)
+	if err := c.Set(ctx, AccountVersionKey(accountID), version, AccountVersionTTL); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// Jitter возвращает ttl, случайно изменённый в пределах ±10%
+func Jitter(ttl time.Duration) time.Duration {
+	delta := time.Duration(float64(ttl) * ttlJitter)
+	if delta <= 0 {
+		return ttl
+	}
+	return ttl - delta + rand.N(2*delta+1)
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock — управляемые часы для проверки TTL
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestMemoryCache(maxEntries int) (*MemoryCache, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	m := NewMemoryCache(maxEntries)
+	m.now = clock.Now
+	return m, clock
+}
+
+func mustGet(t *testing.T, c Cache, key string) string {
+	t.Helper()
+
+	value, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return value
+}
+
+func expectMiss(t *testing.T, c Cache, key string) {
+	t.Helper()
+
+	if value, err := c.Get(context.Background(), key); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get(%q) = %q, %v, ожидался промах", key, value, err)
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMemoryCache(3)
+
+	for _, key := range []string{"a", "b", "c"} {
+		m.Set(ctx, key, key+"-value", 0)
+	}
+	// Чтение a и перезапись b делают их недавно использованными
+	mustGet(t, m, "a")
+	m.Set(ctx, "b", "b-updated", 0)
+
+	m.Set(ctx, "d", "d-value", 0)
+	expectMiss(t, m, "c")
+
+	m.Set(ctx, "e", "e-value", 0)
+	expectMiss(t, m, "a")
+
+	if got := mustGet(t, m, "b"); got != "b-updated" {
+		t.Errorf("b = %q", got)
+	}
+	mustGet(t, m, "d")
+	mustGet(t, m, "e")
+	if m.Len() != 3 {
+		t.Errorf("Len = %d, ожидалось 3", m.Len())
+	}
+
+	// Exists тоже считается обращением
+	if found, _ := m.Exists(ctx, "b"); !found {
+		t.Fatal("b не найден")
+	}
+	m.Set(ctx, "f", "f-value", 0)
+	expectMiss(t, m, "d")
+	mustGet(t, m, "b")
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	m, clock := newTestMemoryCache(10)
+
+	m.Set(ctx, "short", "1", time.Second)
+	m.Set(ctx, "long", "2", time.Minute)
+	m.Set(ctx, "forever", "3", 0)
+
+	clock.Advance(999 * time.Millisecond)
+	mustGet(t, m, "short")
+
+	// Срок истекает ровно в момент expiresAt
+	clock.Advance(time.Millisecond)
+	expectMiss(t, m, "short")
+	if found, _ := m.Exists(ctx, "short"); found {
+		t.Error("Exists для просроченного ключа")
+	}
+	if m.Len() != 2 {
+		t.Errorf("просроченный ключ не удалён при обращении: Len = %d", m.Len())
+	}
+
+	// Перезапись продлевает срок
+	m.Set(ctx, "long", "2-updated", time.Minute)
+	clock.Advance(59 * time.Second)
+	if got := mustGet(t, m, "long"); got != "2-updated" {
+		t.Errorf("long = %q", got)
+	}
+	clock.Advance(time.Second)
+	expectMiss(t, m, "long")
+
+	clock.Advance(365 * 24 * time.Hour)
+	mustGet(t, m, "forever")
+}
+
+func TestMemoryCacheDeleteAndFlush(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestMemoryCache(10)
+
+	for _, key := range []string{"a", "b", "c"} {
+		m.Set(ctx, key, key, 0)
+	}
+	m.Delete(ctx, "a", "b", "missing")
+	expectMiss(t, m, "a")
+	expectMiss(t, m, "b")
+	mustGet(t, m, "c")
+
+	m.Flush()
+	expectMiss(t, m, "c")
+	if m.Len() != 0 {
+		t.Errorf("Len после Flush = %d", m.Len())
+	}
+}
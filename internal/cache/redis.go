@@ -2,12 +2,16 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"bank-prototype/internal/tracing"
 )
 
+var _ Cache = (*RedisCache)(nil)
+
 type RedisCache struct {
 	client *redis.Client
 }
@@ -23,10 +27,17 @@ func NewRedisCache(addr string) *RedisCache {
 		PoolSize:     10,
 		MinIdleConns: 5,
 	})
+	client.AddHook(tracing.NewRedisHook())
 
 	return &RedisCache{client: client}
 }
 
+// Client возвращает клиент Redis для компонентов, которым нужны команды
+// помимо кеширования (например, Lua-скрипты rate limiter)
+func (r *RedisCache) Client() *redis.Client {
+	return r.client
+}
+
 func (r *RedisCache) Ping(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
@@ -36,27 +47,15 @@ func (r *RedisCache) Close() error {
 }
 
 func (r *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
-}
-
-func (r *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	return r.client.Set(ctx, key, value, ttl).Err()
-}
-
-func (r *RedisCache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	data, err := json.Marshal(value)
-	if err != nil {
-		return err
+	value, err := r.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrMiss
 	}
-	return r.client.Set(ctx, key, data, ttl).Err()
+	return value, err
 }
 
-func (r *RedisCache) GetJSON(ctx context.Context, key string, dest interface{}) error {
-	data, err := r.client.Get(ctx, key).Result()
-	if err != nil {
-		return err
-	}
-	return json.Unmarshal([]byte(data), dest)
+func (r *RedisCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
 func (r *RedisCache) Delete(ctx context.Context, keys ...string) error {
@@ -70,21 +69,3 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	}
 	return result > 0, nil
 }
-
-const (
-	AccountBalanceTTL = 60 * time.Second
-	AccountInfoTTL    = 60 * time.Second
-	UserAccountsTTL   = 300 * time.Second
-)
-
-func AccountBalanceKey(accountID string) string {
-	return "account:balance:" + accountID
-}
-
-func AccountInfoKey(accountID string) string {
-	return "account:info:" + accountID
-}
-
-func UserAccountsKey(userID string) string {
-	return "user:accounts:" + userID
-}
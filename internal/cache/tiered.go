@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"bank-prototype/internal/utils"
+)
+
+const (
+	// DefaultLocalTTL — сколько значение живёт в локальном уровне. Это верхняя
+	// граница устаревания, если сообщение об инвалидации потерялось.
+	DefaultLocalTTL = 5 * time.Second
+
+	// invalidationChannel — канал Redis, через который экземпляры API
+	// сообщают друг другу об изменённых ключах
+	invalidationChannel = "cache:invalidate"
+
+	// resubscribeDelay — пауза перед повторным чтением после обрыва соединения
+	resubscribeDelay = time.Second
+)
+
+var _ Cache = (*TieredCache)(nil)
+
+// TieredCache — двухуровневый кеш: L1 в памяти процесса и общий L2 в Redis.
+// Чтение сначала идёт в L1, промах заполняет его из Redis. Запись и удаление
+// выполняются в Redis и публикуются в invalidationChannel, по которому
+// остальные экземпляры удаляют ключи из своего L1.
+//
+// Пока подписка оборвана, сообщения теряются, поэтому при обрыве и
+// переподписке L1 очищается целиком.
+type TieredCache struct {
+	local      *MemoryCache
+	remote     *RedisCache
+	localTTL   time.Duration
+	instanceID string
+
+	pubsub *redis.PubSub
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type invalidationMessage struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewTieredCache создаёт кеш поверх remote и запускает подписку на инвалидации.
+// remote не закрывается в Close — им владеет вызывающий код.
+func NewTieredCache(remote *RedisCache, maxEntries int, localTTL time.Duration) *TieredCache {
+	if localTTL <= 0 {
+		localTTL = DefaultLocalTTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &TieredCache{
+		local:      NewMemoryCache(maxEntries),
+		remote:     remote,
+		localTTL:   localTTL,
+		instanceID: uuid.NewString(),
+		pubsub:     remote.Client().Subscribe(ctx, invalidationChannel),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go t.listen(ctx)
+
+	utils.LogSuccess("Cache", "Двухуровневый кеш: L1 до %d ключей (TTL %v), L2 Redis", t.local.maxEntries, localTTL)
+	return t
+}
+
+func (t *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := t.local.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	value, err := t.remote.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	_ = t.local.Set(ctx, key, value, t.localTTL)
+	return value, nil
+}
+
+func (t *TieredCache) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := t.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	localTTL := t.localTTL
+	if ttl > 0 && ttl < localTTL {
+		localTTL = ttl
+	}
+	_ = t.local.Set(ctx, key, value, localTTL)
+
+	t.publish(ctx, key)
+	return nil
+}
+
+func (t *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := t.remote.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	_ = t.local.Delete(ctx, keys...)
+
+	t.publish(ctx, keys...)
+	return nil
+}
+
+func (t *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if found, _ := t.local.Exists(ctx, key); found {
+		return true, nil
+	}
+	return t.remote.Exists(ctx, key)
+}
+
+func (t *TieredCache) Ping(ctx context.Context) error {
+	return t.remote.Ping(ctx)
+}
+
+// Close останавливает подписку и очищает L1
+func (t *TieredCache) Close() error {
+	t.cancel()
+	err := t.pubsub.Close()
+	<-t.done
+	t.local.Flush()
+	return err
+}
+
+// publish сообщает остальным экземплярам об изменённых ключах. Ошибка
+// не возвращается: значение в Redis уже обновлено, а чужой L1 устареет
+// не дольше чем на localTTL.
+func (t *TieredCache) publish(ctx context.Context, keys ...string) {
+	payload, err := json.Marshal(invalidationMessage{Origin: t.instanceID, Keys: keys})
+	if err != nil {
+		return
+	}
+
+	if err := t.remote.Client().Publish(ctx, invalidationChannel, payload).Err(); err != nil {
+		utils.LogWarning("Cache", "Не удалось опубликовать инвалидацию %d ключей: %v", len(keys), err)
+	}
+}
+
+func (t *TieredCache) listen(ctx context.Context) {
+	defer close(t.done)
+
+	subscribed := false
+	for {
+		msg, err := t.pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			utils.LogWarning("Cache", "Подписка на инвалидации прервана, L1 очищен: %v", err)
+			t.local.Flush()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeDelay):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// Повторная подписка после переподключения: за время обрыва
+			// могли быть пропущены инвалидации
+			if subscribed {
+				t.local.Flush()
+				utils.LogInfo("Cache", "Подписка на инвалидации восстановлена, L1 очищен")
+			}
+			subscribed = true
+
+		case *redis.Message:
+			t.invalidate(ctx, msg.Payload)
+		}
+	}
+}
+
+// invalidate удаляет из L1 ключи из сообщения другого экземпляра. Свои
+// сообщения пропускаются: L1 уже обновлён в Set или Delete.
+func (t *TieredCache) invalidate(ctx context.Context, payload string) {
+	var invalidation invalidationMessage
+	if err := json.Unmarshal([]byte(payload), &invalidation); err != nil {
+		utils.LogWarning("Cache", "Некорректное сообщение об инвалидации: %v", err)
+		return
+	}
+	if invalidation.Origin == t.instanceID {
+		return
+	}
+	_ = t.local.Delete(ctx, invalidation.Keys...)
+}
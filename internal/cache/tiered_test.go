@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func invalidationPayload(t *testing.T, origin string, keys ...string) string {
+	t.Helper()
+
+	payload, err := json.Marshal(invalidationMessage{Origin: origin, Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(payload)
+}
+
+func TestTieredCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	local, _ := newTestMemoryCache(10)
+	tiered := &TieredCache{local: local, instanceID: "self"}
+
+	for _, key := range []string{"a", "b", "c"} {
+		local.Set(ctx, key, key, 0)
+	}
+
+	// Собственное сообщение пропускается: L1 уже обновлён при записи
+	tiered.invalidate(ctx, invalidationPayload(t, "self", "a"))
+	mustGet(t, local, "a")
+
+	// Сообщение другого экземпляра удаляет ключи из L1
+	tiered.invalidate(ctx, invalidationPayload(t, "other", "a", "b"))
+	expectMiss(t, local, "a")
+	expectMiss(t, local, "b")
+	mustGet(t, local, "c")
+
+	// Некорректное сообщение ничего не удаляет
+	tiered.invalidate(ctx, "not json")
+	mustGet(t, local, "c")
+}
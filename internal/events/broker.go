@@ -0,0 +1,310 @@
+// Package events доставляет клиентам события о транзакциях и балансах в
+// реальном времени. События пользователя хранятся в Redis Stream — по нему
+// клиент возобновляет поток после обрыва (Last-Event-ID), — и рассылаются
+// всем экземплярам API через Redis pub/sub: клиент может быть подключён к
+// любому из них.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+const (
+	// channel — канал pub/sub, общий для всех экземпляров API
+	channel = "events"
+
+	streamKeyPrefix = "events:user:"
+
+	// streamLength — сколько последних событий пользователя хранится для
+	// возобновления; хранятся не дольше streamTTL после последнего события
+	streamLength = 1000
+	streamTTL    = 24 * time.Hour
+
+	// subscriberBuffer — сколько событий ждут медленного клиента, прежде
+	// чем его поток будет закрыт
+	subscriberBuffer = 64
+)
+
+var (
+	// ErrSlowConsumer — клиент не успевал читать события, поток закрыт.
+	// Клиент переподключается с Last-Event-ID и получает пропущенное.
+	ErrSlowConsumer = errors.New("клиент не успевает читать события")
+	// ErrClosed — брокер остановлен
+	ErrClosed = errors.New("поток событий закрыт")
+)
+
+// Broker публикует события и раздаёт их подписчикам этого экземпляра API
+type Broker struct {
+	client *redis.Client
+
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+	closed      bool
+}
+
+// envelope — сообщение в канале pub/sub
+type envelope struct {
+	UserID string        `json:"user_id"`
+	Event  *models.Event `json:"event"`
+}
+
+func NewBroker(client *redis.Client) *Broker {
+	utils.LogSuccess("Events", "Инициализирован брокер событий")
+	return &Broker{
+		client:      client,
+		subscribers: make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+// Publish сохраняет событие в истории пользователя и рассылает его всем
+// экземплярам API. ID события назначает Redis Stream.
+func (b *Broker) Publish(ctx context.Context, userID string, event *models.Event) error {
+	ctx, span := tracing.Start(ctx, "events.Publish", attribute.String("event.type", event.Type))
+	defer span.End()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	key := streamKeyPrefix + userID
+	id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: streamLength,
+		Approx: true,
+		Values: map[string]any{"event": payload},
+	}).Result()
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	if err := b.client.Expire(ctx, key, streamTTL).Err(); err != nil {
+		utils.LogWarning("Events", "Не удалось продлить историю событий %s: %v", userID, err)
+	}
+
+	event.ID = id
+	message, err := json.Marshal(envelope{UserID: userID, Event: event})
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	if err := b.client.Publish(ctx, channel, message).Err(); err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	utils.LogDebug("Events", "Событие %s %s для пользователя %s", event.Type, id, userID)
+	return nil
+}
+
+// Run получает события всех экземпляров API из канала pub/sub и раздаёт
+// подписчикам этого экземпляра. Блокирует вызывающую горутину до отмены
+// ctx, после чего закрывает все подписки.
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.client.Subscribe(ctx, channel)
+	defer func() {
+		_ = pubsub.Close()
+		b.closeAll()
+	}()
+
+	utils.LogInfo("Events", "Подписка на канал событий %s", channel)
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var env envelope
+			if err := json.Unmarshal([]byte(message.Payload), &env); err != nil || env.Event == nil {
+				utils.LogWarning("Events", "Некорректное сообщение в канале событий: %v", err)
+				continue
+			}
+			b.dispatch(env.UserID, *env.Event)
+		}
+	}
+}
+
+// Subscribe подписывает клиента на события пользователя. Если передан
+// lastEventID, подписка сначала отдаёт сохранённые события после него.
+// Подписку нужно закрыть через Close.
+func (b *Broker) Subscribe(ctx context.Context, userID, lastEventID string) (*Subscription, error) {
+	sub := &Subscription{
+		broker: b,
+		userID: userID,
+		events: make(chan models.Event, subscriberBuffer),
+	}
+
+	// Подписка регистрируется до чтения истории: событие, опубликованное
+	// между чтением и регистрацией, иначе бы потерялось. Дубликаты
+	// отбрасывает Next по ID.
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	if _, ok := parseID(lastEventID); !ok {
+		return sub, nil
+	}
+	sub.lastID = lastEventID
+
+	entries, err := b.client.XRange(ctx, streamKeyPrefix+userID, "("+lastEventID, "+").Result()
+	if err != nil {
+		sub.Close()
+		return nil, err
+	}
+	for _, entry := range entries {
+		payload, _ := entry.Values["event"].(string)
+		var event models.Event
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			utils.LogWarning("Events", "Некорректное событие %s в истории %s: %v", entry.ID, userID, err)
+			continue
+		}
+		event.ID = entry.ID
+		sub.backlog = append(sub.backlog, event)
+	}
+
+	utils.LogInfo("Events", "Пользователь %s возобновил поток с %s: пропущено событий %d", userID, lastEventID, len(sub.backlog))
+	return sub, nil
+}
+
+// dispatch отправляет событие подписчикам пользователя. Подписчик с
+// заполненным буфером отключается, а не задерживает остальных.
+func (b *Broker) dispatch(userID string, event models.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			utils.LogWarning("Events", "Поток пользователя %s закрыт: клиент не успевает читать события", userID)
+			b.remove(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+// remove снимает подписку и закрывает её канал. Вызывается под b.mu.
+func (b *Broker) remove(sub *Subscription, err error) {
+	subs := b.subscribers[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subscribers, sub.userID)
+	}
+	sub.err = err
+	close(sub.events)
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subscribers {
+		for sub := range subs {
+			b.remove(sub, ErrClosed)
+		}
+	}
+}
+
+// Subscription — поток событий одного клиента
+type Subscription struct {
+	broker  *Broker
+	userID  string
+	backlog []models.Event
+	events  chan models.Event
+	lastID  string // ID последнего отданного события
+	err     error  // причина закрытия канала events
+}
+
+// Next возвращает следующее событие: сначала пропущенные после Last-Event-ID,
+// затем новые. Событие, уже отданное из истории, повторно не возвращается.
+func (s *Subscription) Next(ctx context.Context) (models.Event, error) {
+	for {
+		if len(s.backlog) > 0 {
+			event := s.backlog[0]
+			s.backlog = s.backlog[1:]
+			s.lastID = event.ID
+			return event, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return models.Event{}, ctx.Err()
+		case event, ok := <-s.events:
+			if !ok {
+				return models.Event{}, s.err
+			}
+			if s.lastID != "" && !newer(event.ID, s.lastID) {
+				continue
+			}
+			s.lastID = event.ID
+			return event, nil
+		}
+	}
+}
+
+// Close снимает подписку
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, ErrClosed)
+}
+
+// newer сообщает, что ID записи Redis Stream a больше b
+func newer(a, b string) bool {
+	idA, okA := parseID(a)
+	idB, okB := parseID(b)
+	if !okA || !okB {
+		return true
+	}
+	if idA[0] != idB[0] {
+		return idA[0] > idB[0]
+	}
+	return idA[1] > idB[1]
+}
+
+// parseID разбирает ID записи Redis Stream: <миллисекунды>-<номер>
+func parseID(id string) ([2]uint64, bool) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return [2]uint64{}, false
+	}
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return [2]uint64{}, false
+	}
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return [2]uint64{}, false
+	}
+	return [2]uint64{msValue, seqValue}, true
+}
@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/models"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1700000000001-0", "1700000000000-5", true},
+		{"1700000000000-5", "1700000000000-4", true},
+		{"1700000000000-4", "1700000000000-4", false},
+		{"1700000000000-3", "1700000000000-10", false},
+		{"999-0", "1000-0", false},
+	}
+	for _, tt := range tests {
+		if got := newer(tt.a, tt.b); got != tt.want {
+			t.Errorf("newer(%s, %s) = %v", tt.a, tt.b, got)
+		}
+	}
+	if _, ok := parseID("not-an-id"); ok {
+		t.Error("parseID принял некорректный ID")
+	}
+}
+
+// TestSubscriptionSkipsReplayed проверяет, что событие, пришедшее через
+// pub/sub во время чтения истории, не отдаётся клиенту второй раз
+func TestSubscriptionSkipsReplayed(t *testing.T) {
+	ctx := context.Background()
+	broker := NewBroker(nil)
+
+	sub, err := broker.Subscribe(ctx, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	sub.backlog = []models.Event{{ID: "100-0"}, {ID: "100-1"}}
+
+	broker.dispatch("alice", models.Event{ID: "100-1"})
+	broker.dispatch("bob", models.Event{ID: "100-2"})
+	broker.dispatch("alice", models.Event{ID: "100-3"})
+
+	var got []string
+	for range 3 {
+		event, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		got = append(got, event.ID)
+	}
+	if want := "100-0 100-1 100-3"; strings.Join(got, " ") != want {
+		t.Errorf("события %v, ожидались %s", got, want)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := sub.Next(waitCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("лишнее событие: %v", err)
+	}
+}
+
+func TestSlowConsumerIsDisconnected(t *testing.T) {
+	ctx := context.Background()
+	broker := NewBroker(nil)
+
+	slow, _ := broker.Subscribe(ctx, "alice", "")
+	other, _ := broker.Subscribe(ctx, "alice", "")
+	defer other.Close()
+
+	for i := range subscriberBuffer + 1 {
+		broker.dispatch("alice", models.Event{ID: fmt.Sprintf("1-%d", i)})
+		if i < subscriberBuffer {
+			_, _ = other.Next(ctx)
+		}
+	}
+
+	var err error
+	for err == nil {
+		_, err = slow.Next(ctx)
+	}
+	if !errors.Is(err, ErrSlowConsumer) {
+		t.Errorf("медленный клиент: %v, ожидалось ErrSlowConsumer", err)
+	}
+	slow.Close() // повторное закрытие не паникует
+
+	broker.closeAll()
+	if _, err := other.Next(ctx); err != nil && !errors.Is(err, ErrClosed) {
+		t.Errorf("после остановки брокера: %v", err)
+	}
+	if _, err := broker.Subscribe(ctx, "alice", ""); !errors.Is(err, ErrClosed) {
+		t.Errorf("подписка после остановки: %v, ожидалось ErrClosed", err)
+	}
+}
@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/utils"
+)
+
+// accountServer реализует bank.v1.AccountService
+type accountServer struct {
+	bankv1.UnimplementedAccountServiceServer
+
+	service *services.AccountService
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, _ *bankv1.CreateAccountRequest) (*bankv1.Account, error) {
+	account, err := s.service.CreateAccount(ctx, userID(ctx))
+	if err != nil {
+		return nil, statusError(ctx, bankv1.AccountService_CreateAccount_FullMethodName, err)
+	}
+
+	utils.LogSuccess("gRPC", "Счёт создан: %s", account.ID)
+	return newAccount(account), nil
+}
+
+func (s *accountServer) ListAccounts(ctx context.Context, _ *bankv1.ListAccountsRequest) (*bankv1.ListAccountsResponse, error) {
+	accounts, err := s.service.GetUserAccounts(ctx, userID(ctx))
+	if err != nil {
+		return nil, statusError(ctx, bankv1.AccountService_ListAccounts_FullMethodName, err)
+	}
+
+	response := &bankv1.ListAccountsResponse{Accounts: make([]*bankv1.Account, 0, len(accounts))}
+	for i := range accounts {
+		response.Accounts = append(response.Accounts, newAccount(&accounts[i]))
+	}
+	return response, nil
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *bankv1.GetAccountRequest) (*bankv1.Account, error) {
+	account, err := s.service.GetAccount(ctx, req.GetId(), userID(ctx))
+	if err != nil {
+		return nil, statusError(ctx, bankv1.AccountService_GetAccount_FullMethodName, err)
+	}
+	return newAccount(account), nil
+}
+
+func newAccount(account *models.Account) *bankv1.Account {
+	return &bankv1.Account{
+		Id:               account.ID,
+		UserId:           account.UserID,
+		Balance:          string(models.MoneyOf(account.Balance)),
+		HeldAmount:       string(models.MoneyOf(account.HeldAmount)),
+		AvailableBalance: string(models.MoneyOf(account.AvailableBalance())),
+		Status:           account.Status,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+	}
+}
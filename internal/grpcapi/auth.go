@@ -0,0 +1,135 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// publicMethods — методы, доступные без токена
+var publicMethods = map[string]bool{
+	bankv1.AuthService_Login_FullMethodName: true,
+}
+
+// claimsContextKey — ключ данных токена в context.Context вызова
+type claimsContextKey struct{}
+
+// authenticator проверяет метаданные authorization: Bearer <token> тем же
+// AuthService.Authenticate, что и AuthMiddleware в HTTP
+type authenticator struct {
+	authService *services.AuthService
+}
+
+func (a *authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authenticator) stream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, tracing.ServerStream(stream, ctx))
+}
+
+// authenticate добавляет в контекст данные токена. Ответы те же, что у
+// RequireAuth: нет метаданных — unauthorized, неверный токен — invalid_token.
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		utils.LogRequest("gRPC", method, "anonymous")
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		utils.LogWarning("gRPC", "%s: отсутствуют метаданные authorization", method)
+		return nil, unauthenticated(ctx, method, apierror.CodeUnauthorized, "", nil)
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		utils.LogWarning("gRPC", "%s: неверный формат метаданных authorization", method)
+		detail := i18n.T(language(ctx), "detail.bearer_expected")
+		return nil, unauthenticated(ctx, method, apierror.CodeInvalidToken, detail, nil)
+	}
+
+	claims, err := a.authService.Authenticate(ctx, token)
+	if errors.Is(err, services.ErrInvalidToken) {
+		return nil, unauthenticated(ctx, method, apierror.CodeInvalidToken, "", err)
+	}
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("enduser.id", claims.UserID))
+	utils.LogRequest("gRPC", method, claims.UserID)
+	return context.WithValue(ctx, claimsContextKey{}, claims), nil
+}
+
+// userID возвращает пользователя вызова. Перехватчик не пропускает вызов
+// без токена, поэтому пустое значение означает ошибку в publicMethods.
+func userID(ctx context.Context) string {
+	claims, _ := ctx.Value(claimsContextKey{}).(*services.Claims)
+	if claims == nil {
+		return ""
+	}
+	return claims.UserID
+}
+
+// authServer реализует bank.v1.AuthService
+type authServer struct {
+	bankv1.UnimplementedAuthServiceServer
+
+	authService *services.AuthService
+	userRepo    repository.UserStore
+}
+
+// Login — вход по имени и паролю, как POST /v1/login
+func (s *authServer) Login(ctx context.Context, req *bankv1.LoginRequest) (*bankv1.LoginResponse, error) {
+	const method = bankv1.AuthService_Login_FullMethodName
+
+	user, err := s.userRepo.GetByName(ctx, req.GetName())
+	if errors.Is(err, repository.ErrUserNotFound) {
+		utils.LogWarning("gRPC", "Пользователь не найден: %s", req.GetName())
+		return nil, invalidCredentials(ctx)
+	}
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	if err := s.authService.CheckPasswordHash(req.GetPassword(), user.PasswordHash); err != nil {
+		utils.LogWarning("gRPC", "Неверный пароль для пользователя: %s", req.GetName())
+		return nil, invalidCredentials(ctx)
+	}
+
+	token, err := s.authService.GenerateToken(user)
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	utils.LogSuccess("gRPC", "Пользователь вошёл: %s (ID: %s)", user.Name, user.ID)
+	return &bankv1.LoginResponse{Token: token, UserId: user.ID}, nil
+}
+
+// invalidCredentials — одинаковый ответ для неизвестного имени и неверного пароля
+func invalidCredentials(ctx context.Context) error {
+	return unauthenticated(ctx, bankv1.AuthService_Login_FullMethodName, apierror.CodeInvalidCredentials, "", nil)
+}
@@ -0,0 +1,88 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/handlers"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// errorDomain — домен ErrorInfo: вместе с Reason однозначно задаёт ошибку
+const errorDomain = "bank-prototype"
+
+// grpcCodes сопоставляет статус проблемы apierror с кодом gRPC — единственное
+// место, где gRPC API имеет дело со статусами HTTP. Константы статусов взяты
+// из net/http, от HTTP-сервера пакет не зависит. Неизвестный статус — Internal.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:      codes.InvalidArgument,
+	http.StatusUnauthorized:    codes.Unauthenticated,
+	http.StatusForbidden:       codes.PermissionDenied,
+	http.StatusNotFound:        codes.NotFound,
+	http.StatusConflict:        codes.FailedPrecondition,
+	http.StatusGone:            codes.FailedPrecondition,
+	http.StatusTooManyRequests: codes.ResourceExhausted,
+}
+
+// statusError переводит ошибку сервиса в статус gRPC по таблице handlers.ProblemFor
+func statusError(ctx context.Context, method string, err error) error {
+	if ctx.Err() != nil {
+		// Клиент отменил вызов или истёк дедлайн — отвечаем кодом контекста
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return problemStatus(ctx, method, handlers.ProblemFor(err, language(ctx)), err)
+}
+
+// problemStatus переводит проблему в статус gRPC. Сообщение — подробности
+// или заголовок на языке вызова, стабильный код apierror и идентификатор
+// запроса передаются в errdetails.ErrorInfo.
+func problemStatus(ctx context.Context, method string, problem *apierror.Problem, err error) error {
+	requestID := tracing.RequestIDFromContext(ctx)
+	switch {
+	case problem.Status >= http.StatusInternalServerError:
+		utils.LogError("gRPC", "Ошибка обработки "+method+" (запрос "+requestID+")", err)
+	case err != nil:
+		utils.LogWarning("gRPC", "%s: %s, %v (запрос %s)", method, problem.Code, err, requestID)
+	}
+
+	code, ok := grpcCodes[problem.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	message := problem.Detail
+	if message == "" {
+		message = problem.Code.Title(language(ctx))
+	}
+
+	st := status.New(code, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(problem.Code),
+		Domain:   errorDomain,
+		Metadata: map[string]string{"request_id": requestID},
+	}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// unauthenticated — ответ на вызов без действительного токена или с
+// неверными учётными данными
+func unauthenticated(ctx context.Context, method string, code apierror.Code, detail string, err error) error {
+	return problemStatus(ctx, method, apierror.New(http.StatusUnauthorized, code, detail), err)
+}
+
+// language выбирает язык сообщений по метаданным accept-language так же,
+// как i18n.Middleware по заголовку Accept-Language
+func language(ctx context.Context) i18n.Lang {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return i18n.Negotiate(strings.Join(md.Get("accept-language"), ","))
+}
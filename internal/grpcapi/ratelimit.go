@@ -0,0 +1,70 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/middleware"
+)
+
+// rateLimitedMethods — методы с лимитом частоты и их группа. Вход делит
+// корзину группы auth с POST /v1/login: иначе пароль можно было бы
+// подбирать через gRPC в обход лимита HTTP.
+var rateLimitedMethods = map[string]string{
+	bankv1.AuthService_Login_FullMethodName: middleware.RateGroupAuth,
+}
+
+// rateLimiter ограничивает частоту вызовов по адресу клиента и, для входа,
+// по имени пользователя
+type rateLimiter struct {
+	limiter *middleware.RateLimiter
+}
+
+func (r *rateLimiter) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	group, ok := rateLimitedMethods[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	subjects := []string{middleware.IPSubject(peerIP(ctx))}
+	if login, ok := req.(*bankv1.LoginRequest); ok {
+		subjects = append(subjects, middleware.LoginSubject(login.GetName()))
+	}
+
+	allowed, retryAfter := r.limiter.Allow(ctx, group, subjects...)
+	if allowed {
+		return handler(ctx, req)
+	}
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+
+	var detail string
+	if policy, ok := r.limiter.Policy(group); ok {
+		detail = i18n.T(language(ctx), "detail.rate_limit", group, policy.Limit, policy.Period)
+	}
+	return nil, problemStatus(ctx, info.FullMethod, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, detail), nil)
+}
+
+// peerIP возвращает адрес клиента без порта
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
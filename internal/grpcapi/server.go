@@ -0,0 +1,47 @@
+// Package grpcapi — gRPC API для внутренних сервисов. Описание —
+// proto/bank/v1/bank.proto, сгенерированный код — в пакете bankv1.
+// Методы вызывают те же сервисы, что и HTTP API, а ошибки сопоставляются
+// с кодами по той же таблице, поэтому оба протокола ведут себя одинаково.
+package grpcapi
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=bank-prototype --go-grpc_out=../.. --go-grpc_opt=module=bank-prototype bank/v1/bank.proto
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/middleware"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// NewServer собирает gRPC-сервер с сервисами банка. Перехватчики: спан
+// вызова, лимит частоты для входа (общий с HTTP API), затем проверка токена
+// из метаданных authorization.
+func NewServer(
+	authService *services.AuthService,
+	userRepo repository.UserStore,
+	accountService *services.AccountService,
+	transactionService *services.TransactionService,
+	limiter *middleware.RateLimiter,
+) *grpc.Server {
+	auth := &authenticator{authService: authService}
+	limits := &rateLimiter{limiter: limiter}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracing.UnaryServerInterceptor, limits.unary, auth.unary),
+		grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor, auth.stream),
+	)
+	bankv1.RegisterAuthServiceServer(server, &authServer{authService: authService, userRepo: userRepo})
+	bankv1.RegisterAccountServiceServer(server, &accountServer{service: accountService})
+	bankv1.RegisterTransactionServiceServer(server, &transactionServer{service: transactionService})
+
+	// Описание сервисов для grpcurl и похожих инструментов
+	reflection.Register(server)
+
+	utils.LogSuccess("gRPC", "Инициализирован gRPC API")
+	return server
+}
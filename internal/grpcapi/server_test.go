@@ -0,0 +1,248 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/middleware"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+)
+
+const testPassword = "secret-password"
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testClient — клиенты gRPC к серверу поверх хранилища в памяти
+type testClient struct {
+	auth         bankv1.AuthServiceClient
+	accounts     bankv1.AccountServiceClient
+	transactions bankv1.TransactionServiceClient
+}
+
+func newTestClient(t *testing.T, users ...string) *testClient {
+	t.Helper()
+
+	return newLimitedTestClient(t, middleware.DefaultRateLimitPolicies, users...)
+}
+
+// newLimitedTestClient — клиент к серверу с заданными лимитами частоты;
+// корзины хранятся в памяти
+func newLimitedTestClient(t *testing.T, policies map[string]middleware.RateLimitPolicy, users ...string) *testClient {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	authService := services.NewAuthService("test-secret", time.Hour)
+	hash, err := authService.HashPassword(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range users {
+		if err := store.Users().Create(context.Background(), &models.User{Name: name, PasswordHash: hash}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := NewServer(authService, store.Users(),
+		services.NewAccountService(store.Accounts(), nil),
+		services.NewTransactionService(store.Transactions(), store.Accounts(), nil),
+		middleware.NewRateLimiter(nil, policies))
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return &testClient{
+		auth:         bankv1.NewAuthServiceClient(conn),
+		accounts:     bankv1.NewAccountServiceClient(conn),
+		transactions: bankv1.NewTransactionServiceClient(conn),
+	}
+}
+
+// login возвращает контекст с токеном пользователя name
+func (c *testClient) login(t *testing.T, name string) context.Context {
+	t.Helper()
+
+	resp, err := c.auth.Login(context.Background(), &bankv1.LoginRequest{Name: name, Password: testPassword})
+	if err != nil {
+		t.Fatalf("Login(%s): %v", name, err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+resp.GetToken())
+}
+
+func (c *testClient) createAccount(t *testing.T, ctx context.Context) *bankv1.Account {
+	t.Helper()
+
+	account, err := c.accounts.CreateAccount(ctx, &bankv1.CreateAccountRequest{})
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	return account
+}
+
+// assertStatus проверяет код gRPC и код apierror в ErrorInfo
+func assertStatus(t *testing.T, err error, want codes.Code, reason apierror.Code) *status.Status {
+	t.Helper()
+
+	st, _ := status.FromError(err)
+	if st.Code() != want {
+		t.Fatalf("код %s (%q), ожидался %s", st.Code(), st.Message(), want)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if info.GetReason() != string(reason) || info.GetDomain() != errorDomain {
+				t.Errorf("ErrorInfo = %v, ожидалась причина %s", info, reason)
+			}
+			if info.GetMetadata()["request_id"] == "" {
+				t.Error("в ErrorInfo нет request_id")
+			}
+			return st
+		}
+	}
+	t.Fatalf("в статусе нет ErrorInfo: %v", st.Details())
+	return st
+}
+
+func TestAuthentication(t *testing.T) {
+	client := newTestClient(t, "alice")
+
+	_, err := client.accounts.ListAccounts(context.Background(), &bankv1.ListAccountsRequest{})
+	assertStatus(t, err, codes.Unauthenticated, apierror.CodeUnauthorized)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer not-a-jwt")
+	_, err = client.accounts.ListAccounts(ctx, &bankv1.ListAccountsRequest{})
+	assertStatus(t, err, codes.Unauthenticated, apierror.CodeInvalidToken)
+
+	_, err = client.auth.Login(context.Background(), &bankv1.LoginRequest{Name: "alice", Password: "wrong"})
+	assertStatus(t, err, codes.Unauthenticated, apierror.CodeInvalidCredentials)
+
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(client.login(t, "alice"), "x-request-id", "grpc-request-7")
+	if _, err := client.accounts.ListAccounts(ctx, &bankv1.ListAccountsRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("ListAccounts: %v", err)
+	}
+	if got := header.Get("x-request-id"); len(got) != 1 || got[0] != "grpc-request-7" {
+		t.Errorf("x-request-id = %v", got)
+	}
+}
+
+func TestTransferAndHistory(t *testing.T) {
+	client := newTestClient(t, "alice", "bob")
+	alice := client.login(t, "alice")
+	from := client.createAccount(t, alice)
+	to := client.createAccount(t, client.login(t, "bob"))
+
+	transaction, err := client.transactions.Transfer(alice, &bankv1.TransferRequest{
+		FromAccountId: from.GetId(), ToAccountId: to.GetId(), Amount: "20.00",
+	})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if transaction.GetAmount() != "20.00" || transaction.GetFeeAmount() != "0.20" || transaction.GetTotalDebit() != "20.20" {
+		t.Errorf("транзакция = %v", transaction)
+	}
+
+	account, err := client.accounts.GetAccount(alice, &bankv1.GetAccountRequest{Id: from.GetId()})
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	if account.GetBalance() != "79.80" {
+		t.Errorf("баланс = %s, ожидалось 79.80", account.GetBalance())
+	}
+
+	stream, err := client.transactions.StreamHistory(alice, &bankv1.StreamHistoryRequest{AccountId: from.GetId()})
+	if err != nil {
+		t.Fatalf("StreamHistory: %v", err)
+	}
+	var ids []string
+	for {
+		item, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		ids = append(ids, item.GetId())
+	}
+	if len(ids) != 1 || ids[0] != transaction.GetId() {
+		t.Errorf("история = %v, ожидалась %s", ids, transaction.GetId())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	client := newTestClient(t, "alice", "bob")
+	alice := client.login(t, "alice")
+	bob := client.login(t, "bob")
+	from := client.createAccount(t, alice)
+	to := client.createAccount(t, bob)
+
+	_, err := client.accounts.GetAccount(bob, &bankv1.GetAccountRequest{Id: from.GetId()})
+	assertStatus(t, err, codes.PermissionDenied, apierror.CodeAccessDenied)
+
+	_, err = client.transactions.Transfer(alice, &bankv1.TransferRequest{
+		FromAccountId: from.GetId(), ToAccountId: to.GetId(), Amount: "1000.00",
+	})
+	assertStatus(t, err, codes.FailedPrecondition, apierror.CodeInsufficientBalance)
+
+	for _, amount := range []string{"", "10.505", "1e3", "10."} {
+		_, err = client.transactions.Payment(alice, &bankv1.PaymentRequest{
+			FromAccountId: from.GetId(), ToAccountId: to.GetId(), Amount: amount,
+		})
+		assertStatus(t, err, codes.InvalidArgument, apierror.CodeInvalidAmount)
+	}
+
+	// Язык сообщения — по метаданным accept-language
+	english := metadata.AppendToOutgoingContext(bob, "accept-language", "en-US")
+	_, err = client.transactions.GetTransaction(english, &bankv1.GetTransactionRequest{Id: "00000000-0000-0000-0000-000000000000"})
+	if st := assertStatus(t, err, codes.NotFound, apierror.CodeTransactionNotFound); st.Message() != "Transaction not found" {
+		t.Errorf("сообщение = %q", st.Message())
+	}
+}
+
+func TestLoginRateLimit(t *testing.T) {
+	client := newLimitedTestClient(t, map[string]middleware.RateLimitPolicy{
+		middleware.RateGroupAuth: {Limit: 2, Period: time.Minute},
+	}, "alice")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.auth.Login(ctx, &bankv1.LoginRequest{Name: "alice", Password: "wrong"})
+		assertStatus(t, err, codes.Unauthenticated, apierror.CodeInvalidCredentials)
+	}
+
+	// Корзина пуста: даже верный пароль не проверяется
+	var header metadata.MD
+	_, err := client.auth.Login(ctx, &bankv1.LoginRequest{Name: "alice", Password: testPassword}, grpc.Header(&header))
+	assertStatus(t, err, codes.ResourceExhausted, apierror.CodeRateLimited)
+	if got := header.Get("retry-after"); len(got) != 1 || got[0] != "30" {
+		t.Errorf("retry-after = %v, ожидалось 30", got)
+	}
+}
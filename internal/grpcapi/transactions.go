@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bank-prototype/internal/grpcapi/bankv1"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/utils"
+)
+
+// transactionServer реализует bank.v1.TransactionService
+type transactionServer struct {
+	bankv1.UnimplementedTransactionServiceServer
+
+	service *services.TransactionService
+}
+
+func (s *transactionServer) Transfer(ctx context.Context, req *bankv1.TransferRequest) (*bankv1.Transaction, error) {
+	const method = bankv1.TransactionService_Transfer_FullMethodName
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	transaction, err := s.service.Transfer(ctx, userID(ctx), models.TransferRequest{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        amount,
+	})
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	utils.LogSuccess("gRPC", "Перевод выполнен: %s", transaction.ID)
+	return newTransaction(transaction), nil
+}
+
+func (s *transactionServer) Payment(ctx context.Context, req *bankv1.PaymentRequest) (*bankv1.Transaction, error) {
+	const method = bankv1.TransactionService_Payment_FullMethodName
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	transaction, err := s.service.Payment(ctx, userID(ctx), models.PaymentRequest{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        amount,
+	})
+	if err != nil {
+		return nil, statusError(ctx, method, err)
+	}
+
+	utils.LogSuccess("gRPC", "Платёж выполнен: %s", transaction.ID)
+	return newTransaction(transaction), nil
+}
+
+func (s *transactionServer) GetTransaction(ctx context.Context, req *bankv1.GetTransactionRequest) (*bankv1.Transaction, error) {
+	transaction, err := s.service.GetTransactionByID(ctx, userID(ctx), req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, bankv1.TransactionService_GetTransaction_FullMethodName, err)
+	}
+	return newTransaction(transaction), nil
+}
+
+// StreamHistory отправляет историю по одной транзакции в сообщении: клиент
+// начинает обработку, не дожидаясь всей выборки
+func (s *transactionServer) StreamHistory(req *bankv1.StreamHistoryRequest, stream bankv1.TransactionService_StreamHistoryServer) error {
+	const method = bankv1.TransactionService_StreamHistory_FullMethodName
+	ctx := stream.Context()
+
+	var accountID *string
+	if req.GetAccountId() != "" {
+		accountID = &req.AccountId
+	}
+
+	transactions, err := s.service.GetTransactionHistory(ctx, userID(ctx), accountID)
+	if err != nil {
+		return statusError(ctx, method, err)
+	}
+
+	for i := range transactions {
+		if err := stream.Send(newTransaction(&transactions[i])); err != nil {
+			utils.LogWarning("gRPC", "%s: поток прерван после %d из %d транзакций: %v", method, i, len(transactions), err)
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAmount разбирает сумму-строку. Формат проверяется здесь: у gRPC
+// нет схемы запроса, как у HTTP API v2.
+func parseAmount(amount string) (float64, error) {
+	value, err := models.Money(amount).Float64()
+	if err != nil {
+		return 0, i18n.Errorf(services.ErrInvalidAmount, "validation.decimal")
+	}
+	return value, nil
+}
+
+func newTransaction(t *models.Transaction) *bankv1.Transaction {
+	transaction := &bankv1.Transaction{
+		Id:                t.ID,
+		Type:              t.Type,
+		FromAccountId:     t.FromAccountID,
+		ToAccountId:       t.ToAccountID,
+		Amount:            string(models.MoneyOf(t.Amount)),
+		FeePercent:        int32(t.FeePercent),
+		FeeAmount:         string(models.MoneyOf(t.FeeAmount)),
+		TotalDebit:        string(models.MoneyOf(t.TotalDebit)),
+		Status:            t.Status,
+		CreatedAt:         timestamppb.New(t.CreatedAt),
+		RefundedAmount:    string(models.MoneyOf(t.RefundedAmount)),
+		RefundedFeeAmount: string(models.MoneyOf(t.RefundedFeeAmount)),
+	}
+	if t.OriginalTransactionID != nil {
+		transaction.OriginalTransactionId = *t.OriginalTransactionID
+	}
+	for i := range t.Refunds {
+		transaction.Refunds = append(transaction.Refunds, newTransaction(&t.Refunds[i]))
+	}
+	return transaction
+}
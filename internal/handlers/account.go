@@ -2,13 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/valyala/fasthttp"
 
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/repository"
 	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
 )
 
@@ -24,36 +29,30 @@ func NewAccountHandler(accountService *services.AccountService) *AccountHandler
 
 // CreateAccount обрабатывает POST /accounts - создание нового счёта
 func (h *AccountHandler) CreateAccount(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("AccountHandler", "user_id не найден в контексте", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		writeUnauthorized(ctx, "AccountHandler", "/accounts", startTime)
 		return
 	}
 
 	utils.LogInfo("AccountHandler", " Запрос на создание счёта от пользователя: "+userID)
 
 	// Создаём счёт
-	account, err := h.accountService.CreateAccount(ctx, userID)
+	account, err := h.accountService.CreateAccount(tracing.Context(ctx), userID)
 	if err != nil {
-		if err == services.ErrAccountLimitReached {
-			ctx.SetStatusCode(fasthttp.StatusForbidden)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Достигнут лимит активных счетов (максимум 5)"})
-		} else {
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Ошибка создания счёта"})
-		}
-		utils.LogError("AccountHandler", "Ошибка создания счёта", err)
+		writeError(ctx, "AccountHandler", "/accounts", err, startTime)
 		return
 	}
 
 	// Формируем ответ
 	response := models.AccountResponse{
-		ID:        account.ID,
-		Balance:   account.Balance,
-		Status:    account.Status,
-		CreatedAt: account.CreatedAt.Format("2006-01-02 15:04:05"),
+		ID:               account.ID,
+		Balance:          account.Balance,
+		AvailableBalance: account.AvailableBalance(),
+		Status:           account.Status,
+		CreatedAt:        account.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 
 	ctx.SetStatusCode(fasthttp.StatusCreated)
@@ -65,51 +64,42 @@ func (h *AccountHandler) CreateAccount(ctx *fasthttp.RequestCtx) {
 
 // GetAccounts обрабатывает GET /accounts - список всех активных счетов пользователя
 func (h *AccountHandler) GetAccounts(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("AccountHandler", "user_id не найден в контексте", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		writeUnauthorized(ctx, "AccountHandler", "/accounts", startTime)
 		return
 	}
 
 	utils.LogInfo("AccountHandler", " Запрос списка счетов от пользователя: "+userID)
 
-	accounts, err := h.accountService.GetUserAccounts(ctx, userID)
+	accounts, err := h.accountService.GetUserAccounts(tracing.Context(ctx), userID)
 	if err != nil {
-		utils.LogError("AccountHandler", "Ошибка получения счетов", err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Ошибка получения счетов"})
+		writeError(ctx, "AccountHandler", "/accounts", err, startTime)
 		return
 	}
 
 	// Формируем список ответов и подсчитываем статистику
 	var accountResponses []models.AccountResponse
-	activeCount := 0
-	closedCount := 0
-
 	for _, acc := range accounts {
 		accountResponses = append(accountResponses, models.AccountResponse{
-			ID:        acc.ID,
-			Balance:   acc.Balance,
-			Status:    acc.Status,
-			CreatedAt: acc.CreatedAt.Format("2006-01-02 15:04:05"),
+			ID:               acc.ID,
+			Balance:          acc.Balance,
+			AvailableBalance: acc.AvailableBalance(),
+			Status:           acc.Status,
+			CreatedAt:        acc.CreatedAt.Format("2006-01-02 15:04:05"),
 		})
-
-		if acc.Status == "active" {
-			activeCount++
-		} else {
-			closedCount++
-		}
 	}
+	activeCount, closedCount := countAccounts(accounts)
 
 	response := models.AccountListResponse{
 		Accounts:      accountResponses,
 		Total:         len(accountResponses),
 		ActiveCount:   activeCount,
 		ClosedCount:   closedCount,
-		MaxAccounts:   5,
-		CanCreateMore: activeCount < 5,
+		MaxAccounts:   services.MaxActiveAccounts,
+		CanCreateMore: activeCount < services.MaxActiveAccounts,
 	}
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
@@ -121,85 +111,143 @@ func (h *AccountHandler) GetAccounts(ctx *fasthttp.RequestCtx) {
 
 // GetAccountByID обрабатывает GET /accounts/{id} - информация о конкретном счёте
 func (h *AccountHandler) GetAccountByID(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("AccountHandler", "user_id не найден в контексте", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		writeUnauthorized(ctx, "AccountHandler", "/accounts/:id", startTime)
 		return
 	}
 
-	accountID := ctx.UserValue("id").(string)
-	utils.LogInfo("AccountHandler", "📥 Запрос информации о счёте: "+accountID)
+	account, ok := h.loadAccount(ctx, userID, "/accounts/:id", startTime)
+	if !ok {
+		return
+	}
+
+	response := models.AccountResponse{
+		ID:               account.ID,
+		Balance:          account.Balance,
+		AvailableBalance: account.AvailableBalance(),
+		Status:           account.Status,
+		CreatedAt:        account.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	_ = json.NewEncoder(ctx).Encode(response)
+
+	utils.LogSuccess("AccountHandler", "✅ Информация о счёте отправлена: %s", account.ID)
+}
+
+// GetAccountsV2 обрабатывает GET /v2/accounts — список счетов с суммами строками
+func (h *AccountHandler) GetAccountsV2(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
 
-	account, err := h.accountService.GetAccount(ctx, accountID, userID)
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AccountHandler", "/v2/accounts", startTime)
+		return
+	}
+
+	accounts, err := h.accountService.GetUserAccounts(tracing.Context(ctx), userID)
 	if err != nil {
-		if err == repository.ErrAccountNotFound {
-			ctx.SetStatusCode(fasthttp.StatusNotFound)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Счёт не найден"})
-		} else if err == services.ErrUnauthorizedAccess {
-			ctx.SetStatusCode(fasthttp.StatusForbidden)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Нет доступа к данному счёту"})
-		} else if err == repository.ErrAccountClosed {
-			ctx.SetStatusCode(fasthttp.StatusGone)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Счёт закрыт"})
-		} else {
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Ошибка получения счёта"})
-		}
-		utils.LogError("AccountHandler", "Ошибка получения счёта", err)
+		writeError(ctx, "AccountHandler", "/v2/accounts", err, startTime)
 		return
 	}
 
-	response := models.AccountResponse{
-		ID:        account.ID,
-		Balance:   account.Balance,
-		Status:    account.Status,
-		CreatedAt: account.CreatedAt.Format("2006-01-02 15:04:05"),
+	response := models.AccountListResponseV2{
+		Accounts:    make([]models.AccountV2, 0, len(accounts)),
+		Total:       len(accounts),
+		MaxAccounts: services.MaxActiveAccounts,
 	}
+	for i := range accounts {
+		response.Accounts = append(response.Accounts, models.NewAccountV2(&accounts[i]))
+	}
+	response.ActiveCount, response.ClosedCount = countAccounts(accounts)
+	response.CanCreateMore = response.ActiveCount < services.MaxActiveAccounts
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
 	ctx.SetContentType("application/json")
 	_ = json.NewEncoder(ctx).Encode(response)
 
-	utils.LogSuccess("AccountHandler", "✅ Информация о счёте отправлена: "+accountID)
+	utils.LogResponse("/v2/accounts", fasthttp.StatusOK, time.Since(startTime))
 }
 
-func (h *AccountHandler) DeleteAccount(ctx *fasthttp.RequestCtx) {
+// GetAccountByIDV2 обрабатывает GET /v2/accounts/{id}
+func (h *AccountHandler) GetAccountByIDV2(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("AccountHandler", "user_id не найден в контексте", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		writeUnauthorized(ctx, "AccountHandler", "/v2/accounts/:id", startTime)
+		return
+	}
+
+	account, ok := h.loadAccount(ctx, userID, "/v2/accounts/:id", startTime)
+	if !ok {
 		return
 	}
 
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	_ = json.NewEncoder(ctx).Encode(models.NewAccountV2(account))
+
+	utils.LogResponse("/v2/accounts/:id", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// loadAccount читает счёт {id} пользователя для всех версий API. При
+// ошибке ответ уже отправлен.
+func (h *AccountHandler) loadAccount(ctx *fasthttp.RequestCtx, userID, path string, startTime time.Time) (*models.Account, bool) {
 	accountID := ctx.UserValue("id").(string)
-	utils.LogInfo("AccountHandler", "Запрос на закрытие счёта: "+accountID)
+	utils.LogInfo("AccountHandler", "📥 Запрос информации о счёте: "+accountID)
 
-	err := h.accountService.DeleteAccount(ctx, accountID, userID)
+	account, err := h.accountService.GetAccount(tracing.Context(ctx), accountID, userID)
 	if err != nil {
-		if err == repository.ErrAccountNotFound {
-			ctx.SetStatusCode(fasthttp.StatusNotFound)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Счёт не найден"})
-		} else if err == services.ErrUnauthorizedAccess {
-			ctx.SetStatusCode(fasthttp.StatusForbidden)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Нет доступа к данному счёту"})
-		} else if err == services.ErrAccountAlreadyClosed {
-			ctx.SetStatusCode(fasthttp.StatusGone)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Счёт уже закрыт"})
+		if errors.Is(err, repository.ErrAccountClosed) {
+			// Закрытый счёт при чтении — удалённый ресурс, а не конфликт состояния
+			writeProblem(ctx, "AccountHandler", path,
+				apierror.New(fasthttp.StatusGone, apierror.CodeAccountClosed, ""), err, startTime)
+			return nil, false
+		}
+		writeError(ctx, "AccountHandler", path, err, startTime)
+		return nil, false
+	}
+	return account, true
+}
+
+// countAccounts считает активные и закрытые счета для ответа со списком
+func countAccounts(accounts []models.Account) (active, closed int) {
+	for _, account := range accounts {
+		if account.Status == "active" {
+			active++
 		} else {
-			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-			_ = json.NewEncoder(ctx).Encode(map[string]string{"error": "Ошибка закрытия счёта"})
+			closed++
 		}
-		utils.LogError("AccountHandler", "Ошибка закрытия счёта", err)
+	}
+	return active, closed
+}
+
+func (h *AccountHandler) DeleteAccount(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AccountHandler", "/accounts/:id", startTime)
+		return
+	}
+
+	accountID := ctx.UserValue("id").(string)
+	utils.LogInfo("AccountHandler", "Запрос на закрытие счёта: "+accountID)
+
+	if err := h.accountService.DeleteAccount(tracing.Context(ctx), accountID, userID); err != nil {
+		writeError(ctx, "AccountHandler", "/accounts/:id", err, startTime)
 		return
 	}
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
-	_ = json.NewEncoder(ctx).Encode(map[string]string{
-		"message":    "Счёт успешно закрыт",
-		"account_id": accountID,
+	_ = json.NewEncoder(ctx).Encode(models.CloseAccountResponse{
+		Message:   i18n.T(i18n.FromRequest(ctx), "message.account_closed"),
+		AccountID: accountID,
 	})
 
 	utils.LogSuccess("AccountHandler", "Счёт успешно закрыт: "+accountID)
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/repository"
 	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,10 +18,10 @@ import (
 
 type AuthHandler struct {
 	authService *services.AuthService
-	userRepo    *repository.UserRepository
+	userRepo    repository.UserStore
 }
 
-func NewAuthHandler(authService *services.AuthService, userRepo *repository.UserRepository) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, userRepo repository.UserStore) *AuthHandler {
 	utils.LogSuccess("AuthHandler", "Инициализирован обработчик аутентификации")
 	return &AuthHandler{
 		authService: authService,
@@ -31,35 +35,26 @@ func (h *AuthHandler) RegisterHandler(ctx *fasthttp.RequestCtx) {
 
 	var req models.RegisterRequest
 	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
-		utils.LogError("AuthHandler", "Ошибка парсинга JSON", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Неверный формат данных",
-		})
-		utils.LogResponse("/register", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeMalformed(ctx, "AuthHandler", "/register", err, startTime)
 		return
 	}
 
-	if req.Name == "" || req.Password == "" {
-		utils.LogWarning("AuthHandler", "Отсутствуют обязательные поля")
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Имя и пароль обязательны",
-		})
-		utils.LogResponse("/register", fasthttp.StatusBadRequest, time.Since(startTime))
+	// Схему запроса уже проверил маршрутизатор; проверка остаётся на случай
+	// регистрации обработчика без схемы
+	lang := i18n.FromRequest(ctx)
+	var fieldErrors []apierror.FieldError
+	if req.Name == "" {
+		fieldErrors = append(fieldErrors, apierror.FieldError{In: "body", Field: "name", Message: i18n.T(lang, "validation.required_field")})
+	}
+	if len(fieldErrors) > 0 {
+		writeProblem(ctx, "AuthHandler", "/register",
+			apierror.New(fasthttp.StatusBadRequest, apierror.CodeValidationFailed, "").WithErrors(fieldErrors), nil, startTime)
 		return
 	}
 
-	if len(req.Password) < 6 {
-		utils.LogWarning("AuthHandler", "Пароль слишком короткий")
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Пароль должен быть не менее 6 символов",
-		})
-		utils.LogResponse("/register", fasthttp.StatusBadRequest, time.Since(startTime))
+	// Политика паролей строже схемы: схема проверяет только длину
+	if err := services.ValidatePassword(req.Password, req.Name); err != nil {
+		writeError(ctx, "AuthHandler", "/register", err, startTime)
 		return
 	}
 
@@ -67,13 +62,7 @@ func (h *AuthHandler) RegisterHandler(ctx *fasthttp.RequestCtx) {
 
 	passwordHash, err := h.authService.HashPassword(req.Password)
 	if err != nil {
-		utils.LogError("AuthHandler", "Ошибка хеширования пароля", err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Внутренняя ошибка сервера",
-		})
-		utils.LogResponse("/register", fasthttp.StatusInternalServerError, time.Since(startTime))
+		writeError(ctx, "AuthHandler", "/register", err, startTime)
 		return
 	}
 
@@ -82,14 +71,8 @@ func (h *AuthHandler) RegisterHandler(ctx *fasthttp.RequestCtx) {
 		PasswordHash: passwordHash,
 	}
 
-	if err := h.userRepo.Create(ctx, user); err != nil {
-		utils.LogError("AuthHandler", fmt.Sprintf("Ошибка создания пользователя %s", req.Name), err)
-		ctx.SetStatusCode(fasthttp.StatusConflict)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Пользователь с таким именем уже существует",
-		})
-		utils.LogResponse("/register", fasthttp.StatusConflict, time.Since(startTime))
+	if err := h.userRepo.Create(tracing.Context(ctx), user); err != nil {
+		writeError(ctx, "AuthHandler", "/register", err, startTime)
 		return
 	}
 
@@ -97,11 +80,11 @@ func (h *AuthHandler) RegisterHandler(ctx *fasthttp.RequestCtx) {
 
 	ctx.SetStatusCode(fasthttp.StatusCreated)
 	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"message":    "Пользователь успешно зарегистрирован",
-		"user_id":    user.ID,
-		"name":       user.Name,
-		"created_at": user.CreatedAt,
+	json.NewEncoder(ctx).Encode(models.RegisterResponse{
+		Message:   i18n.T(i18n.FromRequest(ctx), "message.user_registered"),
+		UserID:    user.ID,
+		Name:      user.Name,
+		CreatedAt: user.CreatedAt,
 	})
 
 	utils.LogResponse("/register", fasthttp.StatusCreated, time.Since(startTime))
@@ -114,53 +97,35 @@ func (h *AuthHandler) LoginHandler(ctx *fasthttp.RequestCtx) {
 
 	var req models.LoginRequest
 	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
-		utils.LogError("AuthHandler", "Ошибка парсинга JSON", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Неверный формат данных",
-		})
-		utils.LogResponse("/login", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeMalformed(ctx, "AuthHandler", "/login", err, startTime)
 		return
 	}
 
 	utils.LogInfo("AuthHandler", fmt.Sprintf("Попытка входа пользователя: %s", req.Name))
 
 	// Получение пользователя
-	user, err := h.userRepo.GetByName(ctx, req.Name)
-	if err != nil {
+	user, err := h.userRepo.GetByName(tracing.Context(ctx), req.Name)
+	if errors.Is(err, repository.ErrUserNotFound) {
 		utils.LogWarning("AuthHandler", fmt.Sprintf("Пользователь не найден: %s", req.Name))
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Неверное имя пользователя или пароль",
-		})
-		utils.LogResponse("/login", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeInvalidCredentials(ctx, startTime)
+		return
+	}
+	if err != nil {
+		writeError(ctx, "AuthHandler", "/login", err, startTime)
 		return
 	}
 
 	// Проверка пароля
 	if err := h.authService.CheckPasswordHash(req.Password, user.PasswordHash); err != nil {
 		utils.LogWarning("AuthHandler", fmt.Sprintf("Неверный пароль для пользователя: %s", req.Name))
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Неверное имя пользователя или пароль",
-		})
-		utils.LogResponse("/login", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeInvalidCredentials(ctx, startTime)
 		return
 	}
 
 	// Генерация токена
-	token, err := h.authService.GenerateToken(user.ID)
+	token, err := h.authService.GenerateToken(user)
 	if err != nil {
-		utils.LogError("AuthHandler", "Ошибка генерации токена", err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Внутренняя ошибка сервера",
-		})
-		utils.LogResponse("/login", fasthttp.StatusInternalServerError, time.Since(startTime))
+		writeError(ctx, "AuthHandler", "/login", err, startTime)
 		return
 	}
 
@@ -169,54 +134,20 @@ func (h *AuthHandler) LoginHandler(ctx *fasthttp.RequestCtx) {
 	// Ответ
 	ctx.SetStatusCode(fasthttp.StatusOK)
 	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"message":    "Вход выполнен успешно",
-		"token":      token,
-		"user_id":    user.ID,
-		"name":       user.Name,
-		"expires_in": "24h",
+	json.NewEncoder(ctx).Encode(models.LoginResponse{
+		Message:   i18n.T(i18n.FromRequest(ctx), "message.logged_in"),
+		Token:     token,
+		UserID:    user.ID,
+		Name:      user.Name,
+		ExpiresIn: "24h",
 	})
 
 	utils.LogResponse("/login", fasthttp.StatusOK, time.Since(startTime))
 }
 
-func (h *AuthHandler) DeleteUserHandler(ctx *fasthttp.RequestCtx) {
-	startTime := time.Now()
-
-	userID, ok := ctx.UserValue("user_id").(string)
-	if !ok || userID == "" {
-		utils.LogError("AuthHandler", "user_id не найден в контексте", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Требуется авторизация",
-		})
-		utils.LogResponse("/users/me", fasthttp.StatusUnauthorized, time.Since(startTime))
-		return
-	}
-
-	utils.LogRequest("DELETE", "/users/me", userID)
-	utils.LogInfo("AuthHandler", fmt.Sprintf("Попытка удаления пользователя: %s", userID))
-
-	if err := h.userRepo.Delete(ctx, userID); err != nil {
-		utils.LogError("AuthHandler", fmt.Sprintf("Ошибка удаления пользователя %s", userID), err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{
-			"error": "Ошибка удаления пользователя",
-		})
-		utils.LogResponse("/users/me", fasthttp.StatusInternalServerError, time.Since(startTime))
-		return
-	}
-
-	utils.LogSuccess("AuthHandler", fmt.Sprintf("Пользователь удалён: %s", userID))
-
-	ctx.SetStatusCode(fasthttp.StatusOK)
-	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"message": "Пользователь успешно удалён",
-		"user_id": userID,
-	})
-
-	utils.LogResponse("/users/me", fasthttp.StatusOK, time.Since(startTime))
+// writeInvalidCredentials отвечает одинаково для неизвестного имени и
+// неверного пароля, чтобы по ответу нельзя было перебирать имена
+func writeInvalidCredentials(ctx *fasthttp.RequestCtx, startTime time.Time) {
+	writeProblem(ctx, "AuthHandler", "/login",
+		apierror.New(fasthttp.StatusUnauthorized, apierror.CodeInvalidCredentials, ""), nil, startTime)
 }
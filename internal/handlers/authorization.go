@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type AuthorizationHandler struct {
+	service *services.AuthorizationService
+}
+
+func NewAuthorizationHandler(service *services.AuthorizationService) *AuthorizationHandler {
+	utils.LogSuccess("AuthorizationHandler", "Инициализирован обработчик авторизаций")
+	return &AuthorizationHandler{service: service}
+}
+
+// Create обрабатывает POST /authorizations
+func (h *AuthorizationHandler) Create(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AuthorizationHandler", "/authorizations", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/authorizations", userID)
+
+	var req models.CreateAuthorizationRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "AuthorizationHandler", "/authorizations", err, startTime)
+		return
+	}
+
+	auth, err := h.service.Create(tracing.Context(ctx), userID, req)
+	if err != nil {
+		writeError(ctx, "AuthorizationHandler", "/authorizations", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(auth)
+
+	utils.LogResponse("/authorizations", fasthttp.StatusCreated, time.Since(startTime))
+}
+
+// List обрабатывает GET /authorizations
+func (h *AuthorizationHandler) List(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AuthorizationHandler", "/authorizations", startTime)
+		return
+	}
+
+	utils.LogRequest("GET", "/authorizations", userID)
+
+	auths, err := h.service.List(tracing.Context(ctx), userID)
+	if err != nil {
+		writeError(ctx, "AuthorizationHandler", "/authorizations", err, startTime)
+		return
+	}
+
+	if auths == nil {
+		auths = []models.Authorization{}
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.AuthorizationListResponse{
+		Authorizations: auths,
+		Total:          len(auths),
+	})
+
+	utils.LogResponse("/authorizations", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// GetByID обрабатывает GET /authorizations/{id}
+func (h *AuthorizationHandler) GetByID(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AuthorizationHandler", "/authorizations/:id", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("GET", fmt.Sprintf("/authorizations/%s", id), userID)
+
+	auth, err := h.service.Get(tracing.Context(ctx), userID, id)
+	if err != nil {
+		writeError(ctx, "AuthorizationHandler", "/authorizations/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(auth)
+
+	utils.LogResponse("/authorizations/:id", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// Capture обрабатывает POST /authorizations/{id}/capture
+func (h *AuthorizationHandler) Capture(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AuthorizationHandler", "/authorizations/:id/capture", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("POST", fmt.Sprintf("/authorizations/%s/capture", id), userID)
+
+	var req models.CaptureAuthorizationRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeMalformed(ctx, "AuthorizationHandler", "/authorizations/:id/capture", err, startTime)
+			return
+		}
+	}
+
+	result, err := h.service.Capture(tracing.Context(ctx), userID, id, req)
+	if err != nil {
+		writeError(ctx, "AuthorizationHandler", "/authorizations/:id/capture", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(result)
+
+	utils.LogResponse("/authorizations/:id/capture", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// Release обрабатывает POST /authorizations/{id}/release
+func (h *AuthorizationHandler) Release(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "AuthorizationHandler", "/authorizations/:id/release", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("POST", fmt.Sprintf("/authorizations/%s/release", id), userID)
+
+	auth, err := h.service.Release(tracing.Context(ctx), userID, id)
+	if err != nil {
+		writeError(ctx, "AuthorizationHandler", "/authorizations/:id/release", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(auth)
+
+	utils.LogResponse("/authorizations/:id/release", fasthttp.StatusOK, time.Since(startTime))
+}
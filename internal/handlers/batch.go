@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type BatchHandler struct {
+	service *services.BatchService
+}
+
+func NewBatchHandler(service *services.BatchService) *BatchHandler {
+	utils.LogSuccess("BatchHandler", "Инициализирован обработчик пакетных переводов")
+	return &BatchHandler{service: service}
+}
+
+// Create обрабатывает POST /transactions/batch.
+// Принимает JSON, CSV в теле запроса (text/csv, параметры from_account_id и
+// mode — в query) или загрузку файла multipart/form-data (поле file).
+func (h *BatchHandler) Create(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "BatchHandler", "/transactions/batch", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/transactions/batch", userID)
+
+	req, err := parseBatchRequest(ctx)
+	if err != nil {
+		writeMalformed(ctx, "BatchHandler", "/transactions/batch", err, startTime)
+		return
+	}
+
+	batch, async, err := h.service.Submit(tracing.Context(ctx), userID, *req)
+	if err != nil {
+		writeError(ctx, "BatchHandler", "/transactions/batch", err, startTime)
+		return
+	}
+
+	status := fasthttp.StatusCreated
+	if async {
+		status = fasthttp.StatusAccepted
+		ctx.Response.Header.Set("Location", "/transactions/batch/"+batch.ID)
+	}
+
+	ctx.SetStatusCode(status)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(batchResponse(batch))
+
+	utils.LogResponse("/transactions/batch", status, time.Since(startTime))
+}
+
+// GetByID обрабатывает GET /transactions/batch/{id}
+func (h *BatchHandler) GetByID(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "BatchHandler", "/transactions/batch/:id", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("GET", fmt.Sprintf("/transactions/batch/%s", id), userID)
+
+	batch, err := h.service.Get(tracing.Context(ctx), userID, id)
+	if err != nil {
+		writeError(ctx, "BatchHandler", "/transactions/batch/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(batchResponse(batch))
+
+	utils.LogResponse("/transactions/batch/:id", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// batchResponse заменяет сохранённые причины неудачи пакета и позиций
+// кодами API
+func batchResponse(batch *models.TransferBatch) *models.TransferBatch {
+	response := *batch
+	response.Error = failureCode(batch.Error)
+	response.Items = make([]models.TransferBatchItem, len(batch.Items))
+	for i, item := range batch.Items {
+		item.Error = failureCode(item.Error)
+		response.Items[i] = item
+	}
+	return &response
+}
+
+// errInvalidBatchData — тело пакета не удалось разобрать; подробности на
+// языке клиента добавляются через i18n.Errorf
+var errInvalidBatchData = errors.New("неверные данные пакета")
+
+func parseBatchRequest(ctx *fasthttp.RequestCtx) (*models.BatchTransferRequest, error) {
+	contentType := string(ctx.Request.Header.ContentType())
+
+	switch {
+	case strings.HasPrefix(contentType, "multipart/form-data"):
+		form, err := ctx.MultipartForm()
+		if err != nil {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_form", err.Error())
+		}
+		files := form.File["file"]
+		if len(files) == 0 {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_file_missing")
+		}
+		file, err := files[0].Open()
+		if err != nil {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_file_read", err.Error())
+		}
+		defer file.Close()
+
+		req := &models.BatchTransferRequest{
+			FromAccountID: formValue(form.Value, "from_account_id"),
+			Mode:          formValue(form.Value, "mode"),
+		}
+		req.Items, err = parseBatchCSV(file)
+		return req, err
+
+	case strings.HasPrefix(contentType, "text/csv"):
+		req := &models.BatchTransferRequest{
+			FromAccountID: string(ctx.QueryArgs().Peek("from_account_id")),
+			Mode:          string(ctx.QueryArgs().Peek("mode")),
+		}
+		var err error
+		req.Items, err = parseBatchCSV(bytes.NewReader(ctx.PostBody()))
+		return req, err
+
+	default:
+		var req models.BatchTransferRequest
+		if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+			return nil, err
+		}
+		return &req, nil
+	}
+}
+
+// parseBatchCSV читает строки вида to_account_id,amount[,reference].
+// Строка заголовка, если есть, пропускается.
+func parseBatchCSV(r io.Reader) ([]models.BatchItemRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var items []models.BatchItemRequest
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_csv", err.Error())
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "to_account_id") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_csv_columns", line)
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, i18n.Errorf(errInvalidBatchData, "detail.batch_csv_amount", line, record[1])
+		}
+
+		item := models.BatchItemRequest{
+			ToAccountID: strings.TrimSpace(record[0]),
+			Amount:      amount,
+		}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			reference := strings.TrimSpace(record[2])
+			item.Reference = &reference
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func formValue(values map[string][]string, key string) string {
+	if v := values[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// errorRule сопоставляет ошибку сервиса или репозитория с кодом ответа
+type errorRule struct {
+	err    error
+	status int
+	code   apierror.Code
+}
+
+// errorRules — единая таблица ошибок для всех обработчиков. Ошибки
+// сравниваются через errors.Is, поэтому обёрнутые ошибки находят своё
+// правило. 404 — объекта нет, 403 — объект чужой, 409 —
+// операция противоречит текущему состоянию, 400 — неверные параметры.
+var errorRules = []errorRule{
+	{repository.ErrUserNotFound, fasthttp.StatusNotFound, apierror.CodeUserNotFound},
+	{repository.ErrAccountNotFound, fasthttp.StatusNotFound, apierror.CodeAccountNotFound},
+	{repository.ErrTransactionNotFound, fasthttp.StatusNotFound, apierror.CodeTransactionNotFound},
+	{repository.ErrBatchNotFound, fasthttp.StatusNotFound, apierror.CodeBatchNotFound},
+	{repository.ErrScheduledTransferNotFound, fasthttp.StatusNotFound, apierror.CodeScheduledTransferNotFound},
+	{repository.ErrAuthorizationNotFound, fasthttp.StatusNotFound, apierror.CodeAuthorizationNotFound},
+	{repository.ErrReconciliationReportNotFound, fasthttp.StatusNotFound, apierror.CodeReconciliationReportNotFound},
+	{repository.ErrNotificationNotFound, fasthttp.StatusNotFound, apierror.CodeNotificationNotFound},
+
+	{services.ErrUnauthorizedAccess, fasthttp.StatusForbidden, apierror.CodeAccessDenied},
+	{services.ErrFeeRefundForbidden, fasthttp.StatusForbidden, apierror.CodeFeeRefundForbidden},
+	{services.ErrWrongPassword, fasthttp.StatusForbidden, apierror.CodeWrongPassword},
+
+	{services.ErrAccountAlreadyClosed, fasthttp.StatusGone, apierror.CodeAccountClosed},
+
+	{repository.ErrUserExists, fasthttp.StatusConflict, apierror.CodeUserExists},
+	{repository.ErrEmailTaken, fasthttp.StatusConflict, apierror.CodeEmailTaken},
+	{repository.ErrPhoneTaken, fasthttp.StatusConflict, apierror.CodePhoneTaken},
+	{repository.ErrAccountClosed, fasthttp.StatusConflict, apierror.CodeAccountClosed},
+	{repository.ErrAccountStatusChanged, fasthttp.StatusConflict, apierror.CodeAccountStatusChanged},
+	{services.ErrAccountFrozen, fasthttp.StatusConflict, apierror.CodeAccountFrozen},
+	{services.ErrAccountNotFrozen, fasthttp.StatusConflict, apierror.CodeAccountNotFrozen},
+	{services.ErrAccountHasHolds, fasthttp.StatusConflict, apierror.CodeAccountHasHolds},
+	{services.ErrAccountLimitReached, fasthttp.StatusConflict, apierror.CodeAccountLimitReached},
+	{services.ErrBalanceNotZero, fasthttp.StatusConflict, apierror.CodeBalanceNotZero},
+	{repository.ErrInsufficientBalance, fasthttp.StatusConflict, apierror.CodeInsufficientBalance},
+	{repository.ErrLimitExceeded, fasthttp.StatusConflict, apierror.CodeSpendingLimitExceeded},
+	{repository.ErrTransactionNotRefundable, fasthttp.StatusConflict, apierror.CodeTransactionNotRefundable},
+	{repository.ErrRefundExceedsAmount, fasthttp.StatusConflict, apierror.CodeRefundExceedsAmount},
+	{services.ErrRefundOnlyPayments, fasthttp.StatusConflict, apierror.CodeRefundOnlyPayments},
+	{repository.ErrAuthorizationNotActive, fasthttp.StatusConflict, apierror.CodeAuthorizationNotActive},
+	{repository.ErrAuthorizationExpired, fasthttp.StatusConflict, apierror.CodeAuthorizationExpired},
+	{services.ErrScheduleFinished, fasthttp.StatusConflict, apierror.CodeScheduledTransferFinished},
+	{repository.ErrScheduledTransferNotPaused, fasthttp.StatusConflict, apierror.CodeScheduledTransferNotPaused},
+	{services.ErrBatchNotResumable, fasthttp.StatusConflict, apierror.CodeBatchNotResumable},
+	{repository.ErrReconciliationInProgress, fasthttp.StatusConflict, apierror.CodeReconciliationInProgress},
+	{repository.ErrTransactionFailed, fasthttp.StatusConflict, apierror.CodeTransactionFailed},
+
+	{services.ErrInvalidAmount, fasthttp.StatusBadRequest, apierror.CodeInvalidAmount},
+	{services.ErrWeakPassword, fasthttp.StatusBadRequest, apierror.CodeWeakPassword},
+	{services.ErrInvalidEmail, fasthttp.StatusBadRequest, apierror.CodeInvalidEmail},
+	{services.ErrInvalidPhone, fasthttp.StatusBadRequest, apierror.CodeInvalidPhone},
+	{repository.ErrInvalidResetToken, fasthttp.StatusBadRequest, apierror.CodeInvalidResetToken},
+	{services.ErrSelfTransfer, fasthttp.StatusBadRequest, apierror.CodeSelfTransfer},
+	{services.ErrInvalidPayoutAccount, fasthttp.StatusBadRequest, apierror.CodeInvalidPayoutAccount},
+	{services.ErrInvalidLimit, fasthttp.StatusBadRequest, apierror.CodeInvalidLimit},
+	{services.ErrLimitAboveCeiling, fasthttp.StatusBadRequest, apierror.CodeLimitAboveCeiling},
+	{services.ErrInvalidBatchMode, fasthttp.StatusBadRequest, apierror.CodeInvalidBatchMode},
+	{services.ErrEmptyBatch, fasthttp.StatusBadRequest, apierror.CodeEmptyBatch},
+	{services.ErrBatchTooLarge, fasthttp.StatusBadRequest, apierror.CodeBatchTooLarge},
+	{services.ErrInvalidSchedule, fasthttp.StatusBadRequest, apierror.CodeInvalidSchedule},
+	{services.ErrInvalidCronExpression, fasthttp.StatusBadRequest, apierror.CodeInvalidCronExpression},
+	{services.ErrInvalidScheduleWindow, fasthttp.StatusBadRequest, apierror.CodeInvalidScheduleWindow},
+	{services.ErrInvalidTransactionType, fasthttp.StatusBadRequest, apierror.CodeInvalidTransactionType},
+	{services.ErrInvalidFundsPolicy, fasthttp.StatusBadRequest, apierror.CodeInvalidFundsPolicy},
+	{services.ErrInvalidScheduleStatus, fasthttp.StatusBadRequest, apierror.CodeInvalidScheduleStatus},
+	{services.ErrInvalidAuthorizationTTL, fasthttp.StatusBadRequest, apierror.CodeInvalidAuthorizationTTL},
+	{services.ErrCaptureExceedsAmount, fasthttp.StatusBadRequest, apierror.CodeCaptureExceedsAmount},
+}
+
+// ProblemFor находит правило для ошибки. Текст ошибки в ответ не попадает:
+// он на языке журнала, а у неизвестной ошибки может раскрыть устройство
+// базы данных (500 без подробностей). detail берётся из i18n.Error, если
+// ошибка обёрнута с подробностями, иначе — из каталога по ключу
+// detail.<код>, если он там есть. Ошибка позиции пакета указывает позицию
+// в errors. Таблицей пользуется и gRPC API, чтобы ошибки обоих протоколов
+// имели одни коды.
+func ProblemFor(err error, lang i18n.Lang) *apierror.Problem {
+	for _, rule := range errorRules {
+		if !errors.Is(err, rule.err) {
+			continue
+		}
+
+		var detail string
+		var localized *i18n.Error
+		if errors.As(err, &localized) {
+			detail = localized.Localize(lang)
+		} else if _, ok := i18n.Lookup(lang, "detail."+string(rule.code)); ok {
+			detail = i18n.T(lang, "detail."+string(rule.code))
+		}
+		problem := apierror.New(rule.status, rule.code, detail)
+
+		var itemErr *repository.BatchItemError
+		if errors.As(err, &itemErr) && itemErr.Index >= 0 {
+			message := detail
+			if message == "" {
+				message = rule.code.Title(lang)
+			}
+			problem.WithErrors([]apierror.FieldError{{In: "body", Field: fmt.Sprintf("items[%d]", itemErr.Index), Message: message}})
+		}
+		return problem
+	}
+	return apierror.New(fasthttp.StatusInternalServerError, apierror.CodeInternal, "")
+}
+
+// failureCodes переводит ключи причин неудачи фоновых операций в коды API
+var failureCodes = map[string]apierror.Code{
+	services.FailureInsufficientBalance: apierror.CodeInsufficientBalance,
+	services.FailureLimitExceeded:       apierror.CodeSpendingLimitExceeded,
+	services.FailureAccountNotFound:     apierror.CodeAccountNotFound,
+	services.FailureAccountClosed:       apierror.CodeAccountClosed,
+	services.FailureAccessDenied:        apierror.CodeAccessDenied,
+	services.FailureInvalidAmount:       apierror.CodeInvalidAmount,
+	services.FailureSelfTransfer:        apierror.CodeSelfTransfer,
+	services.FailureBatchCancelled:      apierror.CodeBatchCancelled,
+}
+
+// failureCode возвращает код API для сохранённой причины неудачи.
+// Неизвестные ключи, в том числе текст ошибок из записей до перехода на
+// ключи, отдаются как internal_error.
+func failureCode(key *string) *string {
+	if key == nil {
+		return nil
+	}
+	code, ok := failureCodes[*key]
+	if !ok {
+		code = apierror.CodeInternal
+	}
+	result := string(code)
+	return &result
+}
+
+// writeError отвечает problem+json по таблице errorRules и пишет в журнал.
+// Ошибки клиента — предупреждения, ошибки сервера — с текстом исходной ошибки.
+func writeError(ctx *fasthttp.RequestCtx, component, path string, err error, startTime time.Time) {
+	writeProblem(ctx, component, path, ProblemFor(err, i18n.FromRequest(ctx)), err, startTime)
+}
+
+// writeUnauthorized отвечает 401, если в контексте нет user_id. После
+// RequireAuth такого не бывает, проверка защищает от ошибки в маршрутах.
+func writeUnauthorized(ctx *fasthttp.RequestCtx, component, path string, startTime time.Time) {
+	utils.LogError(component, "Не удалось получить user_id из контекста", nil)
+	writeProblem(ctx, component, path, apierror.New(fasthttp.StatusUnauthorized, apierror.CodeUnauthorized, ""), nil, startTime)
+}
+
+// writeMalformed отвечает 400 на тело, которое не удалось разобрать.
+// Ошибки encoding/json не переводятся и передаются как есть.
+func writeMalformed(ctx *fasthttp.RequestCtx, component, path string, err error, startTime time.Time) {
+	lang := i18n.FromRequest(ctx)
+	detail := i18n.T(lang, "validation.invalid_json", err)
+
+	var localized *i18n.Error
+	if errors.As(err, &localized) {
+		detail = localized.Localize(lang)
+	}
+	writeProblem(ctx, component, path, apierror.New(fasthttp.StatusBadRequest, apierror.CodeMalformedRequest, detail), err, startTime)
+}
+
+func writeProblem(ctx *fasthttp.RequestCtx, component, path string, problem *apierror.Problem, err error, startTime time.Time) {
+	requestID := tracing.RequestID(ctx)
+	switch {
+	case problem.Status >= fasthttp.StatusInternalServerError:
+		utils.LogError(component, "Ошибка обработки "+path+" (запрос "+requestID+")", err)
+	case err != nil:
+		utils.LogWarning(component, "%s: %s, %v (запрос %s)", path, problem.Code, err, requestID)
+	}
+
+	problem.Write(ctx)
+	utils.LogResponse(path, problem.Status, time.Since(startTime))
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/events"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+const (
+	// eventsHeartbeat — интервал комментария-пинга: прокси не закрывают
+	// простаивающее соединение, а сервер замечает ушедшего клиента
+	eventsHeartbeat = 15 * time.Second
+
+	// eventsRetry — через сколько браузерный EventSource переподключается
+	eventsRetry = 3 * time.Second
+)
+
+type EventHandler struct {
+	broker *events.Broker
+}
+
+func NewEventHandler(broker *events.Broker) *EventHandler {
+	utils.LogSuccess("EventHandler", "Инициализирован обработчик потока событий")
+	return &EventHandler{broker: broker}
+}
+
+// Stream обрабатывает GET /v1/events — поток Server-Sent Events о
+// транзакциях и балансах счетов пользователя. После обрыва клиент
+// передаёт ID последнего события в заголовке Last-Event-ID (EventSource
+// делает это сам) или в параметре last_event_id и получает пропущенное.
+func (h *EventHandler) Stream(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "EventHandler", "/events", startTime)
+		return
+	}
+
+	utils.LogRequest("GET", "/events", userID)
+
+	lastEventID := string(ctx.Request.Header.Peek("Last-Event-ID"))
+	if lastEventID == "" {
+		lastEventID = string(ctx.QueryArgs().Peek("last_event_id"))
+	}
+
+	sub, err := h.broker.Subscribe(tracing.Context(ctx), userID, lastEventID)
+	if err != nil {
+		writeError(ctx, "EventHandler", "/events", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no") // nginx не буферизует поток
+
+	// Поток пишется после возврата из обработчика: RequestCtx здесь уже
+	// использовать нельзя, только w
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprintf(w, "retry: %d\n\n", eventsRetry.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			waitCtx, cancel := context.WithTimeout(context.Background(), eventsHeartbeat)
+			event, err := sub.Next(waitCtx)
+			cancel()
+
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				_, _ = w.WriteString(": ping\n\n")
+			case err != nil:
+				// Брокер остановлен или клиент не успевал читать: клиент
+				// переподключится с Last-Event-ID
+				utils.LogInfo("EventHandler", "Поток пользователя %s закрыт: %v", userID, err)
+				return
+			default:
+				data, _ := json.Marshal(event)
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			}
+
+			if err := w.Flush(); err != nil {
+				utils.LogDebug("EventHandler", "Клиент %s отключился от потока событий", userID)
+				return
+			}
+		}
+	})
+
+	utils.LogResponse("/events", fasthttp.StatusOK, time.Since(startTime))
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/buildinfo"
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	// healthCheckTimeout ограничивает время одной проверки зависимости
+	healthCheckTimeout = 2 * time.Second
+
+	// maxWorkerSaturation — доля заполненности очереди, после которой
+	// экземпляр перестаёт принимать трафик
+	maxWorkerSaturation = 0.9
+)
+
+// ComponentStatus — результат проверки одной зависимости
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+}
+
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Time       string                     `json:"time"`
+	Service    string                     `json:"service"`
+	Build      buildinfo.Info             `json:"build"`
+	Components map[string]ComponentStatus `json:"components,omitempty"`
+}
+
+type HealthHandler struct {
+	db                       *pgxpool.Pool
+	redisCache               *cache.RedisCache
+	workerPool               *worker.WorkerPool
+	expectedMigrationVersion uint
+}
+
+func NewHealthHandler(db *pgxpool.Pool, redisCache *cache.RedisCache, workerPool *worker.WorkerPool, expectedMigrationVersion uint) *HealthHandler {
+	return &HealthHandler{
+		db:                       db,
+		redisCache:               redisCache,
+		workerPool:               workerPool,
+		expectedMigrationVersion: expectedMigrationVersion,
+	}
+}
+
+// Live обрабатывает GET /health/live - процесс жив и обслуживает запросы.
+// Зависимости не проверяются, чтобы оркестратор не перезапускал
+// экземпляр из-за недоступности базы данных.
+func (h *HealthHandler) Live(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	path := string(ctx.Path())
+	utils.LogRequest("GET", path, "system")
+
+	h.writeResponse(ctx, HealthResponse{
+		Status:  healthStatusOK,
+		Time:    time.Now().Format(time.RFC1123),
+		Service: "Bank Prototype API",
+		Build:   buildinfo.Get(),
+	})
+
+	utils.LogResponse(path, ctx.Response.StatusCode(), time.Since(startTime))
+}
+
+// Ready обрабатывает GET /health/ready - экземпляр готов принимать трафик
+func (h *HealthHandler) Ready(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	utils.LogRequest("GET", "/health/ready", "system")
+
+	components := map[string]ComponentStatus{
+		"postgres":    h.checkPostgres(ctx),
+		"redis":       h.checkRedis(ctx),
+		"migrations":  h.checkMigrations(ctx),
+		"worker_pool": h.checkWorkerPool(),
+	}
+
+	status := healthStatusOK
+	for name, component := range components {
+		if component.Status != healthStatusOK {
+			status = healthStatusFail
+			utils.LogWarning("HealthCheck", "Компонент %s не готов: %s", name, component.Error)
+		}
+	}
+
+	h.writeResponse(ctx, HealthResponse{
+		Status:     status,
+		Time:       time.Now().Format(time.RFC1123),
+		Service:    "Bank Prototype API",
+		Build:      buildinfo.Get(),
+		Components: components,
+	})
+
+	utils.LogResponse("/health/ready", ctx.Response.StatusCode(), time.Since(startTime))
+}
+
+func (h *HealthHandler) checkPostgres(parent context.Context) ComponentStatus {
+	return runCheck(parent, func(ctx context.Context) (any, error) {
+		if err := h.db.Ping(ctx); err != nil {
+			return nil, err
+		}
+		stat := h.db.Stat()
+		return map[string]int32{
+			"total_conns":    stat.TotalConns(),
+			"idle_conns":     stat.IdleConns(),
+			"acquired_conns": stat.AcquiredConns(),
+			"max_conns":      stat.MaxConns(),
+		}, nil
+	})
+}
+
+func (h *HealthHandler) checkRedis(parent context.Context) ComponentStatus {
+	return runCheck(parent, func(ctx context.Context) (any, error) {
+		return nil, h.redisCache.Ping(ctx)
+	})
+}
+
+func (h *HealthHandler) checkMigrations(parent context.Context) ComponentStatus {
+	return runCheck(parent, func(ctx context.Context) (any, error) {
+		var version int64
+		var dirty bool
+		err := h.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+		}
+
+		details := map[string]any{
+			"version":  version,
+			"expected": h.expectedMigrationVersion,
+			"dirty":    dirty,
+		}
+		if dirty {
+			return details, fmt.Errorf("миграция %d применена не полностью", version)
+		}
+		if uint(version) != h.expectedMigrationVersion {
+			return details, fmt.Errorf("версия схемы %d, ожидается %d", version, h.expectedMigrationVersion)
+		}
+		return details, nil
+	})
+}
+
+func (h *HealthHandler) checkWorkerPool() ComponentStatus {
+	startTime := time.Now()
+	stats := h.workerPool.GetStats()
+	saturation := stats.Saturation()
+
+	result := ComponentStatus{
+		Status: healthStatusOK,
+		Details: map[string]any{
+			"workers":        stats.ActiveWorkers,
+			"queued_jobs":    stats.QueuedJobs,
+			"queue_capacity": stats.QueueCapacity,
+			"saturation":     saturation,
+			"failed_jobs":    stats.FailedJobs,
+		},
+	}
+	if saturation >= maxWorkerSaturation {
+		result.Status = healthStatusFail
+		result.Error = fmt.Sprintf("очередь заполнена на %.0f%%", saturation*100)
+	}
+	result.LatencyMs = float64(time.Since(startTime).Microseconds()) / 1000
+	return result
+}
+
+// runCheck выполняет проверку с таймаутом и замеряет её длительность
+func runCheck(parent context.Context, check func(ctx context.Context) (any, error)) ComponentStatus {
+	ctx, cancel := context.WithTimeout(parent, healthCheckTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	details, err := check(ctx)
+	result := ComponentStatus{
+		Status:    healthStatusOK,
+		LatencyMs: float64(time.Since(startTime).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = healthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *HealthHandler) writeResponse(ctx *fasthttp.RequestCtx, response HealthResponse) {
+	statusCode := fasthttp.StatusOK
+	if response.Status != healthStatusOK {
+		statusCode = fasthttp.StatusServiceUnavailable
+	}
+
+	jsonEncode, err := json.Marshal(response)
+	if err != nil {
+		utils.LogError("HealthCheck", "Ошибка кодирования JSON", err)
+		ctx.Error("Ошибка кодирования JSON", fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.SetStatusCode(statusCode)
+	ctx.Write(jsonEncode)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type LimitHandler struct {
+	service *services.SpendingLimitService
+}
+
+func NewLimitHandler(service *services.SpendingLimitService) *LimitHandler {
+	utils.LogSuccess("LimitHandler", "Инициализирован обработчик лимитов")
+	return &LimitHandler{service: service}
+}
+
+// GetAccountLimits обрабатывает GET /accounts/{id}/limits
+func (h *LimitHandler) GetAccountLimits(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "LimitHandler", "/accounts/:id/limits", startTime)
+		return
+	}
+
+	accountID := ctx.UserValue("id").(string)
+	utils.LogRequest("GET", fmt.Sprintf("/accounts/%s/limits", accountID), userID)
+
+	limits, err := h.service.GetAccountLimits(tracing.Context(ctx), accountID, userID)
+	if err != nil {
+		writeError(ctx, "LimitHandler", "/accounts/:id/limits", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(limits)
+
+	utils.LogResponse("/accounts/:id/limits", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// UpdateAccountLimits обрабатывает PUT /accounts/{id}/limits
+func (h *LimitHandler) UpdateAccountLimits(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "LimitHandler", "/accounts/:id/limits", startTime)
+		return
+	}
+
+	accountID := ctx.UserValue("id").(string)
+	utils.LogRequest("PUT", fmt.Sprintf("/accounts/%s/limits", accountID), userID)
+
+	var req models.SpendingLimits
+	if !decodeLimits(ctx, &req, "/accounts/:id/limits", startTime) {
+		return
+	}
+
+	limits, err := h.service.SetAccountLimits(tracing.Context(ctx), accountID, userID, req)
+	if err != nil {
+		writeError(ctx, "LimitHandler", "/accounts/:id/limits", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(limits)
+
+	utils.LogResponse("/accounts/:id/limits", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// UpdateUserLimits обрабатывает PUT /users/me/limits
+func (h *LimitHandler) UpdateUserLimits(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "LimitHandler", "/users/me/limits", startTime)
+		return
+	}
+
+	utils.LogRequest("PUT", "/users/me/limits", userID)
+
+	var req models.SpendingLimits
+	if !decodeLimits(ctx, &req, "/users/me/limits", startTime) {
+		return
+	}
+
+	limits, err := h.service.SetUserLimits(tracing.Context(ctx), userID, req)
+	if err != nil {
+		writeError(ctx, "LimitHandler", "/users/me/limits", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(limits)
+
+	utils.LogResponse("/users/me/limits", fasthttp.StatusOK, time.Since(startTime))
+}
+
+func decodeLimits(ctx *fasthttp.RequestCtx, req *models.SpendingLimits, path string, startTime time.Time) bool {
+	if err := json.Unmarshal(ctx.PostBody(), req); err != nil {
+		writeMalformed(ctx, "LimitHandler", path, err, startTime)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	utils.LogSuccess("NotificationHandler", "Инициализирован обработчик уведомлений")
+	return &NotificationHandler{service: service}
+}
+
+// Preferences обрабатывает GET /users/me/notification-preferences
+func (h *NotificationHandler) Preferences(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	const path = "/users/me/notification-preferences"
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "NotificationHandler", path, startTime)
+		return
+	}
+
+	utils.LogRequest("GET", path, userID)
+
+	preferences, err := h.service.Preferences(tracing.Context(ctx), userID)
+	if err != nil {
+		writeError(ctx, "NotificationHandler", path, err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(preferences)
+
+	utils.LogResponse(path, fasthttp.StatusOK, time.Since(startTime))
+}
+
+// UpdatePreferences обрабатывает PATCH /users/me/notification-preferences
+func (h *NotificationHandler) UpdatePreferences(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	const path = "/users/me/notification-preferences"
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "NotificationHandler", path, startTime)
+		return
+	}
+
+	utils.LogRequest("PATCH", path, userID)
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "NotificationHandler", path, err, startTime)
+		return
+	}
+
+	preferences, err := h.service.UpdatePreferences(tracing.Context(ctx), userID, req)
+	if err != nil {
+		writeError(ctx, "NotificationHandler", path, err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(preferences)
+
+	utils.LogResponse(path, fasthttp.StatusOK, time.Since(startTime))
+}
+
+// List обрабатывает GET /notifications?unread=true
+func (h *NotificationHandler) List(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "NotificationHandler", "/notifications", startTime)
+		return
+	}
+
+	utils.LogRequest("GET", "/notifications", userID)
+
+	unreadOnly := string(ctx.QueryArgs().Peek("unread")) == "true"
+	inbox, err := h.service.Inbox(tracing.Context(ctx), userID, unreadOnly)
+	if err != nil {
+		writeError(ctx, "NotificationHandler", "/notifications", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(inbox)
+
+	utils.LogResponse("/notifications", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// MarkRead обрабатывает POST /notifications/{id}/read
+func (h *NotificationHandler) MarkRead(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "NotificationHandler", "/notifications/:id/read", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/notifications/:id/read", userID)
+
+	if err := h.service.MarkRead(tracing.Context(ctx), userID, ctx.UserValue("id").(string)); err != nil {
+		writeError(ctx, "NotificationHandler", "/notifications/:id/read", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.MarkNotificationsReadResponse{Marked: 1})
+
+	utils.LogResponse("/notifications/:id/read", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// MarkAllRead обрабатывает POST /notifications/read-all
+func (h *NotificationHandler) MarkAllRead(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "NotificationHandler", "/notifications/read-all", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/notifications/read-all", userID)
+
+	marked, err := h.service.MarkAllRead(tracing.Context(ctx), userID)
+	if err != nil {
+		writeError(ctx, "NotificationHandler", "/notifications/read-all", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.MarkNotificationsReadResponse{Marked: marked})
+
+	utils.LogResponse("/notifications/read-all", fasthttp.StatusOK, time.Since(startTime))
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type PasswordResetHandler struct {
+	service *services.PasswordResetService
+}
+
+func NewPasswordResetHandler(service *services.PasswordResetService) *PasswordResetHandler {
+	utils.LogSuccess("PasswordResetHandler", "Инициализирован обработчик сброса пароля")
+	return &PasswordResetHandler{service: service}
+}
+
+// Request обрабатывает POST /auth/password-reset/request. Ответ всегда
+// 202 с одним и тем же текстом, есть такой пользователь или нет.
+func (h *PasswordResetHandler) Request(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	utils.LogRequest("POST", "/auth/password-reset/request", "anonymous")
+
+	var req models.PasswordResetRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "PasswordResetHandler", "/auth/password-reset/request", err, startTime)
+		return
+	}
+
+	lang := i18n.FromRequest(ctx)
+	h.service.Request(tracing.Context(ctx), req.Login, lang)
+
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.PasswordResetResponse{
+		Message: i18n.T(lang, "message.password_reset_sent"),
+	})
+
+	utils.LogResponse("/auth/password-reset/request", fasthttp.StatusAccepted, time.Since(startTime))
+}
+
+// Confirm обрабатывает POST /auth/password-reset/confirm
+func (h *PasswordResetHandler) Confirm(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	utils.LogRequest("POST", "/auth/password-reset/confirm", "anonymous")
+
+	var req models.PasswordResetConfirmRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "PasswordResetHandler", "/auth/password-reset/confirm", err, startTime)
+		return
+	}
+
+	if err := h.service.Confirm(tracing.Context(ctx), req.Token, req.NewPassword); err != nil {
+		writeError(ctx, "PasswordResetHandler", "/auth/password-reset/confirm", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.PasswordResetResponse{
+		Message: i18n.T(i18n.FromRequest(ctx), "message.password_reset"),
+	})
+
+	utils.LogResponse("/auth/password-reset/confirm", fasthttp.StatusOK, time.Since(startTime))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type ReconciliationHandler struct {
+	service *services.ReconciliationService
+}
+
+func NewReconciliationHandler(service *services.ReconciliationService) *ReconciliationHandler {
+	utils.LogSuccess("ReconciliationHandler", "Инициализирован обработчик сверки балансов")
+	return &ReconciliationHandler{service: service}
+}
+
+// Run обрабатывает POST /admin/reconciliation/run - ручной запуск сверки
+func (h *ReconciliationHandler) Run(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	userID, _ := ctx.UserValue("user_id").(string)
+	utils.LogRequest("POST", "/admin/reconciliation/run", userID)
+
+	report, err := h.service.Run(tracing.Context(ctx), services.ReconciliationTriggerAdmin)
+	if err != nil {
+		writeError(ctx, "ReconciliationHandler", "/admin/reconciliation/run", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(report)
+
+	utils.LogResponse("/admin/reconciliation/run", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// List обрабатывает GET /admin/reconciliation/reports
+func (h *ReconciliationHandler) List(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	userID, _ := ctx.UserValue("user_id").(string)
+	utils.LogRequest("GET", "/admin/reconciliation/reports", userID)
+
+	reports, err := h.service.List(tracing.Context(ctx))
+	if err != nil {
+		writeError(ctx, "ReconciliationHandler", "/admin/reconciliation/reports", err, startTime)
+		return
+	}
+
+	if reports == nil {
+		reports = []models.ReconciliationReport{}
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.ReconciliationReportListResponse{
+		Reports: reports,
+		Total:   len(reports),
+	})
+
+	utils.LogResponse("/admin/reconciliation/reports", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// GetByID обрабатывает GET /admin/reconciliation/reports/{id} - отчёт с расхождениями
+func (h *ReconciliationHandler) GetByID(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+	userID, _ := ctx.UserValue("user_id").(string)
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("GET", "/admin/reconciliation/reports/"+id, userID)
+
+	report, err := h.service.Get(tracing.Context(ctx), id)
+	if err != nil {
+		writeError(ctx, "ReconciliationHandler", "/admin/reconciliation/reports/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(report)
+
+	utils.LogResponse("/admin/reconciliation/reports/:id", fasthttp.StatusOK, time.Since(startTime))
+}
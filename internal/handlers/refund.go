@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// Refund обрабатывает POST /transactions/{id}/refund
+func (h *TransactionHandler) Refund(ctx *fasthttp.RequestCtx) {
+	h.handleRefund(ctx, "/transactions/:id/refund", h.service.Refund)
+}
+
+// Reverse обрабатывает POST /admin/transactions/{id}/reversal
+func (h *TransactionHandler) Reverse(ctx *fasthttp.RequestCtx) {
+	h.handleRefund(ctx, "/admin/transactions/:id/reversal", h.service.Reverse)
+}
+
+type refundFunc func(ctx context.Context, userID, transactionID string, req models.RefundRequest) (*models.RefundResult, error)
+
+func (h *TransactionHandler) handleRefund(ctx *fasthttp.RequestCtx, path string, execute refundFunc) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "TransactionHandler", path, startTime)
+		return
+	}
+
+	transactionID := ctx.UserValue("id").(string)
+	utils.LogRequest("POST", path, userID)
+
+	var req models.RefundRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeMalformed(ctx, "TransactionHandler", path, err, startTime)
+			return
+		}
+	}
+
+	result, err := execute(tracing.Context(ctx), userID, transactionID, req)
+	if err != nil {
+		writeError(ctx, "TransactionHandler", path, err, startTime)
+		return
+	}
+
+	utils.LogSuccess("TransactionHandler", "Возврат выполнен: %s", result.Refund.ID)
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(result)
+
+	utils.LogResponse(path, fasthttp.StatusCreated, time.Since(startTime))
+}
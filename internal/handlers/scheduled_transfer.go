@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type ScheduledTransferHandler struct {
+	service *services.ScheduledTransferService
+}
+
+func NewScheduledTransferHandler(service *services.ScheduledTransferService) *ScheduledTransferHandler {
+	utils.LogSuccess("ScheduledTransferHandler", "Инициализирован обработчик регулярных переводов")
+	return &ScheduledTransferHandler{service: service}
+}
+
+// Create обрабатывает POST /scheduled-transfers
+func (h *ScheduledTransferHandler) Create(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "ScheduledTransferHandler", "/scheduled-transfers", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/scheduled-transfers", userID)
+
+	var req models.CreateScheduledTransferRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "ScheduledTransferHandler", "/scheduled-transfers", err, startTime)
+		return
+	}
+
+	st, err := h.service.Create(tracing.Context(ctx), userID, req)
+	if err != nil {
+		writeError(ctx, "ScheduledTransferHandler", "/scheduled-transfers", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(st)
+
+	utils.LogResponse("/scheduled-transfers", fasthttp.StatusCreated, time.Since(startTime))
+}
+
+// List обрабатывает GET /scheduled-transfers
+func (h *ScheduledTransferHandler) List(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "ScheduledTransferHandler", "/scheduled-transfers", startTime)
+		return
+	}
+
+	utils.LogRequest("GET", "/scheduled-transfers", userID)
+
+	transfers, err := h.service.List(tracing.Context(ctx), userID)
+	if err != nil {
+		writeError(ctx, "ScheduledTransferHandler", "/scheduled-transfers", err, startTime)
+		return
+	}
+
+	if transfers == nil {
+		transfers = []models.ScheduledTransfer{}
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.ScheduledTransferListResponse{
+		ScheduledTransfers: transfers,
+		Total:              len(transfers),
+	})
+
+	utils.LogResponse("/scheduled-transfers", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// GetByID обрабатывает GET /scheduled-transfers/{id}
+func (h *ScheduledTransferHandler) GetByID(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("GET", fmt.Sprintf("/scheduled-transfers/%s", id), userID)
+
+	st, runs, err := h.service.Get(tracing.Context(ctx), userID, id)
+	if err != nil {
+		writeError(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	// Причины неудачи выполнений хранятся ключами — клиенту отдаются коды API
+	for i := range runs {
+		runs[i].Error = failureCode(runs[i].Error)
+	}
+	json.NewEncoder(ctx).Encode(models.ScheduledTransferResponse{
+		ScheduledTransfer: *st,
+		Runs:              runs,
+	})
+
+	utils.LogResponse("/scheduled-transfers/:id", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// Update обрабатывает PATCH /scheduled-transfers/{id}
+func (h *ScheduledTransferHandler) Update(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("PATCH", fmt.Sprintf("/scheduled-transfers/%s", id), userID)
+
+	var req models.UpdateScheduledTransferRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", err, startTime)
+		return
+	}
+
+	st, err := h.service.Update(tracing.Context(ctx), userID, id, req)
+	if err != nil {
+		writeError(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(st)
+
+	utils.LogResponse("/scheduled-transfers/:id", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// Cancel обрабатывает DELETE /scheduled-transfers/{id}
+func (h *ScheduledTransferHandler) Cancel(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", startTime)
+		return
+	}
+
+	id := ctx.UserValue("id").(string)
+	utils.LogRequest("DELETE", fmt.Sprintf("/scheduled-transfers/%s", id), userID)
+
+	if err := h.service.Cancel(tracing.Context(ctx), userID, id); err != nil {
+		writeError(ctx, "ScheduledTransferHandler", "/scheduled-transfers/:id", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.CancelScheduledTransferResponse{
+		Message: i18n.T(i18n.FromRequest(ctx), "message.schedule_cancelled"),
+		ID:      id,
+	})
+
+	utils.LogResponse("/scheduled-transfers/:id", fasthttp.StatusOK, time.Since(startTime))
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"bank-prototype/internal/i18n"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -27,11 +30,7 @@ func (h *TransactionHandler) Transfer(ctx *fasthttp.RequestCtx) {
 	// Получаем user_id из контекста (добавлено middleware)
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("TransactionHandler", "Не удалось получить user_id из контекста", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "unauthorized"})
-		utils.LogResponse("/transactions/transfer", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeUnauthorized(ctx, "TransactionHandler", "/transactions/transfer", startTime)
 		return
 	}
 
@@ -39,21 +38,13 @@ func (h *TransactionHandler) Transfer(ctx *fasthttp.RequestCtx) {
 
 	var req models.TransferRequest
 	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
-		utils.LogError("TransactionHandler", "Ошибка парсинга JSON", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "invalid request body"})
-		utils.LogResponse("/transactions/transfer", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeMalformed(ctx, "TransactionHandler", "/transactions/transfer", err, startTime)
 		return
 	}
 
-	transaction, err := h.service.Transfer(ctx, userID, req)
+	transaction, err := h.service.Transfer(tracing.Context(ctx), userID, req)
 	if err != nil {
-		utils.LogError("TransactionHandler", "Ошибка выполнения перевода", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": err.Error()})
-		utils.LogResponse("/transactions/transfer", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeError(ctx, "TransactionHandler", "/transactions/transfer", err, startTime)
 		return
 	}
 
@@ -61,9 +52,9 @@ func (h *TransactionHandler) Transfer(ctx *fasthttp.RequestCtx) {
 
 	ctx.SetStatusCode(fasthttp.StatusCreated)
 	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"message":     "transfer successful",
-		"transaction": transaction,
+	json.NewEncoder(ctx).Encode(models.TransactionResult{
+		Message:     i18n.T(i18n.FromRequest(ctx), "message.transfer_completed"),
+		Transaction: transaction,
 	})
 
 	utils.LogResponse("/transactions/transfer", fasthttp.StatusCreated, time.Since(startTime))
@@ -75,11 +66,7 @@ func (h *TransactionHandler) Payment(ctx *fasthttp.RequestCtx) {
 
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("TransactionHandler", "Не удалось получить user_id из контекста", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "unauthorized"})
-		utils.LogResponse("/transactions/payment", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeUnauthorized(ctx, "TransactionHandler", "/transactions/payment", startTime)
 		return
 	}
 
@@ -87,21 +74,13 @@ func (h *TransactionHandler) Payment(ctx *fasthttp.RequestCtx) {
 
 	var req models.PaymentRequest
 	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
-		utils.LogError("TransactionHandler", "Ошибка парсинга JSON", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "invalid request body"})
-		utils.LogResponse("/transactions/payment", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeMalformed(ctx, "TransactionHandler", "/transactions/payment", err, startTime)
 		return
 	}
 
-	transaction, err := h.service.Payment(ctx, userID, req)
+	transaction, err := h.service.Payment(tracing.Context(ctx), userID, req)
 	if err != nil {
-		utils.LogError("TransactionHandler", "Ошибка выполнения платежа", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": err.Error()})
-		utils.LogResponse("/transactions/payment", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeError(ctx, "TransactionHandler", "/transactions/payment", err, startTime)
 		return
 	}
 
@@ -109,25 +88,86 @@ func (h *TransactionHandler) Payment(ctx *fasthttp.RequestCtx) {
 
 	ctx.SetStatusCode(fasthttp.StatusCreated)
 	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"message":     "payment successful",
-		"transaction": transaction,
+	json.NewEncoder(ctx).Encode(models.TransactionResult{
+		Message:     i18n.T(i18n.FromRequest(ctx), "message.payment_completed"),
+		Transaction: transaction,
 	})
 
 	utils.LogResponse("/transactions/payment", fasthttp.StatusCreated, time.Since(startTime))
 }
 
+// TransferV2 обрабатывает POST /v2/transactions/transfer: сумма строкой
+func (h *TransactionHandler) TransferV2(ctx *fasthttp.RequestCtx) {
+	h.executeV2(ctx, "/v2/transactions/transfer", "message.transfer_completed",
+		func(c context.Context, userID string, req models.TransferRequestV2, amount float64) (*models.Transaction, error) {
+			return h.service.Transfer(c, userID, models.TransferRequest{
+				FromAccountID: req.FromAccountID,
+				ToAccountID:   req.ToAccountID,
+				Amount:        amount,
+			})
+		})
+}
+
+// PaymentV2 обрабатывает POST /v2/transactions/payment: сумма строкой
+func (h *TransactionHandler) PaymentV2(ctx *fasthttp.RequestCtx) {
+	h.executeV2(ctx, "/v2/transactions/payment", "message.payment_completed",
+		func(c context.Context, userID string, req models.TransferRequestV2, amount float64) (*models.Transaction, error) {
+			return h.service.Payment(c, userID, models.PaymentRequest{
+				FromAccountID: req.FromAccountID,
+				ToAccountID:   req.ToAccountID,
+				Amount:        amount,
+			})
+		})
+}
+
+// executeV2 — общая часть переводов и платежей API v2: разбор запроса,
+// вызов того же сервиса, что у v1, и ответ с суммами строками
+func (h *TransactionHandler) executeV2(ctx *fasthttp.RequestCtx, path, message string,
+	execute func(context.Context, string, models.TransferRequestV2, float64) (*models.Transaction, error)) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "TransactionHandler", path, startTime)
+		return
+	}
+
+	utils.LogRequest("POST", path, userID)
+
+	var req models.TransferRequestV2
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "TransactionHandler", path, err, startTime)
+		return
+	}
+	amount, err := req.Amount.Float64()
+	if err != nil {
+		writeError(ctx, "TransactionHandler", path, services.ErrInvalidAmount, startTime)
+		return
+	}
+
+	transaction, err := execute(tracing.Context(ctx), userID, req, amount)
+	if err != nil {
+		writeError(ctx, "TransactionHandler", path, err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.TransactionResultV2{
+		Message:     i18n.T(i18n.FromRequest(ctx), message),
+		Transaction: models.NewTransactionV2(transaction),
+	})
+
+	utils.LogResponse(path, fasthttp.StatusCreated, time.Since(startTime))
+}
+
 // GetHistory обрабатывает GET /transactions или GET /transactions?account_id=xxx
 func (h *TransactionHandler) GetHistory(ctx *fasthttp.RequestCtx) {
 	startTime := time.Now()
 
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("TransactionHandler", "Не удалось получить user_id из контекста", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "unauthorized"})
-		utils.LogResponse("/transactions", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeUnauthorized(ctx, "TransactionHandler", "/transactions", startTime)
 		return
 	}
 
@@ -141,13 +181,9 @@ func (h *TransactionHandler) GetHistory(ctx *fasthttp.RequestCtx) {
 		utils.LogInfo("TransactionHandler", fmt.Sprintf("Фильтр по счёту: %s", accountIDStr))
 	}
 
-	transactions, err := h.service.GetTransactionHistory(ctx, userID, accountID)
+	transactions, err := h.service.GetTransactionHistory(tracing.Context(ctx), userID, accountID)
 	if err != nil {
-		utils.LogError("TransactionHandler", "Ошибка получения истории", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": err.Error()})
-		utils.LogResponse("/transactions", fasthttp.StatusBadRequest, time.Since(startTime))
+		writeError(ctx, "TransactionHandler", "/transactions", err, startTime)
 		return
 	}
 
@@ -155,10 +191,17 @@ func (h *TransactionHandler) GetHistory(ctx *fasthttp.RequestCtx) {
 
 	ctx.SetStatusCode(fasthttp.StatusOK)
 	ctx.SetContentType("application/json")
-	json.NewEncoder(ctx).Encode(map[string]interface{}{
-		"transactions": transactions,
-		"total":        len(transactions),
-	})
+	response := models.TransactionListResponse{
+		Transactions: transactions,
+		Total:        len(transactions),
+	}
+	if response.Transactions == nil {
+		response.Transactions = []models.Transaction{}
+	}
+	if accountID != nil {
+		response.AccountID = *accountID
+	}
+	json.NewEncoder(ctx).Encode(response)
 
 	utils.LogResponse("/transactions", fasthttp.StatusOK, time.Since(startTime))
 }
@@ -169,33 +212,17 @@ func (h *TransactionHandler) GetByID(ctx *fasthttp.RequestCtx) {
 
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
-		utils.LogError("TransactionHandler", "Не удалось получить user_id из контекста", nil)
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "unauthorized"})
-		utils.LogResponse("/transactions/:id", fasthttp.StatusUnauthorized, time.Since(startTime))
+		writeUnauthorized(ctx, "TransactionHandler", "/transactions/:id", startTime)
 		return
 	}
 
-	transactionID, ok := ctx.UserValue("id").(string)
-	if !ok {
-		utils.LogError("TransactionHandler", "Не удалось получить transaction_id из URL", nil)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "invalid transaction id"})
-		utils.LogResponse("/transactions/:id", fasthttp.StatusBadRequest, time.Since(startTime))
-		return
-	}
+	transactionID := ctx.UserValue("id").(string)
 
 	utils.LogRequest("GET", fmt.Sprintf("/transactions/%s", transactionID), userID)
 
-	transaction, err := h.service.GetTransactionByID(ctx, userID, transactionID)
+	transaction, err := h.service.GetTransactionByID(tracing.Context(ctx), userID, transactionID)
 	if err != nil {
-		utils.LogError("TransactionHandler", "Ошибка получения транзакции", err)
-		ctx.SetStatusCode(fasthttp.StatusNotFound)
-		ctx.SetContentType("application/json")
-		json.NewEncoder(ctx).Encode(map[string]string{"error": err.Error()})
-		utils.LogResponse("/transactions/:id", fasthttp.StatusNotFound, time.Since(startTime))
+		writeError(ctx, "TransactionHandler", "/transactions/:id", err, startTime)
 		return
 	}
 
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/services"
 	"encoding/json"
@@ -24,16 +26,15 @@ func (h *TransactionAsyncHandler) CreateTransactionAsync(ctx *fasthttp.RequestCt
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
 		log.Println("[ERROR] [TransactionAsyncHandler]  Не удалось получить user_id из контекста")
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		apierror.Write(ctx, fasthttp.StatusUnauthorized, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	var req models.TransferRequest
 	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
 		log.Printf("[ERROR] [TransactionAsyncHandler]  Ошибка парсинга запроса: %v\n", err)
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "Invalid request format"})
+		apierror.Write(ctx, fasthttp.StatusBadRequest, apierror.CodeMalformedRequest,
+			i18n.T(i18n.FromRequest(ctx), "validation.invalid_json", err))
 		return
 	}
 
@@ -54,7 +55,7 @@ func (h *TransactionAsyncHandler) CreateTransactionAsync(ctx *fasthttp.RequestCt
 	ctx.SetStatusCode(fasthttp.StatusAccepted)
 	json.NewEncoder(ctx).Encode(map[string]string{
 		"status":  "accepted",
-		"message": "Transaction is being processed",
+		"message": i18n.T(i18n.FromRequest(ctx), "message.transaction_accepted"),
 	})
 	log.Printf("[SUCCESS] [TransactionAsyncHandler]  Транзакция принята в обработку\n")
 }
@@ -64,16 +65,16 @@ func (h *TransactionAsyncHandler) GetTransactionsAsync(ctx *fasthttp.RequestCtx)
 	userID, ok := ctx.UserValue("user_id").(string)
 	if !ok {
 		log.Println("[ERROR] [TransactionAsyncHandler]  Не удалось получить user_id из контекста")
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "Unauthorized"})
+		apierror.Write(ctx, fasthttp.StatusUnauthorized, apierror.CodeUnauthorized, "")
 		return
 	}
 
 	accountID := string(ctx.QueryArgs().Peek("account_id"))
 	if accountID == "" {
 		log.Println("[ERROR] [TransactionAsyncHandler]  Отсутствует account_id")
-		ctx.SetStatusCode(fasthttp.StatusBadRequest)
-		json.NewEncoder(ctx).Encode(map[string]string{"error": "account_id is required"})
+		apierror.New(fasthttp.StatusBadRequest, apierror.CodeValidationFailed, "").
+			WithErrors([]apierror.FieldError{{In: "query", Field: "account_id", Message: i18n.T(i18n.FromRequest(ctx), "validation.required_param")}}).
+			Write(ctx)
 		return
 	}
 
@@ -99,8 +100,7 @@ func (h *TransactionAsyncHandler) GetTransactionsAsync(ctx *fasthttp.RequestCtx)
 
 	if result.err != nil {
 		log.Printf("[ERROR] [TransactionAsyncHandler]  Ошибка получения транзакций: %v\n", result.err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		json.NewEncoder(ctx).Encode(map[string]string{"error": result.err.Error()})
+		ProblemFor(result.err, i18n.FromRequest(ctx)).Write(ctx)
 		return
 	}
 
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+type UserHandler struct {
+	service *services.UserService
+}
+
+func NewUserHandler(service *services.UserService) *UserHandler {
+	utils.LogSuccess("UserHandler", "Инициализирован обработчик профиля пользователя")
+	return &UserHandler{service: service}
+}
+
+// Profile обрабатывает GET /users/me
+func (h *UserHandler) Profile(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "UserHandler", "/users/me", startTime)
+		return
+	}
+
+	utils.LogRequest("GET", "/users/me", userID)
+
+	profile, err := h.service.Profile(tracing.Context(ctx), userID)
+	if err != nil {
+		writeError(ctx, "UserHandler", "/users/me", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(profile)
+
+	utils.LogResponse("/users/me", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// UpdateProfile обрабатывает PATCH /users/me
+func (h *UserHandler) UpdateProfile(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "UserHandler", "/users/me", startTime)
+		return
+	}
+
+	utils.LogRequest("PATCH", "/users/me", userID)
+
+	var req models.UpdateProfileRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "UserHandler", "/users/me", err, startTime)
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(tracing.Context(ctx), userID, req)
+	if err != nil {
+		writeError(ctx, "UserHandler", "/users/me", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(profile)
+
+	utils.LogResponse("/users/me", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// ChangePassword обрабатывает POST /users/me/password. Токен запроса после
+// смены пароля больше не действует, в ответе — токен новой сессии.
+func (h *UserHandler) ChangePassword(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok {
+		writeUnauthorized(ctx, "UserHandler", "/users/me/password", startTime)
+		return
+	}
+
+	utils.LogRequest("POST", "/users/me/password", userID)
+
+	var req models.ChangePasswordRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeMalformed(ctx, "UserHandler", "/users/me/password", err, startTime)
+		return
+	}
+
+	token, err := h.service.ChangePassword(tracing.Context(ctx), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		writeError(ctx, "UserHandler", "/users/me/password", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.ChangePasswordResponse{
+		Message:   i18n.T(i18n.FromRequest(ctx), "message.password_changed"),
+		Token:     token,
+		ExpiresIn: "24h",
+	})
+
+	utils.LogResponse("/users/me/password", fasthttp.StatusOK, time.Since(startTime))
+}
+
+// DeleteUser обрабатывает DELETE /users/me. Тело необязательно: в нём можно
+// указать счёт, на который переводятся остатки со счетов пользователя.
+func (h *UserHandler) DeleteUser(ctx *fasthttp.RequestCtx) {
+	startTime := time.Now()
+
+	userID, ok := ctx.UserValue("user_id").(string)
+	if !ok || userID == "" {
+		writeUnauthorized(ctx, "UserHandler", "/users/me", startTime)
+		return
+	}
+
+	utils.LogRequest("DELETE", "/users/me", userID)
+
+	var req models.DeleteUserRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeMalformed(ctx, "UserHandler", "/users/me", err, startTime)
+			return
+		}
+	}
+
+	deletion, err := h.service.DeleteUser(tracing.Context(ctx), userID, req.PayoutAccountID)
+	if err != nil {
+		writeError(ctx, "UserHandler", "/users/me", err, startTime)
+		return
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	json.NewEncoder(ctx).Encode(models.DeleteUserResponse{
+		Message: i18n.T(i18n.FromRequest(ctx), "message.user_deleted"),
+		UserID:  userID,
+		Payouts: deletion.Payouts,
+	})
+
+	utils.LogResponse("/users/me", fasthttp.StatusOK, time.Since(startTime))
+}
@@ -0,0 +1,169 @@
+package i18n
+
+// en — каталог на английском языке, ключи совпадают с ru
+var en = map[string]string{
+	// Ошибки запроса и доступа
+	"validation_failed":      "Request does not match the schema",
+	"malformed_request":      "Malformed request data",
+	"unsupported_media_type": "Unsupported media type",
+	"route_not_found":        "Route not found",
+	"unauthorized":           "Authentication required",
+	"invalid_token":          "Invalid or expired token",
+	"invalid_credentials":    "Invalid user name or password",
+	"admin_required":         "Administrator rights required",
+	"access_denied":          "Access to the resource denied",
+	"rate_limited":           "Too many requests, try again later",
+	"internal_error":         "Internal server error",
+
+	// Пользователи и счета
+	"user_not_found":         "User not found",
+	"user_exists":            "A user with this name already exists",
+	"account_not_found":      "Account not found",
+	"account_closed":         "Account is closed",
+	"account_frozen":         "Account is frozen",
+	"account_not_frozen":     "Account is not frozen",
+	"account_has_holds":      "Account has active authorizations",
+	"account_limit_reached":  "Active account limit reached",
+	"account_status_changed": "Account status changed, retry the operation",
+	"balance_not_zero":       "Accounts still hold funds",
+	"invalid_payout_account": "Funds cannot be paid out to your own account",
+
+	// Профиль и пароль
+	"weak_password":       "Password does not meet the requirements",
+	"wrong_password":      "Current password is incorrect",
+	"invalid_email":       "Invalid email address",
+	"invalid_phone":       "Invalid phone number",
+	"email_taken":         "Email address is already in use",
+	"phone_taken":         "Phone number is already in use",
+	"invalid_reset_token": "Password reset token is invalid or expired",
+
+	// Деньги и лимиты
+	"insufficient_balance":    "Insufficient funds",
+	"invalid_amount":          "Amount must be greater than 0",
+	"self_transfer":           "Cannot transfer to the same account",
+	"spending_limit_exceeded": "Spending limit exceeded",
+	"invalid_limit":           "Limit must be greater than 0",
+	"limit_above_ceiling":     "Limit cannot exceed the bank ceiling",
+
+	// Транзакции и возвраты
+	"transaction_not_found":      "Transaction not found",
+	"transaction_failed":         "Transaction failed",
+	"transaction_not_refundable": "Transaction cannot be refunded",
+	"refund_exceeds_amount":      "Refund exceeds the unrefunded amount",
+	"refund_only_payments":       "Only payments can be refunded",
+	"fee_refund_forbidden":       "Only an administrator can refund the fee",
+
+	// Пакеты переводов
+	"batch_not_found":     "Transfer batch not found",
+	"invalid_batch_mode":  "Invalid batch mode",
+	"empty_batch":         "Batch contains no transfers",
+	"batch_too_large":     "Too many transfers in the batch",
+	"batch_not_resumable": "Batch cannot be resumed",
+	"batch_cancelled":     "Transfer not executed: the batch was cancelled",
+
+	// Регулярные переводы
+	"scheduled_transfer_not_found":  "Scheduled transfer not found",
+	"scheduled_transfer_finished":   "Scheduled transfer is finished or cancelled",
+	"scheduled_transfer_not_paused": "Scheduled transfer is not paused",
+	"invalid_schedule":              "Invalid schedule",
+	"invalid_cron_expression":       "Invalid cron expression",
+	"invalid_schedule_window":       "End date must be after the start date",
+	"invalid_transaction_type":      "Invalid operation type",
+	"invalid_funds_policy":          "Invalid insufficient funds policy",
+	"invalid_schedule_status":       "Invalid scheduled transfer status",
+
+	// Авторизации (холды)
+	"authorization_not_found":   "Authorization not found",
+	"authorization_not_active":  "Authorization is already completed",
+	"authorization_expired":     "Authorization has expired",
+	"invalid_authorization_ttl": "Invalid authorization lifetime",
+	"capture_exceeds_amount":    "Capture exceeds the authorized amount",
+
+	// Сверка балансов
+	"reconciliation_report_not_found": "Reconciliation report not found",
+	"reconciliation_in_progress":      "Reconciliation is already running",
+
+	// Уведомления
+	"notification_not_found": "Notification not found",
+
+	// Подробности ошибок
+	"detail.account_limit_reached":     "At most 5 active accounts are allowed",
+	"detail.invalid_batch_mode":        "Batch mode must be all_or_nothing or best_effort",
+	"detail.batch_not_resumable":       "Processing has finished or the all_or_nothing batch has already run",
+	"detail.invalid_schedule":          "Specify exactly one of cron_expression or interval_seconds (at least 60 seconds)",
+	"detail.invalid_transaction_type":  "Type must be transfer or payment",
+	"detail.invalid_funds_policy":      "on_insufficient_funds must be skip or retry",
+	"detail.invalid_schedule_status":   "Status can only be changed to active or paused",
+	"detail.invalid_authorization_ttl": "Lifetime must be between 1 minute and 30 days",
+	"detail.bearer_expected":           "Expected header Authorization: Bearer <token>",
+	"detail.rate_limit":                "Group %s limit: %d requests per %v",
+	"detail.limit_single":              "operation amount exceeds %.2f",
+	"detail.limit_daily":               "%s daily limit %.2f, already spent %.2f",
+	"detail.limit_monthly":             "%s monthly limit %.2f, already spent %.2f",
+	"detail.limit_daily_count":         "at most %d operations per day for the %s",
+	"detail.capture_exceeds_hold":      "capture exceeds the authorized amount",
+	"detail.amount_precision":          "amount must be at least 0.01 with at most two decimal places",
+	"detail.cron_parse":                "parse error: %s",
+	"detail.batch_form":                "invalid form: %s",
+	"detail.batch_file_missing":        "the file field is missing",
+	"detail.batch_file_read":           "cannot read the file: %s",
+	"detail.batch_csv":                 "CSV parse error: %s",
+	"detail.batch_csv_columns":         "line %d: expected to_account_id,amount[,reference]",
+	"detail.batch_csv_amount":          "line %d: invalid amount %q",
+	"detail.password_too_short":        "password must be at least %d characters long",
+	"detail.password_too_long":         "password must be at most %d bytes long",
+	"detail.password_classes":          "password must contain letters and also digits or other symbols",
+	"detail.password_contains_name":    "password must not contain the user name",
+	"detail.password_common":           "password is too common and easy to guess",
+	"detail.password_unchanged":        "new password is the same as the current one",
+	"detail.invalid_email":             "Expected an address like name@example.com",
+	"detail.invalid_phone":             "Expected a number in international format, e.g. +79161234567",
+	"detail.balance_not_zero":          "remaining balance %.2f: provide payout_account_id or close the accounts",
+
+	"limit.level.account": "account",
+	"limit.level.user":    "user",
+
+	// Нарушения схемы запроса
+	"validation.invalid_content_type":     "invalid media type",
+	"validation.unsupported_content_type": "type %s is not supported, expected %s",
+	"validation.body_required":            "request body is required",
+	"validation.invalid_json":             "invalid JSON: %s",
+	"validation.trailing_data":            "invalid JSON: unexpected data after the value",
+	"validation.required_param":           "required parameter",
+	"validation.required_field":           "required field",
+	"validation.not_null":                 "value must not be null",
+	"validation.enum":                     "allowed values: %s",
+	"validation.expected":                 "expected %s",
+	"validation.or":                       " or ",
+	"validation.min_items":                "at least %d items",
+	"validation.max_items":                "at most %d items",
+	"validation.not_empty":                "must not be empty",
+	"validation.min_length":               "at least %d characters",
+	"validation.max_length":               "at most %d characters",
+	"validation.date_time":                "expected an RFC 3339 date-time",
+	"validation.uuid":                     "expected a UUID",
+	"validation.decimal":                  "expected an amount as a string with at most two decimal places, e.g. \"10.50\"",
+	"validation.gt":                       "must be greater than %s",
+	"validation.min":                      "must be at least %s",
+	"validation.max":                      "must be at most %s",
+
+	"type.object":  "object",
+	"type.array":   "array",
+	"type.string":  "string",
+	"type.number":  "number",
+	"type.integer": "integer",
+	"type.boolean": "boolean",
+
+	// Сообщения успешных ответов
+	"message.user_registered":      "User registered successfully",
+	"message.logged_in":            "Logged in successfully",
+	"message.user_deleted":         "User deleted successfully",
+	"message.account_closed":       "Account closed successfully",
+	"message.transfer_completed":   "Transfer completed",
+	"message.payment_completed":    "Payment completed",
+	"message.transaction_accepted": "Transaction is being processed",
+	"message.schedule_cancelled":   "Scheduled transfer cancelled",
+	"message.password_changed":     "Password changed, other sessions signed out",
+	"message.password_reset_sent":  "If the account exists, password reset instructions have been sent to it",
+	"message.password_reset":       "Password changed, sign in with the new password",
+}
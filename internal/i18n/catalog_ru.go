@@ -0,0 +1,170 @@
+package i18n
+
+// ru — каталог на русском языке. Заголовки ошибок — по коду apierror,
+// detail.<код> — подробности, которые добавляются к ошибке с этим кодом.
+var ru = map[string]string{
+	// Ошибки запроса и доступа
+	"validation_failed":      "Запрос не соответствует схеме",
+	"malformed_request":      "Неверный формат данных",
+	"unsupported_media_type": "Неподдерживаемый тип содержимого",
+	"route_not_found":        "Маршрут не найден",
+	"unauthorized":           "Требуется авторизация",
+	"invalid_token":          "Невалидный или истёкший токен",
+	"invalid_credentials":    "Неверное имя пользователя или пароль",
+	"admin_required":         "Требуются права администратора",
+	"access_denied":          "Нет доступа к ресурсу",
+	"rate_limited":           "Слишком много запросов, повторите позже",
+	"internal_error":         "Внутренняя ошибка сервера",
+
+	// Пользователи и счета
+	"user_not_found":         "Пользователь не найден",
+	"user_exists":            "Пользователь с таким именем уже существует",
+	"account_not_found":      "Счёт не найден",
+	"account_closed":         "Счёт закрыт",
+	"account_frozen":         "Счёт заморожен",
+	"account_not_frozen":     "Счёт не заморожен",
+	"account_has_holds":      "На счёте есть активные авторизации",
+	"account_limit_reached":  "Достигнут лимит активных счетов",
+	"account_status_changed": "Статус счёта изменился, повторите операцию",
+	"balance_not_zero":       "На счетах остались средства",
+	"invalid_payout_account": "Остатки нельзя перевести на собственный счёт",
+
+	// Профиль и пароль
+	"weak_password":       "Пароль не соответствует требованиям",
+	"wrong_password":      "Неверный текущий пароль",
+	"invalid_email":       "Неверный адрес электронной почты",
+	"invalid_phone":       "Неверный номер телефона",
+	"email_taken":         "Адрес электронной почты уже используется",
+	"phone_taken":         "Номер телефона уже используется",
+	"invalid_reset_token": "Ссылка для сброса пароля недействительна или устарела",
+
+	// Деньги и лимиты
+	"insufficient_balance":    "Недостаточно средств",
+	"invalid_amount":          "Сумма должна быть больше 0",
+	"self_transfer":           "Нельзя переводить на свой же счёт",
+	"spending_limit_exceeded": "Превышен лимит расходов",
+	"invalid_limit":           "Лимит должен быть больше 0",
+	"limit_above_ceiling":     "Лимит не может превышать потолок банка",
+
+	// Транзакции и возвраты
+	"transaction_not_found":      "Транзакция не найдена",
+	"transaction_failed":         "Транзакция не выполнена",
+	"transaction_not_refundable": "Транзакцию нельзя вернуть",
+	"refund_exceeds_amount":      "Сумма возврата превышает невозвращённый остаток",
+	"refund_only_payments":       "Возврат возможен только по платежам",
+	"fee_refund_forbidden":       "Вернуть комиссию может только администратор",
+
+	// Пакеты переводов
+	"batch_not_found":     "Пакет переводов не найден",
+	"invalid_batch_mode":  "Неверный режим пакета",
+	"empty_batch":         "Пакет не содержит переводов",
+	"batch_too_large":     "Слишком много переводов в пакете",
+	"batch_not_resumable": "Пакет нельзя перезапустить",
+	"batch_cancelled":     "Перевод не выполнен: пакет отменён",
+
+	// Регулярные переводы
+	"scheduled_transfer_not_found":  "Регулярный перевод не найден",
+	"scheduled_transfer_finished":   "Регулярный перевод завершён или отменён",
+	"scheduled_transfer_not_paused": "Регулярный перевод не приостановлен",
+	"invalid_schedule":              "Неверное расписание",
+	"invalid_cron_expression":       "Некорректное cron-выражение",
+	"invalid_schedule_window":       "Дата окончания должна быть позже даты начала",
+	"invalid_transaction_type":      "Неверный тип операции",
+	"invalid_funds_policy":          "Неверная политика при нехватке средств",
+	"invalid_schedule_status":       "Неверный статус регулярного перевода",
+
+	// Авторизации (холды)
+	"authorization_not_found":   "Авторизация не найдена",
+	"authorization_not_active":  "Авторизация уже завершена",
+	"authorization_expired":     "Срок действия авторизации истёк",
+	"invalid_authorization_ttl": "Неверный срок действия авторизации",
+	"capture_exceeds_amount":    "Сумма списания превышает авторизованную",
+
+	// Сверка балансов
+	"reconciliation_report_not_found": "Отчёт сверки не найден",
+	"reconciliation_in_progress":      "Сверка уже выполняется",
+
+	// Уведомления
+	"notification_not_found": "Уведомление не найдено",
+
+	// Подробности ошибок
+	"detail.account_limit_reached":     "Можно открыть не более 5 активных счетов",
+	"detail.invalid_batch_mode":        "Режим пакета должен быть all_or_nothing или best_effort",
+	"detail.batch_not_resumable":       "Обработка завершена или пакет all_or_nothing уже выполнялся",
+	"detail.invalid_schedule":          "Укажите ровно одно из полей cron_expression или interval_seconds (не менее 60 секунд)",
+	"detail.invalid_transaction_type":  "Тип должен быть transfer или payment",
+	"detail.invalid_funds_policy":      "on_insufficient_funds должен быть skip или retry",
+	"detail.invalid_schedule_status":   "Статус можно изменить только на active или paused",
+	"detail.invalid_authorization_ttl": "Срок действия должен быть от 1 минуты до 30 дней",
+	"detail.bearer_expected":           "Ожидается заголовок Authorization: Bearer <token>",
+	"detail.rate_limit":                "Лимит группы %s: %d запросов за %v",
+	"detail.limit_single":              "сумма операции больше %.2f",
+	"detail.limit_daily":               "дневной лимит %s %.2f, израсходовано %.2f",
+	"detail.limit_monthly":             "месячный лимит %s %.2f, израсходовано %.2f",
+	"detail.limit_daily_count":         "не более %d операций в день для %s",
+	"detail.capture_exceeds_hold":      "сумма списания превышает авторизованную",
+	"detail.amount_precision":          "сумма должна быть не меньше 0.01 и содержать не больше двух знаков после запятой",
+	"detail.cron_parse":                "ошибка разбора: %s",
+	"detail.batch_form":                "неверная форма: %s",
+	"detail.batch_file_missing":        "не передан файл file",
+	"detail.batch_file_read":           "ошибка чтения файла: %s",
+	"detail.batch_csv":                 "ошибка разбора CSV: %s",
+	"detail.batch_csv_columns":         "строка %d: ожидается to_account_id,amount[,reference]",
+	"detail.batch_csv_amount":          "строка %d: неверная сумма %q",
+	"detail.password_too_short":        "пароль должен быть не короче %d символов",
+	"detail.password_too_long":         "пароль должен быть не длиннее %d байт",
+	"detail.password_classes":          "пароль должен содержать буквы, а также цифры или другие символы",
+	"detail.password_contains_name":    "пароль не должен содержать имя пользователя",
+	"detail.password_common":           "пароль слишком распространён и легко подбирается",
+	"detail.password_unchanged":        "новый пароль совпадает с текущим",
+	"detail.invalid_email":             "Ожидается адрес вида name@example.com",
+	"detail.invalid_phone":             "Ожидается номер в международном формате, например +79161234567",
+	"detail.balance_not_zero":          "остаток %.2f: укажите payout_account_id или закройте счета",
+
+	"limit.level.account": "счёта",
+	"limit.level.user":    "пользователя",
+
+	// Нарушения схемы запроса
+	"validation.invalid_content_type":     "некорректный тип содержимого",
+	"validation.unsupported_content_type": "тип %s не поддерживается, ожидается %s",
+	"validation.body_required":            "тело запроса обязательно",
+	"validation.invalid_json":             "некорректный JSON: %s",
+	"validation.trailing_data":            "некорректный JSON: лишние данные после значения",
+	"validation.required_param":           "обязательный параметр",
+	"validation.required_field":           "обязательное поле",
+	"validation.not_null":                 "значение не может быть null",
+	"validation.enum":                     "допустимые значения: %s",
+	"validation.expected":                 "ожидается %s",
+	"validation.or":                       " или ",
+	"validation.min_items":                "не менее %d элементов",
+	"validation.max_items":                "не более %d элементов",
+	"validation.not_empty":                "не может быть пустым",
+	"validation.min_length":               "не короче %d символов",
+	"validation.max_length":               "не длиннее %d символов",
+	"validation.date_time":                "ожидается дата и время в формате RFC 3339",
+	"validation.uuid":                     "ожидается UUID",
+	"validation.decimal":                  "ожидается сумма строкой с не более чем двумя знаками после точки, например \"10.50\"",
+	"validation.gt":                       "должно быть больше %s",
+	"validation.min":                      "должно быть не меньше %s",
+	"validation.max":                      "должно быть не больше %s",
+
+	"type.object":  "объект",
+	"type.array":   "массив",
+	"type.string":  "строка",
+	"type.number":  "число",
+	"type.integer": "целое число",
+	"type.boolean": "логическое значение",
+
+	// Сообщения успешных ответов
+	"message.user_registered":      "Пользователь успешно зарегистрирован",
+	"message.logged_in":            "Вход выполнен успешно",
+	"message.user_deleted":         "Пользователь успешно удалён",
+	"message.account_closed":       "Счёт успешно закрыт",
+	"message.transfer_completed":   "Перевод выполнен",
+	"message.payment_completed":    "Платёж выполнен",
+	"message.transaction_accepted": "Транзакция принята в обработку",
+	"message.schedule_cancelled":   "Регулярный перевод отменён",
+	"message.password_changed":     "Пароль изменён, остальные сессии завершены",
+	"message.password_reset_sent":  "Если такая учётная запись есть, на её адрес отправлены инструкции по сбросу пароля",
+	"message.password_reset":       "Пароль изменён, войдите с новым паролем",
+}
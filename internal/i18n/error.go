@@ -0,0 +1,40 @@
+package i18n
+
+// Key — аргумент сообщения, который сам переводится по каталогу:
+// например, уровень лимита «счёта» / «account»
+type Key string
+
+// Error дополняет ошибку подробностями, которые показываются клиенту на
+// его языке. Error() возвращает текст на языке по умолчанию для журнала,
+// errors.Is видит исходную ошибку.
+type Error struct {
+	Err  error
+	Key  string
+	Args []any
+}
+
+// Errorf оборачивает err подробностями из каталога: сообщение key с
+// аргументами args
+func Errorf(err error, key string, args ...any) error {
+	return &Error{Err: err, Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error() + ": " + e.Localize(Default)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Localize возвращает подробности на языке lang
+func (e *Error) Localize(lang Lang) string {
+	args := make([]any, len(e.Args))
+	for i, arg := range e.Args {
+		if key, ok := arg.(Key); ok {
+			arg = T(lang, string(key))
+		}
+		args[i] = arg
+	}
+	return T(lang, e.Key, args...)
+}
@@ -0,0 +1,129 @@
+// Package i18n — каталог сообщений API на русском и английском языках.
+// Язык выбирается для каждого запроса по заголовку Accept-Language.
+// Ключи сообщений об ошибках совпадают с кодами apierror, поэтому коды
+// остаются независимыми от языка, а меняется только текст.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Lang — язык сообщений (первичный тег BCP 47)
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+
+	// Default — язык, если клиент не указал поддерживаемый
+	Default = RU
+)
+
+// langKey — ключ языка запроса в fasthttp.RequestCtx
+const langKey = "lang"
+
+var bundles = map[Lang]map[string]string{
+	RU: ru,
+	EN: en,
+}
+
+// Supported возвращает поддерживаемые языки
+func Supported() []Lang {
+	return []Lang{RU, EN}
+}
+
+// Lookup возвращает шаблон сообщения key на языке lang
+func Lookup(lang Lang, key string) (string, bool) {
+	format, ok := bundles[lang][key]
+	return format, ok
+}
+
+// T возвращает сообщение key на языке lang. Если перевода нет, берётся
+// язык по умолчанию, если нет и его — сам ключ: ответ не должен ломаться
+// из-за пропущенной строки каталога.
+func T(lang Lang, key string, args ...any) string {
+	format, ok := Lookup(lang, key)
+	if !ok {
+		if format, ok = Lookup(Default, key); !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Negotiate выбирает язык по заголовку Accept-Language (RFC 9110):
+// поддерживаемый язык с наибольшим весом q, при равных весах — первый
+// в списке. Регион не учитывается: en-GB соответствует en.
+func Negotiate(header string) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		primary, _, _ := strings.Cut(tag, "-")
+		lang := Lang(primary)
+		if primary == "*" {
+			lang = Default
+		}
+		if _, ok := bundles[lang]; ok {
+			candidates = append(candidates, candidate{lang: lang, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
+
+// Middleware выбирает язык запроса и сообщает его в Content-Language.
+// Vary: Accept-Language не даёт промежуточным кешам отдать ответ на
+// другом языке.
+func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		lang := Negotiate(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage)))
+		ctx.SetUserValue(langKey, lang)
+		ctx.Response.Header.Set(fasthttp.HeaderContentLanguage, string(lang))
+		ctx.Response.Header.Add(fasthttp.HeaderVary, fasthttp.HeaderAcceptLanguage)
+
+		next(ctx)
+	}
+}
+
+// FromRequest возвращает язык запроса. Без Middleware язык выбирается по
+// заголовку при каждом вызове.
+func FromRequest(ctx *fasthttp.RequestCtx) Lang {
+	if lang, ok := ctx.UserValue(langKey).(Lang); ok {
+		return lang
+	}
+	return Negotiate(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage)))
+}
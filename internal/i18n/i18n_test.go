@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Lang
+	}{
+		{"", Default},
+		{"en", EN},
+		{"en-US,en;q=0.9", EN},
+		{"EN-gb", EN},
+		{"de-DE,de;q=0.9", Default},
+		{"de, en;q=0.5", EN},
+		{"ru;q=0.3, en;q=0.8", EN},
+		{"en;q=0.5, ru;q=0.5", EN},
+		{"en;q=0, ru", RU},
+		{"en;q=abc, ru;q=0.1", RU},
+		{"*", Default},
+		{"fr, *;q=0.1", Default},
+	}
+
+	for _, tt := range tests {
+		if got := Negotiate(tt.header); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, ожидался %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+// TestCatalogsMatch проверяет, что каталоги содержат одни и те же ключи
+// с одинаковыми глаголами форматирования: перевод не должен терять или
+// переставлять аргументы
+func TestCatalogsMatch(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+	for _, lang := range Supported()[1:] {
+		for key, want := range bundles[Default] {
+			got, ok := bundles[lang][key]
+			if !ok {
+				t.Errorf("%s: нет ключа %q", lang, key)
+				continue
+			}
+			if a, b := verbs.FindAllString(want, -1), verbs.FindAllString(got, -1); strings.Join(a, " ") != strings.Join(b, " ") {
+				t.Errorf("%s: %q — аргументы %v, в %s — %v", lang, key, b, Default, a)
+			}
+		}
+		for key := range bundles[lang] {
+			if _, ok := bundles[Default][key]; !ok {
+				t.Errorf("%s: лишний ключ %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T(EN, "validation.min_items", 2); got != "at least 2 items" {
+		t.Errorf("T(en) = %q", got)
+	}
+	if got := T("de", "insufficient_balance"); got != ru["insufficient_balance"] {
+		t.Errorf("неизвестный язык: %q, ожидался текст на языке по умолчанию", got)
+	}
+	if got := T(EN, "no.such.key"); got != "no.such.key" {
+		t.Errorf("неизвестный ключ: %q", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	errLimit := errors.New("превышен лимит расходов")
+	err := Errorf(errLimit, "detail.limit_daily", Key("limit.level.account"), 500.0, 120.5)
+
+	if !errors.Is(err, errLimit) {
+		t.Fatal("errors.Is не находит исходную ошибку")
+	}
+	if want := "превышен лимит расходов: дневной лимит счёта 500.00, израсходовано 120.50"; err.Error() != want {
+		t.Errorf("Error() = %q, ожидалось %q", err.Error(), want)
+	}
+
+	var localized *Error
+	if !errors.As(err, &localized) {
+		t.Fatal("errors.As не находит *Error")
+	}
+	if got := localized.Localize(EN); got != "account daily limit 500.00, already spent 120.50" {
+		t.Errorf("Localize(en) = %q", got)
+	}
+}
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
 	"bank-prototype/internal/services"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -29,11 +33,7 @@ func (m *AuthMiddleware) RequireAuth(next fasthttp.RequestHandler) fasthttp.Requ
 		authHeader := string(ctx.Request.Header.Peek("Authorization"))
 		if authHeader == "" {
 			utils.LogWarning("Middleware", "Отсутствует заголовок Authorization")
-			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-			ctx.SetContentType("application/json")
-			json.NewEncoder(ctx).Encode(map[string]string{
-				"error": "Требуется авторизация",
-			})
+			apierror.Write(ctx, fasthttp.StatusUnauthorized, apierror.CodeUnauthorized, "")
 			utils.LogResponse("RequireAuth", fasthttp.StatusUnauthorized, time.Since(startTime))
 			return
 		}
@@ -41,32 +41,48 @@ func (m *AuthMiddleware) RequireAuth(next fasthttp.RequestHandler) fasthttp.Requ
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			utils.LogWarning("Middleware", "Неверный формат заголовка Authorization")
-			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-			ctx.SetContentType("application/json")
-			json.NewEncoder(ctx).Encode(map[string]string{
-				"error": "Неверный формат токена",
-			})
+			apierror.Write(ctx, fasthttp.StatusUnauthorized, apierror.CodeInvalidToken, i18n.T(i18n.FromRequest(ctx), "detail.bearer_expected"))
 			utils.LogResponse("RequireAuth", fasthttp.StatusUnauthorized, time.Since(startTime))
 			return
 		}
 
 		token := parts[1]
 
-		claims, err := m.authService.ValidateToken(token)
-		if err != nil {
+		claims, err := m.authService.Authenticate(tracing.Context(ctx), token)
+		if errors.Is(err, services.ErrInvalidToken) {
 			utils.LogWarning("Middleware", fmt.Sprintf("Невалидный токен: %v", err))
-			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-			ctx.SetContentType("application/json")
-			json.NewEncoder(ctx).Encode(map[string]string{
-				"error": "Невалидный или истёкший токен",
-			})
+			apierror.Write(ctx, fasthttp.StatusUnauthorized, apierror.CodeInvalidToken, "")
 			utils.LogResponse("RequireAuth", fasthttp.StatusUnauthorized, time.Since(startTime))
 			return
 		}
+		if err != nil {
+			// Версию сессии не удалось проверить: пропускать запрос нельзя
+			utils.LogError("Middleware", "Ошибка проверки сессии", err)
+			apierror.Write(ctx, fasthttp.StatusInternalServerError, apierror.CodeInternal, "")
+			utils.LogResponse("RequireAuth", fasthttp.StatusInternalServerError, time.Since(startTime))
+			return
+		}
 
 		ctx.SetUserValue("user_id", claims.UserID)
+		ctx.SetUserValue("role", claims.Role)
 		utils.LogDebug("Middleware", fmt.Sprintf("Аутентифицирован пользователь: %s", claims.UserID))
 
 		next(ctx)
 	}
 }
+
+// RequireAdmin пропускает только пользователей с ролью администратора.
+// Должен применяться внутри RequireAuth.
+func (m *AuthMiddleware) RequireAdmin(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		role, _ := ctx.UserValue("role").(string)
+		if role != models.RoleAdmin {
+			userID, _ := ctx.UserValue("user_id").(string)
+			utils.LogWarning("Middleware", "Пользователь %s без прав администратора обратился к %s", userID, string(ctx.Path()))
+			apierror.Write(ctx, fasthttp.StatusForbidden, apierror.CodeAdminRequired, "")
+			return
+		}
+
+		next(ctx)
+	}
+}
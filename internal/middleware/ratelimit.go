@@ -0,0 +1,396 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/utils"
+)
+
+// Группы маршрутов с отдельными лимитами
+const (
+	RateGroupAuth  = "auth"
+	RateGroupReads = "reads"
+	RateGroupMoney = "money"
+	// RateGroupPasswordReset — сброс пароля: запросы рассылают письма,
+	// поэтому лимит строже, чем у входа
+	RateGroupPasswordReset = "password_reset"
+)
+
+// RateLimitPolicy описывает token bucket: ёмкость Limit запросов,
+// которая полностью восстанавливается за Period
+type RateLimitPolicy struct {
+	Limit  int
+	Period time.Duration
+}
+
+func (p RateLimitPolicy) refillPerMs() float64 {
+	return float64(p.Limit) / float64(p.Period.Milliseconds())
+}
+
+// DefaultRateLimitPolicies — лимиты по умолчанию. Переопределяются
+// переменными окружения RATE_LIMIT_AUTH, RATE_LIMIT_READS, RATE_LIMIT_MONEY,
+// RATE_LIMIT_PASSWORD_RESET в формате "<запросов>/<период>", например "20/1m".
+var DefaultRateLimitPolicies = map[string]RateLimitPolicy{
+	RateGroupAuth:  {Limit: 10, Period: time.Minute},
+	RateGroupReads: {Limit: 100, Period: time.Second},
+	RateGroupMoney: {Limit: 20, Period: time.Second},
+
+	RateGroupPasswordReset: {Limit: 5, Period: 15 * time.Minute},
+}
+
+// tokenBucketScript атомарно списывает токен из корзины.
+// Возвращает {разрешено (0/1), оставшиеся токены, мс до появления токена, мс до полной корзины}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refill_per_ms = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * refill_per_ms)
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry_after = math.ceil((1 - tokens) / refill_per_ms)
+end
+
+local reset = math.ceil((capacity - tokens) / refill_per_ms)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+
+return {allowed, math.floor(tokens), retry_after, reset}
+`)
+
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+// RateLimiter ограничивает частоту запросов по пользователю (после
+// авторизации) или по IP-адресу. Состояние корзин хранится в Redis, поэтому
+// лимит общий для всех экземпляров API. Если Redis недоступен, используется
+// локальное хранилище в памяти процесса.
+type RateLimiter struct {
+	redis    redis.Scripter
+	policies map[string]RateLimitPolicy
+	fallback *memoryBuckets
+	// trusted — балансировщики, которым разрешено передавать адрес клиента
+	// в X-Forwarded-For (переменная TRUSTED_PROXIES)
+	trusted []netip.Prefix
+
+	mu               sync.Mutex
+	lastFallbackWarn time.Time
+}
+
+func NewRateLimiter(client redis.Scripter, policies map[string]RateLimitPolicy) *RateLimiter {
+	merged := make(map[string]RateLimitPolicy, len(policies))
+	for group, policy := range policies {
+		merged[group] = policyFromEnv(group, policy)
+	}
+
+	for group, policy := range merged {
+		utils.LogInfo("RateLimiter", "Группа %s: %d запросов за %v", group, policy.Limit, policy.Period)
+	}
+	trusted := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if len(trusted) > 0 {
+		utils.LogInfo("RateLimiter", "X-Forwarded-For учитывается от доверенных прокси: %v", trusted)
+	}
+	utils.LogSuccess("Middleware", "Инициализирован rate limiter")
+
+	return &RateLimiter{
+		redis:    client,
+		policies: merged,
+		fallback: newMemoryBuckets(),
+		trusted:  trusted,
+	}
+}
+
+// Limit применяет лимит группы group к обработчику
+func (rl *RateLimiter) Limit(group string, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	policy, ok := rl.policies[group]
+	if !ok {
+		utils.LogWarning("RateLimiter", "Для группы %s не задан лимит, запросы не ограничиваются", group)
+		return next
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		startTime := time.Now()
+		key := rl.key(group, ctx)
+
+		result := rl.take(ctx, key, policy)
+
+		ctx.Response.Header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		ctx.Response.Header.Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		ctx.Response.Header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+		ctx.Response.Header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+
+		if !result.allowed {
+			utils.LogWarning("RateLimiter", "Превышен лимит группы %s для %s", group, key)
+			ctx.Response.Header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+			apierror.Write(ctx, fasthttp.StatusTooManyRequests, apierror.CodeRateLimited,
+				i18n.T(i18n.FromRequest(ctx), "detail.rate_limit", group, policy.Limit, policy.Period))
+			utils.LogResponse(string(ctx.Path()), fasthttp.StatusTooManyRequests, time.Since(startTime))
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// Policy возвращает лимит группы group
+func (rl *RateLimiter) Policy(group string) (RateLimitPolicy, bool) {
+	policy, ok := rl.policies[group]
+	return policy, ok
+}
+
+// Allow списывает токен группы group из корзины каждого ключа subjects —
+// для вызовов вне HTTP (gRPC). Ключ вида IPSubject(addr) совпадает с
+// ключом HTTP-запросов без авторизации, поэтому оба протокола расходуют
+// одну корзину. Если хотя бы одна корзина пуста, возвращает false и время
+// до появления токена.
+func (rl *RateLimiter) Allow(ctx context.Context, group string, subjects ...string) (bool, time.Duration) {
+	policy, ok := rl.policies[group]
+	if !ok {
+		return true, 0
+	}
+
+	allowed := true
+	var retryAfter time.Duration
+	for _, subject := range subjects {
+		key := "ratelimit:" + group + ":" + subject
+		result := rl.take(ctx, key, policy)
+		if !result.allowed {
+			utils.LogWarning("RateLimiter", "Превышен лимит группы %s для %s", group, key)
+			allowed = false
+			retryAfter = max(retryAfter, result.retryAfter)
+		}
+	}
+	return allowed, retryAfter
+}
+
+// IPSubject — ключ корзины для адреса клиента
+func IPSubject(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginSubject — ключ корзины для имени пользователя при входе: перебор
+// паролей одной учётной записи с разных адресов тоже ограничивается
+func LoginSubject(name string) string {
+	return "login:" + name
+}
+
+func (rl *RateLimiter) take(ctx context.Context, key string, policy RateLimitPolicy) rateLimitResult {
+	now := time.Now()
+
+	if rl.redis != nil {
+		values, err := tokenBucketScript.Run(ctx, rl.redis, []string{key},
+			policy.Limit, policy.refillPerMs(), now.UnixMilli(),
+		).Int64Slice()
+		if err == nil && len(values) == 4 {
+			return rateLimitResult{
+				allowed:    values[0] == 1,
+				remaining:  int(values[1]),
+				retryAfter: time.Duration(values[2]) * time.Millisecond,
+				reset:      time.Duration(values[3]) * time.Millisecond,
+			}
+		}
+		rl.warnFallback(err)
+	}
+
+	return rl.fallback.take(key, policy, now)
+}
+
+// warnFallback пишет предупреждение о переходе на локальные лимиты не чаще раза в минуту
+func (rl *RateLimiter) warnFallback(err error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if time.Since(rl.lastFallbackWarn) < time.Minute {
+		return
+	}
+	rl.lastFallbackWarn = time.Now()
+	utils.LogWarning("RateLimiter", "Redis недоступен, используются локальные лимиты: %v", err)
+}
+
+// key возвращает ключ корзины: пользователь, если запрос
+// уже прошёл авторизацию, иначе IP-адрес клиента
+func (rl *RateLimiter) key(group string, ctx *fasthttp.RequestCtx) string {
+	if userID, ok := ctx.UserValue("user_id").(string); ok && userID != "" {
+		return "ratelimit:" + group + ":user:" + userID
+	}
+	return "ratelimit:" + group + ":" + IPSubject(rl.clientIP(ctx))
+}
+
+// clientIP возвращает адрес клиента. X-Forwarded-For учитывается, только
+// если соединение пришло от доверенного прокси: иначе клиент мог бы
+// подставлять произвольный адрес и получать новую корзину на каждый запрос.
+// Цепочка разбирается справа налево до первого недоверенного адреса —
+// левые значения мог дописать сам клиент.
+func (rl *RateLimiter) clientIP(ctx *fasthttp.RequestCtx) string {
+	remote, ok := netip.AddrFromSlice(ctx.RemoteIP())
+	if !ok || !rl.isTrusted(remote.Unmap()) {
+		return ctx.RemoteIP().String()
+	}
+
+	var forwarded []string
+	ctx.Request.Header.VisitAll(func(name, value []byte) {
+		if strings.EqualFold(string(name), "X-Forwarded-For") {
+			forwarded = append(forwarded, strings.Split(string(value), ",")...)
+		}
+	})
+
+	client := remote.Unmap()
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !rl.isTrusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func (rl *RateLimiter) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range rl.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies разбирает список подсетей и адресов через запятую,
+// например "10.0.0.0/8,192.168.1.10"
+func parseTrustedProxies(value string) []netip.Prefix {
+	var trusted []netip.Prefix
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			trusted = append(trusted, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			utils.LogWarning("RateLimiter", "Некорректный адрес в TRUSTED_PROXIES: %q", item)
+			continue
+		}
+		trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return trusted
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// policyFromEnv переопределяет лимит из переменной RATE_LIMIT_<GROUP>
+func policyFromEnv(group string, policy RateLimitPolicy) RateLimitPolicy {
+	envName := "RATE_LIMIT_" + strings.ToUpper(group)
+	value := os.Getenv(envName)
+	if value == "" {
+		return policy
+	}
+
+	limitStr, periodStr, found := strings.Cut(value, "/")
+	limit, err1 := strconv.Atoi(limitStr)
+	period, err2 := time.ParseDuration(periodStr)
+	if !found || err1 != nil || err2 != nil || limit <= 0 || period <= 0 {
+		utils.LogWarning("RateLimiter", "Некорректное значение %s=%q, используется лимит по умолчанию", envName, value)
+		return policy
+	}
+
+	return RateLimitPolicy{Limit: limit, Period: period}
+}
+
+// memoryBuckets — локальное хранилище корзин на случай недоступности Redis
+type memoryBuckets struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// maxMemoryBuckets — порог, после которого из памяти удаляются полные корзины
+const maxMemoryBuckets = 100_000
+
+func newMemoryBuckets() *memoryBuckets {
+	return &memoryBuckets{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *memoryBuckets) take(key string, policy RateLimitPolicy, now time.Time) rateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.buckets) >= maxMemoryBuckets {
+		m.evictIdle(now)
+	}
+
+	capacity := float64(policy.Limit)
+	refillPerMs := policy.refillPerMs()
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: capacity, updatedAt: now, period: policy.Period}
+		m.buckets[key] = bucket
+	}
+
+	elapsedMs := float64(now.Sub(bucket.updatedAt).Milliseconds())
+	bucket.tokens = math.Min(capacity, bucket.tokens+elapsedMs*refillPerMs)
+	bucket.updatedAt = now
+
+	result := rateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = time.Duration(math.Ceil((1-bucket.tokens)/refillPerMs)) * time.Millisecond
+	}
+	result.remaining = int(bucket.tokens)
+	result.reset = time.Duration(math.Ceil((capacity-bucket.tokens)/refillPerMs)) * time.Millisecond
+
+	return result
+}
+
+// evictIdle удаляет корзины, которые успели полностью восстановиться
+func (m *memoryBuckets) evictIdle(now time.Time) {
+	for key, bucket := range m.buckets {
+		if now.Sub(bucket.updatedAt) > bucket.period {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp"
+)
+
+func newRequest(remoteAddr string, forwardedFor ...string) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.Header.SetMethod("POST")
+	req.SetRequestURI("/v1/login")
+	for _, value := range forwardedFor {
+		req.Header.Add("X-Forwarded-For", value)
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", remoteAddr)
+	if err != nil {
+		panic(err)
+	}
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, addr, nil)
+	return &ctx
+}
+
+func TestClientIP(t *testing.T) {
+	rl := &RateLimiter{trusted: parseTrustedProxies("10.0.0.0/8, 192.168.1.10, не-адрес")}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"без прокси", "203.0.113.5:4000", nil, "203.0.113.5"},
+		{"XFF от недоверенного адреса", "203.0.113.5:4000", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"XFF от доверенной подсети", "10.1.2.3:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"XFF от доверенного адреса", "192.168.1.10:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"подделанное начало цепочки", "10.1.2.3:4000", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"цепочка прокси", "10.1.2.3:4000", []string{"198.51.100.1, 10.0.0.7"}, "198.51.100.1"},
+		{"несколько заголовков", "10.1.2.3:4000", []string{"1.1.1.1", "198.51.100.1"}, "198.51.100.1"},
+		{"доверенный прокси без XFF", "10.1.2.3:4000", nil, "10.1.2.3"},
+		{"мусор в XFF", "10.1.2.3:4000", []string{"unknown"}, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rl.clientIP(newRequest(tt.remote, tt.forwarded...)); got != tt.want {
+				t.Errorf("clientIP = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+
+	// Без TRUSTED_PROXIES заголовок не учитывается вовсе
+	untrusted := &RateLimiter{}
+	if got := untrusted.clientIP(newRequest("10.1.2.3:4000", "198.51.100.1")); got != "10.1.2.3" {
+		t.Errorf("clientIP без доверенных прокси = %q", got)
+	}
+}
+
+func TestMemoryBucketsRefill(t *testing.T) {
+	buckets := newMemoryBuckets()
+	policy := RateLimitPolicy{Limit: 2, Period: 2 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 2; i++ {
+		if result := buckets.take("k", policy, now); !result.allowed {
+			t.Fatalf("запрос %d отклонён", i+1)
+		}
+	}
+	result := buckets.take("k", policy, now)
+	if result.allowed || result.remaining != 0 || result.retryAfter != time.Second {
+		t.Fatalf("третий запрос: %+v", result)
+	}
+
+	// За секунду восстанавливается один токен
+	now = now.Add(time.Second)
+	if result := buckets.take("k", policy, now); !result.allowed || result.remaining != 0 {
+		t.Fatalf("после секунды: %+v", result)
+	}
+	if result := buckets.take("k", policy, now); result.allowed {
+		t.Fatalf("токенов больше, чем восстановилось: %+v", result)
+	}
+
+	// Корзина не наполняется сверх ёмкости
+	now = now.Add(time.Hour)
+	if result := buckets.take("k", policy, now); !result.allowed || result.remaining != 1 {
+		t.Fatalf("после долгого простоя: %+v", result)
+	}
+
+	// Корзины разных ключей независимы
+	if result := buckets.take("other", policy, now); !result.allowed {
+		t.Fatalf("чужая корзина: %+v", result)
+	}
+}
+
+func TestRateLimiterRedisFallback(t *testing.T) {
+	// Redis на закрытом порту: каждый вызов скрипта завершается ошибкой
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	t.Cleanup(func() { client.Close() })
+
+	rl := NewRateLimiter(client, map[string]RateLimitPolicy{RateGroupAuth: {Limit: 2, Period: time.Minute}})
+	handler := rl.Limit(RateGroupAuth, func(ctx *fasthttp.RequestCtx) {})
+
+	statuses := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		ctx := newRequest("203.0.113.5:4000")
+		handler(ctx)
+		statuses = append(statuses, ctx.Response.StatusCode())
+	}
+	if statuses[0] != fasthttp.StatusOK || statuses[1] != fasthttp.StatusOK || statuses[2] != fasthttp.StatusTooManyRequests {
+		t.Fatalf("статусы без Redis: %v, ожидалось [200 200 429]", statuses)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	rl := NewRateLimiter(nil, map[string]RateLimitPolicy{RateGroupAuth: {Limit: 1, Period: time.Minute}})
+	called := 0
+	handler := rl.Limit(RateGroupAuth, func(ctx *fasthttp.RequestCtx) { called++ })
+
+	first := newRequest("203.0.113.5:4000")
+	handler(first)
+	if got := string(first.Response.Header.Peek("Retry-After")); got != "" {
+		t.Errorf("Retry-After в разрешённом ответе: %q", got)
+	}
+	if got := string(first.Response.Header.Peek("RateLimit-Policy")); got != "1;w=60" {
+		t.Errorf("RateLimit-Policy = %q", got)
+	}
+
+	second := newRequest("203.0.113.5:4000")
+	handler(second)
+	if second.Response.StatusCode() != fasthttp.StatusTooManyRequests {
+		t.Fatalf("статус второго запроса: %d", second.Response.StatusCode())
+	}
+	if got := string(second.Response.Header.Peek("Retry-After")); got != "60" {
+		t.Errorf("Retry-After = %q, ожидалось 60", got)
+	}
+	if got := string(second.Response.Header.Peek("RateLimit-Remaining")); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q", got)
+	}
+	if called != 1 {
+		t.Errorf("обработчик вызван %d раз, ожидался 1", called)
+	}
+
+	// Лимит по IP: у другого клиента своя корзина
+	other := newRequest("203.0.113.6:4000")
+	handler(other)
+	if other.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("статус запроса другого клиента: %d", other.Response.StatusCode())
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	rl := NewRateLimiter(nil, map[string]RateLimitPolicy{RateGroupAuth: {Limit: 2, Period: time.Minute}})
+	ctx := context.Background()
+
+	// Корзина адреса общая с HTTP-запросами без авторизации
+	handler := rl.Limit(RateGroupAuth, func(ctx *fasthttp.RequestCtx) {})
+	handler(newRequest("203.0.113.5:4000"))
+
+	if allowed, _ := rl.Allow(ctx, RateGroupAuth, IPSubject("203.0.113.5"), LoginSubject("alice")); !allowed {
+		t.Fatal("второй запрос с адреса отклонён")
+	}
+	allowed, retryAfter := rl.Allow(ctx, RateGroupAuth, IPSubject("203.0.113.5"), LoginSubject("bob"))
+	if allowed || retryAfter != 30*time.Second {
+		t.Fatalf("третий запрос с адреса: %v, %v", allowed, retryAfter)
+	}
+
+	// Подбор пароля одного пользователя с разных адресов тоже ограничен
+	if allowed, _ := rl.Allow(ctx, RateGroupAuth, IPSubject("198.51.100.1"), LoginSubject("alice")); !allowed {
+		t.Fatal("второй вход alice отклонён")
+	}
+	if allowed, _ := rl.Allow(ctx, RateGroupAuth, IPSubject("198.51.100.2"), LoginSubject("alice")); allowed {
+		t.Fatal("исчерпанная корзина пользователя не учтена")
+	}
+
+	// Группа без лимита не ограничивается
+	if allowed, _ := rl.Allow(ctx, "unknown", IPSubject("203.0.113.5")); !allowed {
+		t.Error("группа без лимита ограничена")
+	}
+}
@@ -0,0 +1,292 @@
+// Package migration управляет версией схемы базы данных по миграциям,
+// встроенным в бинарник (пакет migrations).
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/utils"
+	"bank-prototype/migrations"
+)
+
+var (
+	ErrSchemaMismatch = errors.New("версия схемы базы данных не совпадает с ожидаемой")
+	ErrSchemaDirty    = errors.New("предыдущая миграция применена не полностью")
+	ErrLockTimeout    = errors.New("не удалось дождаться блокировки миграций")
+	ErrInvalidCommand = errors.New("неверная команда миграций")
+)
+
+// Usage — описание команд для подкоманды migrate в API и bankctl
+const Usage = `  migrate up          применить все неприменённые миграции
+  migrate down [N]    откатить N последних миграций (по умолчанию 1)
+  migrate goto V      перейти на версию V (вверх или вниз)
+  migrate force V     записать версию V без выполнения (снять признак dirty)
+  migrate status      текущая версия и неприменённые миграции`
+
+// lockKey — ключ advisory lock, под которым выполняются все операции
+// с миграциями. Блокировка сессионная и держится на время всей команды
+// (например, goto через несколько версий), а не отдельного шага, поэтому
+// одновременно запущенные реплики и утилиты выполняют миграции по очереди.
+const lockKey = 7_340_002
+
+// DefaultLockTimeout — сколько ждать, пока другой процесс закончит миграции
+const DefaultLockTimeout = 5 * time.Minute
+
+// Status — состояние схемы относительно миграций в бинарнике
+type Status struct {
+	Version  uint   `json:"version"`  // применённая версия, 0 — миграций нет
+	Dirty    bool   `json:"dirty"`    // миграция Version завершилась ошибкой
+	Expected uint   `json:"expected"` // последняя миграция в бинарнике
+	Pending  []uint `json:"pending"`  // версии, которые применит up
+}
+
+type Migrator struct {
+	dbURL       string
+	lockTimeout time.Duration
+}
+
+func New(dbURL string) *Migrator {
+	lockTimeout := DefaultLockTimeout
+	if value := os.Getenv("MIGRATION_LOCK_TIMEOUT"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			lockTimeout = parsed
+		}
+	}
+	return &Migrator{dbURL: dbURL, lockTimeout: lockTimeout}
+}
+
+// Exec выполняет команду up | down [N] | goto V | force V | status и
+// возвращает состояние схемы после неё
+func (m *Migrator) Exec(ctx context.Context, args []string) (*Status, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidCommand
+	}
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = m.Up(ctx)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return nil, fmt.Errorf("%w: количество миграций должно быть положительным числом", ErrInvalidCommand)
+			}
+		}
+		err = m.Down(ctx, steps)
+
+	case "goto", "force":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("%w: укажите версию", ErrInvalidCommand)
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("%w: некорректная версия %s", ErrInvalidCommand, args[1])
+		}
+		if args[0] == "goto" {
+			err = m.Goto(ctx, uint(version))
+		} else {
+			err = m.Force(ctx, int(version))
+		}
+
+	case "status":
+
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCommand, args[0])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return m.Status(ctx)
+}
+
+// Up применяет все неприменённые миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, "up", func(mg *migrate.Migrate) error {
+		return mg.Up()
+	})
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("количество откатываемых миграций должно быть положительным")
+	}
+	return m.run(ctx, fmt.Sprintf("down %d", steps), func(mg *migrate.Migrate) error {
+		return mg.Steps(-steps)
+	})
+}
+
+// Goto переводит схему на версию version вверх или вниз
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, fmt.Sprintf("goto %d", version), func(mg *migrate.Migrate) error {
+		return mg.Migrate(version)
+	})
+}
+
+// Force записывает версию без выполнения миграций. Нужна, чтобы снять
+// признак dirty после ручного исправления схемы.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.run(ctx, fmt.Sprintf("force %d", version), func(mg *migrate.Migrate) error {
+		return mg.Force(version)
+	})
+}
+
+// Status возвращает применённую версию и список неприменённых миграций
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	mg, err := m.open()
+	if err != nil {
+		return nil, err
+	}
+	defer mg.Close()
+
+	version, dirty, err := mg.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+
+	versions, err := Versions()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{Version: version, Dirty: dirty, Pending: []uint{}}
+	for _, v := range versions {
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+		status.Expected = v
+	}
+
+	return status, nil
+}
+
+// run выполняет операцию под advisory lock. ErrNoChange не считается ошибкой.
+func (m *Migrator) run(ctx context.Context, operation string, fn func(*migrate.Migrate) error) error {
+	conn, err := pgx.Connect(ctx, m.dbURL)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout)
+	defer cancel()
+
+	utils.LogInfo("Migration", "Ожидание блокировки миграций...")
+	if _, err := conn.Exec(lockCtx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		if lockCtx.Err() != nil {
+			return fmt.Errorf("%w за %v", ErrLockTimeout, m.lockTimeout)
+		}
+		return fmt.Errorf("ошибка получения блокировки миграций: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	mg, err := m.open()
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	utils.LogInfo("Migration", "Выполнение миграций: %s", operation)
+	if err := fn(mg); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("ошибка миграции (%s): %w", operation, err)
+	}
+
+	version, dirty, err := mg.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+	utils.LogSuccess("Migration", "Миграции выполнены, версия схемы: %d (dirty: %v)", version, dirty)
+
+	return nil
+}
+
+func (m *Migrator) open() (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения встроенных миграций: %w", err)
+	}
+
+	mg, err := migrate.NewWithSourceInstance("iofs", source, m.dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации миграций: %w", err)
+	}
+	return mg, nil
+}
+
+// Versions возвращает версии всех встроенных миграций по возрастанию
+func Versions() ([]uint, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения встроенных миграций: %w", err)
+	}
+	defer source.Close()
+
+	var versions []uint
+	version, err := source.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения встроенных миграций: %w", err)
+	}
+
+	return versions, nil
+}
+
+// LatestVersion — версия схемы, которую ожидает этот бинарник
+func LatestVersion() (uint, error) {
+	versions, err := Versions()
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, fmt.Errorf("встроенные миграции не найдены")
+	}
+	return versions[len(versions)-1], nil
+}
+
+// CheckVersion проверяет, что схема базы данных соответствует бинарнику.
+// API вызывает её при запуске и не обслуживает запросы при несовпадении:
+// код, рассчитанный на другую схему, может повредить данные.
+func CheckVersion(ctx context.Context, db *pgxpool.Pool) (uint, error) {
+	expected, err := LatestVersion()
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	var dirty bool
+	err = db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return expected, fmt.Errorf("%w: миграции не применялись, ожидается %d", ErrSchemaMismatch, expected)
+		}
+		return expected, fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+	}
+
+	if dirty {
+		return expected, fmt.Errorf("%w: версия %d", ErrSchemaDirty, version)
+	}
+	if uint(version) != expected {
+		return expected, fmt.Errorf("%w: в базе %d, ожидается %d", ErrSchemaMismatch, version, expected)
+	}
+
+	return expected, nil
+}
@@ -3,21 +3,30 @@ package models
 import "time"
 
 type Account struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Balance   float64   `json:"balance"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Balance    float64   `json:"balance"`
+	HeldAmount float64   `json:"held_amount"` // зарезервировано активными авторизациями
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
-type CreateAccountRequest struct {
+// AvailableBalance — сумма, которую можно потратить с учётом холдов
+func (a *Account) AvailableBalance() float64 {
+	return a.Balance - a.HeldAmount
 }
 
 type AccountResponse struct {
-	ID        string  `json:"id"`
-	Balance   float64 `json:"balance"`
-	Status    string  `json:"status"`
-	CreatedAt string  `json:"created_at"`
+	ID               string  `json:"id"`
+	Balance          float64 `json:"balance"`
+	AvailableBalance float64 `json:"available_balance"`
+	Status           string  `json:"status"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+type CloseAccountResponse struct {
+	Message   string `json:"message"`
+	AccountID string `json:"account_id"`
 }
 
 type AccountListResponse struct {
@@ -28,3 +37,31 @@ type AccountListResponse struct {
 	MaxAccounts   int               `json:"max_accounts"`
 	CanCreateMore bool              `json:"can_create_more"`
 }
+
+// AccountV2 — счёт в API v2: суммы строками, дата в RFC 3339
+type AccountV2 struct {
+	ID               string    `json:"id"`
+	Balance          Money     `json:"balance"`
+	AvailableBalance Money     `json:"available_balance"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func NewAccountV2(account *Account) AccountV2 {
+	return AccountV2{
+		ID:               account.ID,
+		Balance:          MoneyOf(account.Balance),
+		AvailableBalance: MoneyOf(account.AvailableBalance()),
+		Status:           account.Status,
+		CreatedAt:        account.CreatedAt,
+	}
+}
+
+type AccountListResponseV2 struct {
+	Accounts      []AccountV2 `json:"accounts"`
+	Total         int         `json:"total"`
+	ActiveCount   int         `json:"active_count"`
+	ClosedCount   int         `json:"closed_count"`
+	MaxAccounts   int         `json:"max_accounts"`
+	CanCreateMore bool        `json:"can_create_more"`
+}
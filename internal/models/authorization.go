@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Authorization — холд: средства зарезервированы на счёте плательщика,
+// но ещё не списаны. Списываются при capture, возвращаются в доступный
+// остаток при release или по истечении срока действия.
+type Authorization struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	FromAccountID  string    `json:"from_account_id"`
+	ToAccountID    string    `json:"to_account_id"`
+	Amount         float64   `json:"amount"`
+	HeldAmount     float64   `json:"held_amount"` // сумма платежа + комиссия 3%
+	CapturedAmount float64   `json:"captured_amount"`
+	TransactionID  *string   `json:"transaction_id,omitempty"`
+	Status         string    `json:"status"` // active, captured, released, expired
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreateAuthorizationRequest struct {
+	FromAccountID string  `json:"from_account_id" validate:"required,min=1"`
+	ToAccountID   string  `json:"to_account_id" validate:"required,min=1"`
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+	TTLSeconds    *int    `json:"ttl_seconds,omitempty" validate:"min=60,max=2592000"`
+}
+
+// CaptureAuthorizationRequest — если Amount не указан, списывается вся сумма
+// авторизации. Остаток после частичного списания возвращается в доступный баланс.
+type CaptureAuthorizationRequest struct {
+	Amount *float64 `json:"amount,omitempty" validate:"gt=0"`
+}
+
+type CaptureAuthorizationResponse struct {
+	Authorization *Authorization `json:"authorization"`
+	Transaction   *Transaction   `json:"transaction"`
+}
+
+type AuthorizationListResponse struct {
+	Authorizations []Authorization `json:"authorizations"`
+	Total          int             `json:"total"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+type TransferBatch struct {
+	ID             string              `json:"id"`
+	UserID         string              `json:"user_id"`
+	FromAccountID  string              `json:"from_account_id"`
+	Mode           string              `json:"mode"`   // all_or_nothing или best_effort
+	Status         string              `json:"status"` // pending, processing, completed, partially_completed, failed
+	TotalItems     int                 `json:"total_items"`
+	SucceededItems int                 `json:"succeeded_items"`
+	FailedItems    int                 `json:"failed_items"`
+	TotalAmount    float64             `json:"total_amount"`
+	Error          *string             `json:"error,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	CompletedAt    *time.Time          `json:"completed_at,omitempty"`
+	Items          []TransferBatchItem `json:"items,omitempty"`
+}
+
+type TransferBatchItem struct {
+	Index         int     `json:"index"`
+	ToAccountID   string  `json:"to_account_id"`
+	Amount        float64 `json:"amount"`
+	Reference     *string `json:"reference,omitempty"`
+	Status        string  `json:"status"` // pending, succeeded, failed
+	TransactionID *string `json:"transaction_id,omitempty"`
+	Error         *string `json:"error,omitempty"`
+}
+
+type BatchTransferRequest struct {
+	FromAccountID string             `json:"from_account_id" validate:"required,min=1"`
+	Mode          string             `json:"mode" validate:"required,enum=all_or_nothing|best_effort"`
+	Items         []BatchItemRequest `json:"items" validate:"required,min=1,max=1000"`
+}
+
+type BatchItemRequest struct {
+	ToAccountID string  `json:"to_account_id" validate:"required,min=1"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+	Reference   *string `json:"reference,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Типы событий потока GET /v1/events
+const (
+	EventTransactionCompleted = "transaction.completed"
+	EventBalanceChanged       = "balance.changed"
+)
+
+// Event — событие для клиента в реальном времени. По типу заполнено одно
+// из полей Transaction или Balance; суммы — строками, как в API v2.
+type Event struct {
+	ID          string         `json:"id"` // возрастает; клиент возобновляет поток с него (Last-Event-ID)
+	Type        string         `json:"type" validate:"required,enum=transaction.completed|balance.changed"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Transaction *TransactionV2 `json:"transaction,omitempty"`
+	Balance     *BalanceChange `json:"balance,omitempty"`
+}
+
+// BalanceChange — баланс счёта после транзакции или изменения холдов
+type BalanceChange struct {
+	AccountID        string `json:"account_id"`
+	Balance          Money  `json:"balance"`
+	AvailableBalance Money  `json:"available_balance"`
+	TransactionID    string `json:"transaction_id,omitempty"` // пусто, если остаток изменил холд
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FailedJob — фоновая задача, которая завершилась ошибкой или зависла
+// и может быть перезапущена оператором
+type FailedJob struct {
+	Kind     string    `json:"kind"` // scheduled_transfer или transfer_batch
+	ID       string    `json:"id"`
+	UserID   string    `json:"user_id"`
+	Status   string    `json:"status"`
+	Error    *string   `json:"error,omitempty"`
+	FailedAt time.Time `json:"failed_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// SpendingLimits — набор лимитов расходов. nil означает, что лимит не задан.
+type SpendingLimits struct {
+	SingleMax     *float64 `json:"single_max" validate:"gt=0"`
+	DailyMax      *float64 `json:"daily_max" validate:"gt=0"`
+	MonthlyMax    *float64 `json:"monthly_max" validate:"gt=0"`
+	DailyCountMax *int     `json:"daily_count_max" validate:"gt=0"`
+}
+
+// SpendingUsage — исходящие переводы и платежи за текущие сутки и месяц (UTC)
+type SpendingUsage struct {
+	Daily      float64 `json:"daily"`
+	Monthly    float64 `json:"monthly"`
+	DailyCount int     `json:"daily_count"`
+}
+
+// LimitHeadroom — действующие лимиты, использование и остаток.
+// Remaining-поля равны nil, если соответствующий лимит не задан.
+type LimitHeadroom struct {
+	Limits              SpendingLimits `json:"limits"`
+	Used                SpendingUsage  `json:"used"`
+	DailyRemaining      *float64       `json:"daily_remaining"`
+	MonthlyRemaining    *float64       `json:"monthly_remaining"`
+	DailyCountRemaining *int           `json:"daily_count_remaining"`
+}
+
+type AccountLimitsResponse struct {
+	AccountID string `json:"account_id"`
+	// Максимальная сумма одной операции с учётом всех уровней
+	SingleMax *float64 `json:"single_max"`
+	// Лимиты счёта, установленные пользователем
+	Account LimitHeadroom `json:"account"`
+	// Лимиты пользователя по всем его счетам (не выше потолков банка)
+	User LimitHeadroom `json:"user"`
+	// Потолки банка, выше которых лимиты поднять нельзя
+	Ceilings SpendingLimits `json:"ceilings"`
+}
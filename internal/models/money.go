@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney — сумма не в формате десятичной строки
+var ErrInvalidMoney = errors.New("неверный формат суммы")
+
+// Money — денежная сумма в API v2 и gRPC API: десятичная строка с двумя
+// знаками после точки ("1250.00"). В JSON-клиентах, где каждое число —
+// double, строка не теряет точность, а сервисы по-прежнему работают с float64.
+type Money string
+
+// MoneyOf возвращает сумму amount в виде Money
+func MoneyOf(amount float64) Money {
+	return Money(strconv.FormatFloat(amount, 'f', 2, 64))
+}
+
+// Float64 возвращает сумму числом. Допускается только десятичная запись
+// с не более чем двумя знаками после точки: "10", "10.5", "-3.25". В HTTP
+// формат проверяет ещё схема запроса (format=decimal), в gRPC схемы нет.
+func (m Money) Float64() (float64, error) {
+	whole, fraction, hasPoint := strings.Cut(strings.TrimPrefix(string(m), "-"), ".")
+	if !isDigits(whole) || len(whole) > 13 || (hasPoint && !isDigits(fraction)) || len(fraction) > 2 {
+		return 0, ErrInvalidMoney
+	}
+	return strconv.ParseFloat(string(m), 64)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
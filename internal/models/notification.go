@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Виды уведомлений
+const (
+	NotificationIncomingTransfer        = "incoming_transfer"
+	NotificationLowBalance              = "low_balance"
+	NotificationScheduledTransferFailed = "scheduled_transfer_failed"
+)
+
+// NotificationPreferences — настройки уведомлений пользователя: каналы
+// доставки, включённые виды уведомлений и язык сообщений
+type NotificationPreferences struct {
+	EmailEnabled bool `json:"email_enabled"`
+	SMSEnabled   bool `json:"sms_enabled"`
+	InboxEnabled bool `json:"inbox_enabled"`
+
+	IncomingTransfer        bool    `json:"incoming_transfer"`
+	LowBalance              bool    `json:"low_balance"`
+	LowBalanceThreshold     float64 `json:"low_balance_threshold"` // уведомление, когда доступный остаток опускается ниже
+	ScheduledTransferFailed bool    `json:"scheduled_transfer_failed"`
+
+	Language string `json:"language"`
+}
+
+// DefaultNotificationPreferences — настройки пользователя, который их не менял
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		EmailEnabled:            true,
+		InboxEnabled:            true,
+		IncomingTransfer:        true,
+		LowBalance:              true,
+		LowBalanceThreshold:     10,
+		ScheduledTransferFailed: true,
+		Language:                "ru",
+	}
+}
+
+// Enabled сообщает, включён ли вид уведомлений kind
+func (p NotificationPreferences) Enabled(kind string) bool {
+	switch kind {
+	case NotificationIncomingTransfer:
+		return p.IncomingTransfer
+	case NotificationLowBalance:
+		return p.LowBalance
+	case NotificationScheduledTransferFailed:
+		return p.ScheduledTransferFailed
+	}
+	return false
+}
+
+// UpdateNotificationPreferencesRequest — изменение настроек уведомлений.
+// Отсутствующее поле не меняется.
+type UpdateNotificationPreferencesRequest struct {
+	EmailEnabled            *bool    `json:"email_enabled"`
+	SMSEnabled              *bool    `json:"sms_enabled"`
+	InboxEnabled            *bool    `json:"inbox_enabled"`
+	IncomingTransfer        *bool    `json:"incoming_transfer"`
+	LowBalance              *bool    `json:"low_balance"`
+	LowBalanceThreshold     *float64 `json:"low_balance_threshold" validate:"min=0"`
+	ScheduledTransferFailed *bool    `json:"scheduled_transfer_failed"`
+	Language                *string  `json:"language" validate:"enum=ru|en"`
+}
+
+// Notification — уведомление во входящих приложения
+type Notification struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int            `json:"total"`
+	Unread        int            `json:"unread"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Marked int `json:"marked"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type ReconciliationReport struct {
+	ID               string               `json:"id"`
+	TriggeredBy      string               `json:"triggered_by"` // scheduler, admin или cli
+	Status           string               `json:"status"`       // running, completed, failed
+	AccountsChecked  int                  `json:"accounts_checked"`
+	DiscrepancyCount int                  `json:"discrepancy_count"`
+	TotalDifference  float64              `json:"total_difference"`
+	Error            *string              `json:"error,omitempty"`
+	StartedAt        time.Time            `json:"started_at"`
+	FinishedAt       *time.Time           `json:"finished_at,omitempty"`
+	Discrepancies    []BalanceDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// BalanceDiscrepancy — счёт, фактический баланс которого не совпадает с
+// рассчитанным по истории транзакций
+type BalanceDiscrepancy struct {
+	AccountID       string  `json:"account_id"`
+	AccountStatus   string  `json:"account_status"`
+	ExpectedBalance float64 `json:"expected_balance"`
+	ActualBalance   float64 `json:"actual_balance"`
+	Difference      float64 `json:"difference"` // actual - expected
+}
+
+type ReconciliationReportListResponse struct {
+	Reports []ReconciliationReport `json:"reports"`
+	Total   int                    `json:"total"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+type ScheduledTransfer struct {
+	ID                   string     `json:"id"`
+	UserID               string     `json:"user_id"`
+	Type                 string     `json:"type"` // "transfer" или "payment"
+	FromAccountID        string     `json:"from_account_id"`
+	ToAccountID          string     `json:"to_account_id"`
+	Amount               float64    `json:"amount"`
+	CronExpression       *string    `json:"cron_expression,omitempty"`
+	IntervalSeconds      *int64     `json:"interval_seconds,omitempty"`
+	StartAt              time.Time  `json:"start_at"`
+	EndAt                *time.Time `json:"end_at,omitempty"`
+	MaxOccurrences       *int       `json:"max_occurrences,omitempty"`
+	Occurrences          int        `json:"occurrences"`
+	NextRunAt            *time.Time `json:"next_run_at,omitempty"`
+	OnInsufficientFunds  string     `json:"on_insufficient_funds"` // "skip" или "retry"
+	RetryIntervalSeconds int        `json:"retry_interval_seconds"`
+	MaxRetries           int        `json:"max_retries"`
+	RetryCount           int        `json:"retry_count"`
+	RetryAt              *time.Time `json:"retry_at,omitempty"`
+	Status               string     `json:"status"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	// LockedUntil — аренда, взятая планировщиком; по ней FinishRun
+	// проверяет, что перевод всё ещё принадлежит этому выполнению
+	LockedUntil *time.Time `json:"-"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  string    `json:"id"`
+	ScheduledTransferID string    `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	Attempt             int       `json:"attempt"`
+	Status              string    `json:"status"` // succeeded, failed, skipped, retry_scheduled
+	TransactionID       *string   `json:"transaction_id,omitempty"`
+	Error               *string   `json:"error,omitempty"`
+	ExecutedAt          time.Time `json:"executed_at"`
+}
+
+type CreateScheduledTransferRequest struct {
+	Type                 string     `json:"type" validate:"required,enum=transfer|payment"`
+	FromAccountID        string     `json:"from_account_id" validate:"required,min=1"`
+	ToAccountID          string     `json:"to_account_id" validate:"required,min=1"`
+	Amount               float64    `json:"amount" validate:"required,gt=0"`
+	CronExpression       *string    `json:"cron_expression,omitempty"`
+	IntervalSeconds      *int64     `json:"interval_seconds,omitempty" validate:"min=60"`
+	StartAt              *time.Time `json:"start_at,omitempty"`
+	EndAt                *time.Time `json:"end_at,omitempty"`
+	MaxOccurrences       *int       `json:"max_occurrences,omitempty" validate:"min=1"`
+	OnInsufficientFunds  string     `json:"on_insufficient_funds,omitempty" validate:"enum=skip|retry"`
+	RetryIntervalSeconds *int       `json:"retry_interval_seconds,omitempty" validate:"min=60"`
+	MaxRetries           *int       `json:"max_retries,omitempty" validate:"min=0"`
+}
+
+// UpdateScheduledTransferRequest — частичное обновление, nil-поля не меняются
+type UpdateScheduledTransferRequest struct {
+	Amount              *float64   `json:"amount,omitempty" validate:"gt=0"`
+	EndAt               *time.Time `json:"end_at,omitempty"`
+	MaxOccurrences      *int       `json:"max_occurrences,omitempty" validate:"min=1"`
+	OnInsufficientFunds *string    `json:"on_insufficient_funds,omitempty" validate:"enum=skip|retry"`
+	Status              *string    `json:"status,omitempty" validate:"enum=active|paused"`
+}
+
+type ScheduledTransferResponse struct {
+	ScheduledTransfer
+	Runs []ScheduledTransferRun `json:"runs,omitempty"`
+}
+
+type CancelScheduledTransferResponse struct {
+	Message string `json:"message"`
+	ID      string `json:"id"`
+}
+
+type ScheduledTransferListResponse struct {
+	ScheduledTransfers []ScheduledTransfer `json:"scheduled_transfers"`
+	Total              int                 `json:"total"`
+}
@@ -14,18 +14,41 @@ type Transaction struct {
 	FeeAccountID  string    `json:"fee_account_id"`
 	Status        string    `json:"status"`
 	CreatedAt     time.Time `json:"created_at"`
+
+	// Для компенсирующих транзакций (refund, reversal, fee_refund) — ID исходной
+	OriginalTransactionID *string `json:"original_transaction_id,omitempty"`
+	// Для исходных транзакций — сколько уже возвращено
+	RefundedAmount    float64 `json:"refunded_amount"`
+	RefundedFeeAmount float64 `json:"refunded_fee_amount"`
+	// Компенсирующие транзакции, связанные с этой (заполняется в GetTransactionByID)
+	Refunds []Transaction `json:"refunds,omitempty"`
 }
 
 type TransferRequest struct {
-	FromAccountID string  `json:"from_account_id"`
-	ToAccountID   string  `json:"to_account_id"`
-	Amount        float64 `json:"amount"`
+	FromAccountID string  `json:"from_account_id" validate:"required,min=1"`
+	ToAccountID   string  `json:"to_account_id" validate:"required,min=1"`
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+	// IdempotencyKey задают внутренние вызовы, которые могут повториться
+	// после сбоя (регулярные и пакетные переводы): повтор с тем же ключом
+	// возвращает уже проведённую транзакцию. Из тела запроса не читается.
+	IdempotencyKey string `json:"-"`
+	// BatchItem задаёт пакет best_effort для позиций после первой успешной:
+	// пакет уже учтён в лимите количества как одна операция
+	BatchItem bool `json:"-"`
 }
 
 type PaymentRequest struct {
-	FromAccountID string  `json:"from_account_id"`
-	ToAccountID   string  `json:"to_account_id"`
-	Amount        float64 `json:"amount"`
+	FromAccountID string  `json:"from_account_id" validate:"required,min=1"`
+	ToAccountID   string  `json:"to_account_id" validate:"required,min=1"`
+	Amount        float64 `json:"amount" validate:"required,gt=0"`
+	// IdempotencyKey — как в TransferRequest
+	IdempotencyKey string `json:"-"`
+}
+
+// TransactionResult — ответ на перевод или платёж
+type TransactionResult struct {
+	Message     string       `json:"message"`
+	Transaction *Transaction `json:"transaction"`
 }
 
 type TransactionRequest struct {
@@ -35,6 +58,19 @@ type TransactionRequest struct {
 	Amount        float64 `json:"amount"`
 }
 
+// RefundRequest — возврат или сторнирование. Если Amount не указан,
+// возвращается весь остаток исходной транзакции.
+type RefundRequest struct {
+	Amount    *float64 `json:"amount,omitempty" validate:"gt=0"`
+	RefundFee bool     `json:"refund_fee"` // вернуть плательщику пропорциональную часть комиссии
+}
+
+type RefundResult struct {
+	Refund    *Transaction `json:"refund"`
+	FeeRefund *Transaction `json:"fee_refund,omitempty"`
+	Original  *Transaction `json:"original"`
+}
+
 type TransactionResponse struct {
 	ID            string  `json:"id"`
 	Type          string  `json:"type"`
@@ -49,7 +85,59 @@ type TransactionResponse struct {
 }
 
 type TransactionListResponse struct {
-	Transactions []TransactionResponse `json:"transactions"`
-	Total        int                   `json:"total"`
-	AccountID    string                `json:"account_id,omitempty"`
+	Transactions []Transaction `json:"transactions"`
+	Total        int           `json:"total"`
+	AccountID    string        `json:"account_id,omitempty"`
+}
+
+// TransferRequestV2 — перевод или платёж в API v2: сумма строкой
+type TransferRequestV2 struct {
+	FromAccountID string `json:"from_account_id" validate:"required,min=1"`
+	ToAccountID   string `json:"to_account_id" validate:"required,min=1"`
+	Amount        Money  `json:"amount" validate:"required,format=decimal,gt=0"`
+}
+
+// TransactionV2 — транзакция в API v2: суммы строками
+type TransactionV2 struct {
+	ID                    string          `json:"id"`
+	Type                  string          `json:"type"`
+	FromAccountID         string          `json:"from_account_id"`
+	ToAccountID           string          `json:"to_account_id"`
+	Amount                Money           `json:"amount"`
+	FeePercent            int             `json:"fee_percent"`
+	FeeAmount             Money           `json:"fee_amount"`
+	TotalDebit            Money           `json:"total_debit"`
+	Status                string          `json:"status"`
+	CreatedAt             time.Time       `json:"created_at"`
+	OriginalTransactionID *string         `json:"original_transaction_id,omitempty"`
+	RefundedAmount        Money           `json:"refunded_amount"`
+	RefundedFeeAmount     Money           `json:"refunded_fee_amount"`
+	Refunds               []TransactionV2 `json:"refunds,omitempty"`
+}
+
+func NewTransactionV2(t *Transaction) *TransactionV2 {
+	result := &TransactionV2{
+		ID:                    t.ID,
+		Type:                  t.Type,
+		FromAccountID:         t.FromAccountID,
+		ToAccountID:           t.ToAccountID,
+		Amount:                MoneyOf(t.Amount),
+		FeePercent:            t.FeePercent,
+		FeeAmount:             MoneyOf(t.FeeAmount),
+		TotalDebit:            MoneyOf(t.TotalDebit),
+		Status:                t.Status,
+		CreatedAt:             t.CreatedAt,
+		OriginalTransactionID: t.OriginalTransactionID,
+		RefundedAmount:        MoneyOf(t.RefundedAmount),
+		RefundedFeeAmount:     MoneyOf(t.RefundedFeeAmount),
+	}
+	for i := range t.Refunds {
+		result.Refunds = append(result.Refunds, *NewTransactionV2(&t.Refunds[i]))
+	}
+	return result
+}
+
+type TransactionResultV2 struct {
+	Message     string         `json:"message"`
+	Transaction *TransactionV2 `json:"transaction"`
 }
@@ -6,15 +6,133 @@ type User struct {
 	ID           string
 	Name         string
 	PasswordHash string
-	CreatedAt    time.Time
+	Role         string
+	DisplayName  string // пусто, если не задано
+	Email        string // в нижнем регистре; пусто, если не задан
+	Phone        string // в формате E.164; пусто, если не задан
+	// SessionVersion входит в JWT: после смены пароля версия растёт, и
+	// выданные раньше токены перестают приниматься
+	SessionVersion int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type RegisterRequest struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required,min=1,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type RegisterResponse struct {
+	Message   string    `json:"message"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type LoginRequest struct {
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"required,min=1"`
+	Password string `json:"password" validate:"required,min=1"`
+}
+
+type LoginResponse struct {
+	Message   string `json:"message"`
+	Token     string `json:"token"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	ExpiresIn string `json:"expires_in"`
+}
+
+// DeleteUserRequest — необязательное тело DELETE /users/me. Без счёта
+// выплаты удалить можно только пользователя с нулевыми балансами.
+type DeleteUserRequest struct {
+	// PayoutAccountID — счёт в банке, на который переводятся остатки
+	PayoutAccountID string `json:"payout_account_id" validate:"min=1"`
+}
+
+type DeleteUserResponse struct {
+	Message string `json:"message"`
+	UserID  string `json:"user_id"`
+	// Payouts — переводы остатков на счёт выплаты
+	Payouts []Transaction `json:"payouts,omitempty"`
+}
+
+// UserDeletion — результат мягкого удаления пользователя
+type UserDeletion struct {
+	Payouts        []Transaction
+	ClosedAccounts []string
+	DeletedAt      time.Time
+}
+
+// RetentionResult — итог удаления данных с истёкшим сроком хранения
+type RetentionResult struct {
+	Users        int // удалено пользователей полностью
+	Accounts     int
+	Transactions int
+}
+
+// UserProfile — профиль текущего пользователя (GET /users/me). Незаданные
+// display_name, email и phone — пустые строки.
+type UserProfile struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	Phone       string    `json:"phone"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UpdateProfileRequest — изменение профиля (PATCH /users/me). Отсутствующее
+// поле или null не меняется, пустая строка очищает значение. Телефон —
+// в международном формате: "+79161234567".
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" validate:"max=100"`
+	Email       *string `json:"email" validate:"max=254"`
+	Phone       *string `json:"phone" validate:"max=20"`
+}
+
+// ChangePasswordRequest — смена пароля (POST /users/me/password)
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,min=1"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// ChangePasswordResponse содержит новый токен: после смены пароля прежние
+// токены, в том числе токен этого запроса, больше не действуют
+type ChangePasswordResponse struct {
+	Message   string `json:"message"`
+	Token     string `json:"token"`
+	ExpiresIn string `json:"expires_in"`
+}
+
+// PasswordResetRequest — запрос ссылки для сброса пароля
+// (POST /auth/password-reset/request). Login — имя пользователя или email.
+type PasswordResetRequest struct {
+	Login string `json:"login" validate:"required,min=1,max=254"`
+}
+
+// PasswordResetConfirmRequest — установка нового пароля по токену из
+// сообщения (POST /auth/password-reset/confirm)
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required,min=1"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type PasswordResetResponse struct {
+	Message string `json:"message"`
+}
+
+// PasswordResetToken — действующий токен сброса пароля. Сам токен не
+// хранится, только его хеш.
+type PasswordResetToken struct {
+	ID        string
+	UserID    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
 }
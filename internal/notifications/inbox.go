@@ -0,0 +1,23 @@
+package notifications
+
+import "context"
+
+// InboxStore сохраняет уведомления во входящие пользователя.
+// Реализация — repository.NotificationRepository.
+type InboxStore interface {
+	AddNotification(ctx context.Context, userID, kind, subject, body string) error
+}
+
+// InboxNotifier кладёт сообщение во входящие приложения. Адрес ему не
+// нужен, поэтому сообщение доставляется всегда.
+type InboxNotifier struct {
+	store InboxStore
+}
+
+func NewInboxNotifier(store InboxStore) *InboxNotifier {
+	return &InboxNotifier{store: store}
+}
+
+func (n *InboxNotifier) Notify(ctx context.Context, msg Message) error {
+	return n.store.AddNotification(ctx, msg.UserID, msg.Kind, msg.Subject, msg.Body)
+}
@@ -0,0 +1,109 @@
+// Package notifications доставляет сообщения пользователям: ссылки для
+// сброса пароля и уведомления о событиях по счетам. Сообщение доставляется
+// по каналам: почта (SMTP, а для локальной разработки — журнал или файл),
+// SMS через HTTP-шлюз и входящие в приложении. Тексты берутся из шаблонов
+// (см. Render).
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"bank-prototype/internal/utils"
+)
+
+// Каналы доставки уведомлений
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelInbox = "inbox"
+)
+
+var (
+	// ErrNoAddress — у получателя нет адреса для выбранного способа доставки
+	ErrNoAddress = errors.New("у получателя нет адреса для доставки")
+	// ErrRejected — сервер или шлюз отклонил сообщение; повтор не поможет
+	ErrRejected = errors.New("сообщение отклонено при доставке")
+)
+
+// Message — сообщение пользователю
+type Message struct {
+	UserID  string
+	Name    string
+	Email   string
+	Phone   string
+	Kind    string // вид уведомления (models.Notification*); пусто для служебных сообщений
+	Subject string
+	Body    string
+	Short   string // текст для SMS; если пуст, отправляется Body
+}
+
+// Notifier доставляет сообщение получателю. Реализации должны быть
+// безопасны для вызова из нескольких горутин.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// LogNotifier пишет сообщения в журнал сервера. Только для локальной
+// разработки: в журнал попадает и текст сообщения, в том числе токены.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, msg Message) error {
+	utils.LogInfo("Notifier", "Сообщение для %s (%s): %s\n%s", msg.Name, msg.UserID, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier дописывает сообщения в файл построчно в JSON. Подходит
+// для локальной проверки и интеграционных тестов, которые читают файл.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+// fileRecord — строка файла FileNotifier
+type fileRecord struct {
+	Time    time.Time `json:"time"`
+	UserID  string    `json:"user_id"`
+	Name    string    `json:"name"`
+	Email   string    `json:"email,omitempty"`
+	Kind    string    `json:"kind,omitempty"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(fileRecord{
+		Time:    time.Now(),
+		UserID:  msg.UserID,
+		Name:    msg.Name,
+		Email:   msg.Email,
+		Kind:    msg.Kind,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("ошибка открытия файла сообщений: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка записи сообщения: %w", err)
+	}
+	return file.Close()
+}
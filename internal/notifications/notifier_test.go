@@ -0,0 +1,169 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bank-prototype/internal/notifications/notifytest"
+)
+
+func TestSMTPNotifier(t *testing.T) {
+	server := notifytest.NewSMTPServer(t)
+	notifier, err := NewSMTPNotifier(SMTPConfig{Addr: server.Addr(), From: "Банк <noreply@bank.test>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := Message{UserID: "u1", Name: "Алиса", Email: "alice@example.com", Subject: "Сброс пароля", Body: "Строка 1\nСтрока 2"}
+	if err := notifier.Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	mails := server.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("получено писем: %d, ожидалось 1", len(mails))
+	}
+	mail := mails[0]
+	if mail.From != "noreply@bank.test" || len(mail.To) != 1 || mail.To[0] != "alice@example.com" {
+		t.Errorf("конверт: from %q, to %v", mail.From, mail.To)
+	}
+	if !strings.Contains(mail.Data, "Subject: =?utf-8?q?") || !strings.Contains(mail.Data, "\r\n\r\nСтрока 1\r\nСтрока 2\r\n") {
+		t.Errorf("письмо:\n%s", mail.Data)
+	}
+
+	// Без адреса и с попыткой дописать заголовок письмо не отправляется
+	if err := notifier.Notify(context.Background(), Message{Name: "bob"}); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("без адреса: %v, ожидалось ErrNoAddress", err)
+	}
+	if err := notifier.Notify(context.Background(), Message{Email: "a@example.com\r\nBcc: x@example.com"}); !errors.Is(err, ErrRejected) {
+		t.Errorf("адрес с переводом строки: %v, ожидалось ErrRejected", err)
+	}
+	// Адресат, которого сервер не знает, — постоянная ошибка
+	server.Reject("gone@example.com")
+	if err := notifier.Notify(context.Background(), Message{Email: "gone@example.com"}); !errors.Is(err, ErrRejected) {
+		t.Errorf("отклонённый адресат: %v, ожидалось ErrRejected", err)
+	}
+	if got := len(server.Mails()); got != 1 {
+		t.Errorf("получено писем: %d, ожидалось 1", got)
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.jsonl")
+	notifier := NewFileNotifier(path)
+
+	for _, subject := range []string{"первое", "второе"} {
+		if err := notifier.Notify(context.Background(), Message{UserID: "u1", Subject: subject, Body: "текст"}); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("строк в файле: %d, ожидалось 2", len(lines))
+	}
+	var record fileRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil || record.Subject != "второе" || record.UserID != "u1" {
+		t.Errorf("запись: %+v, %v", record, err)
+	}
+}
+
+func TestSMSNotifier(t *testing.T) {
+	gateway := notifytest.NewSMSGateway(t)
+	notifier, err := NewSMSNotifier(SMSConfig{URL: gateway.URL(), Token: "secret", From: "Bank"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// В SMS уходит короткий текст, а без него — полный
+	if err := notifier.Notify(ctx, Message{Phone: "+79990000001", Body: "длинный текст", Short: "коротко"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if err := notifier.Notify(ctx, Message{Phone: "+79990000001", Body: "только текст"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	messages := gateway.Messages()
+	if len(messages) != 2 || messages[0].Text != "коротко" || messages[1].Text != "только текст" {
+		t.Fatalf("сообщения: %+v", messages)
+	}
+	if messages[0].To != "+79990000001" || messages[0].From != "Bank" || messages[0].Authorization != "Bearer secret" {
+		t.Errorf("запрос к шлюзу: %+v", messages[0])
+	}
+
+	if err := notifier.Notify(ctx, Message{Body: "без номера"}); !errors.Is(err, ErrNoAddress) {
+		t.Errorf("без номера: %v, ожидалось ErrNoAddress", err)
+	}
+
+	// 4xx — отказ без повтора, 5xx и 429 — временная ошибка
+	gateway.FailWith(http.StatusUnprocessableEntity)
+	if err := notifier.Notify(ctx, Message{Phone: "+79990000001", Body: "x"}); !errors.Is(err, ErrRejected) {
+		t.Errorf("ответ 422: %v, ожидалось ErrRejected", err)
+	}
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+		gateway.FailWith(status)
+		err := notifier.Notify(ctx, Message{Phone: "+79990000001", Body: "x"})
+		if err == nil || errors.Is(err, ErrRejected) {
+			t.Errorf("ответ %d: %v, ожидалась временная ошибка", status, err)
+		}
+	}
+
+	if _, err := NewSMSNotifier(SMSConfig{URL: "ftp://gateway"}); err == nil {
+		t.Error("принят адрес шлюза не по HTTP")
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := map[string]any{
+		"Name":          "alice",
+		"Amount":        "150.00",
+		"FromAccountID": "a1",
+		"ToAccountID":   "a2",
+		"Skipped":       true,
+		"Paused":        false,
+	}
+
+	rendered, err := Render("en", "scheduled_transfer_failed", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != "Scheduled transfer failed" || rendered.Short == "" ||
+		!strings.HasPrefix(rendered.Body, "Hello, alice!") || !strings.Contains(rendered.Body, "insufficient funds") ||
+		strings.Contains(rendered.Body, "paused") {
+		t.Errorf("шаблон:\n%+v", rendered)
+	}
+
+	// Для неизвестного языка — русский шаблон
+	rendered, err = Render("de", "scheduled_transfer_failed", data)
+	if err != nil || rendered.Subject != "Регулярный перевод не выполнен" {
+		t.Errorf("запасной язык: %+v, %v", rendered, err)
+	}
+
+	// У каждого шаблона есть версии на обоих языках
+	for key := range templates {
+		kind := key[strings.Index(key, "/")+1:]
+		for _, lang := range []string{"ru", "en"} {
+			if _, ok := templates[lang+"/"+kind]; !ok {
+				t.Errorf("нет шаблона %s/%s", lang, kind)
+			}
+		}
+	}
+
+	if _, err := Render("ru", "unknown", data); err == nil {
+		t.Error("неизвестный вид уведомления отрисован")
+	}
+	// Недостающие данные — ошибка, а не «<no value>» в тексте
+	if _, err := Render("ru", "low_balance", map[string]any{"Name": "alice"}); err == nil {
+		t.Error("шаблон без данных отрисован")
+	}
+}
@@ -0,0 +1,76 @@
+package notifytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// SMSGateway — HTTP-шлюз SMS: принимает POST с JSON {to, from, text} и
+// отвечает 202. Статус ответа можно изменить через FailWith.
+type SMSGateway struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	messages []SMS
+	status   int
+}
+
+// SMS — сообщение, принятое SMSGateway
+type SMS struct {
+	To            string `json:"to"`
+	From          string `json:"from"`
+	Text          string `json:"text"`
+	Authorization string `json:"-"`
+}
+
+// NewSMSGateway запускает шлюз; он останавливается по завершении теста
+func NewSMSGateway(t testing.TB) *SMSGateway {
+	t.Helper()
+
+	g := &SMSGateway{status: http.StatusAccepted}
+	g.server = httptest.NewServer(http.HandlerFunc(g.serve))
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+// URL — адрес, на который отправляются сообщения
+func (g *SMSGateway) URL() string {
+	return g.server.URL + "/messages"
+}
+
+// Messages возвращает принятые сообщения в порядке получения
+func (g *SMSGateway) Messages() []SMS {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]SMS(nil), g.messages...)
+}
+
+// FailWith заставляет шлюз отвечать status, не принимая сообщения
+func (g *SMSGateway) FailWith(status int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.status = status
+}
+
+func (g *SMSGateway) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/messages" {
+		http.NotFound(w, r)
+		return
+	}
+	var sms SMS
+	if err := json.NewDecoder(r.Body).Decode(&sms); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sms.Authorization = r.Header.Get("Authorization")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.status < 300 {
+		g.messages = append(g.messages, sms)
+	}
+	w.WriteHeader(g.status)
+}
@@ -0,0 +1,132 @@
+// Package notifytest — заглушки внешних сервисов доставки для тестов:
+// SMTP-сервер и HTTP-шлюз SMS, которые складывают сообщения в память.
+package notifytest
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// SMTPServer — минимальный SMTP-сервер: принимает письма без TLS и
+// аутентификации. Адресат из Reject получает ответ 550 на RCPT.
+type SMTPServer struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	mails  []Mail
+	reject map[string]bool
+}
+
+// Mail — письмо, принятое SMTPServer
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// NewSMTPServer запускает сервер на свободном порту localhost; он
+// останавливается по завершении теста
+func NewSMTPServer(t testing.TB) *SMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &SMTPServer{listener: listener, reject: make(map[string]bool)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// Addr — адрес сервера в виде host:port
+func (s *SMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Mails возвращает принятые письма в порядке получения
+func (s *SMTPServer) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+// Reject заставляет сервер отклонять письма на address
+func (s *SMTPServer) Reject(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject[strings.ToLower(address)] = true
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var current Mail
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 stand-in")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			current = Mail{From: strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			address := strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			s.mu.Lock()
+			rejected := s.reject[strings.ToLower(address)]
+			s.mu.Unlock()
+			if rejected {
+				reply("550 No such user")
+				continue
+			}
+			current.To = append(current.To, address)
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			current.Data = data.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, current)
+			s.mu.Unlock()
+			reply("250 OK")
+		case command == "RSET":
+			current = Mail{}
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// smsTimeout ограничивает один запрос к шлюзу
+const smsTimeout = 10 * time.Second
+
+// SMSConfig — параметры HTTP-шлюза SMS. Token передаётся заголовком
+// Authorization: Bearer, если задан.
+type SMSConfig struct {
+	URL   string
+	Token string
+	From  string // имя или номер отправителя
+}
+
+// SMSNotifier отправляет сообщения через HTTP-шлюз: POST на URL с телом
+// {"to": "+7...", "from": "...", "text": "..."}. Ответ 2xx — сообщение
+// принято, 4xx — отклонено (ErrRejected), остальное — временная ошибка.
+type SMSNotifier struct {
+	config SMSConfig
+	client *http.Client
+}
+
+func NewSMSNotifier(config SMSConfig) (*SMSNotifier, error) {
+	gateway, err := url.Parse(config.URL)
+	if err != nil || (gateway.Scheme != "http" && gateway.Scheme != "https") || gateway.Host == "" {
+		return nil, fmt.Errorf("неверный адрес SMS-шлюза %q", config.URL)
+	}
+	return &SMSNotifier{
+		config: config,
+		client: &http.Client{Timeout: smsTimeout},
+	}, nil
+}
+
+// smsRequest — тело запроса к шлюзу
+type smsRequest struct {
+	To   string `json:"to"`
+	From string `json:"from,omitempty"`
+	Text string `json:"text"`
+}
+
+func (n *SMSNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Phone == "" {
+		return ErrNoAddress
+	}
+	text := msg.Short
+	if text == "" {
+		text = msg.Body
+	}
+
+	payload, err := json.Marshal(smsRequest{To: msg.Phone, From: n.config.From, Text: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к SMS-шлюзу: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("%w: SMS-шлюз ответил %d", ErrRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("SMS-шлюз ответил %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,134 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpTimeout ограничивает отправку одного письма, если у ctx нет своего срока
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig — параметры почтового сервера. Без Username письма
+// отправляются без аутентификации (локальный релей).
+type SMTPConfig struct {
+	Addr     string // host:port
+	From     string
+	Username string
+	Password string
+}
+
+// SMTPNotifier отправляет сообщения письмами. STARTTLS используется, если
+// сервер его поддерживает; логин и пароль net/smtp передаёт только по TLS
+// или на localhost.
+type SMTPNotifier struct {
+	config SMTPConfig
+	host   string
+}
+
+func NewSMTPNotifier(config SMTPConfig) (*SMTPNotifier, error) {
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("неверный адрес SMTP-сервера %q: %w", config.Addr, err)
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("неверный адрес отправителя %q: %w", config.From, err)
+	}
+	return &SMTPNotifier{config: config, host: host}, nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	if msg.Email == "" {
+		return ErrNoAddress
+	}
+	// ParseAddress отсекает переводы строк, через которые можно дописать заголовки
+	to, err := mail.ParseAddress(msg.Email)
+	if err != nil {
+		return fmt.Errorf("%w: неверный адрес получателя: %v", ErrRejected, err)
+	}
+	from, _ := mail.ParseAddress(n.config.From)
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.config.Addr)
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к SMTP-серверу: %w", err)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("ошибка SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return fmt.Errorf("ошибка STARTTLS: %w", err)
+		}
+	}
+	if n.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, n.host)); err != nil {
+			return fmt.Errorf("ошибка аутентификации SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("ошибка SMTP MAIL: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		// 5xx — сервер не примет письмо этому адресату и при повторе
+		var reply *textproto.Error
+		if errors.As(err, &reply) && reply.Code >= 500 {
+			return fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+		return fmt.Errorf("ошибка SMTP RCPT: %w", err)
+	}
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("ошибка SMTP DATA: %w", err)
+	}
+	if _, err := data.Write(buildMail(from, to, msg)); err != nil {
+		data.Close()
+		return fmt.Errorf("ошибка отправки письма: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("сервер не принял письмо: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMail собирает текстовое письмо в UTF-8. Тема кодируется по
+// RFC 2047, тело передаётся как есть (8bit) с переводами строк CRLF.
+func buildMail(from, to *mail.Address, msg Message) []byte {
+	if msg.Name != "" && to.Name == "" {
+		to = &mail.Address{Name: msg.Name, Address: to.Address}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
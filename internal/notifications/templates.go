@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// defaultLanguage — язык шаблонов, если для запрошенного шаблона нет
+const defaultLanguage = "ru"
+
+// Шаблоны лежат в templates/<язык>/<вид>.tmpl. Каждый файл определяет
+// блоки subject и body и, если сообщение уходит и в SMS, короткий short.
+//
+//go:embed templates
+var templateFiles embed.FS
+
+// templates — разобранные шаблоны по ключу "<язык>/<вид>"
+var templates = mustParseTemplates()
+
+// Rendered — текст сообщения по шаблону
+type Rendered struct {
+	Subject string
+	Body    string
+	Short   string
+}
+
+// Render заполняет шаблон вида kind на языке lang данными data. Если
+// шаблона на этом языке нет, используется русский.
+func Render(lang, kind string, data any) (Rendered, error) {
+	tmpl, ok := templates[lang+"/"+kind]
+	if !ok {
+		if tmpl, ok = templates[defaultLanguage+"/"+kind]; !ok {
+			return Rendered{}, fmt.Errorf("нет шаблона уведомления %q", kind)
+		}
+	}
+
+	var rendered Rendered
+	for _, block := range []struct {
+		name string
+		dest *string
+	}{
+		{"subject", &rendered.Subject},
+		{"body", &rendered.Body},
+		{"short", &rendered.Short},
+	} {
+		if tmpl.Lookup(block.name) == nil {
+			continue
+		}
+		var buf strings.Builder
+		if err := tmpl.ExecuteTemplate(&buf, block.name, data); err != nil {
+			return Rendered{}, fmt.Errorf("ошибка шаблона %s/%s: %w", lang, kind, err)
+		}
+		*block.dest = strings.TrimSpace(buf.String())
+	}
+	return rendered, nil
+}
+
+func mustParseTemplates() map[string]*template.Template {
+	parsed := make(map[string]*template.Template)
+	err := fs.WalkDir(templateFiles, "templates", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(name) != ".tmpl" {
+			return err
+		}
+		tmpl, err := template.New(path.Base(name)).Option("missingkey=error").ParseFS(templateFiles, name)
+		if err != nil {
+			return err
+		}
+		lang := path.Base(path.Dir(name))
+		parsed[lang+"/"+strings.TrimSuffix(path.Base(name), ".tmpl")] = tmpl
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("шаблоны уведомлений: %v", err))
+	}
+	return parsed
+}
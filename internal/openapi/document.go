@@ -0,0 +1,104 @@
+// Package openapi описывает HTTP API в формате OpenAPI 3.1 и маршрутизирует
+// запросы по этому описанию. Маршрут объявляется один раз: из него строятся
+// и обработчик, и операция в документе /openapi.json, а схемы тел запросов
+// и ответов генерируются из типов models. Поэтому документ не расходится
+// с кодом, а запросы проверяются по той же схеме, что видят клиенты.
+package openapi
+
+// Version — версия спецификации OpenAPI
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem — операции одного пути по HTTP-методам в нижнем регистре
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path или query
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// SecurityRequirement — схемы авторизации операции и требуемые области
+type SecurityRequirement map[string][]string
+
+// bearerAuth — JWT из POST /login в заголовке Authorization: Bearer <token>
+const bearerAuth = "bearerAuth"
+
+const contentTypeJSON = "application/json"
+
+// contentTypeEventStream — тип ответов EventStream (Server-Sent Events)
+const contentTypeEventStream = "text/event-stream"
+
+// statusDescriptions — описания ответов по умолчанию
+var statusDescriptions = map[int]string{
+	200: "Успешно",
+	201: "Создано",
+	202: "Принято в обработку",
+	400: "Некорректный запрос",
+	401: "Требуется авторизация",
+	403: "Доступ запрещён",
+	404: "Не найдено",
+	409: "Конфликт с текущим состоянием",
+	410: "Ресурс закрыт",
+	415: "Неподдерживаемый тип содержимого",
+	429: "Превышен лимит запросов",
+	500: "Внутренняя ошибка сервера",
+	503: "Сервис не готов обслуживать запросы",
+}
@@ -0,0 +1,316 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/apierror"
+)
+
+type testItem struct {
+	ToAccountID string  `json:"to_account_id" validate:"required,min=1"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+}
+
+type testRequest struct {
+	Mode  string     `json:"mode" validate:"enum=fast|slow"`
+	Limit *int       `json:"limit" validate:"min=1"`
+	Items []testItem `json:"items" validate:"required,min=1,max=2"`
+}
+
+func TestSchemaFromTags(t *testing.T) {
+	g := newGenerator()
+	ref := g.schemaOf(testRequest{})
+	if ref.Ref != schemaRefPrefix+"testRequest" {
+		t.Fatalf("ссылка на схему: %q", ref.Ref)
+	}
+
+	request := g.schemas["testRequest"]
+	if strings.Join(request.Required, ",") != "items" {
+		t.Errorf("required = %v, ожидалось [items]", request.Required)
+	}
+	if limit := request.Properties["limit"]; !limit.Type.has("null") || limit.Minimum == nil || *limit.Minimum != 1 {
+		t.Errorf("limit: %+v, ожидалось integer|null с minimum 1", limit)
+	}
+	if items := request.Properties["items"]; *items.MinItems != 1 || *items.MaxItems != 2 {
+		t.Errorf("items: minItems/maxItems = %d/%d", *items.MinItems, *items.MaxItems)
+	}
+	if mode := request.Properties["mode"]; len(mode.Enum) != 2 {
+		t.Errorf("mode.enum = %v", mode.Enum)
+	}
+
+	item := g.schemas["testItem"]
+	if amount := item.Properties["amount"]; amount.ExclusiveMinimum == nil || *amount.ExclusiveMinimum != 0 {
+		t.Errorf("amount: exclusiveMinimum не задан")
+	}
+	if id := item.Properties["to_account_id"]; *id.MinLength != 1 {
+		t.Errorf("to_account_id: minLength = %d", *id.MinLength)
+	}
+}
+
+func TestSchemaBadTagPanics(t *testing.T) {
+	type bad struct {
+		Amount float64 `json:"amount" validate:"gt=zero"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("некорректный тег validate не привёл к панике")
+		}
+	}()
+	newGenerator().schemaOf(bad{})
+}
+
+func TestValidateBody(t *testing.T) {
+	g := newGenerator()
+	v := &validator{schemas: g.schemas}
+	requestBody := &RequestBody{
+		Required: true,
+		Content: map[string]MediaType{
+			contentTypeJSON: {Schema: g.schemaOf(testRequest{})},
+			"text/csv":      {},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		fields      []string
+	}{
+		{"корректный", "application/json; charset=utf-8", `{"items":[{"to_account_id":"1","amount":10}]}`, 0, nil},
+		{"без Content-Type", "", `{"items":[{"to_account_id":"1","amount":1}],"limit":null}`, 0, nil},
+		{"CSV разбирает обработчик", "text/csv", "a,b", 0, nil},
+		{"пустое тело", "", "", 400, []string{""}},
+		{"некорректный JSON", "", `{"items":`, 400, []string{""}},
+		{"лишние данные", "", `{"items":[{"to_account_id":"1","amount":1}]} {}`, 400, []string{""}},
+		{"нет обязательного поля", "", `{}`, 400, []string{"items"}},
+		{"неверный тип", "", `{"items":"x"}`, 400, []string{"items"}},
+		{"вложенные нарушения", "", `{"items":[{"to_account_id":"","amount":0}]}`, 400, []string{"items[0].amount", "items[0].to_account_id"}},
+		{"enum и минимум", "", `{"mode":"turbo","limit":0,"items":[{"to_account_id":"1","amount":1}]}`, 400, []string{"limit", "mode"}},
+		{"слишком много элементов", "", `{"items":[{},{},{}]}`, 400, []string{"items"}},
+		{"неподдерживаемый тип", "application/xml", `<a/>`, 415, []string{"Content-Type"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, errs := v.body(requestBody, tt.contentType, []byte(tt.body))
+			if status != tt.status {
+				t.Fatalf("код %d, ожидался %d (%+v)", status, tt.status, errs)
+			}
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("поля %q, ожидались %q", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	var calls []string
+	router := NewRouter(Info{Title: "test", Version: "1"}, func(route *Route, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			calls = append(calls, route.OperationID)
+			next(ctx)
+		}
+	})
+
+	router.Handle(Route{
+		Method: "GET", Path: "/items/{id}/limits", OperationID: "limits",
+		Handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("limits " + ctx.UserValue("id").(string)) },
+	})
+	router.Handle(Route{
+		Method: "GET", Path: "/items/{id}", OperationID: "item",
+		Query:   []Param{{Name: "page", Schema: &Schema{Type: Types{"integer"}}}},
+		Handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("item " + ctx.UserValue("id").(string)) },
+	})
+	router.Handle(Route{
+		Method: "GET", Path: "/orders/{id}", OperationID: "order",
+		PathParams: []Param{{Name: "id", Schema: &Schema{Type: Types{"string"}, Format: "uuid"}}},
+		Query:      []Param{{Name: "status"}},
+		Handler:    func(ctx *fasthttp.RequestCtx) {},
+	})
+	router.Handle(Route{
+		Method: "POST", Path: "/items", OperationID: "create", Body: testItem{},
+		Responses: map[int]any{fasthttp.StatusCreated: testItem{}},
+		Handler:   func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusCreated) },
+	})
+
+	serve := func(method, uri, body string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.SetBodyString(body)
+		router.Handler(ctx)
+		return ctx
+	}
+
+	if ctx := serve("GET", "/items/42/limits", ""); string(ctx.Response.Body()) != "limits 42" {
+		t.Errorf("GET /items/42/limits: %q", ctx.Response.Body())
+	}
+	if ctx := serve("GET", "/items/7?page=2", ""); string(ctx.Response.Body()) != "item 7" {
+		t.Errorf("GET /items/7: %q", ctx.Response.Body())
+	}
+	if ctx := serve("GET", "/items/7?page=x", ""); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("нечисловой page: код %d", ctx.Response.StatusCode())
+	}
+	if ctx := serve("POST", "/items", `{"to_account_id":"1","amount":5}`); ctx.Response.StatusCode() != fasthttp.StatusCreated {
+		t.Errorf("POST /items: код %d", ctx.Response.StatusCode())
+	}
+
+	ctx := serve("POST", "/items", `{"amount":-1}`)
+	var problem apierror.Problem
+	if err := json.Unmarshal(ctx.Response.Body(), &problem); err != nil || ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Fatalf("POST /items с нарушениями: код %d, %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if string(ctx.Response.Header.ContentType()) != apierror.ContentType || problem.Code != apierror.CodeValidationFailed {
+		t.Errorf("ответ %s с кодом %q", ctx.Response.Header.ContentType(), problem.Code)
+	}
+	if len(problem.Errors) != 2 {
+		t.Errorf("errors: %+v, ожидалось 2 нарушения", problem.Errors)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	ctx.Request.SetRequestURI("/items")
+	ctx.Request.SetBodyString(`{"to_account_id":"1"}`)
+	router.Handler(ctx)
+	problem = apierror.Problem{}
+	_ = json.Unmarshal(ctx.Response.Body(), &problem)
+	if problem.Title != "Request does not match the schema" || len(problem.Errors) != 1 || problem.Errors[0].Message != "required field" {
+		t.Errorf("ответ на английском: %s", ctx.Response.Body())
+	}
+
+	if ctx := serve("GET", "/orders/not-a-uuid?status=new", ""); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("идентификатор не UUID: код %d", ctx.Response.StatusCode())
+	}
+	if ctx := serve("GET", "/orders/5f0c4b1e-8a2d-4c1e-9f3a-2b7d6e8c9a10?status=new", ""); ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("GET /orders/{uuid}: код %d, %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	for _, uri := range []string{"/items", "/items/", "/items/1/2/3"} {
+		ctx := serve("GET", uri, "")
+		var problem apierror.Problem
+		_ = json.Unmarshal(ctx.Response.Body(), &problem)
+		if ctx.Response.StatusCode() != fasthttp.StatusNotFound || problem.Code != apierror.CodeRouteNotFound {
+			t.Errorf("GET %s: код %d (%s), ожидался 404 route_not_found", uri, ctx.Response.StatusCode(), problem.Code)
+		}
+	}
+
+	// Промежуточный обработчик вызывается и для запросов, не прошедших проверку
+	if strings.Join(calls, ",") != "limits,item,item,create,create,create,order,order" {
+		t.Errorf("вызовы middleware: %v", calls)
+	}
+
+	operation := (*router.Document().Paths["/items"])["post"]
+	for _, status := range []string{"201", "400", "415", "500"} {
+		if _, ok := operation.Responses[status]; !ok {
+			t.Errorf("POST /items: нет ответа %s", status)
+		}
+	}
+}
+
+func TestRouterDuplicateRoutePanics(t *testing.T) {
+	router := NewRouter(Info{}, nil)
+	route := Route{Method: "GET", Path: "/a", OperationID: "a", Handler: func(*fasthttp.RequestCtx) {}}
+	router.Handle(route)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("повторная регистрация маршрута не привела к панике")
+		}
+	}()
+	router.Handle(route)
+}
+
+func TestGroupLegacyAliases(t *testing.T) {
+	router := NewRouter(Info{}, nil)
+	deprecation := Deprecation{
+		Since:  time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+	}
+	v1 := router.Group("/v1").WithLegacyAliases(deprecation)
+	v1.Handle(Route{
+		Method: "GET", Path: "/items/{id}", OperationID: "getItem",
+		Handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString(ctx.UserValue("id").(string)) },
+	})
+	router.Group("v2").Handle(Route{
+		Method: "GET", Path: "/items/{id}", OperationID: "getItem",
+		Handler: func(ctx *fasthttp.RequestCtx) { ctx.SetBodyString("v2 " + ctx.UserValue("id").(string)) },
+	})
+
+	serve := func(uri string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod("GET")
+		ctx.Request.SetRequestURI(uri)
+		router.Handler(ctx)
+		return ctx
+	}
+
+	ctx := serve("/v1/items/7")
+	if string(ctx.Response.Body()) != "7" || ctx.Response.Header.Peek("Deprecation") != nil {
+		t.Errorf("GET /v1/items/7: %q, Deprecation %q", ctx.Response.Body(), ctx.Response.Header.Peek("Deprecation"))
+	}
+	if ctx := serve("/v2/items/7"); string(ctx.Response.Body()) != "v2 7" {
+		t.Errorf("GET /v2/items/7: %q", ctx.Response.Body())
+	}
+
+	ctx = serve("/items/7")
+	if string(ctx.Response.Body()) != "7" {
+		t.Fatalf("GET /items/7: %q", ctx.Response.Body())
+	}
+	headers := map[string]string{
+		"Deprecation": "@1792368000",
+		"Sunset":      "Fri, 30 Apr 2027 00:00:00 GMT",
+		"Link":        `</v1/items/7>; rel="successor-version"`,
+	}
+	for name, want := range headers {
+		if got := string(ctx.Response.Header.Peek(name)); got != want {
+			t.Errorf("%s: %q, ожидалось %q", name, got, want)
+		}
+	}
+
+	paths := router.Document().Paths
+	if operation := (*paths["/items/{id}"])["get"]; operation.OperationID != "getItem" || !operation.Deprecated {
+		t.Errorf("синоним: operationId %q, deprecated %v", operation.OperationID, operation.Deprecated)
+	}
+	if operation := (*paths["/v1/items/{id}"])["get"]; operation.OperationID != "v1GetItem" || operation.Deprecated {
+		t.Errorf("/v1: operationId %q, deprecated %v", operation.OperationID, operation.Deprecated)
+	}
+	if operation := (*paths["/v2/items/{id}"])["get"]; operation.OperationID != "v2GetItem" {
+		t.Errorf("/v2: operationId %q", operation.OperationID)
+	}
+}
+
+func TestValidateDecimal(t *testing.T) {
+	type payment struct {
+		Amount string `json:"amount" validate:"required,format=decimal,gt=0"`
+	}
+	g := newGenerator()
+	v := &validator{schemas: g.schemas}
+	requestBody := &RequestBody{Content: map[string]MediaType{contentTypeJSON: {Schema: g.schemaOf(payment{})}}}
+
+	for body, valid := range map[string]bool{
+		`{"amount":"10"}`:     true,
+		`{"amount":"10.5"}`:   true,
+		`{"amount":"0.01"}`:   true,
+		`{"amount":"0.00"}`:   false,
+		`{"amount":"-1.00"}`:  false,
+		`{"amount":"10.505"}`: false,
+		`{"amount":"1e3"}`:    false,
+		`{"amount":10.5}`:     false,
+	} {
+		status, errs := v.body(requestBody, "", []byte(body))
+		if (status == 0) != valid {
+			t.Errorf("%s: код %d (%+v), ожидалось valid=%v", body, status, errs, valid)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+)
+
+// Auth — требования операции к аутентификации
+type Auth int
+
+const (
+	AuthNone  Auth = iota // публичная операция
+	AuthUser              // нужен JWT
+	AuthAdmin             // нужен JWT с ролью admin
+)
+
+// Param — параметр пути или query-параметр операции
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+// Route — маршрут API вместе с описанием операции
+type Route struct {
+	Method      string
+	Path        string // шаблон OpenAPI, параметры пути — {id}
+	OperationID string
+	Summary     string
+	Tag         string
+	Auth        Auth
+	RateGroup   string // группа rate limiter, пустая — без ограничения
+
+	// Deprecation — маршрут устарел: ответы несут заголовки Deprecation и
+	// Sunset. Обычно задаётся через Group.WithLegacyAliases.
+	Deprecation *Deprecation
+
+	PathParams   []Param // описания параметров пути, по умолчанию — строка
+	Query        []Param
+	Body         any      // модель тела JSON, nil — операция без тела
+	BodyOptional bool     // тело можно не передавать
+	BodyTypes    []string // другие типы содержимого, тело которых разбирает обработчик
+
+	// Responses — модели ответов по кодам. nil вместо модели — ошибка
+	// apierror.Problem, EventStream — поток событий. Ответы 400, 401, 403,
+	// 415, 429 и 500 добавляются автоматически по Auth, RateGroup и наличию
+	// параметров.
+	Responses map[int]any
+
+	Handler fasthttp.RequestHandler
+}
+
+// EventStream — модель ответа text/event-stream (Server-Sent Events):
+// Event описывает JSON в поле data одного события
+type EventStream struct {
+	Event any
+}
+
+// Middleware оборачивает обработчик маршрута: аутентификация, rate limiter.
+// Проверка запроса по схеме выполняется внутри, после аутентификации, чтобы
+// анонимный клиент получал 401, а не подробности схемы.
+type Middleware func(route *Route, next fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Router выбирает маршрут по методу и шаблону пути в порядке регистрации
+// и строит документ OpenAPI по зарегистрированным маршрутам
+type Router struct {
+	doc        *Document
+	generator  *generator
+	validator  *validator
+	middleware Middleware
+	routes     []*compiledRoute
+
+	specOnce sync.Once
+	spec     []byte
+}
+
+type compiledRoute struct {
+	method   string
+	path     string   // шаблон пути: /v1/accounts/{id}
+	segments []string // "{id}" — параметр пути
+	handler  fasthttp.RequestHandler
+}
+
+func NewRouter(info Info, middleware Middleware) *Router {
+	generator := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: generator.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	if middleware == nil {
+		middleware = func(_ *Route, next fasthttp.RequestHandler) fasthttp.RequestHandler { return next }
+	}
+
+	return &Router{
+		doc:        doc,
+		generator:  generator,
+		validator:  &validator{schemas: generator.schemas},
+		middleware: middleware,
+	}
+}
+
+// Handle регистрирует маршрут. Повторная регистрация метода и пути,
+// как и некорректный тег validate в модели, — ошибка программиста и
+// приводит к панике при запуске.
+func (r *Router) Handle(route Route) {
+	operation := r.operation(&route)
+
+	item, ok := r.doc.Paths[route.Path]
+	if !ok {
+		item = &PathItem{}
+		r.doc.Paths[route.Path] = item
+	}
+	method := strings.ToLower(route.Method)
+	if _, exists := (*item)[method]; exists {
+		panic(fmt.Sprintf("openapi: маршрут %s %s зарегистрирован дважды", route.Method, route.Path))
+	}
+	(*item)[method] = operation
+
+	handler := r.middleware(&route, r.validate(operation, route.Handler))
+	if route.Deprecation != nil {
+		handler = deprecated(route.Deprecation, handler)
+	}
+
+	r.routes = append(r.routes, &compiledRoute{
+		method:   route.Method,
+		path:     route.Path,
+		segments: strings.Split(strings.Trim(route.Path, "/"), "/"),
+		handler:  handler,
+	})
+}
+
+// Document возвращает документ OpenAPI по зарегистрированным маршрутам
+func (r *Router) Document() *Document {
+	return r.doc
+}
+
+// Handler — обработчик всех запросов: находит маршрут, заполняет параметры
+// пути (ctx.UserValue) и вызывает цепочку маршрута
+func (r *Router) Handler(ctx *fasthttp.RequestCtx) {
+	method := string(ctx.Method())
+	path := string(ctx.Path())
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for _, route := range r.routes {
+		if route.method != method || !route.match(ctx, segments) {
+			continue
+		}
+		tracing.SetRoute(ctx, route.path)
+		route.handler(ctx)
+		return
+	}
+
+	utils.LogWarning("Router", "Неизвестный маршрут: %s %s", method, path)
+	apierror.Write(ctx, fasthttp.StatusNotFound, apierror.CodeRouteNotFound, method+" "+path)
+}
+
+// ServeSpec обрабатывает GET /openapi.json
+func (r *Router) ServeSpec(ctx *fasthttp.RequestCtx) {
+	r.specOnce.Do(func() {
+		spec, err := json.MarshalIndent(r.doc, "", "  ")
+		if err != nil {
+			panic(fmt.Sprintf("openapi: сериализация документа: %v", err))
+		}
+		r.spec = spec
+	})
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType(contentTypeJSON)
+	ctx.Write(r.spec)
+}
+
+func (c *compiledRoute) match(ctx *fasthttp.RequestCtx, segments []string) bool {
+	if len(segments) != len(c.segments) {
+		return false
+	}
+
+	for i, segment := range c.segments {
+		if isParam(segment) {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != segments[i] {
+			return false
+		}
+	}
+
+	for i, segment := range c.segments {
+		if isParam(segment) {
+			ctx.SetUserValue(strings.Trim(segment, "{}"), segments[i])
+		}
+	}
+	return true
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// validate проверяет параметры и тело запроса по операции и отвечает
+// 400 (415) со списком нарушений, не вызывая обработчик
+func (r *Router) validate(operation *Operation, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		v := r.validator.in(i18n.FromRequest(ctx))
+
+		var errs []apierror.FieldError
+		for _, param := range operation.Parameters {
+			switch param.In {
+			case "path":
+				raw, _ := ctx.UserValue(param.Name).(string)
+				v.param(param, raw, true, &errs)
+			case "query":
+				raw := ctx.QueryArgs().Peek(param.Name)
+				v.param(param, string(raw), ctx.QueryArgs().Has(param.Name), &errs)
+			}
+		}
+
+		status := fasthttp.StatusBadRequest
+		if len(errs) == 0 && operation.RequestBody != nil {
+			status, errs = v.body(operation.RequestBody, string(ctx.Request.Header.ContentType()), ctx.PostBody())
+		}
+
+		if len(errs) == 0 {
+			next(ctx)
+			return
+		}
+
+		utils.LogWarning("Validation", "Запрос %s %s не соответствует схеме: %d нарушений", ctx.Method(), ctx.Path(), len(errs))
+		code := apierror.CodeValidationFailed
+		if status == fasthttp.StatusUnsupportedMediaType {
+			code = apierror.CodeUnsupportedMediaType
+		}
+		apierror.New(status, code, "").WithErrors(errs).Write(ctx)
+	}
+}
+
+// operation строит описание операции по маршруту
+func (r *Router) operation(route *Route) *Operation {
+	operation := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Responses:   make(map[string]*Response),
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+	if route.Auth != AuthNone {
+		operation.Security = []SecurityRequirement{{bearerAuth: {}}}
+	}
+	if route.Deprecation != nil {
+		operation.Deprecated = true
+		operation.Description = deprecationNote(route.Deprecation)
+	}
+
+	for _, segment := range strings.Split(strings.Trim(route.Path, "/"), "/") {
+		if !isParam(segment) {
+			continue
+		}
+		parameter := Parameter{
+			Name:     strings.Trim(segment, "{}"),
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: Types{"string"}},
+		}
+		for _, param := range route.PathParams {
+			if param.Name == parameter.Name {
+				parameter.Description = param.Description
+				if param.Schema != nil {
+					parameter.Schema = param.Schema
+				}
+			}
+		}
+		operation.Parameters = append(operation.Parameters, parameter)
+	}
+	for _, param := range route.Query {
+		schema := param.Schema
+		if schema == nil {
+			schema = &Schema{Type: Types{"string"}}
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:        param.Name,
+			In:          "query",
+			Description: param.Description,
+			Required:    param.Required,
+			Schema:      schema,
+		})
+	}
+
+	if route.Body != nil || len(route.BodyTypes) > 0 {
+		operation.RequestBody = &RequestBody{
+			Required: !route.BodyOptional,
+			Content:  make(map[string]MediaType),
+		}
+		if route.Body != nil {
+			operation.RequestBody.Content[contentTypeJSON] = MediaType{Schema: r.generator.schemaOf(route.Body)}
+		}
+		for _, contentType := range route.BodyTypes {
+			operation.RequestBody.Content[contentType] = MediaType{}
+		}
+	}
+
+	responses := make(map[int]any, len(route.Responses))
+	for status, model := range route.Responses {
+		responses[status] = model
+	}
+	if len(operation.Parameters) > 0 || operation.RequestBody != nil {
+		responses[fasthttp.StatusBadRequest] = nil
+	}
+	if operation.RequestBody != nil {
+		responses[fasthttp.StatusUnsupportedMediaType] = nil
+	}
+	if route.Auth != AuthNone {
+		responses[fasthttp.StatusUnauthorized] = nil
+	}
+	if route.Auth == AuthAdmin {
+		responses[fasthttp.StatusForbidden] = nil
+	}
+	if route.RateGroup != "" {
+		responses[fasthttp.StatusTooManyRequests] = nil
+	}
+	responses[fasthttp.StatusInternalServerError] = nil
+
+	statuses := make([]int, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	for _, status := range statuses {
+		contentType, model := contentTypeJSON, responses[status]
+		switch typed := model.(type) {
+		case nil:
+			contentType, model = apierror.ContentType, apierror.Problem{}
+		case EventStream:
+			contentType, model = contentTypeEventStream, typed.Event
+		}
+
+		operation.Responses[strconv.Itoa(status)] = &Response{
+			Description: statusDescriptions[status],
+			Content:     map[string]MediaType{contentType: {Schema: r.generator.schemaOf(model)}},
+		}
+	}
+
+	return operation
+}
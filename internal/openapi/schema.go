@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types — значение ключевого слова type. В OpenAPI 3.1 поле, допускающее
+// null, описывается списком: ["number", "null"].
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t Types) has(name string) bool {
+	for _, typ := range t {
+		if typ == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema — подмножество JSON Schema 2020-12, которого достаточно для
+// моделей API и проверки запросов
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// String — схема строкового параметра с допустимыми значениями enum
+func String(enum ...string) *Schema {
+	schema := &Schema{Type: Types{"string"}}
+	for _, value := range enum {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator строит схемы по типам Go. Именованные структуры выносятся
+// в components.schemas и подставляются ссылкой $ref.
+//
+// Ограничения задаются тегом validate у полей модели:
+//
+//	Amount float64 `json:"amount" validate:"required,gt=0"`
+//
+// required — поле обязательно; gt — строго больше (exclusiveMinimum);
+// min и max — minimum/maximum для чисел, minLength/maxLength для строк,
+// minItems/maxItems для массивов; enum=a|b — допустимые значения;
+// format — формат строки (uuid, date-time, decimal — сумма строкой,
+// её gt проверяется как у числа).
+type generator struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		types:   make(map[string]reflect.Type),
+	}
+}
+
+// schemaOf возвращает схему для значения v (обычно нулевого значения модели)
+func (g *generator) schemaOf(v any) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}
+
+	case t.Kind() == reflect.Pointer:
+		schema := g.schema(t.Elem())
+		// Ссылки на структуры оставляем как есть, а указатель на скаляр
+		// означает, что вместо значения может прийти null
+		if schema.Ref == "" && len(schema.Type) > 0 && !schema.Type.has("null") {
+			schema.Type = append(schema.Type, "null")
+		}
+		return schema
+
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &Schema{Ref: schemaRefPrefix + g.component(t)}
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: Types{"string"}, Format: "byte"}
+
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &Schema{Type: Types{"array"}, Items: g.schema(t.Elem())}
+
+	case t.Kind() == reflect.Map:
+		return &Schema{Type: Types{"object"}, AdditionalProperties: g.schema(t.Elem())}
+
+	case t.Kind() == reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}
+
+	case t.Kind() == reflect.String:
+		return &Schema{Type: Types{"string"}}
+
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema := &Schema{Type: Types{"integer"}}
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			schema.Format = "int64"
+		}
+		return schema
+
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &Schema{Type: Types{"number"}, Format: "double"}
+
+	default:
+		// interface{} и прочее — любое значение
+		return &Schema{}
+	}
+}
+
+// component регистрирует именованную структуру в components.schemas
+func (g *generator) component(t reflect.Type) string {
+	name := t.Name()
+	if existing, ok := g.types[name]; ok && existing != t {
+		// Одноимённые типы из разных пакетов
+		name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + name
+	}
+	if _, ok := g.schemas[name]; ok {
+		return name
+	}
+
+	// Заглушка до построения схемы: рекурсивные типы ссылаются на себя
+	g.types[name] = t
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.object(t)
+	return name
+}
+
+func (g *generator) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: Types{"object"}, Properties: make(map[string]*Schema)}
+	g.fields(t, schema)
+	return schema
+}
+
+// fields добавляет поля структуры в schema по правилам encoding/json:
+// встроенные структуры без тега json раскрываются на уровень родителя
+func (g *generator) fields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, schema)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schema(field.Type)
+		required, err := applyConstraints(property, field.Tag.Get("validate"))
+		if err != nil {
+			panic(fmt.Sprintf("openapi: %s.%s: %v", t.Name(), field.Name, err))
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyConstraints переносит ограничения из тега validate в схему поля.
+// Некорректный тег — ошибка программиста, поэтому generator паникует
+// при регистрации маршрута, а не при обработке запроса.
+func applyConstraints(schema *Schema, tag string) (required bool, err error) {
+	if tag == "" {
+		return false, nil
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case "required":
+			required = true
+
+		case "enum":
+			for _, option := range strings.Split(value, "|") {
+				schema.Enum = append(schema.Enum, option)
+			}
+
+		case "format":
+			schema.Format = value
+			if value == "decimal" {
+				schema.Pattern = decimalPattern.String()
+			}
+
+		case "gt", "min", "max":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("некорректное значение %s=%q", key, value)
+			}
+			count := int(number)
+
+			switch {
+			case key == "gt":
+				schema.ExclusiveMinimum = &number
+			case schema.Type.has("string"):
+				if key == "min" {
+					schema.MinLength = &count
+				} else {
+					schema.MaxLength = &count
+				}
+			case schema.Type.has("array"):
+				if key == "min" {
+					schema.MinItems = &count
+				} else {
+					schema.MaxItems = &count
+				}
+			case key == "min":
+				schema.Minimum = &number
+			default:
+				schema.Maximum = &number
+			}
+
+		default:
+			return false, fmt.Errorf("неизвестное правило %q", key)
+		}
+	}
+
+	// enum ограничивает и null, поэтому для необязательного поля его нужно перечислить явно
+	if len(schema.Enum) > 0 && schema.Type.has("null") {
+		schema.Enum = append(schema.Enum, nil)
+	}
+
+	return required, nil
+}
@@ -0,0 +1,317 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"bank-prototype/internal/apierror"
+	"bank-prototype/internal/i18n"
+)
+
+// decimalPattern — формат decimal: денежная сумма строкой, не больше двух
+// знаков после точки и не больше 13 до неё (DECIMAL(15,2) в базе)
+var decimalPattern = regexp.MustCompile(`^-?[0-9]{1,13}(\.[0-9]{1,2})?$`)
+
+// validator проверяет значения по схемам документа. Сообщения о
+// нарушениях — на языке lang.
+type validator struct {
+	schemas map[string]*Schema
+	lang    i18n.Lang
+}
+
+// in возвращает валидатор с сообщениями на языке lang
+func (v *validator) in(lang i18n.Lang) *validator {
+	return &validator{schemas: v.schemas, lang: lang}
+}
+
+func (v *validator) message(key string, args ...any) string {
+	return i18n.T(v.lang, key, args...)
+}
+
+// body разбирает и проверяет тело запроса с типом содержимого contentType.
+// Возвращает код ответа: 415 для неподдерживаемого типа, 400 для
+// нарушений схемы, 0 — запрос корректен.
+func (v *validator) body(requestBody *RequestBody, contentType string, body []byte) (int, []apierror.FieldError) {
+	mediaType := contentTypeJSON
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return 415, []apierror.FieldError{{In: "header", Field: "Content-Type", Message: v.message("validation.invalid_content_type")}}
+		}
+		mediaType = parsed
+	}
+
+	media, ok := requestBody.Content[mediaType]
+	if !ok {
+		supported := make([]string, 0, len(requestBody.Content))
+		for name := range requestBody.Content {
+			supported = append(supported, name)
+		}
+		sort.Strings(supported)
+		return 415, []apierror.FieldError{{
+			In:      "header",
+			Field:   "Content-Type",
+			Message: v.message("validation.unsupported_content_type", mediaType, strings.Join(supported, ", ")),
+		}}
+	}
+
+	// Тела в CSV и multipart разбирают сами обработчики
+	if mediaType != contentTypeJSON || media.Schema == nil {
+		return 0, nil
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if requestBody.Required {
+			return 400, []apierror.FieldError{{In: "body", Message: v.message("validation.body_required")}}
+		}
+		return 0, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return 400, []apierror.FieldError{{In: "body", Message: v.message("validation.invalid_json", err)}}
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return 400, []apierror.FieldError{{In: "body", Message: v.message("validation.trailing_data")}}
+	}
+
+	var errs []apierror.FieldError
+	v.value(media.Schema, value, "body", "", &errs)
+	if len(errs) > 0 {
+		return 400, errs
+	}
+	return 0, nil
+}
+
+// param проверяет query-параметр. present — параметр передан в запросе.
+func (v *validator) param(param Parameter, raw string, present bool, errs *[]apierror.FieldError) {
+	if !present {
+		if param.Required {
+			*errs = append(*errs, apierror.FieldError{In: param.In, Field: param.Name, Message: v.message("validation.required_param")})
+		}
+		return
+	}
+
+	schema := v.resolve(param.Schema)
+	var value any = raw
+	switch {
+	case schema.Type.has("integer"), schema.Type.has("number"):
+		value = json.Number(raw)
+	case schema.Type.has("boolean"):
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			*errs = append(*errs, apierror.FieldError{In: param.In, Field: param.Name, Message: v.message("validation.expected", v.message("type.boolean"))})
+			return
+		}
+		value = parsed
+	}
+
+	v.value(schema, value, param.In, param.Name, errs)
+}
+
+func (v *validator) resolve(schema *Schema) *Schema {
+	for schema.Ref != "" {
+		resolved, ok := v.schemas[strings.TrimPrefix(schema.Ref, schemaRefPrefix)]
+		if !ok {
+			return &Schema{}
+		}
+		schema = resolved
+	}
+	return schema
+}
+
+// value проверяет значение, полученное json.Decoder с UseNumber
+func (v *validator) value(schema *Schema, value any, in, path string, errs *[]apierror.FieldError) {
+	schema = v.resolve(schema)
+	fail := func(key string, args ...any) {
+		*errs = append(*errs, apierror.FieldError{In: in, Field: path, Message: v.message(key, args...)})
+	}
+
+	if value == nil {
+		if len(schema.Type) > 0 && !schema.Type.has("null") {
+			fail("validation.not_null")
+		}
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		fail("validation.enum", enumList(schema.Enum))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		if !v.expect(schema, "object", fail) {
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, apierror.FieldError{In: in, Field: joinPath(path, name), Message: v.message("validation.required_field")})
+			}
+		}
+		for _, name := range sortedKeys(value) {
+			if property, ok := schema.Properties[name]; ok {
+				v.value(property, value[name], in, joinPath(path, name), errs)
+			} else if schema.AdditionalProperties != nil {
+				v.value(schema.AdditionalProperties, value[name], in, joinPath(path, name), errs)
+			}
+		}
+
+	case []any:
+		if !v.expect(schema, "array", fail) {
+			return
+		}
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			fail("validation.min_items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			fail("validation.max_items", *schema.MaxItems)
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range value {
+				v.value(schema.Items, item, in, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+
+	case string:
+		if !v.expect(schema, "string", fail) {
+			return
+		}
+		length := utf8.RuneCountInString(value)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				fail("validation.not_empty")
+			} else {
+				fail("validation.min_length", *schema.MinLength)
+			}
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			fail("validation.max_length", *schema.MaxLength)
+		}
+		switch schema.Format {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				fail("validation.date_time")
+			}
+		case "uuid":
+			if _, err := uuid.Parse(value); err != nil {
+				fail("validation.uuid")
+			}
+		case "decimal":
+			if !decimalPattern.MatchString(value) {
+				fail("validation.decimal")
+				return
+			}
+			// Границы суммы задаются так же, как у чисел: gt=0
+			n, _ := strconv.ParseFloat(value, 64)
+			v.bounds(schema, n, fail)
+		}
+
+	case json.Number:
+		if !schema.Type.has("number") && !schema.Type.has("integer") {
+			v.expect(schema, "number", fail)
+			return
+		}
+		number, ok := new(big.Float).SetString(value.String())
+		if !ok {
+			fail("validation.expected", v.message("type.number"))
+			return
+		}
+		if schema.Type.has("integer") && !schema.Type.has("number") && !number.IsInt() {
+			fail("validation.expected", v.message("type.integer"))
+			return
+		}
+		n, _ := number.Float64()
+		v.bounds(schema, n, fail)
+
+	case bool:
+		v.expect(schema, "boolean", fail)
+	}
+}
+
+// bounds проверяет числовые границы схемы
+func (v *validator) bounds(schema *Schema, n float64, fail func(string, ...any)) {
+	if schema.ExclusiveMinimum != nil && n <= *schema.ExclusiveMinimum {
+		fail("validation.gt", formatNumber(*schema.ExclusiveMinimum))
+	}
+	if schema.Minimum != nil && n < *schema.Minimum {
+		fail("validation.min", formatNumber(*schema.Minimum))
+	}
+	if schema.Maximum != nil && n > *schema.Maximum {
+		fail("validation.max", formatNumber(*schema.Maximum))
+	}
+}
+
+// expect проверяет, что схема допускает тип typ, и сообщает об ошибке
+func (v *validator) expect(schema *Schema, typ string, fail func(string, ...any)) bool {
+	if len(schema.Type) == 0 || schema.Type.has(typ) {
+		return true
+	}
+
+	expected := make([]string, 0, len(schema.Type))
+	for _, t := range schema.Type {
+		if t != "null" {
+			expected = append(expected, v.message("type."+t))
+		}
+	}
+	fail("validation.expected", strings.Join(expected, v.message("validation.or")))
+	return false
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, option := range enum {
+		if option == nil {
+			continue
+		}
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []any) string {
+	options := make([]string, 0, len(enum))
+	for _, option := range enum {
+		if option != nil {
+			options = append(options, fmt.Sprint(option))
+		}
+	}
+	return strings.Join(options, ", ")
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
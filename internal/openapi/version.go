@@ -0,0 +1,95 @@
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"bank-prototype/internal/tracing"
+)
+
+// Deprecation — сведения об устаревшем маршруте. Ответы такого маршрута
+// несут заголовки Deprecation (RFC 9745), Sunset (RFC 8594) и Link на
+// замену, а операция в документе помечается deprecated.
+type Deprecation struct {
+	Since     time.Time // с какой даты маршрут устарел
+	Sunset    time.Time // после этой даты маршрут может быть удалён
+	Successor string    // префикс версии-преемника: тот же путь под ним, например /v1
+}
+
+// Group — маршруты одной версии API под общим префиксом (/v1, /v2).
+// Версии регистрируются в одном Router и вызывают одни и те же сервисы,
+// различаются только обработчики и модели запросов и ответов.
+type Group struct {
+	router *Router
+	prefix string
+	name   string
+	legacy *Deprecation
+}
+
+// Group возвращает группу маршрутов с префиксом prefix. OperationID
+// маршрутов группы получают имя версии: listAccounts → v1ListAccounts.
+func (r *Router) Group(prefix string) *Group {
+	return &Group{
+		router: r,
+		prefix: "/" + strings.Trim(prefix, "/"),
+		name:   strings.Trim(prefix, "/"),
+	}
+}
+
+// WithLegacyAliases регистрирует каждый маршрут группы ещё и без префикса:
+// так прежние неверсионированные пути продолжают работать до даты Sunset.
+// Синонимы сохраняют прежние operationId и помечены устаревшими.
+func (g *Group) WithLegacyAliases(deprecation Deprecation) *Group {
+	deprecation.Successor = g.prefix
+	g.legacy = &deprecation
+	return g
+}
+
+// Handle регистрирует маршрут группы и, если заданы, его устаревший синоним
+func (g *Group) Handle(route Route) {
+	if g.legacy != nil {
+		alias := route
+		alias.Deprecation = g.legacy
+		g.router.Handle(alias)
+	}
+
+	route.Path = g.prefix + route.Path
+	route.OperationID = g.name + strings.ToUpper(route.OperationID[:1]) + route.OperationID[1:]
+	g.router.Handle(route)
+}
+
+// deprecated добавляет к ответу заголовки устаревшего маршрута. Заголовки
+// ставятся до вызова обработчика, поэтому есть и в ответах с ошибкой.
+func deprecated(deprecation *Deprecation, next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	since := "@" + strconv.FormatInt(deprecation.Since.Unix(), 10)
+	sunset := string(fasthttp.AppendHTTPDate(nil, deprecation.Sunset))
+
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("Deprecation", since)
+		ctx.Response.Header.Set("Sunset", sunset)
+		if deprecation.Successor != "" {
+			ctx.Response.Header.Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, deprecation.Successor, ctx.Path()))
+		}
+
+		// По атрибуту в трейсах видно, кто ещё ходит по устаревшим путям
+		trace.SpanFromContext(tracing.Context(ctx)).SetAttributes(attribute.Bool("http.route.deprecated", true))
+
+		next(ctx)
+	}
+}
+
+// deprecationNote — описание устаревшей операции для документа
+func deprecationNote(deprecation *Deprecation) string {
+	note := "Устарело с " + deprecation.Since.Format(time.DateOnly) +
+		", будет удалено после " + deprecation.Sunset.Format(time.DateOnly) + "."
+	if deprecation.Successor != "" {
+		note += " Используйте тот же путь с префиксом " + deprecation.Successor + "."
+	}
+	return note
+}
@@ -14,10 +14,12 @@ import (
 )
 
 var (
-	ErrAccountNotFound     = errors.New("счёт не найден")
-	ErrAccountClosed       = errors.New("счёт закрыт")
-	ErrInsufficientBalance = errors.New("недостаточно средств")
-	SystemBankAccountID    = "00000000000001"
+	ErrAccountNotFound      = errors.New("счёт не найден")
+	ErrAccountClosed        = errors.New("счёт закрыт")
+	ErrAccountFrozen        = errors.New("счёт заморожен")
+	ErrAccountStatusChanged = errors.New("статус счёта изменился, повторите операцию")
+	ErrInsufficientBalance  = errors.New("недостаточно средств")
+	SystemBankAccountID     = "00000000000001"
 )
 
 type AccountRepository struct {
@@ -67,7 +69,7 @@ func (r *AccountRepository) Create(ctx context.Context, userID string) (*models.
 	query := `
 		INSERT INTO accounts (id, user_id, balance, status, created_at)
 		VALUES ($1, $2, 100.00, 'active', NOW())
-		RETURNING id, user_id, balance, status, created_at
+		RETURNING id, user_id, balance, held_amount, status, created_at
 	`
 
 	var account models.Account
@@ -75,6 +77,7 @@ func (r *AccountRepository) Create(ctx context.Context, userID string) (*models.
 		&account.ID,
 		&account.UserID,
 		&account.Balance,
+		&account.HeldAmount,
 		&account.Status,
 		&account.CreatedAt,
 	)
@@ -88,7 +91,7 @@ func (r *AccountRepository) Create(ctx context.Context, userID string) (*models.
 
 func (r *AccountRepository) GetByID(ctx context.Context, accountID string) (*models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, status, created_at
+		SELECT id, user_id, balance, held_amount, status, created_at
 		FROM accounts
 		WHERE id = $1
 	`
@@ -98,6 +101,7 @@ func (r *AccountRepository) GetByID(ctx context.Context, accountID string) (*mod
 		&account.ID,
 		&account.UserID,
 		&account.Balance,
+		&account.HeldAmount,
 		&account.Status,
 		&account.CreatedAt,
 	)
@@ -111,7 +115,7 @@ func (r *AccountRepository) GetByID(ctx context.Context, accountID string) (*mod
 
 func (r *AccountRepository) GetByUserID(ctx context.Context, userID string) ([]models.Account, error) {
 	query := `
-		SELECT id, user_id, balance, status, created_at
+		SELECT id, user_id, balance, held_amount, status, created_at
 		FROM accounts
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -130,6 +134,7 @@ func (r *AccountRepository) GetByUserID(ctx context.Context, userID string) ([]m
 			&account.ID,
 			&account.UserID,
 			&account.Balance,
+			&account.HeldAmount,
 			&account.Status,
 			&account.CreatedAt,
 		)
@@ -145,7 +150,8 @@ func (r *AccountRepository) GetByUserID(ctx context.Context, userID string) ([]m
 // CountActiveAccountsByUserID возвращает количество активных счетов пользователя
 func (r *AccountRepository) CountActiveAccountsByUserID(ctx context.Context, userID string) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM accounts WHERE user_id = $1 AND status = 'active'`
+	// Замороженные счета тоже занимают место в лимите
+	query := `SELECT COUNT(*) FROM accounts WHERE user_id = $1 AND status <> 'closed'`
 
 	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
 	if err != nil {
@@ -175,6 +181,54 @@ func (r *AccountRepository) UpdateStatus(ctx context.Context, accountID, status
 	return nil
 }
 
+// ChangeStatus переводит счёт из статуса from в статус to. Если статус
+// успел измениться, возвращает ErrAccountStatusChanged.
+func (r *AccountRepository) ChangeStatus(ctx context.Context, accountID, from, to string) error {
+	result, err := r.db.Exec(ctx, `UPDATE accounts SET status = $3 WHERE id = $1 AND status = $2`, accountID, from, to)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления статуса счёта: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAccountStatusChanged
+	}
+
+	return nil
+}
+
+// Export передаёт в fn все счета по порядку номеров, не загружая их в память целиком
+func (r *AccountRepository) Export(ctx context.Context, fn func(models.Account) error) error {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, balance, held_amount, status, created_at
+		FROM accounts
+		ORDER BY id
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка выгрузки счетов: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account models.Account
+		err := rows.Scan(
+			&account.ID,
+			&account.UserID,
+			&account.Balance,
+			&account.HeldAmount,
+			&account.Status,
+			&account.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка сканирования счёта: %w", err)
+		}
+		if err := fn(account); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 func (r *AccountRepository) GetBalance(ctx context.Context, accountID string) (float64, error) {
 	var balance float64
 	query := `SELECT balance FROM accounts WHERE id = $1 AND status = 'active'`
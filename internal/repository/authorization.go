@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/utils"
+)
+
+var (
+	ErrAuthorizationNotFound  = errors.New("авторизация не найдена")
+	ErrAuthorizationNotActive = errors.New("авторизация уже завершена")
+	ErrAuthorizationExpired   = errors.New("срок действия авторизации истёк")
+)
+
+const authorizationColumns = `
+	id, user_id, from_account_id, to_account_id, amount, held_amount,
+	captured_amount, transaction_id, status, expires_at, created_at, updated_at
+`
+
+type AuthorizationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuthorizationRepository(db *pgxpool.Pool) *AuthorizationRepository {
+	return &AuthorizationRepository{db: db}
+}
+
+func scanAuthorization(row pgx.Row) (*models.Authorization, error) {
+	var a models.Authorization
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.FromAccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.HeldAmount,
+		&a.CapturedAmount,
+		&a.TransactionID,
+		&a.Status,
+		&a.ExpiresAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanAuthorizations(rows pgx.Rows) ([]models.Authorization, error) {
+	defer rows.Close()
+
+	var result []models.Authorization
+	for rows.Next() {
+		a, err := scanAuthorization(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования авторизации: %w", err)
+		}
+		result = append(result, *a)
+	}
+
+	return result, rows.Err()
+}
+
+// Create резервирует HeldAmount на счёте плательщика и сохраняет авторизацию.
+// Доступный остаток проверяется под блокировкой счёта, как и в ExecuteTransfer.
+func (r *AuthorizationRepository) Create(ctx context.Context, a *models.Authorization) (*models.Authorization, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var available float64
+	err = tx.QueryRow(ctx,
+		"SELECT balance - held_amount FROM accounts WHERE id = $1 AND status = 'active' FOR UPDATE",
+		a.FromAccountID,
+	).Scan(&available)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения баланса плательщика: %w", err)
+	}
+
+	if available < a.HeldAmount {
+		return nil, ErrInsufficientBalance
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET held_amount = held_amount + $1 WHERE id = $2",
+		a.HeldAmount, a.FromAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка резервирования средств: %w", err)
+	}
+
+	created, err := scanAuthorization(tx.QueryRow(ctx, `
+		INSERT INTO authorizations (
+			user_id, from_account_id, to_account_id, amount, held_amount, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+authorizationColumns,
+		a.UserID, a.FromAccountID, a.ToAccountID, a.Amount, a.HeldAmount, a.ExpiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания авторизации: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	utils.LogSuccess("AuthorizationRepo", "Авторизация %s: зарезервировано %.2f на счёте %s",
+		created.ID, created.HeldAmount, created.FromAccountID)
+
+	return created, nil
+}
+
+func (r *AuthorizationRepository) GetByID(ctx context.Context, id string) (*models.Authorization, error) {
+	query := `SELECT ` + authorizationColumns + ` FROM authorizations WHERE id = $1`
+
+	a, err := scanAuthorization(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuthorizationNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения авторизации: %w", err)
+	}
+
+	return a, nil
+}
+
+func (r *AuthorizationRepository) GetByUserID(ctx context.Context, userID string) ([]models.Authorization, error) {
+	query := `SELECT ` + authorizationColumns + `
+		FROM authorizations
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения авторизаций: %w", err)
+	}
+
+	return scanAuthorizations(rows)
+}
+
+// lockActiveAuthorization блокирует авторизацию и проверяет, что её ещё можно завершить
+func lockActiveAuthorization(ctx context.Context, tx pgx.Tx, id string) (*models.Authorization, error) {
+	a, err := scanAuthorization(tx.QueryRow(ctx,
+		`SELECT `+authorizationColumns+` FROM authorizations WHERE id = $1 FOR UPDATE`,
+		id,
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAuthorizationNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения авторизации: %w", err)
+	}
+
+	if a.Status != "active" {
+		return nil, ErrAuthorizationNotActive
+	}
+
+	return a, nil
+}
+
+// Capture списывает amount (+ feeAmount комиссии) в пользу получателя и
+// снимает весь холд. Неиспользованный остаток холда возвращается в доступный баланс.
+func (r *AuthorizationRepository) Capture(ctx context.Context, id string, amount, feeAmount float64) (*models.Authorization, *models.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	a, err := lockActiveAuthorization(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !a.ExpiresAt.After(time.Now()) {
+		return nil, nil, ErrAuthorizationExpired
+	}
+
+	totalDebit := amount + feeAmount
+	if amount > a.Amount || totalDebit > a.HeldAmount {
+		return nil, nil, i18n.Errorf(ErrTransactionFailed, "detail.capture_exceeds_hold")
+	}
+
+	if err := enforceSpendingLimits(ctx, tx, a.FromAccountID, amount); err != nil {
+		return nil, nil, err
+	}
+
+	// Блокируем счета в порядке ID, как и при возвратах
+	rows, err := tx.Query(ctx,
+		"SELECT id, status FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		[]string{a.FromAccountID, a.ToAccountID, SystemBankAccountID},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+	statuses := make(map[string]string, 3)
+	for rows.Next() {
+		var accountID, status string
+		if err := rows.Scan(&accountID, &status); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("ошибка чтения счёта: %w", err)
+		}
+		statuses[accountID] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	// Плательщика могли заморозить или закрыть после резервирования
+	switch statuses[a.FromAccountID] {
+	case "active":
+	case "frozen":
+		return nil, nil, ErrAccountFrozen
+	default:
+		return nil, nil, ErrAccountClosed
+	}
+	if statuses[a.ToAccountID] != "active" {
+		return nil, nil, ErrAccountClosed
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance - $1, held_amount = held_amount - $2 WHERE id = $3",
+		totalDebit, a.HeldAmount, a.FromAccountID,
+	); err != nil {
+		return nil, nil, fmt.Errorf("ошибка списания со счёта плательщика: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+		amount, a.ToAccountID,
+	); err != nil {
+		return nil, nil, fmt.Errorf("ошибка зачисления на счёт получателя: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+		feeAmount, SystemBankAccountID,
+	); err != nil {
+		return nil, nil, fmt.Errorf("ошибка начисления комиссии: %w", err)
+	}
+
+	transaction, err := scanTransaction(tx.QueryRow(ctx, `
+		INSERT INTO transactions (
+			id, type, from_account_id, to_account_id,
+			amount, fee_percent, fee_amount, total_debit,
+			fee_account_id, status, created_at
+		) VALUES ($1, 'payment', $2, $3, $4, 3, $5, $6, $7, 'completed', NOW())
+		RETURNING `+transactionColumns,
+		uuid.New().String(), a.FromAccountID, a.ToAccountID,
+		amount, feeAmount, totalDebit, SystemBankAccountID,
+	))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка записи транзакции: %w", err)
+	}
+
+	captured, err := scanAuthorization(tx.QueryRow(ctx, `
+		UPDATE authorizations
+		SET status = 'captured', captured_amount = $2, transaction_id = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+authorizationColumns,
+		a.ID, amount, transaction.ID,
+	))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка обновления авторизации: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	utils.LogSuccess("AuthorizationRepo", "Авторизация %s списана: %.2f + %.2f комиссии (транзакция %s)",
+		a.ID, amount, feeAmount, transaction.ID)
+
+	return captured, transaction, nil
+}
+
+// Release снимает холд без движения денег
+func (r *AuthorizationRepository) Release(ctx context.Context, id string) (*models.Authorization, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	a, err := lockActiveAuthorization(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET held_amount = held_amount - $1 WHERE id = $2",
+		a.HeldAmount, a.FromAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка снятия холда: %w", err)
+	}
+
+	released, err := scanAuthorization(tx.QueryRow(ctx, `
+		UPDATE authorizations SET status = 'released', updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+authorizationColumns,
+		a.ID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления авторизации: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	return released, nil
+}
+
+// ExpireDue переводит просроченные авторизации в статус expired и снимает
+// их холды одним запросом. SKIP LOCKED позволяет нескольким экземплярам API
+// обрабатывать истечения параллельно, не мешая capture/release.
+func (r *AuthorizationRepository) ExpireDue(ctx context.Context, limit int) ([]models.Authorization, error) {
+	query := `
+		WITH expired AS (
+			UPDATE authorizations
+			SET status = 'expired', updated_at = NOW()
+			WHERE id IN (
+				SELECT id FROM authorizations
+				WHERE status = 'active' AND expires_at <= NOW()
+				ORDER BY expires_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + authorizationColumns + `
+		), released AS (
+			UPDATE accounts a
+			SET held_amount = a.held_amount - e.total
+			FROM (
+				SELECT from_account_id, SUM(held_amount) AS total
+				FROM expired
+				GROUP BY from_account_id
+			) e
+			WHERE a.id = e.from_account_id
+		)
+		SELECT ` + authorizationColumns + ` FROM expired
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка снятия просроченных авторизаций: %w", err)
+	}
+
+	return scanAuthorizations(rows)
+}
@@ -0,0 +1,421 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/utils"
+)
+
+var (
+	ErrBatchNotFound = errors.New("пакет переводов не найден")
+)
+
+const transferBatchColumns = `
+	id, user_id, from_account_id, mode, status, total_items,
+	succeeded_items, failed_items, total_amount, error, created_at, completed_at
+`
+
+// BatchItemError — ошибка конкретной позиции пакета. Index = -1 означает,
+// что ошибка относится к пакету целиком (например, лимит количества операций).
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	if e.Index < 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("позиция %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchTransfer — одна позиция пакета с уже рассчитанной комиссией
+type BatchTransfer struct {
+	ToAccountID string
+	Amount      float64
+	FeeAmount   float64
+}
+
+// ExecuteBatch выполняет все переводы пакета в одной DB-транзакции:
+// либо проходят все, либо ни один. Ошибка конкретной позиции
+// возвращается как *BatchItemError.
+func (r *TransactionRepository) ExecuteBatch(
+	ctx context.Context,
+	fromAccountID string,
+	items []BatchTransfer,
+	feePercent int,
+) ([]models.Transaction, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := lockSpendingLimitState(ctx, tx, fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make([]float64, len(items))
+	for i, item := range items {
+		amounts[i] = item.Amount
+	}
+	if index, err := state.CheckBatch(amounts); err != nil {
+		return nil, &BatchItemError{Index: index, Err: err}
+	}
+
+	// Блокируем все счета пакета в порядке ID
+	seen := map[string]bool{fromAccountID: true, SystemBankAccountID: true}
+	accountIDs := []string{fromAccountID, SystemBankAccountID}
+	for _, item := range items {
+		if !seen[item.ToAccountID] {
+			seen[item.ToAccountID] = true
+			accountIDs = append(accountIDs, item.ToAccountID)
+		}
+	}
+	sort.Strings(accountIDs)
+
+	rows, err := tx.Query(ctx,
+		"SELECT id, balance - held_amount, status FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		accountIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	available := make(map[string]float64, len(accountIDs))
+	statuses := make(map[string]string, len(accountIDs))
+	for rows.Next() {
+		var id, status string
+		var balance float64
+		if err := rows.Scan(&id, &balance, &status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения счёта: %w", err)
+		}
+		available[id] = balance
+		statuses[id] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	if statuses[fromAccountID] != "active" {
+		return nil, ErrAccountNotFound
+	}
+
+	var totalDebit, totalFee float64
+	credits := make(map[string]float64)
+	for i, item := range items {
+		status, ok := statuses[item.ToAccountID]
+		if !ok {
+			return nil, &BatchItemError{Index: i, Err: ErrAccountNotFound}
+		}
+		if status != "active" {
+			return nil, &BatchItemError{Index: i, Err: ErrAccountClosed}
+		}
+
+		totalDebit += item.Amount + item.FeeAmount
+		if totalDebit > available[fromAccountID] {
+			return nil, &BatchItemError{Index: i, Err: ErrInsufficientBalance}
+		}
+
+		totalFee += item.FeeAmount
+		credits[item.ToAccountID] += item.Amount
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance - $1 WHERE id = $2",
+		totalDebit, fromAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка списания со счёта отправителя: %w", err)
+	}
+
+	for accountID, amount := range credits {
+		if _, err := tx.Exec(ctx,
+			"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+			amount, accountID,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка зачисления на счёт %s: %w", accountID, err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+		totalFee, SystemBankAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка начисления комиссии: %w", err)
+	}
+
+	query := `
+		INSERT INTO transactions (
+			id, type, from_account_id, to_account_id,
+			amount, fee_percent, fee_amount, total_debit,
+			fee_account_id, status, created_at
+		) VALUES ($1, 'transfer', $2, $3, $4, $5, $6, $7, $8, 'completed', NOW())
+		RETURNING ` + transactionColumns
+
+	transactions := make([]models.Transaction, 0, len(items))
+	for i, item := range items {
+		transaction, err := scanTransaction(tx.QueryRow(ctx, query,
+			uuid.New().String(), fromAccountID, item.ToAccountID,
+			item.Amount, feePercent, item.FeeAmount, item.Amount+item.FeeAmount,
+			SystemBankAccountID,
+		))
+		if err != nil {
+			return nil, &BatchItemError{Index: i, Err: fmt.Errorf("ошибка записи транзакции: %w", err)}
+		}
+		transactions = append(transactions, *transaction)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	utils.LogSuccess("TransactionRepo", "Пакет из %d переводов со счёта %s выполнен (%.2f + %.2f комиссии)",
+		len(items), fromAccountID, totalDebit-totalFee, totalFee)
+
+	return transactions, nil
+}
+
+type BatchRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewBatchRepository(db *pgxpool.Pool) *BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+func scanTransferBatch(row pgx.Row) (*models.TransferBatch, error) {
+	var b models.TransferBatch
+	err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.FromAccountID,
+		&b.Mode,
+		&b.Status,
+		&b.TotalItems,
+		&b.SucceededItems,
+		&b.FailedItems,
+		&b.TotalAmount,
+		&b.Error,
+		&b.CreatedAt,
+		&b.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Create сохраняет пакет и его позиции в статусе pending
+func (r *BatchRepository) Create(ctx context.Context, batch *models.TransferBatch) (*models.TransferBatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := scanTransferBatch(tx.QueryRow(ctx, `
+		INSERT INTO transfer_batches (user_id, from_account_id, mode, total_items, total_amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+transferBatchColumns,
+		batch.UserID, batch.FromAccountID, batch.Mode, len(batch.Items), batch.TotalAmount,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания пакета переводов: %w", err)
+	}
+
+	rows := make([][]any, len(batch.Items))
+	for i, item := range batch.Items {
+		rows[i] = []any{created.ID, i, item.ToAccountID, item.Amount, item.Reference}
+	}
+	_, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"transfer_batch_items"},
+		[]string{"batch_id", "item_index", "to_account_id", "amount", "reference"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сохранения позиций пакета: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	created.Items = make([]models.TransferBatchItem, len(batch.Items))
+	for i, item := range batch.Items {
+		created.Items[i] = item
+		created.Items[i].Index = i
+		created.Items[i].Status = "pending"
+	}
+
+	return created, nil
+}
+
+func (r *BatchRepository) GetByID(ctx context.Context, id string) (*models.TransferBatch, error) {
+	batch, err := scanTransferBatch(r.db.QueryRow(ctx,
+		`SELECT `+transferBatchColumns+` FROM transfer_batches WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения пакета переводов: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT item_index, to_account_id, amount, reference, status, transaction_id, error
+		FROM transfer_batch_items
+		WHERE batch_id = $1
+		ORDER BY item_index
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения позиций пакета: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.TransferBatchItem
+		if err := rows.Scan(
+			&item.Index,
+			&item.ToAccountID,
+			&item.Amount,
+			&item.Reference,
+			&item.Status,
+			&item.TransactionID,
+			&item.Error,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования позиции пакета: %w", err)
+		}
+		batch.Items = append(batch.Items, item)
+	}
+
+	return batch, rows.Err()
+}
+
+// ListStale возвращает пакеты, обработка которых не завершилась за olderThan —
+// обычно из-за перезапуска API во время выполнения
+func (r *BatchRepository) ListStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.FailedJob, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, status, error, created_at
+		FROM transfer_batches
+		WHERE status IN ('pending', 'processing')
+		  AND created_at < NOW() - $1::bigint * INTERVAL '1 millisecond'
+		ORDER BY created_at
+		LIMIT $2
+	`, olderThan.Milliseconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения зависших пакетов: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.FailedJob
+	for rows.Next() {
+		job := models.FailedJob{Kind: "transfer_batch"}
+		if err := rows.Scan(&job.ID, &job.UserID, &job.Status, &job.Error, &job.FailedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования пакета: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (r *BatchRepository) SetStatus(ctx context.Context, id, status string) error {
+	_, err := r.db.Exec(ctx, `UPDATE transfer_batches SET status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления статуса пакета: %w", err)
+	}
+	return nil
+}
+
+// UpdateItem сохраняет результат позиции и обновляет счётчики пакета
+func (r *BatchRepository) UpdateItem(ctx context.Context, batchID string, item *models.TransferBatchItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateBatchItem(ctx, tx, batchID, item); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE transfer_batches
+		SET succeeded_items = succeeded_items + $2, failed_items = failed_items + $3
+		WHERE id = $1
+	`, batchID, boolToInt(item.Status == "succeeded"), boolToInt(item.Status == "failed"))
+	if err != nil {
+		return fmt.Errorf("ошибка обновления счётчиков пакета: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// Finish сохраняет итоговые результаты всех позиций и статус пакета
+func (r *BatchRepository) Finish(ctx context.Context, batch *models.TransferBatch) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range batch.Items {
+		if err := updateBatchItem(ctx, tx, batch.ID, &batch.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE transfer_batches
+		SET status = $2, succeeded_items = $3, failed_items = $4, error = $5, completed_at = NOW()
+		WHERE id = $1
+		RETURNING completed_at
+	`, batch.ID, batch.Status, batch.SucceededItems, batch.FailedItems, batch.Error).Scan(&batch.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка завершения пакета: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	return nil
+}
+
+func updateBatchItem(ctx context.Context, tx pgx.Tx, batchID string, item *models.TransferBatchItem) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE transfer_batch_items
+		SET status = $3, transaction_id = $4, error = $5
+		WHERE batch_id = $1 AND item_index = $2
+	`, batchID, item.Index, item.Status, item.TransactionID, item.Error)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления позиции пакета: %w", err)
+	}
+	return nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,1530 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+)
+
+// systemBankUserID — владелец системного счёта банка (пользователя с таким ID нет)
+const systemBankUserID = "00000000-0000-0000-0000-000000000000"
+
+// MemoryStore — потокобезопасная реализация AccountStore, TransactionStore,
+// UserStore, PasswordResetStore, NotificationStore, BatchStore,
+// AuthorizationStore и ScheduledTransferStore в памяти процесса для тестов
+// сервисов. Семантика совпадает с репозиториями PostgreSQL: переводы проверяют
+// лимиты расходов, статус счетов и доступный остаток с учётом холдов, проводки
+// атомарны, ошибки те же. Все операции выполняются под одной блокировкой, что
+// соответствует последовательному выполнению транзакций БД. Лимиты задаются
+// через SetSpendingLimits.
+//
+// Как и в базе после миграций, хранилище создаётся с системным счётом банка.
+type MemoryStore struct {
+	mu           sync.Mutex
+	users        map[string]*models.User
+	accounts     map[string]*models.Account
+	transactions []*models.Transaction // в порядке создания
+	idempotency  map[string]string     // ключ идемпотентности → ID транзакции
+	resets       []*memoryReset
+	preferences  map[string]models.NotificationPreferences
+	inbox        []*memoryNotification            // в порядке создания
+	limits       map[string]models.SpendingLimits // scope:ID владельца → лимиты
+	batches      map[string]*models.TransferBatch
+	holds        map[string]*models.Authorization
+	schedules    map[string]*models.ScheduledTransfer
+	runs         []*models.ScheduledTransferRun // в порядке выполнения
+}
+
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{
+		users:       make(map[string]*models.User),
+		accounts:    make(map[string]*models.Account),
+		preferences: make(map[string]models.NotificationPreferences),
+		idempotency: make(map[string]string),
+		limits:      make(map[string]models.SpendingLimits),
+		batches:     make(map[string]*models.TransferBatch),
+		holds:       make(map[string]*models.Authorization),
+		schedules:   make(map[string]*models.ScheduledTransfer),
+	}
+	m.accounts[SystemBankAccountID] = &models.Account{
+		ID:        SystemBankAccountID,
+		UserID:    systemBankUserID,
+		Status:    "active",
+		CreatedAt: time.Now(),
+	}
+	return m
+}
+
+func (m *MemoryStore) Accounts() *MemoryAccountStore {
+	return &MemoryAccountStore{m}
+}
+
+func (m *MemoryStore) Transactions() *MemoryTransactionStore {
+	return &MemoryTransactionStore{m}
+}
+
+func (m *MemoryStore) Users() *MemoryUserStore {
+	return &MemoryUserStore{m}
+}
+
+func (m *MemoryStore) PasswordResets() *MemoryPasswordResetStore {
+	return &MemoryPasswordResetStore{m}
+}
+
+func (m *MemoryStore) Notifications() *MemoryNotificationStore {
+	return &MemoryNotificationStore{m}
+}
+
+func (m *MemoryStore) Batches() *MemoryBatchStore {
+	return &MemoryBatchStore{m}
+}
+
+func (m *MemoryStore) Authorizations() *MemoryAuthorizationStore {
+	return &MemoryAuthorizationStore{m}
+}
+
+func (m *MemoryStore) ScheduledTransfers() *MemoryScheduledTransferStore {
+	return &MemoryScheduledTransferStore{m}
+}
+
+// PutAccount добавляет или заменяет счёт целиком — для подготовки данных
+// в тестах (например, счёт с холдом или с заданным номером)
+func (m *MemoryStore) PutAccount(account models.Account) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = time.Now()
+	}
+	m.accounts[account.ID] = &account
+}
+
+// PutAuthorization заменяет авторизацию целиком — например, чтобы сдвинуть
+// срок её действия в прошлое. Холд на счёте не пересчитывается.
+func (m *MemoryStore) PutAuthorization(a models.Authorization) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.holds[a.ID] = &a
+}
+
+// SetSpendingLimits задаёт лимиты уровня scope. ownerID — ID пользователя
+// или счёта; для LimitScopeBank не используется.
+func (m *MemoryStore) SetSpendingLimits(scope, ownerID string, limits models.SpendingLimits) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if scope == LimitScopeBank {
+		ownerID = ""
+	}
+	m.limits[scope+":"+ownerID] = limits
+}
+
+// spendingLimitState — аналог loadSpendingLimitState: лимиты всех уровней и
+// исходящие переводы и платежи владельца счёта за сутки и месяц UTC
+func (m *MemoryStore) spendingLimitState(accountID string) (*SpendingLimitState, error) {
+	account, ok := m.accounts[accountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+
+	state := &SpendingLimitState{
+		UserID:    account.UserID,
+		AccountID: accountID,
+		Bank:      m.limits[LimitScopeBank+":"],
+		User:      m.limits[LimitScopeUser+":"+account.UserID],
+		Account:   m.limits[LimitScopeAccount+":"+accountID],
+	}
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for _, transaction := range m.transactions {
+		if transaction.Type != "transfer" && transaction.Type != "payment" {
+			continue
+		}
+		if transaction.CreatedAt.Before(monthStart) {
+			continue
+		}
+		from, ok := m.accounts[transaction.FromAccountID]
+		if !ok || from.UserID != account.UserID {
+			continue
+		}
+
+		today := !transaction.CreatedAt.Before(dayStart)
+		addSpendingUsage(&state.UserUsage, transaction.Amount, today)
+		if transaction.FromAccountID == accountID {
+			addSpendingUsage(&state.AccountUsage, transaction.Amount, today)
+		}
+	}
+
+	return state, nil
+}
+
+var (
+	_ AccountStore           = (*MemoryAccountStore)(nil)
+	_ TransactionStore       = (*MemoryTransactionStore)(nil)
+	_ UserStore              = (*MemoryUserStore)(nil)
+	_ PasswordResetStore     = (*MemoryPasswordResetStore)(nil)
+	_ NotificationStore      = (*MemoryNotificationStore)(nil)
+	_ BatchStore             = (*MemoryBatchStore)(nil)
+	_ AuthorizationStore     = (*MemoryAuthorizationStore)(nil)
+	_ ScheduledTransferStore = (*MemoryScheduledTransferStore)(nil)
+)
+
+// MemoryAccountStore — счета MemoryStore
+type MemoryAccountStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryAccountStore) Create(ctx context.Context, userID string) (*models.Account, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	accountID := ""
+	for accountID == "" || s.m.accounts[accountID] != nil {
+		accountID = fmt.Sprintf("13%012d", rand.Int64N(1_000_000_000_000))
+	}
+
+	account := &models.Account{
+		ID:        accountID,
+		UserID:    userID,
+		Balance:   InitialAccountBalance,
+		Status:    "active",
+		CreatedAt: time.Now(),
+	}
+	s.m.accounts[accountID] = account
+
+	result := *account
+	return &result, nil
+}
+
+func (s *MemoryAccountStore) GetByID(ctx context.Context, accountID string) (*models.Account, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	account, ok := s.m.accounts[accountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+
+	result := *account
+	return &result, nil
+}
+
+func (s *MemoryAccountStore) GetByUserID(ctx context.Context, userID string) ([]models.Account, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var accounts []models.Account
+	for _, account := range s.m.accounts {
+		if account.UserID == userID {
+			accounts = append(accounts, *account)
+		}
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].CreatedAt.After(accounts[j].CreatedAt)
+	})
+	return accounts, nil
+}
+
+func (s *MemoryAccountStore) CountActiveAccountsByUserID(ctx context.Context, userID string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	count := 0
+	for _, account := range s.m.accounts {
+		if account.UserID == userID && account.Status != "closed" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryAccountStore) UpdateStatus(ctx context.Context, accountID, status string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	account, ok := s.m.accounts[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Status = status
+	return nil
+}
+
+func (s *MemoryAccountStore) ChangeStatus(ctx context.Context, accountID, from, to string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	account, ok := s.m.accounts[accountID]
+	if !ok || account.Status != from {
+		return ErrAccountStatusChanged
+	}
+	account.Status = to
+	return nil
+}
+
+func (s *MemoryAccountStore) GetBalance(ctx context.Context, accountID string) (float64, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	account, ok := s.m.accounts[accountID]
+	if !ok || account.Status != "active" {
+		return 0, ErrAccountNotFound
+	}
+	return account.Balance, nil
+}
+
+func (s *MemoryAccountStore) UpdateBalance(ctx context.Context, accountID string, newBalance float64) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	account, ok := s.m.accounts[accountID]
+	if !ok || account.Status != "active" {
+		return ErrAccountNotFound
+	}
+	account.Balance = newBalance
+	return nil
+}
+
+// MemoryTransactionStore — транзакции MemoryStore
+type MemoryTransactionStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryTransactionStore) ExecuteTransfer(
+	ctx context.Context,
+	fromAccountID, toAccountID string,
+	amount, feeAmount float64,
+	feePercent int,
+	txType string,
+	opts TransferOptions,
+) (*models.Transaction, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if opts.IdempotencyKey != "" && s.m.idempotency[opts.IdempotencyKey] != "" {
+		return nil, ErrDuplicateTransaction
+	}
+
+	state, err := s.m.spendingLimitState(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BatchItem {
+		err = state.CheckBatchItem(amount)
+	} else {
+		err = state.Check(amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	totalDebit := amount + feeAmount
+
+	from, ok := s.m.accounts[fromAccountID]
+	if !ok || from.Status != "active" {
+		return nil, ErrAccountNotFound
+	}
+	if from.AvailableBalance() < totalDebit {
+		return nil, ErrInsufficientBalance
+	}
+
+	to, ok := s.m.accounts[toAccountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	if to.Status != "active" {
+		return nil, ErrAccountClosed
+	}
+
+	from.Balance -= totalDebit
+	to.Balance += amount
+	if system, ok := s.m.accounts[SystemBankAccountID]; ok {
+		system.Balance += feeAmount
+	}
+
+	transaction := &models.Transaction{
+		ID:            uuid.New().String(),
+		Type:          txType,
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        amount,
+		FeePercent:    feePercent,
+		FeeAmount:     feeAmount,
+		TotalDebit:    totalDebit,
+		FeeAccountID:  SystemBankAccountID,
+		Status:        "completed",
+		CreatedAt:     time.Now(),
+	}
+	s.m.transactions = append(s.m.transactions, transaction)
+	if opts.IdempotencyKey != "" {
+		s.m.idempotency[opts.IdempotencyKey] = transaction.ID
+	}
+
+	result := *transaction
+	return &result, nil
+}
+
+func (s *MemoryTransactionStore) ExecuteBatch(
+	ctx context.Context,
+	fromAccountID string,
+	items []BatchTransfer,
+	feePercent int,
+) ([]models.Transaction, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	state, err := s.m.spendingLimitState(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	amounts := make([]float64, len(items))
+	for i, item := range items {
+		amounts[i] = item.Amount
+	}
+	if index, err := state.CheckBatch(amounts); err != nil {
+		return nil, &BatchItemError{Index: index, Err: err}
+	}
+
+	from := s.m.accounts[fromAccountID]
+	if from.Status != "active" {
+		return nil, ErrAccountNotFound
+	}
+
+	var totalDebit float64
+	for i, item := range items {
+		to, ok := s.m.accounts[item.ToAccountID]
+		if !ok {
+			return nil, &BatchItemError{Index: i, Err: ErrAccountNotFound}
+		}
+		if to.Status != "active" {
+			return nil, &BatchItemError{Index: i, Err: ErrAccountClosed}
+		}
+
+		totalDebit += item.Amount + item.FeeAmount
+		if totalDebit > from.AvailableBalance() {
+			return nil, &BatchItemError{Index: i, Err: ErrInsufficientBalance}
+		}
+	}
+
+	transactions := make([]models.Transaction, 0, len(items))
+	for _, item := range items {
+		from.Balance -= item.Amount + item.FeeAmount
+		s.m.accounts[item.ToAccountID].Balance += item.Amount
+		if system, ok := s.m.accounts[SystemBankAccountID]; ok {
+			system.Balance += item.FeeAmount
+		}
+
+		transaction := &models.Transaction{
+			ID:            uuid.New().String(),
+			Type:          "transfer",
+			FromAccountID: fromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+			FeePercent:    feePercent,
+			FeeAmount:     item.FeeAmount,
+			TotalDebit:    item.Amount + item.FeeAmount,
+			FeeAccountID:  SystemBankAccountID,
+			Status:        "completed",
+			CreatedAt:     time.Now(),
+		}
+		s.m.transactions = append(s.m.transactions, transaction)
+		transactions = append(transactions, *transaction)
+	}
+
+	return transactions, nil
+}
+
+func (s *MemoryTransactionStore) ExecuteRefund(ctx context.Context, params RefundParams) (*models.RefundResult, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	original := s.m.findTransaction(params.OriginalID)
+	if original == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	if original.Type != "transfer" && original.Type != "payment" {
+		return nil, ErrTransactionNotRefundable
+	}
+
+	remaining := roundMoney(original.Amount - original.RefundedAmount)
+	if remaining <= 0 {
+		return nil, ErrTransactionNotRefundable
+	}
+
+	amount := remaining
+	if params.Amount != nil {
+		amount = roundMoney(*params.Amount)
+		if amount > remaining {
+			return nil, ErrRefundExceedsAmount
+		}
+	}
+
+	var feeRefund float64
+	if params.RefundFee {
+		feeRemaining := roundMoney(original.FeeAmount - original.RefundedFeeAmount)
+		if amount == remaining {
+			feeRefund = feeRemaining
+		} else {
+			feeRefund = roundMoney(original.FeeAmount * amount / original.Amount)
+			if feeRefund > feeRemaining {
+				feeRefund = feeRemaining
+			}
+		}
+	}
+
+	accountIDs := []string{original.FromAccountID, original.ToAccountID}
+	if feeRefund > 0 {
+		accountIDs = append(accountIDs, SystemBankAccountID)
+	}
+	for _, id := range accountIDs {
+		account, ok := s.m.accounts[id]
+		if !ok {
+			return nil, ErrAccountNotFound
+		}
+		if account.Status != "active" {
+			return nil, ErrAccountClosed
+		}
+	}
+
+	payer := s.m.accounts[original.FromAccountID]
+	payee := s.m.accounts[original.ToAccountID]
+	system := s.m.accounts[SystemBankAccountID]
+
+	if payee.AvailableBalance() < amount {
+		return nil, ErrInsufficientBalance
+	}
+	if feeRefund > 0 && system.AvailableBalance() < feeRefund {
+		return nil, ErrInsufficientBalance
+	}
+
+	payee.Balance -= amount
+	payer.Balance += amount + feeRefund
+
+	result := &models.RefundResult{}
+	result.Refund = s.m.insertCompensating(params.Type, original.ToAccountID, original.FromAccountID, amount, original.ID)
+
+	if feeRefund > 0 {
+		system.Balance -= feeRefund
+		result.FeeRefund = s.m.insertCompensating("fee_refund", SystemBankAccountID, original.FromAccountID, feeRefund, original.ID)
+	}
+
+	original.RefundedAmount += amount
+	original.RefundedFeeAmount += feeRefund
+	if original.RefundedAmount >= original.Amount {
+		original.Status = "refunded"
+	} else {
+		original.Status = "partially_refunded"
+	}
+
+	updated := *original
+	result.Original = &updated
+	return result, nil
+}
+
+func (s *MemoryTransactionStore) GetByID(ctx context.Context, transactionID string) (*models.Transaction, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	transaction := s.m.findTransaction(transactionID)
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	result := *transaction
+	return &result, nil
+}
+
+func (s *MemoryTransactionStore) GetByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	transaction := s.m.findTransaction(s.m.idempotency[key])
+	if transaction == nil {
+		return nil, ErrTransactionNotFound
+	}
+
+	result := *transaction
+	return &result, nil
+}
+
+func (s *MemoryTransactionStore) GetByAccountID(ctx context.Context, accountID string) ([]models.Transaction, error) {
+	return s.m.selectTransactions(true, func(t *models.Transaction) bool {
+		return t.FromAccountID == accountID || t.ToAccountID == accountID
+	}), nil
+}
+
+func (s *MemoryTransactionStore) GetByUserID(ctx context.Context, userID string) ([]models.Transaction, error) {
+	s.m.mu.Lock()
+	owned := make(map[string]bool)
+	for id, account := range s.m.accounts {
+		if account.UserID == userID {
+			owned[id] = true
+		}
+	}
+	s.m.mu.Unlock()
+
+	return s.m.selectTransactions(true, func(t *models.Transaction) bool {
+		return owned[t.FromAccountID] || owned[t.ToAccountID]
+	}), nil
+}
+
+func (s *MemoryTransactionStore) GetByOriginalID(ctx context.Context, originalID string) ([]models.Transaction, error) {
+	return s.m.selectTransactions(false, func(t *models.Transaction) bool {
+		return t.OriginalTransactionID != nil && *t.OriginalTransactionID == originalID
+	}), nil
+}
+
+// findTransaction вызывается под m.mu
+func (m *MemoryStore) findTransaction(transactionID string) *models.Transaction {
+	for _, transaction := range m.transactions {
+		if transaction.ID == transactionID {
+			return transaction
+		}
+	}
+	return nil
+}
+
+// insertCompensating записывает проведённую транзакцию без комиссии:
+// компенсирующую или, при пустом originalID, выплату; вызывается под m.mu
+func (m *MemoryStore) insertCompensating(txType, fromAccountID, toAccountID string, amount float64, originalID string) *models.Transaction {
+	var original *string
+	if originalID != "" {
+		original = &originalID
+	}
+	transaction := &models.Transaction{
+		ID:                    uuid.New().String(),
+		Type:                  txType,
+		FromAccountID:         fromAccountID,
+		ToAccountID:           toAccountID,
+		Amount:                amount,
+		TotalDebit:            amount,
+		FeeAccountID:          SystemBankAccountID,
+		Status:                "completed",
+		CreatedAt:             time.Now(),
+		OriginalTransactionID: original,
+	}
+	m.transactions = append(m.transactions, transaction)
+
+	result := *transaction
+	return &result
+}
+
+// selectTransactions возвращает копии подходящих транзакций: newestFirst —
+// от новых к старым, как ORDER BY created_at DESC
+func (m *MemoryStore) selectTransactions(newestFirst bool, match func(*models.Transaction) bool) []models.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var transactions []models.Transaction
+	for _, transaction := range m.transactions {
+		if match(transaction) {
+			transactions = append(transactions, *transaction)
+		}
+	}
+
+	if newestFirst {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+	return transactions
+}
+
+// MemoryUserStore — пользователи MemoryStore
+type MemoryUserStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryUserStore) Create(ctx context.Context, user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, existing := range s.m.users {
+		if existing.Name == user.Name {
+			return ErrUserExists
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	user.ID = uuid.New().String()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	stored := *user
+	s.m.users[user.ID] = &stored
+	return nil
+}
+
+func (s *MemoryUserStore) GetByName(ctx context.Context, name string) (*models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, user := range s.m.users {
+		if user.Name == name {
+			result := *user
+			return &result, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s *MemoryUserStore) GetByID(ctx context.Context, userID string) (*models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	user, ok := s.m.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	result := *user
+	return &result, nil
+}
+
+func (s *MemoryUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, user := range s.m.users {
+		if user.Email != "" && user.Email == email {
+			result := *user
+			return &result, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// SoftDelete повторяет UserRepository.SoftDelete; удалённый пользователь
+// исчезает из users, его закрытые счета и транзакции остаются
+func (s *MemoryUserStore) SoftDelete(ctx context.Context, userID, payoutAccountID string) (*models.UserDeletion, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if _, ok := s.m.users[userID]; !ok {
+		return nil, ErrUserNotFound
+	}
+
+	var payout *models.Account
+	if payoutAccountID != "" {
+		payout = s.m.accounts[payoutAccountID]
+		if payout == nil || payout.UserID == userID {
+			return nil, ErrAccountNotFound
+		}
+		if payout.Status != "active" {
+			return nil, ErrAccountClosed
+		}
+	}
+
+	var accounts []*models.Account
+	for _, account := range s.m.accounts {
+		if account.UserID != userID || account.Status == "closed" {
+			continue
+		}
+		if account.Status != "active" || account.HeldAmount > 0 || (account.Balance > 0 && payout == nil) {
+			return nil, ErrAccountStatusChanged
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].ID < accounts[j].ID })
+
+	// Лимиты проверяются до изменений: откатить их, как транзакцию БД, нельзя
+	var checked []models.Transaction
+	for _, account := range accounts {
+		if account.Balance <= 0 {
+			continue
+		}
+		state, err := s.m.spendingLimitState(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := state.CheckPayout(account.Balance, checked); err != nil {
+			return nil, err
+		}
+		checked = append(checked, models.Transaction{Amount: account.Balance})
+	}
+
+	deletion := &models.UserDeletion{DeletedAt: time.Now()}
+	for _, account := range accounts {
+		if account.Balance > 0 {
+			payout.Balance += account.Balance
+			deletion.Payouts = append(deletion.Payouts, *s.m.insertCompensating("payout", account.ID, payout.ID, account.Balance, ""))
+			account.Balance = 0
+		}
+		account.Status = "closed"
+		deletion.ClosedAccounts = append(deletion.ClosedAccounts, account.ID)
+	}
+
+	delete(s.m.preferences, userID)
+	inbox := s.m.inbox[:0]
+	for _, stored := range s.m.inbox {
+		if stored.userID != userID {
+			inbox = append(inbox, stored)
+		}
+	}
+	s.m.inbox = inbox
+
+	delete(s.m.users, userID)
+	return deletion, nil
+}
+
+func (s *MemoryUserStore) SetRole(ctx context.Context, name, role string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, user := range s.m.users {
+		if user.Name == name {
+			user.Role = role
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
+
+func (s *MemoryUserStore) UpdateProfile(ctx context.Context, user *models.User) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.users[user.ID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	for id, other := range s.m.users {
+		switch {
+		case id == user.ID:
+		case user.Email != "" && other.Email == user.Email:
+			return ErrEmailTaken
+		case user.Phone != "" && other.Phone == user.Phone:
+			return ErrPhoneTaken
+		}
+	}
+
+	stored.DisplayName, stored.Email, stored.Phone = user.DisplayName, user.Email, user.Phone
+	stored.UpdatedAt = time.Now()
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (s *MemoryUserStore) UpdatePassword(ctx context.Context, userID, passwordHash string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	user, ok := s.m.users[userID]
+	if !ok {
+		return 0, ErrUserNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.SessionVersion++
+	user.UpdatedAt = time.Now()
+	return user.SessionVersion, nil
+}
+
+type memoryReset struct {
+	token     models.PasswordResetToken
+	tokenHash string
+	used      bool
+}
+
+// MemoryPasswordResetStore — токены сброса пароля MemoryStore
+type MemoryPasswordResetStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryPasswordResetStore) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	s.m.resets = append(s.m.resets, &memoryReset{
+		token: models.PasswordResetToken{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now(),
+		},
+		tokenHash: tokenHash,
+	})
+	return nil
+}
+
+func (s *MemoryPasswordResetStore) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	count := 0
+	for _, reset := range s.m.resets {
+		if reset.token.UserID == userID && reset.token.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryPasswordResetStore) GetValid(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	reset := s.m.validReset(tokenHash)
+	if reset == nil {
+		return nil, ErrInvalidResetToken
+	}
+	token := reset.token
+	return &token, nil
+}
+
+func (s *MemoryPasswordResetStore) Consume(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	reset := s.m.validReset(tokenHash)
+	if reset == nil {
+		return "", ErrInvalidResetToken
+	}
+
+	user := s.m.users[reset.token.UserID]
+	user.PasswordHash = passwordHash
+	user.SessionVersion++
+	user.UpdatedAt = time.Now()
+
+	for _, other := range s.m.resets {
+		if other.token.UserID == user.ID {
+			other.used = true
+		}
+	}
+	return user.ID, nil
+}
+
+// validReset ищет неиспользованный и не истёкший токен действующего
+// пользователя; вызывается под m.mu
+func (m *MemoryStore) validReset(tokenHash string) *memoryReset {
+	for _, reset := range m.resets {
+		if reset.tokenHash != tokenHash {
+			continue
+		}
+		if reset.used || !time.Now().Before(reset.token.ExpiresAt) || m.users[reset.token.UserID] == nil {
+			return nil
+		}
+		return reset
+	}
+	return nil
+}
+
+type memoryNotification struct {
+	userID       string
+	notification models.Notification
+}
+
+// MemoryNotificationStore — настройки и входящие уведомления MemoryStore
+type MemoryNotificationStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryNotificationStore) GetPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if preferences, ok := s.m.preferences[userID]; ok {
+		return preferences, nil
+	}
+	return models.DefaultNotificationPreferences(), nil
+}
+
+func (s *MemoryNotificationStore) SavePreferences(ctx context.Context, userID string, preferences models.NotificationPreferences) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	s.m.preferences[userID] = preferences
+	return nil
+}
+
+func (s *MemoryNotificationStore) AddNotification(ctx context.Context, userID, kind, subject, body string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	s.m.inbox = append(s.m.inbox, &memoryNotification{
+		userID: userID,
+		notification: models.Notification{
+			ID:        uuid.New().String(),
+			Kind:      kind,
+			Subject:   subject,
+			Body:      body,
+			CreatedAt: time.Now(),
+		},
+	})
+	return nil
+}
+
+func (s *MemoryNotificationStore) ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	notifications := []models.Notification{}
+	for i := len(s.m.inbox) - 1; i >= 0 && len(notifications) < limit; i-- {
+		stored := s.m.inbox[i]
+		if stored.userID != userID || (unreadOnly && stored.notification.ReadAt != nil) {
+			continue
+		}
+		notifications = append(notifications, stored.notification)
+	}
+	return notifications, nil
+}
+
+func (s *MemoryNotificationStore) CountUnread(ctx context.Context, userID string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	count := 0
+	for _, stored := range s.m.inbox {
+		if stored.userID == userID && stored.notification.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *MemoryNotificationStore) MarkRead(ctx context.Context, userID, id string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	for _, stored := range s.m.inbox {
+		if stored.userID == userID && stored.notification.ID == id {
+			if stored.notification.ReadAt == nil {
+				now := time.Now()
+				stored.notification.ReadAt = &now
+			}
+			return nil
+		}
+	}
+	return ErrNotificationNotFound
+}
+
+func (s *MemoryNotificationStore) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	now := time.Now()
+	marked := 0
+	for _, stored := range s.m.inbox {
+		if stored.userID == userID && stored.notification.ReadAt == nil {
+			stored.notification.ReadAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// MemoryBatchStore — пакеты переводов MemoryStore
+type MemoryBatchStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryBatchStore) Create(ctx context.Context, batch *models.TransferBatch) (*models.TransferBatch, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	created := copyBatch(batch)
+	created.ID = uuid.New().String()
+	created.Status = "pending"
+	created.TotalItems = len(batch.Items)
+	created.CreatedAt = time.Now()
+	for i := range created.Items {
+		created.Items[i].Index = i
+		created.Items[i].Status = "pending"
+	}
+	s.m.batches[created.ID] = created
+
+	return copyBatch(created), nil
+}
+
+func (s *MemoryBatchStore) GetByID(ctx context.Context, id string) (*models.TransferBatch, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	batch, ok := s.m.batches[id]
+	if !ok {
+		return nil, ErrBatchNotFound
+	}
+	return copyBatch(batch), nil
+}
+
+func (s *MemoryBatchStore) ListStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.FailedJob, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var jobs []models.FailedJob
+	cutoff := time.Now().Add(-olderThan)
+	for _, batch := range s.m.batches {
+		if (batch.Status == "pending" || batch.Status == "processing") && batch.CreatedAt.Before(cutoff) {
+			jobs = append(jobs, models.FailedJob{
+				Kind:     "transfer_batch",
+				ID:       batch.ID,
+				UserID:   batch.UserID,
+				Status:   batch.Status,
+				Error:    batch.Error,
+				FailedAt: batch.CreatedAt,
+			})
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].FailedAt.Before(jobs[j].FailedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (s *MemoryBatchStore) SetStatus(ctx context.Context, id, status string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if batch, ok := s.m.batches[id]; ok {
+		batch.Status = status
+	}
+	return nil
+}
+
+func (s *MemoryBatchStore) UpdateItem(ctx context.Context, batchID string, item *models.TransferBatchItem) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	batch, ok := s.m.batches[batchID]
+	if !ok {
+		return nil
+	}
+	batch.Items[item.Index] = *item
+	switch item.Status {
+	case "succeeded":
+		batch.SucceededItems++
+	case "failed":
+		batch.FailedItems++
+	}
+	return nil
+}
+
+func (s *MemoryBatchStore) Finish(ctx context.Context, batch *models.TransferBatch) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	now := time.Now()
+	batch.CompletedAt = &now
+	if _, ok := s.m.batches[batch.ID]; ok {
+		s.m.batches[batch.ID] = copyBatch(batch)
+	}
+	return nil
+}
+
+func copyBatch(batch *models.TransferBatch) *models.TransferBatch {
+	result := *batch
+	result.Items = append([]models.TransferBatchItem(nil), batch.Items...)
+	return &result
+}
+
+// MemoryAuthorizationStore — авторизации MemoryStore
+type MemoryAuthorizationStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryAuthorizationStore) Create(ctx context.Context, a *models.Authorization) (*models.Authorization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	from, ok := s.m.accounts[a.FromAccountID]
+	if !ok || from.Status != "active" {
+		return nil, ErrAccountNotFound
+	}
+	if from.AvailableBalance() < a.HeldAmount {
+		return nil, ErrInsufficientBalance
+	}
+
+	from.HeldAmount += a.HeldAmount
+
+	now := time.Now()
+	created := *a
+	created.ID = uuid.New().String()
+	created.CapturedAmount = 0
+	created.TransactionID = nil
+	created.Status = "active"
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.m.holds[created.ID] = &created
+
+	result := created
+	return &result, nil
+}
+
+func (s *MemoryAuthorizationStore) GetByID(ctx context.Context, id string) (*models.Authorization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	a, ok := s.m.holds[id]
+	if !ok {
+		return nil, ErrAuthorizationNotFound
+	}
+	result := *a
+	return &result, nil
+}
+
+func (s *MemoryAuthorizationStore) GetByUserID(ctx context.Context, userID string) ([]models.Authorization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var result []models.Authorization
+	for _, a := range s.m.holds {
+		if a.UserID == userID {
+			result = append(result, *a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+func (s *MemoryAuthorizationStore) Capture(ctx context.Context, id string, amount, feeAmount float64) (*models.Authorization, *models.Transaction, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	a, err := s.m.activeAuthorization(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !a.ExpiresAt.After(time.Now()) {
+		return nil, nil, ErrAuthorizationExpired
+	}
+
+	totalDebit := amount + feeAmount
+	if amount > a.Amount || totalDebit > a.HeldAmount {
+		return nil, nil, i18n.Errorf(ErrTransactionFailed, "detail.capture_exceeds_hold")
+	}
+
+	state, err := s.m.spendingLimitState(a.FromAccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := state.Check(amount); err != nil {
+		return nil, nil, err
+	}
+
+	from := s.m.accounts[a.FromAccountID]
+	switch from.Status {
+	case "active":
+	case "frozen":
+		return nil, nil, ErrAccountFrozen
+	default:
+		return nil, nil, ErrAccountClosed
+	}
+	to, ok := s.m.accounts[a.ToAccountID]
+	if !ok || to.Status != "active" {
+		return nil, nil, ErrAccountClosed
+	}
+
+	from.Balance -= totalDebit
+	from.HeldAmount -= a.HeldAmount
+	to.Balance += amount
+	if system, ok := s.m.accounts[SystemBankAccountID]; ok {
+		system.Balance += feeAmount
+	}
+
+	now := time.Now()
+	transaction := &models.Transaction{
+		ID:            uuid.New().String(),
+		Type:          "payment",
+		FromAccountID: a.FromAccountID,
+		ToAccountID:   a.ToAccountID,
+		Amount:        amount,
+		FeePercent:    3,
+		FeeAmount:     feeAmount,
+		TotalDebit:    totalDebit,
+		FeeAccountID:  SystemBankAccountID,
+		Status:        "completed",
+		CreatedAt:     now,
+	}
+	s.m.transactions = append(s.m.transactions, transaction)
+
+	a.Status = "captured"
+	a.CapturedAmount = amount
+	a.TransactionID = &transaction.ID
+	a.UpdatedAt = now
+
+	captured, result := *a, *transaction
+	return &captured, &result, nil
+}
+
+func (s *MemoryAuthorizationStore) Release(ctx context.Context, id string) (*models.Authorization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	a, err := s.m.activeAuthorization(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.m.releaseHold(a, "released")
+
+	result := *a
+	return &result, nil
+}
+
+// ExpireDue снимает просроченные холды в порядке срока действия. Общая
+// блокировка хранилища заменяет SKIP LOCKED: параллельный capture или
+// release просто выполнится до или после.
+func (s *MemoryAuthorizationStore) ExpireDue(ctx context.Context, limit int) ([]models.Authorization, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	now := time.Now()
+	var due []*models.Authorization
+	for _, a := range s.m.holds {
+		if a.Status == "active" && !a.ExpiresAt.After(now) {
+			due = append(due, a)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ExpiresAt.Before(due[j].ExpiresAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	expired := make([]models.Authorization, 0, len(due))
+	for _, a := range due {
+		s.m.releaseHold(a, "expired")
+		expired = append(expired, *a)
+	}
+	return expired, nil
+}
+
+// activeAuthorization — аналог lockActiveAuthorization
+func (m *MemoryStore) activeAuthorization(id string) (*models.Authorization, error) {
+	a, ok := m.holds[id]
+	if !ok {
+		return nil, ErrAuthorizationNotFound
+	}
+	if a.Status != "active" {
+		return nil, ErrAuthorizationNotActive
+	}
+	return a, nil
+}
+
+// releaseHold возвращает холд в доступный остаток и завершает авторизацию
+func (m *MemoryStore) releaseHold(a *models.Authorization, status string) {
+	if from, ok := m.accounts[a.FromAccountID]; ok {
+		from.HeldAmount -= a.HeldAmount
+	}
+	a.Status = status
+	a.UpdatedAt = time.Now()
+}
+
+// MemoryScheduledTransferStore — регулярные переводы MemoryStore
+type MemoryScheduledTransferStore struct {
+	m *MemoryStore
+}
+
+func (s *MemoryScheduledTransferStore) Create(ctx context.Context, st *models.ScheduledTransfer) (*models.ScheduledTransfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	now := time.Now()
+	created := *st
+	created.ID = uuid.New().String()
+	created.Occurrences = 0
+	created.RetryCount = 0
+	created.RetryAt = nil
+	created.LockedUntil = nil
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.m.schedules[created.ID] = &created
+
+	result := created
+	return &result, nil
+}
+
+func (s *MemoryScheduledTransferStore) GetByID(ctx context.Context, id string) (*models.ScheduledTransfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	st, ok := s.m.schedules[id]
+	if !ok {
+		return nil, ErrScheduledTransferNotFound
+	}
+	result := *st
+	return &result, nil
+}
+
+func (s *MemoryScheduledTransferStore) GetByUserID(ctx context.Context, userID string) ([]models.ScheduledTransfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var result []models.ScheduledTransfer
+	for _, st := range s.m.schedules {
+		if st.UserID == userID {
+			result = append(result, *st)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+func (s *MemoryScheduledTransferStore) Update(ctx context.Context, st *models.ScheduledTransfer) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	stored, ok := s.m.schedules[st.ID]
+	if !ok {
+		return ErrScheduledTransferNotFound
+	}
+	stored.Amount = st.Amount
+	stored.EndAt = st.EndAt
+	stored.MaxOccurrences = st.MaxOccurrences
+	stored.OnInsufficientFunds = st.OnInsufficientFunds
+	stored.Status = st.Status
+	stored.NextRunAt = st.NextRunAt
+	stored.RetryAt = st.RetryAt
+	stored.RetryCount = st.RetryCount
+	stored.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryScheduledTransferStore) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledTransfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	now := time.Now()
+	var due []*models.ScheduledTransfer
+	for _, st := range s.m.schedules {
+		at := scheduledRunAt(st)
+		if st.Status == "active" && at != nil && !at.After(now) &&
+			(st.LockedUntil == nil || st.LockedUntil.Before(now)) {
+			due = append(due, st)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return scheduledRunAt(due[i]).Before(*scheduledRunAt(due[j])) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	lockedUntil := now.Add(lease)
+	result := make([]models.ScheduledTransfer, 0, len(due))
+	for _, st := range due {
+		st.LockedUntil = &lockedUntil
+		result = append(result, *st)
+	}
+	return result, nil
+}
+
+// scheduledRunAt — COALESCE(retry_at, next_run_at)
+func scheduledRunAt(st *models.ScheduledTransfer) *time.Time {
+	if st.RetryAt != nil {
+		return st.RetryAt
+	}
+	return st.NextRunAt
+}
+
+func (s *MemoryScheduledTransferStore) ReleaseLock(ctx context.Context, id string) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	if st, ok := s.m.schedules[id]; ok {
+		st.LockedUntil = nil
+	}
+	return nil
+}
+
+func (s *MemoryScheduledTransferStore) ListFailed(ctx context.Context, limit int) ([]models.FailedJob, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	last := make(map[string]*models.ScheduledTransferRun)
+	for _, run := range s.m.runs {
+		last[run.ScheduledTransferID] = run
+	}
+
+	var jobs []models.FailedJob
+	for id, run := range last {
+		st := s.m.schedules[id]
+		if st == nil || st.Status != "paused" || run.Status != "failed" {
+			continue
+		}
+		jobs = append(jobs, models.FailedJob{
+			Kind:     "scheduled_transfer",
+			ID:       id,
+			UserID:   st.UserID,
+			Status:   st.Status,
+			Error:    run.Error,
+			FailedAt: run.ExecutedAt,
+		})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].FailedAt.After(jobs[j].FailedAt) })
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (s *MemoryScheduledTransferStore) Reactivate(ctx context.Context, id string) (*models.ScheduledTransfer, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	st, ok := s.m.schedules[id]
+	if !ok || st.Status != "paused" {
+		return nil, ErrScheduledTransferNotPaused
+	}
+
+	now := time.Now()
+	st.Status = "active"
+	st.RetryCount = 0
+	st.RetryAt = &now
+	st.LockedUntil = nil
+	st.UpdatedAt = now
+
+	result := *st
+	return &result, nil
+}
+
+func (s *MemoryScheduledTransferStore) FinishRun(ctx context.Context, st *models.ScheduledTransfer, run *models.ScheduledTransferRun) error {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	run.ID = uuid.New().String()
+	run.ScheduledTransferID = st.ID
+	run.ExecutedAt = time.Now()
+	stored := *run
+	s.m.runs = append(s.m.runs, &stored)
+
+	current, ok := s.m.schedules[st.ID]
+	if !ok || current.LockedUntil == nil || st.LockedUntil == nil || !current.LockedUntil.Equal(*st.LockedUntil) {
+		return nil
+	}
+	current.Occurrences = st.Occurrences
+	current.NextRunAt = st.NextRunAt
+	current.RetryCount = st.RetryCount
+	current.RetryAt = st.RetryAt
+	if current.Status == "active" {
+		current.Status = st.Status
+	}
+	current.LockedUntil = nil
+	current.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *MemoryScheduledTransferStore) GetRuns(ctx context.Context, scheduledTransferID string, limit int) ([]models.ScheduledTransferRun, error) {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+
+	var runs []models.ScheduledTransferRun
+	for i := len(s.m.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if s.m.runs[i].ScheduledTransferID == scheduledTransferID {
+			runs = append(runs, *s.m.runs[i])
+		}
+	}
+	return runs, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/utils"
+)
+
+var ErrNotificationNotFound = errors.New("уведомление не найдено")
+
+type NotificationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewNotificationRepository(db *pgxpool.Pool) *NotificationRepository {
+	utils.LogSuccess("NotificationRepository", "Инициализирован репозиторий уведомлений")
+	return &NotificationRepository{db: db}
+}
+
+// GetPreferences возвращает настройки пользователя или настройки по
+// умолчанию, если он их не менял
+func (r *NotificationRepository) GetPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error) {
+	query := `
+		SELECT email_enabled, sms_enabled, inbox_enabled, incoming_transfer, low_balance,
+		       low_balance_threshold, scheduled_transfer_failed, language
+		FROM notification_preferences WHERE user_id = $1`
+
+	var p models.NotificationPreferences
+	err := r.db.QueryRow(ctx, query, userID).Scan(&p.EmailEnabled, &p.SMSEnabled, &p.InboxEnabled,
+		&p.IncomingTransfer, &p.LowBalance, &p.LowBalanceThreshold, &p.ScheduledTransferFailed, &p.Language)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.DefaultNotificationPreferences(), nil
+	}
+	if err != nil {
+		return p, fmt.Errorf("ошибка чтения настроек уведомлений: %w", err)
+	}
+	return p, nil
+}
+
+func (r *NotificationRepository) SavePreferences(ctx context.Context, userID string, p models.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, email_enabled, sms_enabled, inbox_enabled, incoming_transfer,
+		                                      low_balance, low_balance_threshold, scheduled_transfer_failed, language)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email_enabled = EXCLUDED.email_enabled,
+			sms_enabled = EXCLUDED.sms_enabled,
+			inbox_enabled = EXCLUDED.inbox_enabled,
+			incoming_transfer = EXCLUDED.incoming_transfer,
+			low_balance = EXCLUDED.low_balance,
+			low_balance_threshold = EXCLUDED.low_balance_threshold,
+			scheduled_transfer_failed = EXCLUDED.scheduled_transfer_failed,
+			language = EXCLUDED.language,
+			updated_at = NOW()`
+
+	utils.LogDB("UPDATE NOTIFICATION PREFERENCES", fmt.Sprintf("Настройки уведомлений пользователя %s", userID))
+
+	_, err := r.db.Exec(ctx, query, userID, p.EmailEnabled, p.SMSEnabled, p.InboxEnabled, p.IncomingTransfer,
+		p.LowBalance, p.LowBalanceThreshold, p.ScheduledTransferFailed, p.Language)
+	if err != nil {
+		utils.LogError("NotificationRepository", fmt.Sprintf("Ошибка сохранения настроек уведомлений %s", userID), err)
+		return err
+	}
+	return nil
+}
+
+// AddNotification кладёт уведомление во входящие пользователя
+func (r *NotificationRepository) AddNotification(ctx context.Context, userID, kind, subject, body string) error {
+	_, err := r.db.Exec(ctx,
+		`INSERT INTO notifications (user_id, kind, subject, body) VALUES ($1, $2, $3, $4)`,
+		userID, kind, subject, body)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения уведомления: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications возвращает последние limit уведомлений пользователя,
+// новые первыми
+func (r *NotificationRepository) ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := `
+		SELECT id, kind, subject, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения уведомлений: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Subject, &n.Body, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта уведомлений: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead отмечает уведомление прочитанным; повторная отметка не ошибка
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id string) error {
+	result, err := r.db.Exec(ctx,
+		`UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка отметки уведомления: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и
+// возвращает, сколько их было непрочитано
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	result, err := r.db.Exec(ctx,
+		`UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка отметки уведомлений: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
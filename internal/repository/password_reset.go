@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/utils"
+)
+
+var ErrInvalidResetToken = errors.New("токен сброса пароля недействителен или истёк")
+
+// resetTokensKeep — сколько хранятся выпущенные токены: их число за
+// последний час ограничивает частоту запросов сброса
+const resetTokensKeep = 24 * time.Hour
+
+type PasswordResetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(db *pgxpool.Pool) *PasswordResetRepository {
+	utils.LogSuccess("PasswordResetRepository", "Инициализирован репозиторий токенов сброса пароля")
+	return &PasswordResetRepository{db: db}
+}
+
+// Create сохраняет хеш нового токена и удаляет старые токены пользователя
+func (r *PasswordResetRepository) Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error {
+	utils.LogDB("CREATE PASSWORD RESET", fmt.Sprintf("Токен сброса пароля пользователя %s", userID))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1 AND created_at < $2`,
+		userID, time.Now().Add(-resetTokensKeep))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления старых токенов: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, tokenHash, expiresAt)
+	if err != nil {
+		utils.LogError("PasswordResetRepository", fmt.Sprintf("Ошибка создания токена сброса для %s", userID), err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *PasswordResetRepository) CountSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at > $2`,
+		userID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта токенов сброса: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PasswordResetRepository) GetValid(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	query := `
+		SELECT t.id, t.user_id, t.expires_at, t.created_at
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL
+		WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > NOW()`
+
+	token := &models.PasswordResetToken{}
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(&token.ID, &token.UserID, &token.ExpiresAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidResetToken
+		}
+		return nil, fmt.Errorf("ошибка поиска токена сброса: %w", err)
+	}
+	return token, nil
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash, passwordHash string) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Условие на used_at делает погашение однократным и при параллельных запросах
+	var userID string
+	err = tx.QueryRow(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrInvalidResetToken
+		}
+		return "", fmt.Errorf("ошибка погашения токена сброса: %w", err)
+	}
+
+	utils.LogDB("RESET PASSWORD", fmt.Sprintf("Сброс пароля пользователя %s", userID))
+
+	result, err := tx.Exec(ctx, `
+		UPDATE users
+		SET password_hash = $2, session_version = session_version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL`, userID, passwordHash)
+	if err != nil {
+		utils.LogError("PasswordResetRepository", fmt.Sprintf("Ошибка сброса пароля пользователя %s", userID), err)
+		return "", err
+	}
+	if result.RowsAffected() == 0 {
+		return "", ErrInvalidResetToken
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка погашения остальных токенов: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	utils.LogSuccess("PasswordResetRepository", "Пароль пользователя %s сброшен, токены сброса погашены", userID)
+	return userID, nil
+}
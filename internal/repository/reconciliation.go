@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+)
+
+var (
+	ErrReconciliationReportNotFound = errors.New("отчёт сверки не найден")
+	ErrReconciliationInProgress     = errors.New("сверка уже выполняется")
+)
+
+// InitialAccountBalance — стартовый баланс, который начисляется при открытии счёта
+const InitialAccountBalance = 100.00
+
+// reconciliationLockKey — ключ advisory lock, чтобы сверку одновременно
+// выполнял только один экземпляр API или CLI
+const reconciliationLockKey = 7_340_001
+
+const reconciliationReportColumns = `
+	id, triggered_by, status, accounts_checked, discrepancy_count,
+	total_difference, error, started_at, finished_at
+`
+
+// expectedBalancesQuery рассчитывает ожидаемый баланс каждого счёта:
+// стартовый депозит + входящие суммы + начисленные комиссии − списания.
+// Системный счёт открывается с нулевым балансом и получает комиссии.
+// Транзакции, удалённые по истечении срока хранения, учтены в purged_balance.
+const expectedBalancesQuery = `
+	WITH incoming AS (
+		SELECT to_account_id AS id, SUM(amount) AS total FROM transactions GROUP BY to_account_id
+	), outgoing AS (
+		SELECT from_account_id AS id, SUM(total_debit) AS total FROM transactions GROUP BY from_account_id
+	), fees AS (
+		SELECT fee_account_id AS id, SUM(fee_amount) AS total FROM transactions GROUP BY fee_account_id
+	)
+	SELECT a.id, a.status, a.balance,
+	       CASE WHEN a.id = $1 THEN 0 ELSE $2::numeric END
+	       + a.purged_balance + COALESCE(i.total, 0) + COALESCE(f.total, 0) - COALESCE(o.total, 0) AS expected
+	FROM accounts a
+	LEFT JOIN incoming i ON i.id = a.id
+	LEFT JOIN outgoing o ON o.id = a.id
+	LEFT JOIN fees f ON f.id = a.id
+	ORDER BY a.id
+`
+
+type ReconciliationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReconciliationRepository(db *pgxpool.Pool) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+func scanReconciliationReport(row pgx.Row) (*models.ReconciliationReport, error) {
+	var r models.ReconciliationReport
+	err := row.Scan(
+		&r.ID,
+		&r.TriggeredBy,
+		&r.Status,
+		&r.AccountsChecked,
+		&r.DiscrepancyCount,
+		&r.TotalDifference,
+		&r.Error,
+		&r.StartedAt,
+		&r.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Run выполняет сверку и сохраняет отчёт. Балансы и история читаются в одной
+// транзакции REPEATABLE READ, поэтому переводы, идущие во время сверки,
+// не дают ложных расхождений.
+func (r *ReconciliationRepository) Run(ctx context.Context, triggeredBy string) (*models.ReconciliationReport, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения соединения: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", reconciliationLockKey).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("ошибка получения блокировки сверки: %w", err)
+	}
+	if !locked {
+		return nil, ErrReconciliationInProgress
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", reconciliationLockKey)
+
+	report, err := scanReconciliationReport(conn.QueryRow(ctx, `
+		INSERT INTO reconciliation_reports (triggered_by) VALUES ($1)
+		RETURNING `+reconciliationReportColumns,
+		triggeredBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания отчёта сверки: %w", err)
+	}
+
+	if err := r.reconcile(ctx, conn.Conn(), report); err != nil {
+		message := err.Error()
+		report.Status = "failed"
+		report.Error = &message
+		_, _ = conn.Exec(context.Background(), `
+			UPDATE reconciliation_reports SET status = 'failed', error = $2, finished_at = NOW()
+			WHERE id = $1
+		`, report.ID, message)
+		return report, err
+	}
+
+	return report, nil
+}
+
+func (r *ReconciliationRepository) reconcile(ctx context.Context, conn *pgx.Conn, report *models.ReconciliationReport) error {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, expectedBalancesQuery, SystemBankAccountID, InitialAccountBalance)
+	if err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("ошибка расчёта ожидаемых балансов: %w", err)
+	}
+
+	for rows.Next() {
+		var d models.BalanceDiscrepancy
+		if err := rows.Scan(&d.AccountID, &d.AccountStatus, &d.ActualBalance, &d.ExpectedBalance); err != nil {
+			rows.Close()
+			tx.Rollback(ctx)
+			return fmt.Errorf("ошибка чтения баланса: %w", err)
+		}
+		report.AccountsChecked++
+
+		d.Difference = roundMoney(d.ActualBalance - d.ExpectedBalance)
+		if d.Difference != 0 {
+			report.Discrepancies = append(report.Discrepancies, d)
+			report.TotalDifference += d.Difference
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("ошибка расчёта ожидаемых балансов: %w", err)
+	}
+	tx.Rollback(ctx)
+
+	report.DiscrepancyCount = len(report.Discrepancies)
+	report.TotalDifference = roundMoney(report.TotalDifference)
+	report.Status = "completed"
+
+	tx, err = conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if len(report.Discrepancies) > 0 {
+		copyRows := make([][]any, len(report.Discrepancies))
+		for i, d := range report.Discrepancies {
+			copyRows[i] = []any{report.ID, d.AccountID, d.AccountStatus, d.ExpectedBalance, d.ActualBalance, d.Difference}
+		}
+		_, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"reconciliation_discrepancies"},
+			[]string{"report_id", "account_id", "account_status", "expected_balance", "actual_balance", "difference"},
+			pgx.CopyFromRows(copyRows),
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения расхождений: %w", err)
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE reconciliation_reports
+		SET status = 'completed', accounts_checked = $2, discrepancy_count = $3,
+		    total_difference = $4, finished_at = NOW()
+		WHERE id = $1
+		RETURNING finished_at
+	`, report.ID, report.AccountsChecked, report.DiscrepancyCount, report.TotalDifference).Scan(&report.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения отчёта сверки: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReconciliationRepository) List(ctx context.Context, limit int) ([]models.ReconciliationReport, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+reconciliationReportColumns+`
+		FROM reconciliation_reports
+		ORDER BY started_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения отчётов сверки: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []models.ReconciliationReport
+	for rows.Next() {
+		report, err := scanReconciliationReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования отчёта сверки: %w", err)
+		}
+		reports = append(reports, *report)
+	}
+
+	return reports, rows.Err()
+}
+
+func (r *ReconciliationRepository) GetByID(ctx context.Context, id string) (*models.ReconciliationReport, error) {
+	report, err := scanReconciliationReport(r.db.QueryRow(ctx,
+		`SELECT `+reconciliationReportColumns+` FROM reconciliation_reports WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReconciliationReportNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения отчёта сверки: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT account_id, account_status, expected_balance, actual_balance, difference
+		FROM reconciliation_discrepancies
+		WHERE report_id = $1
+		ORDER BY ABS(difference) DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения расхождений: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.BalanceDiscrepancy
+		if err := rows.Scan(&d.AccountID, &d.AccountStatus, &d.ExpectedBalance, &d.ActualBalance, &d.Difference); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования расхождения: %w", err)
+		}
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	return report, rows.Err()
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/utils"
+)
+
+var (
+	ErrScheduledTransferNotFound  = errors.New("регулярный перевод не найден")
+	ErrScheduledTransferNotPaused = errors.New("регулярный перевод не приостановлен")
+)
+
+const scheduledTransferColumns = `
+	id, user_id, type, from_account_id, to_account_id, amount,
+	cron_expression, interval_seconds, start_at, end_at,
+	max_occurrences, occurrences, next_run_at,
+	on_insufficient_funds, retry_interval_seconds, max_retries,
+	retry_count, retry_at, status, created_at, updated_at,
+	locked_until
+`
+
+type ScheduledTransferRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewScheduledTransferRepository(db *pgxpool.Pool) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+func scanScheduledTransfer(row pgx.Row) (*models.ScheduledTransfer, error) {
+	var st models.ScheduledTransfer
+	err := row.Scan(
+		&st.ID,
+		&st.UserID,
+		&st.Type,
+		&st.FromAccountID,
+		&st.ToAccountID,
+		&st.Amount,
+		&st.CronExpression,
+		&st.IntervalSeconds,
+		&st.StartAt,
+		&st.EndAt,
+		&st.MaxOccurrences,
+		&st.Occurrences,
+		&st.NextRunAt,
+		&st.OnInsufficientFunds,
+		&st.RetryIntervalSeconds,
+		&st.MaxRetries,
+		&st.RetryCount,
+		&st.RetryAt,
+		&st.Status,
+		&st.CreatedAt,
+		&st.UpdatedAt,
+		&st.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *ScheduledTransferRepository) Create(ctx context.Context, st *models.ScheduledTransfer) (*models.ScheduledTransfer, error) {
+	query := `
+		INSERT INTO scheduled_transfers (
+			user_id, type, from_account_id, to_account_id, amount,
+			cron_expression, interval_seconds, start_at, end_at,
+			max_occurrences, next_run_at, on_insufficient_funds,
+			retry_interval_seconds, max_retries, status
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING ` + scheduledTransferColumns
+
+	created, err := scanScheduledTransfer(r.db.QueryRow(ctx, query,
+		st.UserID, st.Type, st.FromAccountID, st.ToAccountID, st.Amount,
+		st.CronExpression, st.IntervalSeconds, st.StartAt, st.EndAt,
+		st.MaxOccurrences, st.NextRunAt, st.OnInsufficientFunds,
+		st.RetryIntervalSeconds, st.MaxRetries, st.Status,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания регулярного перевода: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *ScheduledTransferRepository) GetByID(ctx context.Context, id string) (*models.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = $1`
+
+	st, err := scanScheduledTransfer(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScheduledTransferNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения регулярного перевода: %w", err)
+	}
+
+	return st, nil
+}
+
+func (r *ScheduledTransferRepository) GetByUserID(ctx context.Context, userID string) ([]models.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения регулярных переводов: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.ScheduledTransfer
+	for rows.Next() {
+		st, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования регулярного перевода: %w", err)
+		}
+		result = append(result, *st)
+	}
+
+	return result, rows.Err()
+}
+
+// Update сохраняет изменяемые пользователем поля и рассчитанное время следующего запуска
+func (r *ScheduledTransferRepository) Update(ctx context.Context, st *models.ScheduledTransfer) error {
+	query := `
+		UPDATE scheduled_transfers
+		SET amount = $2, end_at = $3, max_occurrences = $4,
+		    on_insufficient_funds = $5, status = $6, next_run_at = $7,
+		    retry_at = $8, retry_count = $9, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query,
+		st.ID, st.Amount, st.EndAt, st.MaxOccurrences,
+		st.OnInsufficientFunds, st.Status, st.NextRunAt,
+		st.RetryAt, st.RetryCount,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления регулярного перевода: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrScheduledTransferNotFound
+	}
+
+	return nil
+}
+
+// ClaimDue выбирает активные переводы, срок выполнения которых наступил, и
+// берёт их в аренду на lease. SKIP LOCKED и аренда гарантируют, что один
+// перевод не будет выполнен одновременно несколькими экземплярами API.
+func (r *ScheduledTransferRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledTransfer, error) {
+	query := `
+		UPDATE scheduled_transfers
+		SET locked_until = NOW() + $2::bigint * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM scheduled_transfers
+			WHERE status = 'active'
+			  AND COALESCE(retry_at, next_run_at) <= NOW()
+			  AND (locked_until IS NULL OR locked_until < NOW())
+			ORDER BY COALESCE(retry_at, next_run_at)
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledTransferColumns
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки регулярных переводов к выполнению: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.ScheduledTransfer
+	for rows.Next() {
+		st, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования регулярного перевода: %w", err)
+		}
+		result = append(result, *st)
+	}
+
+	return result, rows.Err()
+}
+
+// ReleaseLock снимает аренду, если задачу не удалось поставить в очередь
+func (r *ScheduledTransferRepository) ReleaseLock(ctx context.Context, id string) error {
+	_, err := r.db.Exec(ctx, `UPDATE scheduled_transfers SET locked_until = NULL WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка снятия блокировки регулярного перевода: %w", err)
+	}
+	return nil
+}
+
+// ListFailed возвращает переводы, приостановленные планировщиком после
+// неустранимой ошибки последнего выполнения
+func (r *ScheduledTransferRepository) ListFailed(ctx context.Context, limit int) ([]models.FailedJob, error) {
+	query := `
+		SELECT st.id, st.user_id, st.status, last_run.error, last_run.executed_at
+		FROM scheduled_transfers st
+		JOIN LATERAL (
+			SELECT status, error, executed_at
+			FROM scheduled_transfer_runs
+			WHERE scheduled_transfer_id = st.id
+			ORDER BY executed_at DESC
+			LIMIT 1
+		) last_run ON TRUE
+		WHERE st.status = 'paused' AND last_run.status = 'failed'
+		ORDER BY last_run.executed_at DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения неудачных регулярных переводов: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.FailedJob
+	for rows.Next() {
+		job := models.FailedJob{Kind: "scheduled_transfer"}
+		if err := rows.Scan(&job.ID, &job.UserID, &job.Status, &job.Error, &job.FailedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования регулярного перевода: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Reactivate возобновляет приостановленный перевод с немедленным выполнением:
+// планировщик подхватит его на ближайшем проходе
+func (r *ScheduledTransferRepository) Reactivate(ctx context.Context, id string) (*models.ScheduledTransfer, error) {
+	query := `
+		UPDATE scheduled_transfers
+		SET status = 'active', retry_count = 0, retry_at = NOW(),
+		    locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'paused'
+		RETURNING ` + scheduledTransferColumns
+
+	st, err := scanScheduledTransfer(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrScheduledTransferNotPaused
+		}
+		return nil, fmt.Errorf("ошибка возобновления регулярного перевода: %w", err)
+	}
+
+	return st, nil
+}
+
+// FinishRun записывает результат выполнения и новое состояние расписания
+// в одной транзакции, снимая аренду. Расписание обновляется, только пока
+// аренда st.LockedUntil не перехвачена другим экземпляром. Статус меняется
+// только у активного перевода: пауза или отмена, сделанные во время
+// выполнения, сохраняются, а счётчики и время следующего запуска — нет.
+func (r *ScheduledTransferRepository) FinishRun(ctx context.Context, st *models.ScheduledTransfer, run *models.ScheduledTransferRun) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO scheduled_transfer_runs (
+			scheduled_transfer_id, scheduled_for, attempt, status, transaction_id, error
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, executed_at
+	`,
+		st.ID, run.ScheduledFor, run.Attempt, run.Status, run.TransactionID, run.Error,
+	).Scan(&run.ID, &run.ExecutedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка записи выполнения регулярного перевода: %w", err)
+	}
+	run.ScheduledTransferID = st.ID
+
+	result, err := tx.Exec(ctx, `
+		UPDATE scheduled_transfers
+		SET occurrences = $2, next_run_at = $3, retry_count = $4, retry_at = $5,
+		    status = CASE WHEN status = 'active' THEN $6 ELSE status END,
+		    locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND locked_until = $7
+	`,
+		st.ID, st.Occurrences, st.NextRunAt, st.RetryCount, st.RetryAt, st.Status, st.LockedUntil,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления регулярного перевода: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		utils.LogWarning("ScheduledTransferRepo", "Аренда регулярного перевода %s истекла, расписание не обновлено", st.ID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ScheduledTransferRepository) GetRuns(ctx context.Context, scheduledTransferID string, limit int) ([]models.ScheduledTransferRun, error) {
+	query := `
+		SELECT id, scheduled_transfer_id, scheduled_for, attempt, status,
+		       transaction_id, error, executed_at
+		FROM scheduled_transfer_runs
+		WHERE scheduled_transfer_id = $1
+		ORDER BY executed_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, query, scheduledTransferID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории выполнений: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []models.ScheduledTransferRun
+	for rows.Next() {
+		var run models.ScheduledTransferRun
+		err := rows.Scan(
+			&run.ID,
+			&run.ScheduledTransferID,
+			&run.ScheduledFor,
+			&run.Attempt,
+			&run.Status,
+			&run.TransactionID,
+			&run.Error,
+			&run.ExecutedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования выполнения: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
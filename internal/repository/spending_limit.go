@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+)
+
+var (
+	ErrLimitExceeded = errors.New("превышен лимит расходов")
+)
+
+// Уровни лимитов
+const (
+	LimitScopeBank    = "bank"
+	LimitScopeUser    = "user"
+	LimitScopeAccount = "account"
+)
+
+// querier — общее подмножество pgxpool.Pool и pgx.Tx, чтобы одни и те же
+// запросы работали и вне транзакции, и внутри неё
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// SpendingLimitState — лимиты всех уровней и использование для одного счёта
+type SpendingLimitState struct {
+	UserID       string
+	AccountID    string
+	Bank         models.SpendingLimits
+	User         models.SpendingLimits
+	Account      models.SpendingLimits
+	UserUsage    models.SpendingUsage
+	AccountUsage models.SpendingUsage
+}
+
+// EffectiveUser — лимиты пользователя, ограниченные потолками банка
+func (s *SpendingLimitState) EffectiveUser() models.SpendingLimits {
+	return models.SpendingLimits{
+		SingleMax:     minFloatLimit(s.Bank.SingleMax, s.User.SingleMax),
+		DailyMax:      minFloatLimit(s.Bank.DailyMax, s.User.DailyMax),
+		MonthlyMax:    minFloatLimit(s.Bank.MonthlyMax, s.User.MonthlyMax),
+		DailyCountMax: minIntLimit(s.Bank.DailyCountMax, s.User.DailyCountMax),
+	}
+}
+
+// SingleMax — максимальная сумма одной операции с учётом всех уровней
+func (s *SpendingLimitState) SingleMax() *float64 {
+	return minFloatLimit(s.EffectiveUser().SingleMax, s.Account.SingleMax)
+}
+
+// Check проверяет, что операция на amount укладывается во все лимиты
+func (s *SpendingLimitState) Check(amount float64) error {
+	if max := s.SingleMax(); max != nil && amount > *max {
+		return i18n.Errorf(ErrLimitExceeded, "detail.limit_single", *max)
+	}
+
+	return s.checkUsage(amount, 1)
+}
+
+// CheckBatchItem проверяет позицию пакета best_effort. Пакет уже израсходовал
+// одну операцию лимита количества, как и all_or_nothing в CheckBatch,
+// поэтому проверяются только суммы.
+func (s *SpendingLimitState) CheckBatchItem(amount float64) error {
+	if max := s.SingleMax(); max != nil && amount > *max {
+		return i18n.Errorf(ErrLimitExceeded, "detail.limit_single", *max)
+	}
+
+	return s.checkUsage(amount, 0)
+}
+
+// CheckBatch проверяет пакет переводов: каждая сумма — против лимита одной
+// операции, нарастающий итог — против дневного и месячного лимитов. Пакет
+// считается одной операцией для лимита количества. Возвращает индекс позиции,
+// на которой лимит превышен, или -1, если превышен лимит количества.
+func (s *SpendingLimitState) CheckBatch(amounts []float64) (int, error) {
+	single := s.SingleMax()
+
+	var total float64
+	for i, amount := range amounts {
+		if single != nil && amount > *single {
+			return i, i18n.Errorf(ErrLimitExceeded, "detail.limit_single", *single)
+		}
+		total += amount
+		if err := s.checkUsage(total, 0); err != nil {
+			return i, err
+		}
+	}
+
+	if err := s.checkUsage(total, 1); err != nil {
+		return -1, err
+	}
+
+	return 0, nil
+}
+
+// CheckPayout проверяет выплату остатка при удалении пользователя как
+// обычный перевод. earlier — выплаты с других счетов в той же транзакции:
+// подсчёт использования их не видит, поэтому они добавляются здесь.
+func (s *SpendingLimitState) CheckPayout(amount float64, earlier []models.Transaction) error {
+	for _, payout := range earlier {
+		addSpendingUsage(&s.UserUsage, payout.Amount, true)
+	}
+
+	return s.Check(amount)
+}
+
+func (s *SpendingLimitState) checkUsage(amount float64, count int) error {
+	if err := checkUsage("limit.level.account", s.Account, s.AccountUsage, amount, count); err != nil {
+		return err
+	}
+
+	return checkUsage("limit.level.user", s.EffectiveUser(), s.UserUsage, amount, count)
+}
+
+func checkUsage(level i18n.Key, limits models.SpendingLimits, usage models.SpendingUsage, amount float64, count int) error {
+	if limits.DailyMax != nil && usage.Daily+amount > *limits.DailyMax {
+		return i18n.Errorf(ErrLimitExceeded, "detail.limit_daily", level, *limits.DailyMax, usage.Daily)
+	}
+	if limits.MonthlyMax != nil && usage.Monthly+amount > *limits.MonthlyMax {
+		return i18n.Errorf(ErrLimitExceeded, "detail.limit_monthly", level, *limits.MonthlyMax, usage.Monthly)
+	}
+	if count > 0 && limits.DailyCountMax != nil && usage.DailyCount+count > *limits.DailyCountMax {
+		return i18n.Errorf(ErrLimitExceeded, "detail.limit_daily_count", *limits.DailyCountMax, level)
+	}
+	return nil
+}
+
+type SpendingLimitRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSpendingLimitRepository(db *pgxpool.Pool) *SpendingLimitRepository {
+	return &SpendingLimitRepository{db: db}
+}
+
+// GetState возвращает лимиты и текущее использование для счёта
+func (r *SpendingLimitRepository) GetState(ctx context.Context, userID, accountID string) (*SpendingLimitState, error) {
+	return loadSpendingLimitState(ctx, r.db, userID, accountID)
+}
+
+// GetCeilings возвращает потолки банка
+func (r *SpendingLimitRepository) GetCeilings(ctx context.Context) (models.SpendingLimits, error) {
+	var limits models.SpendingLimits
+	err := r.db.QueryRow(ctx, `
+		SELECT single_max, daily_max, monthly_max, daily_count_max
+		FROM spending_limits WHERE scope = 'bank'
+	`).Scan(&limits.SingleMax, &limits.DailyMax, &limits.MonthlyMax, &limits.DailyCountMax)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return limits, fmt.Errorf("ошибка получения потолков лимитов: %w", err)
+	}
+	return limits, nil
+}
+
+// SetUserLimits сохраняет лимиты пользователя по всем его счетам
+func (r *SpendingLimitRepository) SetUserLimits(ctx context.Context, userID string, limits models.SpendingLimits) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO spending_limits (scope, user_id, single_max, daily_max, monthly_max, daily_count_max)
+		VALUES ('user', $1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) WHERE scope = 'user' DO UPDATE
+		SET single_max = EXCLUDED.single_max, daily_max = EXCLUDED.daily_max,
+		    monthly_max = EXCLUDED.monthly_max, daily_count_max = EXCLUDED.daily_count_max,
+		    updated_at = NOW()
+	`, userID, limits.SingleMax, limits.DailyMax, limits.MonthlyMax, limits.DailyCountMax)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения лимитов пользователя: %w", err)
+	}
+	return nil
+}
+
+// SetAccountLimits сохраняет лимиты отдельного счёта
+func (r *SpendingLimitRepository) SetAccountLimits(ctx context.Context, accountID string, limits models.SpendingLimits) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO spending_limits (scope, account_id, single_max, daily_max, monthly_max, daily_count_max)
+		VALUES ('account', $1, $2, $3, $4, $5)
+		ON CONFLICT (account_id) WHERE scope = 'account' DO UPDATE
+		SET single_max = EXCLUDED.single_max, daily_max = EXCLUDED.daily_max,
+		    monthly_max = EXCLUDED.monthly_max, daily_count_max = EXCLUDED.daily_count_max,
+		    updated_at = NOW()
+	`, accountID, limits.SingleMax, limits.DailyMax, limits.MonthlyMax, limits.DailyCountMax)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения лимитов счёта: %w", err)
+	}
+	return nil
+}
+
+// enforceSpendingLimits проверяет лимиты внутри транзакции списания.
+// Строка пользователя блокируется FOR UPDATE, поэтому параллельные переводы
+// с разных счетов одного пользователя проверяются последовательно и не могут
+// вместе превысить лимит. Вызывается до блокировки счетов.
+func enforceSpendingLimits(ctx context.Context, tx pgx.Tx, accountID string, amount float64) error {
+	state, err := lockSpendingLimitState(ctx, tx, accountID)
+	if err != nil {
+		return err
+	}
+
+	return state.Check(amount)
+}
+
+// lockSpendingLimitState блокирует владельца счёта и загружает его лимиты
+func lockSpendingLimitState(ctx context.Context, tx pgx.Tx, accountID string) (*SpendingLimitState, error) {
+	var userID string
+	err := tx.QueryRow(ctx, `
+		SELECT u.id FROM users u
+		JOIN accounts a ON a.user_id = u.id
+		WHERE a.id = $1
+		FOR UPDATE OF u
+	`, accountID).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("ошибка блокировки пользователя: %w", err)
+	}
+
+	return loadSpendingLimitState(ctx, tx, userID, accountID)
+}
+
+func loadSpendingLimitState(ctx context.Context, q querier, userID, accountID string) (*SpendingLimitState, error) {
+	state := &SpendingLimitState{UserID: userID, AccountID: accountID}
+
+	rows, err := q.Query(ctx, `
+		SELECT scope, single_max, daily_max, monthly_max, daily_count_max
+		FROM spending_limits
+		WHERE scope = 'bank'
+		   OR (scope = 'user' AND user_id = $1)
+		   OR (scope = 'account' AND account_id = $2)
+	`, userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения лимитов: %w", err)
+	}
+	for rows.Next() {
+		var scope string
+		var limits models.SpendingLimits
+		if err := rows.Scan(&scope, &limits.SingleMax, &limits.DailyMax, &limits.MonthlyMax, &limits.DailyCountMax); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка сканирования лимитов: %w", err)
+		}
+		switch scope {
+		case LimitScopeBank:
+			state.Bank = limits
+		case LimitScopeUser:
+			state.User = limits
+		case LimitScopeAccount:
+			state.Account = limits
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения лимитов: %w", err)
+	}
+
+	// Учитываются исходящие переводы и платежи; возвраты и комиссии не в счёт.
+	// Сутки и месяц считаются по UTC независимо от часового пояса сессии БД.
+	err = q.QueryRow(ctx, `
+		WITH period AS (
+			SELECT
+				date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS day_start,
+				date_trunc('month', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS month_start
+		)
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE from_account_id = $2 AND created_at >= period.day_start), 0),
+			COALESCE(SUM(amount) FILTER (WHERE from_account_id = $2), 0),
+			COUNT(*) FILTER (WHERE from_account_id = $2 AND created_at >= period.day_start),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= period.day_start), 0),
+			COALESCE(SUM(amount), 0),
+			COUNT(*) FILTER (WHERE created_at >= period.day_start)
+		FROM transactions, period
+		WHERE type IN ('transfer', 'payment')
+		  AND created_at >= period.month_start
+		  AND from_account_id IN (SELECT id FROM accounts WHERE user_id = $1)
+	`, userID, accountID).Scan(
+		&state.AccountUsage.Daily,
+		&state.AccountUsage.Monthly,
+		&state.AccountUsage.DailyCount,
+		&state.UserUsage.Daily,
+		&state.UserUsage.Monthly,
+		&state.UserUsage.DailyCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подсчёта использования лимитов: %w", err)
+	}
+
+	return state, nil
+}
+
+func addSpendingUsage(usage *models.SpendingUsage, amount float64, today bool) {
+	usage.Monthly += amount
+	if today {
+		usage.Daily += amount
+		usage.DailyCount++
+	}
+}
+
+func minFloatLimit(a, b *float64) *float64 {
+	if a == nil {
+		return b
+	}
+	if b == nil || *a < *b {
+		return a
+	}
+	return b
+}
+
+func minIntLimit(a, b *int) *int {
+	if a == nil {
+		return b
+	}
+	if b == nil || *a < *b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+)
+
+func money(v float64) *float64 { return &v }
+
+func count(v int) *int { return &v }
+
+// limitFailure проверяет, что err — превышение лимита с подробностями key
+// для уровня level (пустой level — без уровня, как у лимита одной операции)
+func limitFailure(t *testing.T, err error, key string, level i18n.Key) {
+	t.Helper()
+
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("ошибка %v, ожидалось превышение лимита", err)
+	}
+	var detail *i18n.Error
+	if !errors.As(err, &detail) {
+		t.Fatalf("ошибка %v без подробностей", err)
+	}
+	if detail.Key != key {
+		t.Errorf("подробности %s, ожидались %s", detail.Key, key)
+	}
+	if level == "" {
+		return
+	}
+	for _, arg := range detail.Args {
+		if arg == level {
+			return
+		}
+	}
+	t.Errorf("подробности %s без уровня %s: %v", detail.Key, level, detail.Args)
+}
+
+func TestEffectiveUserCappedByBank(t *testing.T) {
+	state := &SpendingLimitState{
+		Bank: models.SpendingLimits{SingleMax: money(1000), DailyMax: money(5000), DailyCountMax: count(50)},
+		User: models.SpendingLimits{SingleMax: money(300), DailyMax: money(9000), MonthlyMax: money(20000)},
+	}
+
+	effective := state.EffectiveUser()
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"ниже потолка — лимит пользователя", *effective.SingleMax, 300.0},
+		{"выше потолка — потолок банка", *effective.DailyMax, 5000.0},
+		{"нет потолка — лимит пользователя", *effective.MonthlyMax, 20000.0},
+		{"нет лимита пользователя — потолок", *effective.DailyCountMax, 50},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: %v, ожидалось %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	state.Account.SingleMax = money(200)
+	if max := state.SingleMax(); max == nil || *max != 200 {
+		t.Errorf("SingleMax = %v, ожидалось 200 (лимит счёта)", max)
+	}
+}
+
+func TestSpendingLimitCheck(t *testing.T) {
+	const (
+		account = i18n.Key("limit.level.account")
+		user    = i18n.Key("limit.level.user")
+	)
+
+	tests := []struct {
+		name   string
+		state  SpendingLimitState
+		amount float64
+		key    string // пустой — операция проходит
+		level  i18n.Key
+	}{
+		{"без лимитов", SpendingLimitState{}, 1e9, "", ""},
+
+		{"разовый счёта: ровно лимит", SpendingLimitState{Account: models.SpendingLimits{SingleMax: money(100)}}, 100, "", ""},
+		{"разовый счёта", SpendingLimitState{Account: models.SpendingLimits{SingleMax: money(100)}}, 100.01, "detail.limit_single", ""},
+		{"разовый пользователя", SpendingLimitState{User: models.SpendingLimits{SingleMax: money(100)}}, 150, "detail.limit_single", ""},
+		{"разовый банка", SpendingLimitState{Bank: models.SpendingLimits{SingleMax: money(100)}}, 150, "detail.limit_single", ""},
+		{"разовый пользователя выше потолка банка", SpendingLimitState{
+			Bank: models.SpendingLimits{SingleMax: money(100)},
+			User: models.SpendingLimits{SingleMax: money(500)},
+		}, 150, "detail.limit_single", ""},
+
+		{"дневной счёта: остаток", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyMax: money(100)},
+			AccountUsage: models.SpendingUsage{Daily: 70, Monthly: 70, DailyCount: 1},
+		}, 30, "", ""},
+		{"дневной счёта", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyMax: money(100)},
+			AccountUsage: models.SpendingUsage{Daily: 70, Monthly: 70, DailyCount: 1},
+		}, 30.01, "detail.limit_daily", account},
+		{"дневной пользователя по всем счетам", SpendingLimitState{
+			User:         models.SpendingLimits{DailyMax: money(100)},
+			AccountUsage: models.SpendingUsage{Daily: 10, Monthly: 10, DailyCount: 1},
+			UserUsage:    models.SpendingUsage{Daily: 90, Monthly: 90, DailyCount: 3},
+		}, 20, "detail.limit_daily", user},
+		{"дневной потолок банка", SpendingLimitState{
+			Bank:      models.SpendingLimits{DailyMax: money(100)},
+			User:      models.SpendingLimits{DailyMax: money(1000)},
+			UserUsage: models.SpendingUsage{Daily: 90, Monthly: 90, DailyCount: 3},
+		}, 20, "detail.limit_daily", user},
+
+		{"месячный счёта", SpendingLimitState{
+			Account:      models.SpendingLimits{MonthlyMax: money(1000)},
+			AccountUsage: models.SpendingUsage{Monthly: 950},
+		}, 60, "detail.limit_monthly", account},
+		{"месячный пользователя", SpendingLimitState{
+			User:      models.SpendingLimits{MonthlyMax: money(1000)},
+			UserUsage: models.SpendingUsage{Monthly: 950},
+		}, 60, "detail.limit_monthly", user},
+		{"месячный потолок банка", SpendingLimitState{
+			Bank:      models.SpendingLimits{MonthlyMax: money(1000)},
+			UserUsage: models.SpendingUsage{Monthly: 950},
+		}, 60, "detail.limit_monthly", user},
+		{"месячный: вчерашние операции в счёт, дневной свободен", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyMax: money(100), MonthlyMax: money(1000)},
+			AccountUsage: models.SpendingUsage{Monthly: 990},
+		}, 50, "detail.limit_monthly", account},
+
+		{"число операций счёта: последняя", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyCountMax: count(3)},
+			AccountUsage: models.SpendingUsage{DailyCount: 2},
+		}, 1, "", ""},
+		{"число операций счёта", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyCountMax: count(3)},
+			AccountUsage: models.SpendingUsage{DailyCount: 3},
+		}, 1, "detail.limit_daily_count", account},
+		{"число операций пользователя", SpendingLimitState{
+			User:      models.SpendingLimits{DailyCountMax: count(3)},
+			UserUsage: models.SpendingUsage{DailyCount: 3},
+		}, 1, "detail.limit_daily_count", user},
+		{"число операций: потолок банка", SpendingLimitState{
+			Bank:      models.SpendingLimits{DailyCountMax: count(3)},
+			User:      models.SpendingLimits{DailyCountMax: count(10)},
+			UserUsage: models.SpendingUsage{DailyCount: 3},
+		}, 1, "detail.limit_daily_count", user},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.state.Check(tt.amount)
+			if tt.key == "" {
+				if err != nil {
+					t.Fatalf("Check(%.2f): %v", tt.amount, err)
+				}
+				return
+			}
+			limitFailure(t, err, tt.key, tt.level)
+		})
+	}
+}
+
+func TestSpendingLimitCheckBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   SpendingLimitState
+		amounts []float64
+		index   int
+		key     string
+	}{
+		{"пакет — одна операция", SpendingLimitState{
+			User:      models.SpendingLimits{DailyCountMax: count(3)},
+			UserUsage: models.SpendingUsage{DailyCount: 2},
+		}, []float64{10, 10, 10, 10}, 0, ""},
+		{"лимит числа операций исчерпан", SpendingLimitState{
+			User:      models.SpendingLimits{DailyCountMax: count(3)},
+			UserUsage: models.SpendingUsage{DailyCount: 3},
+		}, []float64{10}, -1, "detail.limit_daily_count"},
+		{"разовый лимит на позиции", SpendingLimitState{
+			Account: models.SpendingLimits{SingleMax: money(50)},
+		}, []float64{10, 60, 10}, 1, "detail.limit_single"},
+		{"нарастающий итог против дневного", SpendingLimitState{
+			Account:      models.SpendingLimits{DailyMax: money(100)},
+			AccountUsage: models.SpendingUsage{Daily: 20, Monthly: 20, DailyCount: 1},
+		}, []float64{30, 30, 30}, 2, "detail.limit_daily"},
+		{"нарастающий итог против месячного пользователя", SpendingLimitState{
+			User:      models.SpendingLimits{MonthlyMax: money(100)},
+			UserUsage: models.SpendingUsage{Monthly: 50},
+		}, []float64{20, 20, 20}, 2, "detail.limit_monthly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, err := tt.state.CheckBatch(tt.amounts)
+			if tt.key == "" {
+				if err != nil {
+					t.Fatalf("CheckBatch: %v", err)
+				}
+				return
+			}
+			limitFailure(t, err, tt.key, "")
+			if index != tt.index {
+				t.Errorf("позиция %d, ожидалась %d", index, tt.index)
+			}
+		})
+	}
+}
+
+func TestSpendingLimitCheckBatchItem(t *testing.T) {
+	// Пакет best_effort уже занял операцию лимита количества
+	state := SpendingLimitState{
+		Account:      models.SpendingLimits{DailyMax: money(100), DailyCountMax: count(1)},
+		AccountUsage: models.SpendingUsage{Daily: 60, Monthly: 60, DailyCount: 1},
+	}
+	if err := state.CheckBatchItem(40); err != nil {
+		t.Errorf("позиция в пределах лимита: %v", err)
+	}
+	limitFailure(t, state.CheckBatchItem(40.01), "detail.limit_daily", "limit.level.account")
+	limitFailure(t, state.Check(1), "detail.limit_daily_count", "limit.level.account")
+}
+
+func TestSpendingLimitCheckPayout(t *testing.T) {
+	state := SpendingLimitState{
+		User: models.SpendingLimits{DailyMax: money(100), DailyCountMax: count(2)},
+	}
+	earlier := []models.Transaction{{Amount: 70}}
+
+	if err := state.CheckPayout(30, earlier); err != nil {
+		t.Fatalf("выплата в пределах лимита: %v", err)
+	}
+
+	state = SpendingLimitState{User: models.SpendingLimits{DailyMax: money(100)}}
+	limitFailure(t, state.CheckPayout(30.01, earlier), "detail.limit_daily", "limit.level.user")
+
+	state = SpendingLimitState{User: models.SpendingLimits{DailyCountMax: count(2)}}
+	limitFailure(t, state.CheckPayout(1, []models.Transaction{{Amount: 1}, {Amount: 1}}), "detail.limit_daily_count", "limit.level.user")
+}
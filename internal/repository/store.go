@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bank-prototype/internal/models"
+)
+
+// Интерфейсы хранилищ, от которых зависят сервисы. Основная реализация —
+// репозитории поверх PostgreSQL, для тестов — MemoryStore.
+
+// AccountStore — счета пользователей
+type AccountStore interface {
+	Create(ctx context.Context, userID string) (*models.Account, error)
+	// GetByID возвращает ErrAccountNotFound, если счёта нет
+	GetByID(ctx context.Context, accountID string) (*models.Account, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.Account, error)
+	CountActiveAccountsByUserID(ctx context.Context, userID string) (int, error)
+	UpdateStatus(ctx context.Context, accountID, status string) error
+	ChangeStatus(ctx context.Context, accountID, from, to string) error
+	GetBalance(ctx context.Context, accountID string) (float64, error)
+	UpdateBalance(ctx context.Context, accountID string, newBalance float64) error
+}
+
+// TransactionStore — проводки между счетами. ExecuteTransfer и ExecuteRefund
+// атомарны: при ошибке балансы не меняются.
+type TransactionStore interface {
+	// ExecuteTransfer возвращает ErrDuplicateTransaction, если проводка с
+	// непустым opts.IdempotencyKey уже есть
+	ExecuteTransfer(
+		ctx context.Context,
+		fromAccountID, toAccountID string,
+		amount, feeAmount float64,
+		feePercent int,
+		txType string,
+		opts TransferOptions,
+	) (*models.Transaction, error)
+	// ExecuteBatch проводит все переводы пакета или ни одного; ошибка
+	// позиции возвращается как *BatchItemError
+	ExecuteBatch(ctx context.Context, fromAccountID string, items []BatchTransfer, feePercent int) ([]models.Transaction, error)
+	ExecuteRefund(ctx context.Context, params RefundParams) (*models.RefundResult, error)
+	GetByID(ctx context.Context, transactionID string) (*models.Transaction, error)
+	// GetByIdempotencyKey возвращает ErrTransactionNotFound, если проводки с ключом нет
+	GetByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error)
+	GetByAccountID(ctx context.Context, accountID string) ([]models.Transaction, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.Transaction, error)
+	GetByOriginalID(ctx context.Context, originalID string) ([]models.Transaction, error)
+}
+
+// UserStore — учётные записи пользователей
+type UserStore interface {
+	// Create заполняет ID и CreatedAt; ErrUserExists, если имя занято
+	Create(ctx context.Context, user *models.User) error
+	GetByName(ctx context.Context, name string) (*models.User, error)
+	GetByID(ctx context.Context, userID string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// SoftDelete закрывает счета, переводя остатки на payoutAccountID, и
+	// обезличивает пользователя; история транзакций сохраняется
+	SoftDelete(ctx context.Context, userID, payoutAccountID string) (*models.UserDeletion, error)
+	SetRole(ctx context.Context, name, role string) error
+	// UpdateProfile сохраняет DisplayName, Email и Phone и заполняет
+	// UpdatedAt; ErrEmailTaken или ErrPhoneTaken, если контакт занят
+	UpdateProfile(ctx context.Context, user *models.User) error
+	// UpdatePassword меняет хеш пароля и возвращает новую версию сессий
+	UpdatePassword(ctx context.Context, userID, passwordHash string) (int, error)
+}
+
+// PasswordResetStore — одноразовые токены сброса пароля. Токены
+// хранятся и ищутся по хешу.
+type PasswordResetStore interface {
+	Create(ctx context.Context, userID, tokenHash string, expiresAt time.Time) error
+	// CountSince считает токены пользователя, выпущенные после since
+	CountSince(ctx context.Context, userID string, since time.Time) (int, error)
+	// GetValid возвращает неиспользованный и не истёкший токен
+	// действующего пользователя, иначе ErrInvalidResetToken
+	GetValid(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// Consume атомарно погашает токен, меняет пароль, увеличивает версию
+	// сессий и погашает остальные токены пользователя. Возвращает ID
+	// пользователя; ErrInvalidResetToken, если токен уже недействителен.
+	Consume(ctx context.Context, tokenHash, passwordHash string) (string, error)
+}
+
+// NotificationStore — настройки уведомлений и входящие уведомления
+type NotificationStore interface {
+	GetPreferences(ctx context.Context, userID string) (models.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, userID string, preferences models.NotificationPreferences) error
+	AddNotification(ctx context.Context, userID, kind, subject, body string) error
+	ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID string) (int, error)
+	// MarkRead возвращает ErrNotificationNotFound, если уведомления нет
+	// или оно чужое
+	MarkRead(ctx context.Context, userID, id string) error
+	MarkAllRead(ctx context.Context, userID string) (int, error)
+}
+
+// BatchStore — пакеты переводов и результаты их позиций
+type BatchStore interface {
+	// Create сохраняет пакет в статусе pending и нумерует позиции
+	Create(ctx context.Context, batch *models.TransferBatch) (*models.TransferBatch, error)
+	// GetByID возвращает ErrBatchNotFound, если пакета нет
+	GetByID(ctx context.Context, id string) (*models.TransferBatch, error)
+	ListStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.FailedJob, error)
+	SetStatus(ctx context.Context, id, status string) error
+	UpdateItem(ctx context.Context, batchID string, item *models.TransferBatchItem) error
+	Finish(ctx context.Context, batch *models.TransferBatch) error
+}
+
+// AuthorizationStore — двухфазные платежи: холды и их завершение
+type AuthorizationStore interface {
+	// Create резервирует HeldAmount на счёте плательщика
+	Create(ctx context.Context, a *models.Authorization) (*models.Authorization, error)
+	// GetByID возвращает ErrAuthorizationNotFound, если авторизации нет
+	GetByID(ctx context.Context, id string) (*models.Authorization, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.Authorization, error)
+	Capture(ctx context.Context, id string, amount, feeAmount float64) (*models.Authorization, *models.Transaction, error)
+	Release(ctx context.Context, id string) (*models.Authorization, error)
+	// ExpireDue пропускает авторизации, заблокированные параллельным
+	// capture или release
+	ExpireDue(ctx context.Context, limit int) ([]models.Authorization, error)
+}
+
+// ScheduledTransferStore — регулярные переводы и история их выполнений
+type ScheduledTransferStore interface {
+	Create(ctx context.Context, st *models.ScheduledTransfer) (*models.ScheduledTransfer, error)
+	// GetByID возвращает ErrScheduledTransferNotFound, если перевода нет
+	GetByID(ctx context.Context, id string) (*models.ScheduledTransfer, error)
+	GetByUserID(ctx context.Context, userID string) ([]models.ScheduledTransfer, error)
+	Update(ctx context.Context, st *models.ScheduledTransfer) error
+	// ClaimDue берёт наступившие переводы в аренду на lease
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.ScheduledTransfer, error)
+	ReleaseLock(ctx context.Context, id string) error
+	ListFailed(ctx context.Context, limit int) ([]models.FailedJob, error)
+	// Reactivate возвращает ErrScheduledTransferNotPaused, если перевод
+	// не приостановлен
+	Reactivate(ctx context.Context, id string) (*models.ScheduledTransfer, error)
+	// FinishRun сохраняет выполнение; статус меняется, только если перевод
+	// всё ещё активен и аренда st.LockedUntil не перехвачена
+	FinishRun(ctx context.Context, st *models.ScheduledTransfer, run *models.ScheduledTransferRun) error
+	GetRuns(ctx context.Context, scheduledTransferID string, limit int) ([]models.ScheduledTransferRun, error)
+}
+
+var (
+	_ AccountStore           = (*AccountRepository)(nil)
+	_ TransactionStore       = (*TransactionRepository)(nil)
+	_ UserStore              = (*UserRepository)(nil)
+	_ PasswordResetStore     = (*PasswordResetRepository)(nil)
+	_ NotificationStore      = (*NotificationRepository)(nil)
+	_ BatchStore             = (*BatchRepository)(nil)
+	_ AuthorizationStore     = (*AuthorizationRepository)(nil)
+	_ ScheduledTransferStore = (*ScheduledTransferRepository)(nil)
+)
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"bank-prototype/internal/models"
@@ -14,9 +17,60 @@ import (
 )
 
 var (
-	ErrTransactionFailed = errors.New("транзакция не выполнена")
+	ErrTransactionFailed        = errors.New("транзакция не выполнена")
+	ErrTransactionNotFound      = errors.New("транзакция не найдена")
+	ErrTransactionNotRefundable = errors.New("транзакцию нельзя вернуть")
+	ErrRefundExceedsAmount      = errors.New("сумма возврата превышает невозвращённый остаток")
+	// ErrDuplicateTransaction — проводка с таким ключом идемпотентности уже есть
+	ErrDuplicateTransaction = errors.New("транзакция с этим ключом идемпотентности уже проведена")
 )
 
+const transactionColumns = `
+	id, type, from_account_id, to_account_id, amount,
+	fee_percent, fee_amount, total_debit, fee_account_id,
+	status, created_at, original_transaction_id,
+	refunded_amount, refunded_fee_amount
+`
+
+func scanTransaction(row pgx.Row) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.Type,
+		&transaction.FromAccountID,
+		&transaction.ToAccountID,
+		&transaction.Amount,
+		&transaction.FeePercent,
+		&transaction.FeeAmount,
+		&transaction.TotalDebit,
+		&transaction.FeeAccountID,
+		&transaction.Status,
+		&transaction.CreatedAt,
+		&transaction.OriginalTransactionID,
+		&transaction.RefundedAmount,
+		&transaction.RefundedFeeAmount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func scanTransactions(rows pgx.Rows) ([]models.Transaction, error) {
+	defer rows.Close()
+
+	var transactions []models.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования транзакции: %w", err)
+		}
+		transactions = append(transactions, *tx)
+	}
+
+	return transactions, rows.Err()
+}
+
 type TransactionRepository struct {
 	db *pgxpool.Pool
 }
@@ -25,12 +79,24 @@ func NewTransactionRepository(db *pgxpool.Pool) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
+// TransferOptions — необязательные параметры проводки для внутренних вызовов
+type TransferOptions struct {
+	// IdempotencyKey сохраняется в проводке: повтор с тем же ключом
+	// откатывается с ErrDuplicateTransaction, и деньги не списываются дважды
+	IdempotencyKey string
+	// BatchItem — позиция пакета, уже учтённого в лимите количества как одна
+	// операция: проверяются только суммы
+	BatchItem bool
+}
+
+// ExecuteTransfer проводит перевод или платёж
 func (r *TransactionRepository) ExecuteTransfer(
 	ctx context.Context,
 	fromAccountID, toAccountID string,
 	amount, feeAmount float64,
 	feePercent int,
 	txType string,
+	opts TransferOptions,
 ) (*models.Transaction, error) {
 
 	tx, err := r.db.Begin(ctx)
@@ -41,36 +107,65 @@ func (r *TransactionRepository) ExecuteTransfer(
 
 	totalDebit := amount + feeAmount
 
-	var fromBalance float64
-	err = tx.QueryRow(ctx,
-		"SELECT balance FROM accounts WHERE id = $1 AND status = 'active' FOR UPDATE",
-		fromAccountID,
-	).Scan(&fromBalance)
-
+	state, err := lockSpendingLimitState(ctx, tx, fromAccountID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, ErrAccountNotFound
-		}
-		return nil, fmt.Errorf("ошибка получения баланса отправителя: %w", err)
+		return nil, err
 	}
-
-	if fromBalance < totalDebit {
-		return nil, ErrInsufficientBalance
+	if opts.BatchItem {
+		err = state.CheckBatchItem(amount)
+	} else {
+		err = state.Check(amount)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	var toStatus string
-	err = tx.QueryRow(ctx,
-		"SELECT status FROM accounts WHERE id = $1 FOR UPDATE",
-		toAccountID,
-	).Scan(&toStatus)
+	// Блокируем счета в порядке ID, как в возвратах и пакетах: иначе
+	// встречные переводы A→B и B→A взаимно блокируются. Системный счёт
+	// тоже входит в набор, так как получает комиссию.
+	accountIDs := []string{fromAccountID, toAccountID}
+	if feeAmount > 0 {
+		accountIDs = append(accountIDs, SystemBankAccountID)
+	}
 
+	rows, err := tx.Query(ctx,
+		"SELECT id, balance - held_amount, status FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		accountIDs,
+	)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, ErrAccountNotFound
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	// Учитываем только доступный остаток: средства под активными холдами тратить нельзя
+	balances := make(map[string]float64, len(accountIDs))
+	statuses := make(map[string]string, len(accountIDs))
+	for rows.Next() {
+		var id, status string
+		var balance float64
+		if err := rows.Scan(&id, &balance, &status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения счёта: %w", err)
 		}
-		return nil, fmt.Errorf("ошибка проверки счёта получателя: %w", err)
+		balances[id] = balance
+		statuses[id] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
 	}
 
+	fromStatus, ok := statuses[fromAccountID]
+	if !ok || fromStatus != "active" {
+		return nil, ErrAccountNotFound
+	}
+	if balances[fromAccountID] < totalDebit {
+		return nil, ErrInsufficientBalance
+	}
+
+	toStatus, ok := statuses[toAccountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
 	if toStatus != "active" {
 		return nil, ErrAccountClosed
 	}
@@ -91,12 +186,15 @@ func (r *TransactionRepository) ExecuteTransfer(
 		return nil, fmt.Errorf("ошибка зачисления на счёт получателя: %w", err)
 	}
 
-	_, err = tx.Exec(ctx,
-		"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
-		feeAmount, SystemBankAccountID,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка начисления комиссии: %w", err)
+	// Без комиссии системный счёт не блокировался, и трогать его нельзя
+	if feeAmount > 0 {
+		_, err = tx.Exec(ctx,
+			"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+			feeAmount, SystemBankAccountID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка начисления комиссии: %w", err)
+		}
 	}
 
 	transactionID := uuid.New().String()
@@ -104,33 +202,21 @@ func (r *TransactionRepository) ExecuteTransfer(
 		INSERT INTO transactions (
 			id, type, from_account_id, to_account_id,
 			amount, fee_percent, fee_amount, total_debit,
-			fee_account_id, status, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'completed', NOW())
-		RETURNING id, type, from_account_id, to_account_id, amount,
-		          fee_percent, fee_amount, total_debit, fee_account_id,
-		          status, created_at
-	`
+			fee_account_id, status, created_at, idempotency_key
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'completed', NOW(), NULLIF($10, ''))
+		RETURNING ` + transactionColumns
 
-	var transaction models.Transaction
-	err = tx.QueryRow(ctx, query,
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query,
 		transactionID, txType, fromAccountID, toAccountID,
 		amount, feePercent, feeAmount, totalDebit,
-		SystemBankAccountID,
-	).Scan(
-		&transaction.ID,
-		&transaction.Type,
-		&transaction.FromAccountID,
-		&transaction.ToAccountID,
-		&transaction.Amount,
-		&transaction.FeePercent,
-		&transaction.FeeAmount,
-		&transaction.TotalDebit,
-		&transaction.FeeAccountID,
-		&transaction.Status,
-		&transaction.CreatedAt,
-	)
+		SystemBankAccountID, opts.IdempotencyKey,
+	))
 
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrDuplicateTransaction
+		}
 		return nil, fmt.Errorf("ошибка записи транзакции: %w", err)
 	}
 
@@ -142,48 +228,40 @@ func (r *TransactionRepository) ExecuteTransfer(
 	utils.LogSuccess("TransactionRepo", fmt.Sprintf(" Транзакция %s выполнена: %s → %s (%.2f + %.2f комиссии)",
 		transactionID, fromAccountID, toAccountID, amount, feeAmount))
 
-	return &transaction, nil
+	return transaction, nil
 }
 
 func (r *TransactionRepository) GetByID(ctx context.Context, transactionID string) (*models.Transaction, error) {
-	query := `
-		SELECT id, type, from_account_id, to_account_id, amount,
-		       fee_percent, fee_amount, total_debit, fee_account_id,
-		       status, created_at
-		FROM transactions
-		WHERE id = $1
-	`
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
 
-	var transaction models.Transaction
-	err := r.db.QueryRow(ctx, query, transactionID).Scan(
-		&transaction.ID,
-		&transaction.Type,
-		&transaction.FromAccountID,
-		&transaction.ToAccountID,
-		&transaction.Amount,
-		&transaction.FeePercent,
-		&transaction.FeeAmount,
-		&transaction.TotalDebit,
-		&transaction.FeeAccountID,
-		&transaction.Status,
-		&transaction.CreatedAt,
-	)
+	transaction, err := scanTransaction(r.db.QueryRow(ctx, query, transactionID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения транзакции: %w", err)
+	}
+
+	return transaction, nil
+}
+
+// GetByIdempotencyKey возвращает проводку, сохранённую с ключом key
+func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE idempotency_key = $1`
 
+	transaction, err := scanTransaction(r.db.QueryRow(ctx, query, key))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, errors.New("транзакция не найдена")
+			return nil, ErrTransactionNotFound
 		}
 		return nil, fmt.Errorf("ошибка получения транзакции: %w", err)
 	}
 
-	return &transaction, nil
+	return transaction, nil
 }
 
 func (r *TransactionRepository) GetByAccountID(ctx context.Context, accountID string) ([]models.Transaction, error) {
-	query := `
-		SELECT id, type, from_account_id, to_account_id, amount,
-		       fee_percent, fee_amount, total_debit, fee_account_id,
-		       status, created_at
+	query := `SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE from_account_id = $1 OR to_account_id = $1
 		ORDER BY created_at DESC
@@ -193,78 +271,279 @@ func (r *TransactionRepository) GetByAccountID(ctx context.Context, accountID st
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения транзакций: %w", err)
 	}
+
+	return scanTransactions(rows)
+}
+
+func (r *TransactionRepository) GetByUserID(ctx context.Context, userID string) ([]models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE from_account_id IN (SELECT id FROM accounts WHERE user_id = $1)
+		   OR to_account_id IN (SELECT id FROM accounts WHERE user_id = $1)
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения транзакций пользователя: %w", err)
+	}
+
+	return scanTransactions(rows)
+}
+
+// Export передаёт в fn транзакции за период [from, to) в порядке создания
+func (r *TransactionRepository) Export(ctx context.Context, from, to time.Time, fn func(models.Transaction) error) error {
+	query := `SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE created_at >= $1 AND created_at < $2
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		return fmt.Errorf("ошибка выгрузки транзакций: %w", err)
+	}
 	defer rows.Close()
 
-	var transactions []models.Transaction
 	for rows.Next() {
-		var tx models.Transaction
-		err := rows.Scan(
-			&tx.ID,
-			&tx.Type,
-			&tx.FromAccountID,
-			&tx.ToAccountID,
-			&tx.Amount,
-			&tx.FeePercent,
-			&tx.FeeAmount,
-			&tx.TotalDebit,
-			&tx.FeeAccountID,
-			&tx.Status,
-			&tx.CreatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования транзакции: %w", err)
+			return fmt.Errorf("ошибка сканирования транзакции: %w", err)
+		}
+		if err := fn(*transaction); err != nil {
+			return err
 		}
-		transactions = append(transactions, tx)
 	}
 
-	return transactions, nil
+	return rows.Err()
 }
 
-func (r *TransactionRepository) GetByUserID(ctx context.Context, userID string) ([]models.Transaction, error) {
-	query := `
-		SELECT t.id, t.type, t.from_account_id, t.to_account_id, t.amount,
-		       t.fee_percent, t.fee_amount, t.total_debit, t.fee_account_id,
-		       t.status, t.created_at
-		FROM transactions t
-		INNER JOIN accounts a1 ON t.from_account_id = a1.id
-		WHERE a1.user_id = $1
-		UNION
-		SELECT t.id, t.type, t.from_account_id, t.to_account_id, t.amount,
-		       t.fee_percent, t.fee_amount, t.total_debit, t.fee_account_id,
-		       t.status, t.created_at
-		FROM transactions t
-		INNER JOIN accounts a2 ON t.to_account_id = a2.id
-		WHERE a2.user_id = $1
-		ORDER BY created_at DESC
+// GetByOriginalID возвращает компенсирующие транзакции, связанные с исходной
+func (r *TransactionRepository) GetByOriginalID(ctx context.Context, originalID string) ([]models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE original_transaction_id = $1
+		ORDER BY created_at
 	`
 
-	rows, err := r.db.Query(ctx, query, userID)
+	rows, err := r.db.Query(ctx, query, originalID)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка получения транзакций пользователя: %w", err)
+		return nil, fmt.Errorf("ошибка получения возвратов: %w", err)
 	}
-	defer rows.Close()
 
-	var transactions []models.Transaction
+	return scanTransactions(rows)
+}
+
+// RefundParams описывает компенсирующую операцию по исходной транзакции
+type RefundParams struct {
+	OriginalID string
+	Type       string   // "refund" или "reversal"
+	Amount     *float64 // nil — вернуть весь невозвращённый остаток
+	RefundFee  bool     // вернуть плательщику пропорциональную часть комиссии
+}
+
+// ExecuteRefund в одной транзакции БД создаёт компенсирующую транзакцию
+// (получатель → плательщик) и, при необходимости, возврат комиссии с
+// системного счёта банка. Исходная транзакция блокируется FOR UPDATE, поэтому
+// параллельные возвраты не могут в сумме превысить её сумму.
+func (r *TransactionRepository) ExecuteRefund(ctx context.Context, params RefundParams) (*models.RefundResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	original, err := scanTransaction(tx.QueryRow(ctx,
+		`SELECT `+transactionColumns+` FROM transactions WHERE id = $1 FOR UPDATE`,
+		params.OriginalID,
+	))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения исходной транзакции: %w", err)
+	}
+
+	if original.Type != "transfer" && original.Type != "payment" {
+		return nil, ErrTransactionNotRefundable
+	}
+
+	remaining := roundMoney(original.Amount - original.RefundedAmount)
+	if remaining <= 0 {
+		return nil, ErrTransactionNotRefundable
+	}
+
+	amount := remaining
+	if params.Amount != nil {
+		amount = roundMoney(*params.Amount)
+		if amount > remaining {
+			return nil, ErrRefundExceedsAmount
+		}
+	}
+
+	var feeRefund float64
+	if params.RefundFee {
+		feeRemaining := roundMoney(original.FeeAmount - original.RefundedFeeAmount)
+		if amount == remaining {
+			// Последний возврат забирает остаток комиссии целиком, чтобы
+			// не накапливалась ошибка округления
+			feeRefund = feeRemaining
+		} else {
+			feeRefund = roundMoney(original.FeeAmount * amount / original.Amount)
+			if feeRefund > feeRemaining {
+				feeRefund = feeRemaining
+			}
+		}
+	}
+
+	// Блокируем счета в порядке ID, чтобы встречные возвраты и переводы
+	// не приводили к взаимной блокировке
+	accountIDs := []string{original.FromAccountID, original.ToAccountID}
+	if feeRefund > 0 {
+		accountIDs = append(accountIDs, SystemBankAccountID)
+	}
+
+	rows, err := tx.Query(ctx,
+		"SELECT id, balance - held_amount, status FROM accounts WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		accountIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	balances := make(map[string]float64, len(accountIDs))
+	statuses := make(map[string]string, len(accountIDs))
 	for rows.Next() {
-		var tx models.Transaction
-		err := rows.Scan(
-			&tx.ID,
-			&tx.Type,
-			&tx.FromAccountID,
-			&tx.ToAccountID,
-			&tx.Amount,
-			&tx.FeePercent,
-			&tx.FeeAmount,
-			&tx.TotalDebit,
-			&tx.FeeAccountID,
-			&tx.Status,
-			&tx.CreatedAt,
-		)
+		var id, status string
+		var balance float64
+		if err := rows.Scan(&id, &balance, &status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения счёта: %w", err)
+		}
+		balances[id] = balance
+		statuses[id] = status
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+
+	for _, id := range accountIDs {
+		status, ok := statuses[id]
+		if !ok {
+			return nil, ErrAccountNotFound
+		}
+		if status != "active" {
+			return nil, ErrAccountClosed
+		}
+	}
+
+	if balances[original.ToAccountID] < amount {
+		return nil, ErrInsufficientBalance
+	}
+	if feeRefund > 0 && balances[SystemBankAccountID] < feeRefund {
+		return nil, ErrInsufficientBalance
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance - $1 WHERE id = $2",
+		amount, original.ToAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка списания со счёта получателя: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE accounts SET balance = balance + $1 WHERE id = $2",
+		amount+feeRefund, original.FromAccountID,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка зачисления на счёт плательщика: %w", err)
+	}
+
+	result := &models.RefundResult{}
+
+	result.Refund, err = insertCompensatingTransaction(ctx, tx, params.Type,
+		original.ToAccountID, original.FromAccountID, amount, original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if feeRefund > 0 {
+		if _, err := tx.Exec(ctx,
+			"UPDATE accounts SET balance = balance - $1 WHERE id = $2",
+			feeRefund, SystemBankAccountID,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка списания комиссии с системного счёта: %w", err)
+		}
+
+		result.FeeRefund, err = insertCompensatingTransaction(ctx, tx, "fee_refund",
+			SystemBankAccountID, original.FromAccountID, feeRefund, original.ID)
 		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования транзакции: %w", err)
+			return nil, err
 		}
-		transactions = append(transactions, tx)
 	}
 
-	return transactions, nil
+	result.Original, err = scanTransaction(tx.QueryRow(ctx, `
+		UPDATE transactions
+		SET refunded_amount = refunded_amount + $2,
+		    refunded_fee_amount = refunded_fee_amount + $3,
+		    status = CASE WHEN refunded_amount + $2 >= amount THEN 'refunded' ELSE 'partially_refunded' END
+		WHERE id = $1
+		RETURNING `+transactionColumns,
+		original.ID, amount, feeRefund,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обновления исходной транзакции: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка подтверждения транзакции: %w", err)
+	}
+
+	utils.LogSuccess("TransactionRepo", "Возврат %s по транзакции %s: %.2f (комиссия %.2f)",
+		result.Refund.ID, original.ID, amount, feeRefund)
+
+	return result, nil
+}
+
+func insertCompensatingTransaction(
+	ctx context.Context,
+	tx pgx.Tx,
+	txType, fromAccountID, toAccountID string,
+	amount float64,
+	originalID string,
+) (*models.Transaction, error) {
+	return insertFeelessTransaction(ctx, tx, txType, fromAccountID, toAccountID, amount, &originalID)
+}
+
+// insertFeelessTransaction записывает проведённую транзакцию без комиссии.
+// originalID — исходная транзакция для компенсирующих, nil для остальных.
+func insertFeelessTransaction(
+	ctx context.Context,
+	tx pgx.Tx,
+	txType, fromAccountID, toAccountID string,
+	amount float64,
+	originalID *string,
+) (*models.Transaction, error) {
+	query := `
+		INSERT INTO transactions (
+			id, type, from_account_id, to_account_id,
+			amount, fee_percent, fee_amount, total_debit,
+			fee_account_id, status, created_at, original_transaction_id
+		) VALUES ($1, $2, $3, $4, $5, 0, 0, $5, $6, 'completed', NOW(), $7)
+		RETURNING ` + transactionColumns
+
+	transaction, err := scanTransaction(tx.QueryRow(ctx, query,
+		uuid.New().String(), txType, fromAccountID, toAccountID,
+		amount, SystemBankAccountID, originalID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка записи транзакции %s: %w", txType, err)
+	}
+
+	return transaction, nil
+}
+
+// roundMoney округляет сумму до копеек
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
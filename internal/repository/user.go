@@ -4,11 +4,38 @@ import (
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/utils"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrUserNotFound = errors.New("пользователь не найден")
+	ErrUserExists   = errors.New("пользователь с таким именем уже существует")
+	ErrEmailTaken   = errors.New("email уже используется другим пользователем")
+	ErrPhoneTaken   = errors.New("телефон уже используется другим пользователем")
+)
+
+// userColumns — столбцы users в порядке scanUser. Незаданные контакты
+// хранятся как NULL: так уникальные индексы не мешают пустым значениям.
+const userColumns = `id, name, password_hash, role, COALESCE(display_name, ''), COALESCE(email, ''), COALESCE(phone, ''),
+	session_version, created_at, updated_at`
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(&user.ID, &user.Name, &user.PasswordHash, &user.Role, &user.DisplayName, &user.Email, &user.Phone,
+		&user.SessionVersion, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 type UserRepository struct {
 	db *pgxpool.Pool
 }
@@ -19,13 +46,21 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (name, password_hash) VALUES ($1, $2) RETURNING id, created_at`
+	query := `INSERT INTO users (name, password_hash, role) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at`
 
 	utils.LogDB("CREATE USER", fmt.Sprintf("Создание пользователя: %s", user.Name))
 
-	err := r.db.QueryRow(ctx, query, user.Name, user.PasswordHash).Scan(&user.ID, &user.CreatedAt)
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	err := r.db.QueryRow(ctx, query, user.Name, user.PasswordHash, user.Role).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		utils.LogError("UserRepository", fmt.Sprintf("Ошибка создания пользователя %s", user.Name), err)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return ErrUserExists
+		}
 		return err
 	}
 
@@ -34,14 +69,16 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 }
 
 func (r *UserRepository) GetByName(ctx context.Context, name string) (*models.User, error) {
-	query := `SELECT id, name, password_hash, created_at FROM users WHERE name = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE name = $1 AND deleted_at IS NULL`
 
 	utils.LogDB("GET USER", fmt.Sprintf("Поиск пользователя: %s", name))
 
-	user := &models.User{}
-	err := r.db.QueryRow(ctx, query, name).Scan(&user.ID, &user.Name, &user.PasswordHash, &user.CreatedAt)
+	user, err := scanUser(r.db.QueryRow(ctx, query, name))
 	if err != nil {
 		utils.LogWarning("UserRepository", fmt.Sprintf("Пользователь не найден: %s", name))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 
@@ -50,14 +87,16 @@ func (r *UserRepository) GetByName(ctx context.Context, name string) (*models.Us
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, userID string) (*models.User, error) {
-	query := `SELECT id, name, password_hash, created_at FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1 AND deleted_at IS NULL`
 
 	utils.LogDB("GET USER BY ID", fmt.Sprintf("Поиск пользователя по ID: %s", userID))
 
-	user := &models.User{}
-	err := r.db.QueryRow(ctx, query, userID).Scan(&user.ID, &user.Name, &user.PasswordHash, &user.CreatedAt)
+	user, err := scanUser(r.db.QueryRow(ctx, query, userID))
 	if err != nil {
 		utils.LogWarning("UserRepository", fmt.Sprintf("Пользователь с ID %s не найден", userID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 
@@ -65,22 +104,420 @@ func (r *UserRepository) GetByID(ctx context.Context, userID string) (*models.Us
 	return user, nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, userID string) error {
-	query := `DELETE FROM users WHERE id = $1`
+// GetByEmail ищет пользователя по email; email хранится в нижнем регистре
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1 AND deleted_at IS NULL`
+
+	utils.LogDB("GET USER BY EMAIL", "Поиск пользователя по email")
+
+	user, err := scanUser(r.db.QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		utils.LogError("UserRepository", "Ошибка поиска пользователя по email", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// SoftDelete удаляет пользователя, сохраняя историю операций: остатки
+// активных счетов переводятся на payoutAccountID транзакциями payout,
+// счета закрываются, регулярные переводы отменяются, а учётная запись
+// обезличивается и перестаёт находиться по имени и ID. Всё выполняется в
+// одной транзакции. Счёт выплаты чужой, поэтому выплаты проходят проверку
+// лимитов расходов, как переводы; при превышении — ErrLimitExceeded. Без
+// счёта выплаты остатков быть не должно; если счета изменились после
+// проверки в сервисе — ErrAccountStatusChanged.
+func (r *UserRepository) SoftDelete(ctx context.Context, userID, payoutAccountID string) (*models.UserDeletion, error) {
+	utils.LogDB("SOFT DELETE USER", fmt.Sprintf("Удаление пользователя: %s", userID))
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var locked bool
+	err = tx.QueryRow(ctx, `SELECT TRUE FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка блокировки пользователя: %w", err)
+	}
+
+	// Счета пользователя и счёт выплаты блокируются в порядке номеров, как
+	// в ExecuteTransfer, ExecuteRefund и ExecuteBatch, чтобы не
+	// взаимоблокироваться с ними
+	rows, err := tx.Query(ctx, `
+		SELECT id, user_id, balance, held_amount, status FROM accounts
+		WHERE user_id = $1 OR id = $2
+		ORDER BY id
+		FOR UPDATE`, userID, payoutAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка блокировки счетов: %w", err)
+	}
+	var accounts []models.Account
+	var payout *models.Account
+	for rows.Next() {
+		var account models.Account
+		if err := rows.Scan(&account.ID, &account.UserID, &account.Balance, &account.HeldAmount, &account.Status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("ошибка чтения счёта: %w", err)
+		}
+		if account.ID == payoutAccountID && account.UserID != userID {
+			payout = &account
+			continue
+		}
+		accounts = append(accounts, account)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения счетов: %w", err)
+	}
+
+	if payoutAccountID != "" {
+		if payout == nil {
+			return nil, ErrAccountNotFound
+		}
+		if payout.Status != "active" {
+			return nil, ErrAccountClosed
+		}
+	}
+
+	deletion := &models.UserDeletion{}
+	for _, account := range accounts {
+		if account.Status == "closed" {
+			continue
+		}
+		if account.Status != "active" || account.HeldAmount > 0 || (account.Balance > 0 && payout == nil) {
+			return nil, ErrAccountStatusChanged
+		}
+		deletion.ClosedAccounts = append(deletion.ClosedAccounts, account.ID)
+		if account.Balance <= 0 {
+			continue
+		}
+
+		// Строка пользователя уже заблокирована, как в lockSpendingLimitState
+		state, err := loadSpendingLimitState(ctx, tx, userID, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := state.CheckPayout(account.Balance, deletion.Payouts); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE accounts SET balance = 0 WHERE id = $1`, account.ID); err != nil {
+			return nil, fmt.Errorf("ошибка списания остатка счёта %s: %w", account.ID, err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE accounts SET balance = balance + $1 WHERE id = $2`, account.Balance, payout.ID); err != nil {
+			return nil, fmt.Errorf("ошибка зачисления остатка на счёт выплаты: %w", err)
+		}
+		transaction, err := insertFeelessTransaction(ctx, tx, "payout", account.ID, payout.ID, account.Balance, nil)
+		if err != nil {
+			return nil, err
+		}
+		deletion.Payouts = append(deletion.Payouts, *transaction)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE accounts SET status = 'closed' WHERE id = ANY($1)`, deletion.ClosedAccounts); err != nil {
+		return nil, fmt.Errorf("ошибка закрытия счетов: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE scheduled_transfers SET status = 'cancelled', updated_at = NOW()
+		WHERE user_id = $1 AND status IN ('active', 'paused')`, userID); err != nil {
+		return nil, fmt.Errorf("ошибка отмены регулярных переводов: %w", err)
+	}
+	// Тексты уведомлений содержат имя и суммы — удаляются вместе с настройками
+	if _, err := tx.Exec(ctx, `DELETE FROM notifications WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("ошибка удаления уведомлений: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("ошибка удаления настроек уведомлений: %w", err)
+	}
+
+	// Имя deleted-<id> освобождает прежнее имя и остаётся уникальным, пустой
+	// хеш не совпадает ни с одним паролем, новая версия сессий завершает токены
+	err = tx.QueryRow(ctx, `
+		UPDATE users
+		SET name = 'deleted-' || id, display_name = NULL, email = NULL, phone = NULL, password_hash = '',
+		    session_version = session_version + 1, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING deleted_at`, userID).Scan(&deletion.DeletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обезличивания пользователя: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("ошибка фиксации удаления пользователя: %w", err)
+	}
+
+	utils.LogSuccess("UserRepository", "Пользователь %s удалён: закрыто счетов %d, выплат остатков %d",
+		userID, len(deletion.ClosedAccounts), len(deletion.Payouts))
+	return deletion, nil
+}
+
+// purgeBatch — сколько удалённых пользователей PurgeDeleted выбирает за запрос
+const purgeBatch = 100
+
+// PurgeDeleted окончательно удаляет данные пользователей, удалённых раньше
+// deletedBefore. Транзакция удаляется, только если все её стороны —
+// системный счёт или счета таких пользователей: история действующих
+// клиентов не трогается. Итог удалённых транзакций переносится в
+// accounts.purged_balance, чтобы сверка балансов сходилась. Счёт удаляется,
+// когда на него больше ничто не ссылается, пользователь — когда у него не
+// осталось счетов; остальное ждёт следующего запуска.
+func (r *UserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (models.RetentionResult, error) {
+	var result models.RetentionResult
+
+	// Пользователи, которых пока нельзя удалить целиком, остаются в таблице,
+	// поэтому выборка идёт по курсору, а не с начала
+	var afterTime time.Time
+	var afterID string
+	for {
+		rows, err := r.db.Query(ctx, `
+			SELECT id::text, deleted_at FROM users
+			WHERE deleted_at < $1 AND (deleted_at, id::text) > ($2, $3)
+			ORDER BY deleted_at, id::text
+			LIMIT $4`, deletedBefore, afterTime, afterID, purgeBatch)
+		if err != nil {
+			return result, fmt.Errorf("ошибка поиска удалённых пользователей: %w", err)
+		}
+		var userIDs []string
+		for rows.Next() {
+			if err := rows.Scan(&afterID, &afterTime); err != nil {
+				rows.Close()
+				return result, fmt.Errorf("ошибка поиска удалённых пользователей: %w", err)
+			}
+			userIDs = append(userIDs, afterID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return result, fmt.Errorf("ошибка поиска удалённых пользователей: %w", err)
+		}
+
+		for _, userID := range userIDs {
+			if err := r.purgeUser(ctx, userID, deletedBefore, &result); err != nil {
+				return result, fmt.Errorf("пользователь %s: %w", userID, err)
+			}
+		}
+		if len(userIDs) < purgeBatch {
+			return result, nil
+		}
+	}
+}
+
+func (r *UserRepository) purgeUser(ctx context.Context, userID string, deletedBefore time.Time, result *models.RetentionResult) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Пользователя уже обрабатывает другой экземпляр API
+	var locked bool
+	err = tx.QueryRow(ctx, `SELECT TRUE FROM users WHERE id = $1 AND deleted_at < $2 FOR UPDATE SKIP LOCKED`,
+		userID, deletedBefore).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH expired AS (
+			SELECT a.id FROM accounts a JOIN users u ON u.id = a.user_id
+			WHERE u.deleted_at < $2 OR a.id = $3
+		)
+		SELECT t.id FROM transactions t
+		WHERE (t.from_account_id IN (SELECT id FROM accounts WHERE user_id = $1)
+		       OR t.to_account_id IN (SELECT id FROM accounts WHERE user_id = $1))
+		  AND t.from_account_id IN (SELECT id FROM expired)
+		  AND t.to_account_id IN (SELECT id FROM expired)`,
+		userID, deletedBefore, SystemBankAccountID)
+	if err != nil {
+		return fmt.Errorf("ошибка выбора транзакций: %w", err)
+	}
+	transactionIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("ошибка выбора транзакций: %w", err)
+	}
+
+	// Исходная транзакция остаётся, пока остаётся ссылающийся на неё возврат
+	rows, err = tx.Query(ctx, `
+		SELECT DISTINCT original_transaction_id::text FROM transactions
+		WHERE original_transaction_id = ANY($1::uuid[]) AND NOT id = ANY($1::uuid[])`, transactionIDs)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки возвратов: %w", err)
+	}
+	referenced, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("ошибка проверки возвратов: %w", err)
+	}
+	transactionIDs = slices.DeleteFunc(transactionIDs, func(id string) bool { return slices.Contains(referenced, id) })
+
+	if len(transactionIDs) > 0 {
+		statements := []string{
+			`UPDATE authorizations SET transaction_id = NULL WHERE transaction_id = ANY($1::uuid[])`,
+			`UPDATE scheduled_transfer_runs SET transaction_id = NULL WHERE transaction_id = ANY($1::uuid[])`,
+			`UPDATE transfer_batch_items SET transaction_id = NULL WHERE transaction_id = ANY($1::uuid[])`,
+			`UPDATE accounts a SET purged_balance = a.purged_balance + d.total
+			 FROM (
+				SELECT id, SUM(delta) AS total FROM (
+					SELECT to_account_id AS id, amount AS delta FROM transactions WHERE id = ANY($1::uuid[])
+					UNION ALL
+					SELECT from_account_id, -total_debit FROM transactions WHERE id = ANY($1::uuid[])
+					UNION ALL
+					SELECT fee_account_id, fee_amount FROM transactions WHERE id = ANY($1::uuid[])
+				) deltas GROUP BY id
+			 ) d
+			 WHERE a.id = d.id`,
+			`DELETE FROM transactions WHERE id = ANY($1::uuid[])`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement, transactionIDs); err != nil {
+				return fmt.Errorf("ошибка удаления транзакций: %w", err)
+			}
+		}
+	}
+
+	// Собственные данные пользователя, ссылающиеся на его счета
+	for _, statement := range []string{
+		`DELETE FROM scheduled_transfers WHERE user_id = $1`,
+		`DELETE FROM authorizations WHERE user_id = $1`,
+		`DELETE FROM transfer_batches WHERE user_id = $1`,
+		`DELETE FROM spending_limits WHERE user_id = $1 OR account_id IN (SELECT id FROM accounts WHERE user_id = $1)`,
+	} {
+		if _, err := tx.Exec(ctx, statement, userID); err != nil {
+			return fmt.Errorf("ошибка удаления данных пользователя: %w", err)
+		}
+	}
+
+	rows, err = tx.Query(ctx, `SELECT id FROM accounts WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка выбора счетов: %w", err)
+	}
+	accountIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("ошибка выбора счетов: %w", err)
+	}
+
+	remaining := 0
+	for _, accountID := range accountIDs {
+		// На счёт ещё ссылаются сохранённые транзакции, авторизации или
+		// отчёты сверки — точка сохранения откатывает только это удаление
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = savepoint.Exec(ctx, `DELETE FROM accounts WHERE id = $1`, accountID)
+		var pgErr *pgconn.PgError
+		switch {
+		case err == nil:
+			if err := savepoint.Commit(ctx); err != nil {
+				return err
+			}
+			result.Accounts++
+		case errors.As(err, &pgErr) && pgErr.Code == "23503":
+			if err := savepoint.Rollback(ctx); err != nil {
+				return err
+			}
+			remaining++
+		default:
+			return fmt.Errorf("ошибка удаления счёта %s: %w", accountID, err)
+		}
+	}
+
+	if remaining == 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+			return fmt.Errorf("ошибка удаления пользователя: %w", err)
+		}
+		result.Users++
+	}
+	result.Transactions += len(transactionIDs)
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("ошибка фиксации: %w", err)
+	}
+
+	utils.LogSuccess("UserRepository", "Срок хранения данных пользователя %s истёк: удалено транзакций %d, счетов %d из %d",
+		userID, len(transactionIDs), len(accountIDs)-remaining, len(accountIDs))
+	return nil
+}
+
+// UpdateProfile сохраняет отображаемое имя и контакты пользователя.
+// Пустые значения записываются как NULL. Занятый email или телефон —
+// ErrEmailTaken или ErrPhoneTaken.
+func (r *UserRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users
+		SET display_name = NULLIF($2, ''), email = NULLIF($3, ''), phone = NULLIF($4, ''), updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+
+	utils.LogDB("UPDATE USER PROFILE", fmt.Sprintf("Изменение профиля пользователя: %s", user.ID))
+
+	err := r.db.QueryRow(ctx, query, user.ID, user.DisplayName, user.Email, user.Phone).Scan(&user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			switch pgErr.ConstraintName {
+			case "users_email_key":
+				return ErrEmailTaken
+			case "users_phone_key":
+				return ErrPhoneTaken
+			}
+		}
+		utils.LogError("UserRepository", fmt.Sprintf("Ошибка изменения профиля пользователя %s", user.ID), err)
+		return err
+	}
+
+	utils.LogSuccess("UserRepository", "Профиль пользователя %s изменён", user.ID)
+	return nil
+}
+
+// UpdatePassword сохраняет новый хеш пароля и увеличивает версию сессий,
+// завершая все выданные ранее токены. Возвращает новую версию.
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID, passwordHash string) (int, error) {
+	query := `
+		UPDATE users
+		SET password_hash = $2, session_version = session_version + 1, updated_at = NOW()
+		WHERE id = $1
+		RETURNING session_version`
+
+	utils.LogDB("UPDATE USER PASSWORD", fmt.Sprintf("Смена пароля пользователя: %s", userID))
+
+	var version int
+	if err := r.db.QueryRow(ctx, query, userID, passwordHash).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrUserNotFound
+		}
+		utils.LogError("UserRepository", fmt.Sprintf("Ошибка смены пароля пользователя %s", userID), err)
+		return 0, err
+	}
+
+	utils.LogSuccess("UserRepository", "Пароль пользователя %s изменён (версия сессий: %d)", userID, version)
+	return version, nil
+}
 
-	utils.LogDB("DELETE USER", fmt.Sprintf("Удаление пользователя: %s", userID))
+// SetRole назначает роль пользователю с указанным именем
+func (r *UserRepository) SetRole(ctx context.Context, name, role string) error {
+	utils.LogDB("UPDATE USER ROLE", fmt.Sprintf("Назначение роли %s пользователю %s", role, name))
 
-	result, err := r.db.Exec(ctx, query, userID)
+	result, err := r.db.Exec(ctx, `UPDATE users SET role = $2 WHERE name = $1 AND deleted_at IS NULL`, name, role)
 	if err != nil {
-		utils.LogError("UserRepository", fmt.Sprintf("Ошибка удаления пользователя %s", userID), err)
+		utils.LogError("UserRepository", fmt.Sprintf("Ошибка назначения роли пользователю %s", name), err)
 		return err
 	}
 
 	if result.RowsAffected() == 0 {
-		utils.LogWarning("UserRepository", fmt.Sprintf("Пользователь %s не найден для удаления", userID))
-		return fmt.Errorf("user not found")
+		return ErrUserNotFound
 	}
 
-	utils.LogSuccess("UserRepository", fmt.Sprintf("Пользователь удалён: %s (каскадно удалены все счета и транзакции)", userID))
 	return nil
 }
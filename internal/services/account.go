@@ -4,36 +4,41 @@ import (
 	"bank-prototype/internal/cache"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"time"
 
-	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrUnauthorizedAccess   = errors.New("нет доступа к данному счёту")
 	ErrAccountAlreadyClosed = errors.New("счёт уже закрыт")
 	ErrAccountLimitReached  = errors.New("достигнут лимит активных счетов (максимум 5)")
+	ErrAccountHasHolds      = errors.New("на счёте есть активные авторизации")
+	ErrAccountFrozen        = repository.ErrAccountFrozen
+	ErrAccountNotFrozen     = errors.New("счёт не заморожен")
 )
 
 const MaxActiveAccounts = 5
 
-type AccountService struct {
-	accountRepo *repository.AccountRepository
-	cache       *cache.RedisCache
-}
+// accountLoadTimeout ограничивает общий запрос счёта, который выполняется
+// для нескольких ожидающих
+const accountLoadTimeout = 5 * time.Second
 
-func NewAccountService(accountRepo *repository.AccountRepository) *AccountService {
-	return &AccountService{
-		accountRepo: accountRepo,
-		cache:       nil,
-	}
+type AccountService struct {
+	accountRepo repository.AccountStore
+	cache       cache.Cache
+	loads       singleflight.Group
 }
 
-func NewAccountServiceWithCache(accountRepo *repository.AccountRepository, cache *cache.RedisCache) *AccountService {
+// NewAccountService создаёт сервис счетов; при cache == nil данные всегда
+// читаются из базы
+func NewAccountService(accountRepo repository.AccountStore, cache cache.Cache) *AccountService {
 	return &AccountService{
 		accountRepo: accountRepo,
 		cache:       cache,
@@ -41,11 +46,15 @@ func NewAccountServiceWithCache(accountRepo *repository.AccountRepository, cache
 }
 
 func (s *AccountService) CreateAccount(ctx context.Context, userID string) (*models.Account, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.CreateAccount", attribute.String("user.id", userID))
+	defer span.End()
+
 	utils.LogInfo("AccountService", fmt.Sprintf("Создание нового счёта для пользователя %s", userID))
 
 	activeCount, err := s.accountRepo.CountActiveAccountsByUserID(ctx, userID)
 	if err != nil {
 		utils.LogError("AccountService", "Ошибка проверки лимита счетов", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -57,6 +66,7 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID string) (*mod
 	account, err := s.accountRepo.Create(ctx, userID)
 	if err != nil {
 		utils.LogError("AccountService", fmt.Sprintf("Ошибка создания счёта для пользователя %s", userID), err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -71,17 +81,20 @@ func (s *AccountService) CreateAccount(ctx context.Context, userID string) (*mod
 }
 
 func (s *AccountService) GetUserAccounts(ctx context.Context, userID string) ([]models.Account, error) {
+	ctx, span := tracing.Start(ctx, "AccountService.GetUserAccounts", attribute.String("user.id", userID))
+	defer span.End()
+
 	utils.LogInfo("AccountService", fmt.Sprintf("Получение списка счетов пользователя %s", userID))
 
 	if s.cache != nil {
 		cacheKey := cache.UserAccountsKey(userID)
 		var accounts []models.Account
 
-		err := s.cache.GetJSON(ctx, cacheKey, &accounts)
+		err := cache.GetJSON(ctx, s.cache, cacheKey, &accounts)
 		if err == nil {
 			utils.LogSuccess("Cache", fmt.Sprintf("HIT: Список счетов пользователя %s получен из кеша (%d счетов)", userID, len(accounts)))
 			return accounts, nil
-		} else if err != redis.Nil {
+		} else if !errors.Is(err, cache.ErrMiss) {
 			utils.LogWarning("Cache", fmt.Sprintf("Ошибка чтения из кеша: %v", err))
 		} else {
 			utils.LogInfo("Cache", fmt.Sprintf("MISS: Список счетов пользователя %s не найден в кеше", userID))
@@ -91,15 +104,17 @@ func (s *AccountService) GetUserAccounts(ctx context.Context, userID string) ([]
 	accounts, err := s.accountRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		utils.LogError("AccountService", fmt.Sprintf("Ошибка получения счетов пользователя %s", userID), err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
 	if s.cache != nil {
 		cacheKey := cache.UserAccountsKey(userID)
-		if err := s.cache.SetJSON(ctx, cacheKey, accounts, cache.UserAccountsTTL); err != nil {
+		ttl := cache.Jitter(cache.UserAccountsTTL)
+		if err := cache.SetJSON(ctx, s.cache, cacheKey, accounts, ttl); err != nil {
 			utils.LogWarning("Cache", fmt.Sprintf("Не удалось сохранить в кеш: %v", err))
 		} else {
-			utils.LogSuccess("Cache", fmt.Sprintf("Список счетов пользователя %s сохранён в кеш (TTL: %v)", userID, cache.UserAccountsTTL))
+			utils.LogSuccess("Cache", fmt.Sprintf("Список счетов пользователя %s сохранён в кеш (TTL: %v)", userID, ttl))
 		}
 	}
 
@@ -119,57 +134,18 @@ func (s *AccountService) GetUserAccounts(ctx context.Context, userID string) ([]
 }
 
 func (s *AccountService) GetAccount(ctx context.Context, accountID, userID string) (*models.Account, error) {
-	utils.LogInfo("AccountService", fmt.Sprintf("Получение информации о счёте %s", accountID))
+	ctx, span := tracing.Start(ctx, "AccountService.GetAccount", attribute.String("account.id", accountID))
+	defer span.End()
 
-	var account *models.Account
-	var err error
-
-	if s.cache != nil {
-		balanceKey := cache.AccountBalanceKey(accountID)
-		balanceStr, cacheErr := s.cache.Get(ctx, balanceKey)
-
-		if cacheErr == nil {
-			utils.LogSuccess("Cache", fmt.Sprintf("HIT: Баланс счёта %s найден в кеше: %s", accountID, balanceStr))
-
-			account, err = s.accountRepo.GetByID(ctx, accountID)
-			if err != nil {
-				utils.LogError("AccountService", fmt.Sprintf("Счёт %s не найден", accountID), err)
-				return nil, repository.ErrAccountNotFound
-			}
-
-			balance, parseErr := strconv.ParseFloat(balanceStr, 64)
-			if parseErr == nil {
-				account.Balance = balance
-			}
-		} else if cacheErr == redis.Nil {
-			utils.LogInfo("Cache", fmt.Sprintf("MISS: Баланс счёта %s не найден в кеше", accountID))
-
-			account, err = s.accountRepo.GetByID(ctx, accountID)
-			if err != nil {
-				utils.LogError("AccountService", fmt.Sprintf("Счёт %s не найден", accountID), err)
-				return nil, repository.ErrAccountNotFound
-			}
-
-			if saveErr := s.cache.Set(ctx, balanceKey, fmt.Sprintf("%.2f", account.Balance), cache.AccountBalanceTTL); saveErr != nil {
-				utils.LogWarning("Cache", fmt.Sprintf("Не удалось сохранить баланс в кеш: %v", saveErr))
-			} else {
-				utils.LogSuccess("Cache", fmt.Sprintf("Баланс счёта %s сохранён в кеш: %.2f (TTL: %v)", accountID, account.Balance, cache.AccountBalanceTTL))
-			}
-		} else {
-			utils.LogWarning("Cache", fmt.Sprintf("Ошибка чтения из кеша: %v", cacheErr))
+	utils.LogInfo("AccountService", fmt.Sprintf("Получение информации о счёте %s", accountID))
 
-			account, err = s.accountRepo.GetByID(ctx, accountID)
-			if err != nil {
-				utils.LogError("AccountService", fmt.Sprintf("Счёт %s не найден", accountID), err)
-				return nil, repository.ErrAccountNotFound
-			}
-		}
-	} else {
-		account, err = s.accountRepo.GetByID(ctx, accountID)
-		if err != nil {
-			utils.LogError("AccountService", fmt.Sprintf("Счёт %s не найден", accountID), err)
-			return nil, repository.ErrAccountNotFound
+	account, err := s.loadAccount(ctx, accountID)
+	if err != nil {
+		utils.LogError("AccountService", fmt.Sprintf("Счёт %s не найден", accountID), err)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		return nil, repository.ErrAccountNotFound
 	}
 
 	if account.UserID != userID {
@@ -187,7 +163,73 @@ func (s *AccountService) GetAccount(ctx context.Context, accountID, userID strin
 	return account, nil
 }
 
+// loadAccount возвращает снимок счёта из кеша, а при промахе — из базы.
+// Одновременные промахи по одной версии счёта объединяются: в базу идёт
+// один запрос, остальные ждут его результата.
+func (s *AccountService) loadAccount(ctx context.Context, accountID string) (*models.Account, error) {
+	if s.cache == nil {
+		return s.accountRepo.GetByID(ctx, accountID)
+	}
+
+	version, err := cache.AccountVersion(ctx, s.cache, accountID)
+	if err != nil {
+		utils.LogWarning("Cache", fmt.Sprintf("Ошибка чтения версии счёта %s: %v", accountID, err))
+		return s.accountRepo.GetByID(ctx, accountID)
+	}
+	key := cache.AccountInfoKey(accountID, version)
+
+	var cached models.Account
+	err = cache.GetJSON(ctx, s.cache, key, &cached)
+	if err == nil {
+		utils.LogSuccess("Cache", fmt.Sprintf("HIT: Счёт %s получен из кеша (баланс: %.2f)", accountID, cached.Balance))
+		return &cached, nil
+	}
+	if !errors.Is(err, cache.ErrMiss) {
+		utils.LogWarning("Cache", fmt.Sprintf("Ошибка чтения из кеша: %v", err))
+	} else {
+		utils.LogInfo("Cache", fmt.Sprintf("MISS: Счёт %s не найден в кеше", accountID))
+	}
+
+	result := s.loads.DoChan(key, func() (interface{}, error) {
+		// Запрос выполняется от имени всех ожидающих, поэтому отмена
+		// контекста первого из них не должна его прерывать
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), accountLoadTimeout)
+		defer cancel()
+
+		account, err := s.accountRepo.GetByID(loadCtx, accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl := cache.Jitter(cache.AccountInfoTTL)
+		if err := cache.SetJSON(loadCtx, s.cache, key, account, ttl); err != nil {
+			utils.LogWarning("Cache", fmt.Sprintf("Не удалось сохранить счёт в кеш: %v", err))
+		} else {
+			utils.LogSuccess("Cache", fmt.Sprintf("Счёт %s сохранён в кеш (TTL: %v)", accountID, ttl))
+		}
+		return account, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		if res.Shared {
+			utils.LogDebug("Cache", fmt.Sprintf("Запрос счёта %s объединён с параллельными", accountID))
+		}
+		// Копия: снимок разделяют все ожидавшие запросы
+		account := *res.Val.(*models.Account)
+		return &account, nil
+	}
+}
+
 func (s *AccountService) DeleteAccount(ctx context.Context, accountID, userID string) error {
+	ctx, span := tracing.Start(ctx, "AccountService.DeleteAccount", attribute.String("account.id", accountID))
+	defer span.End()
+
 	utils.LogInfo("AccountService", fmt.Sprintf("Закрытие счёта %s пользователем %s", accountID, userID))
 
 	account, err := s.accountRepo.GetByID(ctx, accountID)
@@ -206,6 +248,16 @@ func (s *AccountService) DeleteAccount(ctx context.Context, accountID, userID st
 		return ErrAccountAlreadyClosed
 	}
 
+	if account.Status == "frozen" {
+		utils.LogWarning("AccountService", fmt.Sprintf("Счёт %s нельзя закрыть: счёт заморожен", accountID))
+		return ErrAccountFrozen
+	}
+
+	if account.HeldAmount > 0 {
+		utils.LogWarning("AccountService", fmt.Sprintf("Счёт %s нельзя закрыть: зарезервировано %.2f", accountID, account.HeldAmount))
+		return ErrAccountHasHolds
+	}
+
 	if account.Balance > 0 {
 		utils.LogInfo("AccountService", fmt.Sprintf("Перевод баланса %.2f со счёта %s на системный счёт", account.Balance, accountID))
 
@@ -233,13 +285,13 @@ func (s *AccountService) DeleteAccount(ctx context.Context, accountID, userID st
 	err = s.accountRepo.UpdateStatus(ctx, accountID, "closed")
 	if err != nil {
 		utils.LogError("AccountService", fmt.Sprintf("Ошибка изменения статуса счёта %s", accountID), err)
+		tracing.Fail(span, err)
 		return err
 	}
 
 	if s.cache != nil {
 		_ = s.cache.Delete(ctx,
-			cache.AccountBalanceKey(accountID),
-			cache.AccountInfoKey(accountID),
+			cache.AccountVersionKey(accountID),
 			cache.UserAccountsKey(userID),
 		)
 		utils.LogInfo("Cache", fmt.Sprintf("Инвалидирован кеш для счёта %s и пользователя %s", accountID, userID))
@@ -250,7 +302,62 @@ func (s *AccountService) DeleteAccount(ctx context.Context, accountID, userID st
 	return nil
 }
 
+// Freeze замораживает счёт: переводы, платежи и закрытие счёта запрещены
+// до разморозки, баланс сохраняется. Операция администратора, владелец не проверяется.
+func (s *AccountService) Freeze(ctx context.Context, accountID string) (*models.Account, error) {
+	return s.changeStatus(ctx, "AccountService.Freeze", accountID, "active", "frozen")
+}
+
+// Unfreeze возвращает замороженный счёт в статус active
+func (s *AccountService) Unfreeze(ctx context.Context, accountID string) (*models.Account, error) {
+	return s.changeStatus(ctx, "AccountService.Unfreeze", accountID, "frozen", "active")
+}
+
+func (s *AccountService) changeStatus(ctx context.Context, spanName, accountID, from, to string) (*models.Account, error) {
+	ctx, span := tracing.Start(ctx, spanName, attribute.String("account.id", accountID))
+	defer span.End()
+
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, repository.ErrAccountNotFound
+	}
+
+	if account.Status != from {
+		switch {
+		case account.Status == "closed":
+			err = ErrAccountAlreadyClosed
+		case from == "frozen":
+			err = ErrAccountNotFrozen
+		default:
+			err = ErrAccountFrozen
+		}
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if err := s.accountRepo.ChangeStatus(ctx, accountID, from, to); err != nil {
+		utils.LogError("AccountService", fmt.Sprintf("Ошибка изменения статуса счёта %s", accountID), err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	account.Status = to
+
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx,
+			cache.AccountVersionKey(accountID),
+			cache.UserAccountsKey(account.UserID),
+		)
+	}
+
+	utils.LogSuccess("AccountService", "Статус счёта %s изменён: %s → %s", accountID, from, to)
+	return account, nil
+}
+
 func (s *AccountService) VerifyOwnership(ctx context.Context, accountID, userID string) error {
+	ctx, span := tracing.Start(ctx, "AccountService.VerifyOwnership", attribute.String("account.id", accountID))
+	defer span.End()
+
 	account, err := s.accountRepo.GetByID(ctx, accountID)
 	if err != nil {
 		return repository.ErrAccountNotFound
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func TestCreateAccountLimit(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+
+	var first *models.Account
+	for i := 0; i < MaxActiveAccounts; i++ {
+		account := bank.openAccount(t, "alice")
+		if first == nil {
+			first = account
+		}
+	}
+
+	if _, err := bank.accounts.CreateAccount(ctx, "alice"); !errors.Is(err, ErrAccountLimitReached) {
+		t.Fatalf("CreateAccount сверх лимита: %v, ожидалось ErrAccountLimitReached", err)
+	}
+
+	// Закрытый счёт освобождает место в лимите
+	if err := bank.accounts.DeleteAccount(ctx, first.ID, "alice"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	if _, err := bank.accounts.CreateAccount(ctx, "alice"); err != nil {
+		t.Fatalf("CreateAccount после закрытия: %v", err)
+	}
+
+	// Лимит считается для каждого пользователя отдельно
+	bank.openAccount(t, "bob")
+}
+
+func TestGetAccountAccess(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	account := bank.openAccount(t, "alice")
+
+	got, err := bank.accounts.GetAccount(ctx, account.ID, "alice")
+	if err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+	assertMoney(t, "баланс нового счёта", got.Balance, repository.InitialAccountBalance)
+
+	if _, err := bank.accounts.GetAccount(ctx, account.ID, "bob"); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("GetAccount чужого счёта: %v, ожидалось ErrUnauthorizedAccess", err)
+	}
+	if _, err := bank.accounts.GetAccount(ctx, "13000000000000", "alice"); !errors.Is(err, repository.ErrAccountNotFound) {
+		t.Errorf("GetAccount несуществующего счёта: %v, ожидалось ErrAccountNotFound", err)
+	}
+}
+
+func TestDeleteAccountMovesBalanceToSystem(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	account := bank.openAccount(t, "alice")
+
+	if err := bank.accounts.DeleteAccount(ctx, account.ID, "bob"); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Fatalf("DeleteAccount чужим пользователем: %v, ожидалось ErrUnauthorizedAccess", err)
+	}
+
+	if err := bank.accounts.DeleteAccount(ctx, account.ID, "alice"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+
+	closed, _ := bank.store.Accounts().GetByID(ctx, account.ID)
+	if closed.Status != "closed" {
+		t.Errorf("статус = %s, ожидалось closed", closed.Status)
+	}
+	assertMoney(t, "баланс закрытого счёта", closed.Balance, 0)
+	assertMoney(t, "баланс системного счёта", bank.balance(t, repository.SystemBankAccountID), repository.InitialAccountBalance)
+
+	if err := bank.accounts.DeleteAccount(ctx, account.ID, "alice"); !errors.Is(err, ErrAccountAlreadyClosed) {
+		t.Errorf("повторный DeleteAccount: %v, ожидалось ErrAccountAlreadyClosed", err)
+	}
+	if _, err := bank.accounts.GetAccount(ctx, account.ID, "alice"); !errors.Is(err, repository.ErrAccountClosed) {
+		t.Errorf("GetAccount закрытого счёта: %v, ожидалось ErrAccountClosed", err)
+	}
+}
+
+func TestDeleteAccountWithHolds(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	bank.store.PutAccount(models.Account{
+		ID: "13000000000001", UserID: "alice", Balance: 100, HeldAmount: 30, Status: "active",
+	})
+
+	if err := bank.accounts.DeleteAccount(ctx, "13000000000001", "alice"); !errors.Is(err, ErrAccountHasHolds) {
+		t.Fatalf("DeleteAccount со счётом под холдом: %v, ожидалось ErrAccountHasHolds", err)
+	}
+	assertMoney(t, "баланс", bank.balance(t, "13000000000001"), 100)
+}
+
+func TestFreezeAndUnfreeze(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	account := bank.openAccount(t, "alice")
+	other := bank.openAccount(t, "bob")
+
+	if _, err := bank.accounts.Unfreeze(ctx, account.ID); !errors.Is(err, ErrAccountNotFrozen) {
+		t.Fatalf("Unfreeze активного счёта: %v, ожидалось ErrAccountNotFrozen", err)
+	}
+
+	frozen, err := bank.accounts.Freeze(ctx, account.ID)
+	if err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	if frozen.Status != "frozen" {
+		t.Fatalf("статус = %s, ожидалось frozen", frozen.Status)
+	}
+
+	if _, err := bank.accounts.Freeze(ctx, account.ID); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("повторный Freeze: %v, ожидалось ErrAccountFrozen", err)
+	}
+	if err := bank.accounts.DeleteAccount(ctx, account.ID, "alice"); !errors.Is(err, ErrAccountFrozen) {
+		t.Errorf("DeleteAccount замороженного счёта: %v, ожидалось ErrAccountFrozen", err)
+	}
+
+	// Переводы не проходят ни с замороженного счёта, ни на него
+	transfer := models.TransferRequest{FromAccountID: account.ID, ToAccountID: other.ID, Amount: 10}
+	if _, err := bank.transactions.Transfer(ctx, "alice", transfer); !errors.Is(err, repository.ErrAccountClosed) {
+		t.Errorf("перевод с замороженного счёта: %v, ожидалось ErrAccountClosed", err)
+	}
+	transfer = models.TransferRequest{FromAccountID: other.ID, ToAccountID: account.ID, Amount: 10}
+	if _, err := bank.transactions.Transfer(ctx, "bob", transfer); !errors.Is(err, repository.ErrAccountClosed) {
+		t.Errorf("перевод на замороженный счёт: %v, ожидалось ErrAccountClosed", err)
+	}
+
+	if _, err := bank.accounts.Unfreeze(ctx, account.ID); err != nil {
+		t.Fatalf("Unfreeze: %v", err)
+	}
+	assertMoney(t, "баланс после разморозки", bank.balance(t, account.ID), repository.InitialAccountBalance)
+}
+
+func TestGetAccountCacheSeesTransfers(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, cache.NewMemoryCache(100))
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	// Первый запрос заполняет кеш
+	if _, err := bank.accounts.GetAccount(ctx, from.ID, "alice"); err != nil {
+		t.Fatalf("GetAccount: %v", err)
+	}
+
+	transfer := models.TransferRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 50}
+	if _, err := bank.transactions.Transfer(ctx, "alice", transfer); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	got, err := bank.accounts.GetAccount(ctx, from.ID, "alice")
+	if err != nil {
+		t.Fatalf("GetAccount после перевода: %v", err)
+	}
+	assertMoney(t, "баланс из кеша после перевода", got.Balance, 49.5)
+
+	// Снимок в кеше не зависит от изменений возвращённой копии
+	got.Balance = 0
+	again, _ := bank.accounts.GetAccount(ctx, from.ID, "alice")
+	assertMoney(t, "баланс при повторном чтении", again.Balance, 49.5)
+
+	if _, err := bank.accounts.Freeze(ctx, from.ID); err != nil {
+		t.Fatalf("Freeze: %v", err)
+	}
+	if _, err := bank.accounts.GetAccount(ctx, from.ID, "alice"); !errors.Is(err, repository.ErrAccountClosed) {
+		t.Errorf("GetAccount после заморозки: %v, ожидалось ErrAccountClosed", err)
+	}
+}
@@ -1,18 +1,36 @@
 package services
 
 import (
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
 	"bank-prototype/internal/utils"
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrInvalidToken — токен не подписан сервером, истёк или его сессия
+// завершена сменой пароля
+var ErrInvalidToken = errors.New("невалидный или истёкший токен")
+
+// sessionVersionTTL — сколько экземпляр API доверяет закешированным версии
+// сессий и роли. Смена пароля удаляет ключ сразу, TTL ограничивает окно,
+// если удаление не дошло или гонка с чтением записала старую версию, а
+// для смены роли в bankctl, у которого нет кеша API, — это всё окно.
+const sessionVersionTTL = time.Minute
+
 type AuthService struct {
 	jwtSecret     string
 	jwtExpiration time.Duration
+	users         repository.UserStore
+	cache         cache.Cache
 }
 
 func NewAuthService(secret string, expiration time.Duration) *AuthService {
@@ -49,16 +67,28 @@ func (s *AuthService) CheckPasswordHash(password, hash string) error {
 	return nil
 }
 
+// SetUserStore включает проверку версии сессий в Authenticate. Без неё
+// (как в bankctl и тестах) токен действует до истечения срока.
+func (s *AuthService) SetUserStore(users repository.UserStore, c cache.Cache) {
+	s.users = users
+	s.cache = c
+}
+
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID         string `json:"user_id"`
+	Role           string `json:"role,omitempty"`
+	SessionVersion int    `json:"sv,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) GenerateToken(userID string) (string, error) {
+func (s *AuthService) GenerateToken(user *models.User) (string, error) {
+	userID := user.ID
 	utils.LogDebug("AuthService", fmt.Sprintf("Генерация JWT токена для пользователя: %s", userID))
 
 	claims := &Claims{
-		UserID: userID,
+		UserID:         userID,
+		Role:           user.Role,
+		SessionVersion: user.SessionVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiration)),
 		},
@@ -98,3 +128,77 @@ func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 	utils.LogSuccess("AuthService", fmt.Sprintf("Токен валиден для пользователя: %s", claims.UserID))
 	return claims, nil
 }
+
+// Authenticate проверяет токен запроса: подпись и срок, а если задано
+// хранилище пользователей — что пользователь существует и сессия не
+// завершена сменой пароля. Роль берётся не из токена, а текущая, вместе с
+// версией сессий, поэтому её смена действует без повторного входа.
+// Отказ в доступе — ErrInvalidToken, другие ошибки означают
+// недоступность хранилища.
+func (s *AuthService) Authenticate(ctx context.Context, tokenString string) (*Claims, error) {
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if s.users == nil {
+		return claims, nil
+	}
+
+	session, err := s.session(ctx, claims.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		utils.LogWarning("AuthService", "Токен пользователя %s, которого больше нет", claims.UserID)
+		return nil, fmt.Errorf("%w: пользователь не найден", ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionVersion != session.version {
+		utils.LogWarning("AuthService", "Токен пользователя %s из завершённой сессии (версия %d, текущая %d)", claims.UserID, claims.SessionVersion, session.version)
+		return nil, fmt.Errorf("%w: сессия завершена", ErrInvalidToken)
+	}
+	if claims.Role != session.role {
+		utils.LogInfo("AuthService", "Роль пользователя %s изменилась: %s → %s", claims.UserID, claims.Role, session.role)
+		claims.Role = session.role
+	}
+	return claims, nil
+}
+
+// userSession — то, что Authenticate сверяет с базой: версия сессий и роль
+type userSession struct {
+	version int
+	role    string
+}
+
+// session возвращает текущие версию сессий и роль пользователя: из кеша
+// (значение "версия:роль"), а при промахе — из базы
+func (s *AuthService) session(ctx context.Context, userID string) (userSession, error) {
+	key := cache.UserSessionKey(userID)
+	if s.cache != nil {
+		if value, err := s.cache.Get(ctx, key); err == nil {
+			versionStr, role, ok := strings.Cut(value, ":")
+			if version, err := strconv.Atoi(versionStr); ok && err == nil {
+				return userSession{version: version, role: role}, nil
+			}
+		}
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return userSession{}, err
+	}
+	if s.cache != nil {
+		_ = s.cache.Set(ctx, key, strconv.Itoa(user.SessionVersion)+":"+user.Role, sessionVersionTTL)
+	}
+	return userSession{version: user.SessionVersion, role: user.Role}, nil
+}
+
+// revokeSessions сбрасывает закешированную версию сессий после её
+// изменения в базе
+func (s *AuthService) revokeSessions(ctx context.Context, userID string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, cache.UserSessionKey(userID)); err != nil {
+		utils.LogError("AuthService", fmt.Sprintf("Не удалось сбросить версию сессий пользователя %s, старые токены действуют до %v", userID, sessionVersionTTL), err)
+	}
+}
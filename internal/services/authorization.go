@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidAuthorizationTTL = errors.New("срок действия авторизации должен быть от 1 минуты до 30 дней")
+	ErrCaptureExceedsAmount    = errors.New("сумма списания превышает авторизованную")
+)
+
+const (
+	DefaultAuthorizationTTL = 7 * 24 * time.Hour
+	MaxAuthorizationTTL     = 30 * 24 * time.Hour
+	MinAuthorizationTTL     = time.Minute
+
+	// authorizationExpiryBatch — сколько просроченных холдов снимается за один запрос
+	authorizationExpiryBatch = 100
+)
+
+// AuthorizationService реализует двухфазные платежи: холд средств на счёте
+// плательщика и последующее списание (capture) или отмену (release).
+type AuthorizationService struct {
+	repo        repository.AuthorizationStore
+	accountRepo repository.AccountStore
+	cache       cache.Cache
+	workerPool  *worker.WorkerPool
+	events      *eventNotifier
+}
+
+func NewAuthorizationService(
+	repo repository.AuthorizationStore,
+	accountRepo repository.AccountStore,
+	cache cache.Cache,
+	workerPool *worker.WorkerPool,
+) *AuthorizationService {
+	utils.LogSuccess("AuthorizationService", "Инициализирован сервис авторизаций")
+	return &AuthorizationService{
+		repo:        repo,
+		accountRepo: accountRepo,
+		cache:       cache,
+		workerPool:  workerPool,
+	}
+}
+
+// SetEventPublisher подключает публикацию событий: списание по авторизации —
+// транзакция, холд и его снятие меняют доступный остаток
+func (s *AuthorizationService) SetEventPublisher(publisher EventPublisher) {
+	s.events = newEventNotifier(publisher, s.accountRepo)
+}
+
+// Create резервирует сумму платежа вместе с комиссией 3%
+func (s *AuthorizationService) Create(ctx context.Context, userID string, req models.CreateAuthorizationRequest) (*models.Authorization, error) {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.Create",
+		attribute.String("account.from", req.FromAccountID),
+		attribute.String("account.to", req.ToAccountID),
+		attribute.Float64("amount", req.Amount),
+	)
+	defer span.End()
+
+	utils.LogInfo("AuthorizationService", "Авторизация от пользователя %s: %s → %s (сумма: %.2f)",
+		userID, req.FromAccountID, req.ToAccountID, req.Amount)
+
+	ttl := DefaultAuthorizationTTL
+	if req.TTLSeconds != nil {
+		ttl = time.Duration(*req.TTLSeconds) * time.Second
+		if ttl < MinAuthorizationTTL || ttl > MaxAuthorizationTTL {
+			tracing.Fail(span, ErrInvalidAuthorizationTTL)
+			return nil, ErrInvalidAuthorizationTTL
+		}
+	}
+
+	if err := s.validate(ctx, userID, req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
+		utils.LogError("AuthorizationService", "Ошибка валидации авторизации", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	amount := roundMoney(req.Amount)
+	auth, err := s.repo.Create(ctx, &models.Authorization{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        amount,
+		HeldAmount:    amount + paymentFee(amount),
+		ExpiresAt:     time.Now().Add(ttl),
+	})
+	if err != nil {
+		utils.LogError("AuthorizationService", "Ошибка создания авторизации", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	s.invalidateCache(ctx, userID, auth.FromAccountID)
+	s.events.balancesChanged(ctx, s.workerPool, auth.FromAccountID)
+
+	return auth, nil
+}
+
+func (s *AuthorizationService) List(ctx context.Context, userID string) ([]models.Authorization, error) {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.List", attribute.String("user.id", userID))
+	defer span.End()
+
+	auths, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return auths, nil
+}
+
+func (s *AuthorizationService) Get(ctx context.Context, userID, id string) (*models.Authorization, error) {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.Get", attribute.String("authorization.id", id))
+	defer span.End()
+
+	auth, _, err := s.getAccessible(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return auth, nil
+}
+
+// Capture списывает авторизованную сумму полностью или частично. Завершить
+// авторизацию может как плательщик, так и владелец счёта получателя.
+func (s *AuthorizationService) Capture(ctx context.Context, userID, id string, req models.CaptureAuthorizationRequest) (*models.CaptureAuthorizationResponse, error) {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.Capture", attribute.String("authorization.id", id))
+	defer span.End()
+
+	auth, merchantUserID, err := s.getAccessible(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	amount := auth.Amount
+	if req.Amount != nil {
+		if err := validateCents(*req.Amount); err != nil {
+			tracing.Fail(span, err)
+			return nil, err
+		}
+		amount = roundMoney(*req.Amount)
+		if amount > auth.Amount {
+			tracing.Fail(span, ErrCaptureExceedsAmount)
+			return nil, ErrCaptureExceedsAmount
+		}
+	}
+
+	captured, transaction, err := s.repo.Capture(ctx, id, amount, paymentFee(amount))
+	if err != nil {
+		utils.LogError("AuthorizationService", "Ошибка списания по авторизации", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	s.invalidateCache(ctx, auth.UserID, auth.FromAccountID)
+	s.invalidateCache(ctx, merchantUserID, auth.ToAccountID, repository.SystemBankAccountID)
+	s.events.transactionsCompleted(ctx, s.workerPool, transaction)
+
+	utils.LogSuccess("AuthorizationService", "Авторизация %s списана (транзакция %s)", id, transaction.ID)
+
+	return &models.CaptureAuthorizationResponse{
+		Authorization: captured,
+		Transaction:   transaction,
+	}, nil
+}
+
+func (s *AuthorizationService) Release(ctx context.Context, userID, id string) (*models.Authorization, error) {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.Release", attribute.String("authorization.id", id))
+	defer span.End()
+
+	auth, _, err := s.getAccessible(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	released, err := s.repo.Release(ctx, id)
+	if err != nil {
+		utils.LogError("AuthorizationService", "Ошибка отмены авторизации", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	s.invalidateCache(ctx, auth.UserID, auth.FromAccountID)
+	s.events.balancesChanged(ctx, s.workerPool, auth.FromAccountID)
+
+	utils.LogSuccess("AuthorizationService", "Авторизация %s отменена, освобождено %.2f", id, released.HeldAmount)
+
+	return released, nil
+}
+
+// RunExpirer периодически ставит в Worker Pool задачу снятия просроченных
+// холдов. Блокирует вызывающую горутину до отмены ctx.
+func (s *AuthorizationService) RunExpirer(ctx context.Context, interval time.Duration) {
+	utils.LogInfo("AuthorizationExpirer", "Снятие просроченных авторизаций запущено (интервал: %v)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.LogInfo("AuthorizationExpirer", "Снятие просроченных авторизаций остановлено")
+			return
+		case <-ticker.C:
+			job := worker.Job{
+				ID:   fmt.Sprintf("authorization-expiry-%d", worker.GetCurrentTimeMs()),
+				Ctx:  ctx,
+				Task: s.expireDue,
+			}
+			if err := s.workerPool.Submit(job); err != nil {
+				utils.LogWarning("AuthorizationExpirer", "Не удалось поставить задачу в очередь: %v", err)
+			}
+		}
+	}
+}
+
+func (s *AuthorizationService) expireDue(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "AuthorizationService.expireDue")
+	defer span.End()
+
+	total := 0
+	for {
+		expired, err := s.repo.ExpireDue(ctx, authorizationExpiryBatch)
+		if err != nil {
+			utils.LogError("AuthorizationExpirer", "Ошибка снятия просроченных авторизаций", err)
+			tracing.Fail(span, err)
+			return err
+		}
+
+		for _, auth := range expired {
+			s.invalidateCache(ctx, auth.UserID, auth.FromAccountID)
+			s.events.balancesChanged(ctx, s.workerPool, auth.FromAccountID)
+		}
+
+		total += len(expired)
+		if len(expired) < authorizationExpiryBatch {
+			break
+		}
+	}
+
+	if total > 0 {
+		span.SetAttributes(attribute.Int("authorizations.expired", total))
+		utils.LogInfo("AuthorizationExpirer", "Снято просроченных авторизаций: %d", total)
+	}
+
+	return nil
+}
+
+// getAccessible возвращает авторизацию, если пользователь — плательщик или
+// владелец счёта получателя, а также ID владельца счёта получателя
+func (s *AuthorizationService) getAccessible(ctx context.Context, userID, id string) (*models.Authorization, string, error) {
+	auth, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	toAccount, err := s.accountRepo.GetByID(ctx, auth.ToAccountID)
+	if err != nil {
+		return nil, "", repository.ErrAccountNotFound
+	}
+
+	if auth.UserID != userID && toAccount.UserID != userID {
+		utils.LogWarning("AuthorizationService", "Попытка доступа к чужой авторизации %s пользователем %s", id, userID)
+		return nil, "", ErrUnauthorizedAccess
+	}
+
+	return auth, toAccount.UserID, nil
+}
+
+func (s *AuthorizationService) validate(ctx context.Context, userID, fromAccountID, toAccountID string, amount float64) error {
+	if err := validateCents(amount); err != nil {
+		return err
+	}
+
+	if fromAccountID == toAccountID {
+		return ErrSelfTransfer
+	}
+
+	fromAccount, err := s.accountRepo.GetByID(ctx, fromAccountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+
+	if fromAccount.UserID != userID {
+		return ErrUnauthorizedAccess
+	}
+
+	if fromAccount.Status != "active" {
+		return repository.ErrAccountClosed
+	}
+
+	toAccount, err := s.accountRepo.GetByID(ctx, toAccountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+
+	if toAccount.Status != "active" {
+		return repository.ErrAccountClosed
+	}
+
+	return nil
+}
+
+// invalidateCache сбрасывает снимки счетов и список счетов владельца
+func (s *AuthorizationService) invalidateCache(ctx context.Context, userID string, accountIDs ...string) {
+	if s.cache == nil {
+		return
+	}
+
+	keys := []string{cache.UserAccountsKey(userID)}
+	for _, accountID := range accountIDs {
+		keys = append(keys, cache.AccountVersionKey(accountID))
+	}
+
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		utils.LogWarning("Cache", "Не удалось инвалидировать кеш: %v", err)
+	}
+}
+
+// paymentFee — комиссия за платёж (3%), округлённая до копеек
+func paymentFee(amount float64) float64 {
+	return roundMoney(amount * 0.03)
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
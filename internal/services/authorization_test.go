@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func newTestAuthorizations(bank *testBank) *AuthorizationService {
+	return NewAuthorizationService(bank.store.Authorizations(), bank.store.Accounts(), nil, nil)
+}
+
+func authorize(t *testing.T, authorizations *AuthorizationService, userID, from, to string, amount float64) *models.Authorization {
+	t.Helper()
+
+	auth, err := authorizations.Create(context.Background(), userID, models.CreateAuthorizationRequest{
+		FromAccountID: from, ToAccountID: to, Amount: amount,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return auth
+}
+
+func (b *testBank) held(t *testing.T, accountID string) float64 {
+	t.Helper()
+
+	account, err := b.store.Accounts().GetByID(context.Background(), accountID)
+	if err != nil {
+		t.Fatalf("GetByID(%s): %v", accountID, err)
+	}
+	return account.HeldAmount
+}
+
+// expire сдвигает срок действия авторизации в прошлое
+func (b *testBank) expire(t *testing.T, id string) {
+	t.Helper()
+
+	auth, err := b.store.Authorizations().GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetByID(%s): %v", id, err)
+	}
+	auth.ExpiresAt = time.Now().Add(-time.Second)
+	b.store.PutAuthorization(*auth)
+}
+
+func TestAuthorizationHoldsAmountWithFee(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	alice := bank.openAccount(t, "alice")
+	shop := bank.openAccount(t, "shop")
+
+	auth := authorize(t, authorizations, "alice", alice.ID, shop.ID, 50)
+
+	if auth.Status != "active" {
+		t.Errorf("статус = %s, ожидался active", auth.Status)
+	}
+	assertMoney(t, "сумма авторизации", auth.Amount, 50)
+	assertMoney(t, "холд авторизации", auth.HeldAmount, 51.5)
+	assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 100)
+	assertMoney(t, "холд на счёте", bank.held(t, alice.ID), 51.5)
+
+	// Доступно 48.50: второй такой же холд не помещается
+	_, err := authorizations.Create(ctx, "alice", models.CreateAuthorizationRequest{
+		FromAccountID: alice.ID, ToAccountID: shop.ID, Amount: 50,
+	})
+	if !errors.Is(err, repository.ErrInsufficientBalance) {
+		t.Errorf("второй холд: ошибка %v, ожидалась %v", err, repository.ErrInsufficientBalance)
+	}
+
+	for _, amount := range []float64{0, 0.004, 10.005} {
+		_, err := authorizations.Create(ctx, "alice", models.CreateAuthorizationRequest{
+			FromAccountID: alice.ID, ToAccountID: shop.ID, Amount: amount,
+		})
+		if !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("сумма %v: ошибка %v, ожидалась %v", amount, err, ErrInvalidAmount)
+		}
+	}
+	assertMoney(t, "холд после отказов", bank.held(t, alice.ID), 51.5)
+}
+
+func TestAuthorizationPartialCaptureReleasesRest(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	alice := bank.openAccount(t, "alice")
+	shop := bank.openAccount(t, "shop")
+	auth := authorize(t, authorizations, "alice", alice.ID, shop.ID, 50)
+
+	// Списывает получатель платежа
+	amount := 20.0
+	result, err := authorizations.Capture(ctx, "shop", auth.ID, models.CaptureAuthorizationRequest{Amount: &amount})
+	if err != nil {
+		t.Fatalf("Capture: %v", err)
+	}
+
+	if result.Authorization.Status != "captured" {
+		t.Errorf("статус = %s, ожидался captured", result.Authorization.Status)
+	}
+	assertMoney(t, "списано по авторизации", result.Authorization.CapturedAmount, 20)
+	if result.Transaction.Type != "payment" || result.Authorization.TransactionID == nil ||
+		*result.Authorization.TransactionID != result.Transaction.ID {
+		t.Errorf("транзакция = %+v, авторизация = %+v", result.Transaction, result.Authorization)
+	}
+	assertMoney(t, "комиссия", result.Transaction.FeeAmount, 0.6)
+
+	// Остаток холда (51.50 - 20.60) вернулся в доступный баланс
+	assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 79.4)
+	assertMoney(t, "холд на счёте", bank.held(t, alice.ID), 0)
+	assertMoney(t, "баланс получателя", bank.balance(t, shop.ID), 120)
+	assertMoney(t, "баланс системного счёта", bank.balance(t, repository.SystemBankAccountID), 0.6)
+
+	_, err = authorizations.Capture(ctx, "alice", auth.ID, models.CaptureAuthorizationRequest{})
+	if !errors.Is(err, repository.ErrAuthorizationNotActive) {
+		t.Errorf("повторное списание: ошибка %v, ожидалась %v", err, repository.ErrAuthorizationNotActive)
+	}
+}
+
+func TestAuthorizationCaptureExceedingHoldRejected(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	alice := bank.openAccount(t, "alice")
+	shop := bank.openAccount(t, "shop")
+	auth := authorize(t, authorizations, "alice", alice.ID, shop.ID, 50)
+
+	amount := 50.01
+	_, err := authorizations.Capture(ctx, "alice", auth.ID, models.CaptureAuthorizationRequest{Amount: &amount})
+	if !errors.Is(err, ErrCaptureExceedsAmount) {
+		t.Errorf("сервис: ошибка %v, ожидалась %v", err, ErrCaptureExceedsAmount)
+	}
+
+	// Хранилище само не даёт списать больше холда, даже если сервис
+	// посчитал комиссию иначе
+	_, _, err = bank.store.Authorizations().Capture(ctx, auth.ID, 50, 2)
+	if !errors.Is(err, repository.ErrTransactionFailed) {
+		t.Errorf("хранилище: ошибка %v, ожидалась %v", err, repository.ErrTransactionFailed)
+	}
+
+	assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 100)
+	assertMoney(t, "холд на счёте", bank.held(t, alice.ID), 51.5)
+	assertMoney(t, "баланс получателя", bank.balance(t, shop.ID), 100)
+}
+
+func TestAuthorizationCaptureRejectsInactivePayer(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	shop := bank.openAccount(t, "shop")
+
+	for _, tt := range []struct {
+		status string
+		err    error
+	}{
+		{"frozen", ErrAccountFrozen},
+		{"closed", repository.ErrAccountClosed},
+	} {
+		t.Run(tt.status, func(t *testing.T) {
+			alice := bank.openAccount(t, "alice")
+			auth := authorize(t, authorizations, "alice", alice.ID, shop.ID, 50)
+			if err := bank.store.Accounts().UpdateStatus(ctx, alice.ID, tt.status); err != nil {
+				t.Fatalf("UpdateStatus: %v", err)
+			}
+
+			_, err := authorizations.Capture(ctx, "shop", auth.ID, models.CaptureAuthorizationRequest{})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ошибка %v, ожидалась %v", err, tt.err)
+			}
+			assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 100)
+			assertMoney(t, "холд на счёте", bank.held(t, alice.ID), 51.5)
+		})
+	}
+	assertMoney(t, "баланс получателя", bank.balance(t, shop.ID), 100)
+}
+
+func TestAuthorizationRelease(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	alice := bank.openAccount(t, "alice")
+	shop := bank.openAccount(t, "shop")
+	bank.openAccount(t, "mallory")
+	auth := authorize(t, authorizations, "alice", alice.ID, shop.ID, 50)
+
+	if _, err := authorizations.Release(ctx, "mallory", auth.ID); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("чужая авторизация: ошибка %v, ожидалась %v", err, ErrUnauthorizedAccess)
+	}
+
+	released, err := authorizations.Release(ctx, "alice", auth.ID)
+	if err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if released.Status != "released" {
+		t.Errorf("статус = %s, ожидался released", released.Status)
+	}
+	assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 100)
+	assertMoney(t, "холд на счёте", bank.held(t, alice.ID), 0)
+	assertMoney(t, "баланс получателя", bank.balance(t, shop.ID), 100)
+
+	if _, err := authorizations.Release(ctx, "alice", auth.ID); !errors.Is(err, repository.ErrAuthorizationNotActive) {
+		t.Errorf("повторная отмена: ошибка %v, ожидалась %v", err, repository.ErrAuthorizationNotActive)
+	}
+}
+
+func TestAuthorizationExpiry(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	authorizations := newTestAuthorizations(bank)
+	alice := bank.openAccount(t, "alice")
+	shop := bank.openAccount(t, "shop")
+	expired := authorize(t, authorizations, "alice", alice.ID, shop.ID, 20)
+	live := authorize(t, authorizations, "alice", alice.ID, shop.ID, 10)
+	bank.expire(t, expired.ID)
+
+	_, err := authorizations.Capture(ctx, "shop", expired.ID, models.CaptureAuthorizationRequest{})
+	if !errors.Is(err, repository.ErrAuthorizationExpired) {
+		t.Errorf("списание просроченной: ошибка %v, ожидалась %v", err, repository.ErrAuthorizationExpired)
+	}
+	assertMoney(t, "холд до снятия", bank.held(t, alice.ID), 30.9)
+
+	if err := authorizations.expireDue(ctx); err != nil {
+		t.Fatalf("expireDue: %v", err)
+	}
+
+	got, err := authorizations.Get(ctx, "alice", expired.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != "expired" {
+		t.Errorf("статус просроченной = %s, ожидался expired", got.Status)
+	}
+	if got, _ := authorizations.Get(ctx, "alice", live.ID); got == nil || got.Status != "active" {
+		t.Errorf("действующая авторизация = %+v", got)
+	}
+	assertMoney(t, "холд после снятия", bank.held(t, alice.ID), 10.3)
+	assertMoney(t, "баланс плательщика", bank.balance(t, alice.ID), 100)
+}
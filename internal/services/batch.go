@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidBatchMode  = errors.New("режим пакета должен быть all_or_nothing или best_effort")
+	ErrEmptyBatch        = errors.New("пакет не содержит переводов")
+	ErrBatchTooLarge     = errors.New("слишком много переводов в пакете")
+	ErrBatchNotResumable = errors.New("пакет нельзя перезапустить: обработка завершена или пакет all_or_nothing уже выполнялся")
+)
+
+const (
+	BatchModeAllOrNothing = "all_or_nothing"
+	BatchModeBestEffort   = "best_effort"
+
+	// MaxBatchItems — максимальное число переводов в одном пакете
+	MaxBatchItems = 1000
+	// BatchSyncThreshold — пакеты крупнее обрабатываются в Worker Pool,
+	// а клиент получает 202 и опрашивает статус
+	BatchSyncThreshold = 50
+)
+
+// BatchService выполняет пакетные переводы с одного счёта
+type BatchService struct {
+	batchRepo          repository.BatchStore
+	transactionRepo    repository.TransactionStore
+	accountRepo        repository.AccountStore
+	transactionService *TransactionService
+	workerPool         *worker.WorkerPool
+}
+
+func NewBatchService(
+	batchRepo repository.BatchStore,
+	transactionRepo repository.TransactionStore,
+	accountRepo repository.AccountStore,
+	transactionService *TransactionService,
+	workerPool *worker.WorkerPool,
+) *BatchService {
+	utils.LogSuccess("BatchService", "Инициализирован сервис пакетных переводов")
+	return &BatchService{
+		batchRepo:          batchRepo,
+		transactionRepo:    transactionRepo,
+		accountRepo:        accountRepo,
+		transactionService: transactionService,
+		workerPool:         workerPool,
+	}
+}
+
+// Submit проверяет и сохраняет пакет. Небольшие пакеты выполняются сразу,
+// крупные ставятся в Worker Pool; во втором случае возвращается async = true
+// и пакет в статусе pending.
+func (s *BatchService) Submit(ctx context.Context, userID string, req models.BatchTransferRequest) (batch *models.TransferBatch, async bool, err error) {
+	ctx, span := tracing.Start(ctx, "BatchService.Submit",
+		attribute.String("account.from", req.FromAccountID),
+		attribute.String("batch.mode", req.Mode),
+		attribute.Int("batch.items", len(req.Items)),
+	)
+	defer span.End()
+
+	utils.LogInfo("BatchService", "Пакет из %d переводов от пользователя %s со счёта %s (%s)",
+		len(req.Items), userID, req.FromAccountID, req.Mode)
+
+	if err := s.validate(ctx, userID, req); err != nil {
+		tracing.Fail(span, err)
+		return nil, false, err
+	}
+
+	batch = &models.TransferBatch{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		Mode:          req.Mode,
+		Items:         make([]models.TransferBatchItem, len(req.Items)),
+	}
+	for i, item := range req.Items {
+		amount := roundMoney(item.Amount)
+		batch.Items[i] = models.TransferBatchItem{
+			ToAccountID: item.ToAccountID,
+			Amount:      amount,
+			Reference:   item.Reference,
+		}
+		batch.TotalAmount += amount
+	}
+	batch.TotalAmount = roundMoney(batch.TotalAmount)
+
+	batch, err = s.batchRepo.Create(ctx, batch)
+	if err != nil {
+		utils.LogError("BatchService", "Ошибка сохранения пакета", err)
+		tracing.Fail(span, err)
+		return nil, false, err
+	}
+	span.SetAttributes(attribute.String("batch.id", batch.ID))
+
+	if len(batch.Items) <= BatchSyncThreshold {
+		s.process(ctx, batch)
+		return batch, false, nil
+	}
+
+	// Воркер изменяет batch по ходу выполнения, клиенту отдаётся снимок
+	snapshot := *batch
+	snapshot.Items = append([]models.TransferBatchItem(nil), batch.Items...)
+
+	job := worker.Job{
+		ID:  fmt.Sprintf("transfer-batch-%s", batch.ID),
+		Ctx: ctx,
+		Task: func(jobCtx context.Context) error {
+			s.process(jobCtx, batch)
+			return nil
+		},
+		// Повтор денежных операций пакета недопустим
+		RetryOn: func(error) bool { return false },
+	}
+	if err := s.workerPool.Submit(job); err != nil {
+		utils.LogWarning("BatchService", "Worker Pool переполнен, пакет %s выполняется синхронно", batch.ID)
+		s.process(ctx, batch)
+		return batch, false, nil
+	}
+
+	utils.LogInfo("BatchService", "Пакет %s поставлен в очередь", batch.ID)
+	return &snapshot, true, nil
+}
+
+// Get возвращает пакет с результатами по позициям
+func (s *BatchService) Get(ctx context.Context, userID, id string) (*models.TransferBatch, error) {
+	ctx, span := tracing.Start(ctx, "BatchService.Get", attribute.String("batch.id", id))
+	defer span.End()
+
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if batch.UserID != userID {
+		utils.LogWarning("BatchService", "Попытка доступа к чужому пакету %s пользователем %s", id, userID)
+		tracing.Fail(span, ErrUnauthorizedAccess)
+		return nil, ErrUnauthorizedAccess
+	}
+
+	return batch, nil
+}
+
+// ListStale возвращает пакеты, зависшие в статусах pending/processing дольше olderThan
+func (s *BatchService) ListStale(ctx context.Context, olderThan time.Duration, limit int) ([]models.FailedJob, error) {
+	ctx, span := tracing.Start(ctx, "BatchService.ListStale")
+	defer span.End()
+
+	jobs, err := s.batchRepo.ListStale(ctx, olderThan, limit)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Resume дообрабатывает пакет, выполнение которого прервалось. Пакет
+// best_effort продолжается с необработанных позиций. Пакет all_or_nothing
+// перезапускается только из pending: в статусе processing его DB-транзакция
+// могла уже зафиксироваться, и повтор привёл бы к двойному списанию.
+func (s *BatchService) Resume(ctx context.Context, id string) (*models.TransferBatch, error) {
+	ctx, span := tracing.Start(ctx, "BatchService.Resume", attribute.String("batch.id", id))
+	defer span.End()
+
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	resumable := batch.Status == "pending" ||
+		(batch.Status == "processing" && batch.Mode == BatchModeBestEffort)
+	if !resumable {
+		tracing.Fail(span, ErrBatchNotResumable)
+		return nil, ErrBatchNotResumable
+	}
+
+	utils.LogInfo("BatchService", "Перезапуск пакета %s (%s, статус %s)", batch.ID, batch.Mode, batch.Status)
+	s.process(ctx, batch)
+	return batch, nil
+}
+
+func (s *BatchService) process(ctx context.Context, batch *models.TransferBatch) {
+	ctx, span := tracing.Start(ctx, "BatchService.process",
+		attribute.String("batch.id", batch.ID),
+		attribute.String("batch.mode", batch.Mode),
+	)
+	defer span.End()
+
+	batch.Status = "processing"
+	if err := s.batchRepo.SetStatus(ctx, batch.ID, batch.Status); err != nil {
+		utils.LogError("BatchService", "Ошибка обновления статуса пакета", err)
+	}
+
+	if batch.Mode == BatchModeAllOrNothing {
+		s.processAtomic(ctx, batch)
+	} else {
+		s.processBestEffort(ctx, batch)
+	}
+
+	switch {
+	case batch.FailedItems == 0:
+		batch.Status = "completed"
+	case batch.SucceededItems == 0:
+		batch.Status = "failed"
+	default:
+		batch.Status = "partially_completed"
+	}
+
+	if err := s.batchRepo.Finish(ctx, batch); err != nil {
+		utils.LogError("BatchService", "Ошибка сохранения результатов пакета", err)
+		tracing.Fail(span, err)
+	}
+
+	span.SetAttributes(
+		attribute.String("batch.status", batch.Status),
+		attribute.Int("batch.succeeded", batch.SucceededItems),
+		attribute.Int("batch.failed", batch.FailedItems),
+	)
+	utils.LogSuccess("BatchService", "Пакет %s обработан: %s (успешно %d, с ошибкой %d)",
+		batch.ID, batch.Status, batch.SucceededItems, batch.FailedItems)
+}
+
+// processAtomic выполняет все переводы в одной DB-транзакции
+func (s *BatchService) processAtomic(ctx context.Context, batch *models.TransferBatch) {
+	items := make([]repository.BatchTransfer, len(batch.Items))
+	for i, item := range batch.Items {
+		items[i] = repository.BatchTransfer{
+			ToAccountID: item.ToAccountID,
+			Amount:      item.Amount,
+			FeeAmount:   transferFee(item.Amount),
+		}
+	}
+
+	transactions, err := s.transactionRepo.ExecuteBatch(ctx, batch.FromAccountID, items, 1)
+	if err != nil {
+		utils.LogError("BatchService", fmt.Sprintf("Пакет %s отклонён", batch.ID), err)
+
+		key := failureKey(err)
+		batch.Error = &key
+
+		failedIndex := -1
+		var itemErr *repository.BatchItemError
+		if errors.As(err, &itemErr) {
+			failedIndex = itemErr.Index
+		}
+
+		for i := range batch.Items {
+			itemKey := FailureBatchCancelled
+			if i == failedIndex {
+				itemKey = failureKey(itemErr.Err)
+			}
+			batch.Items[i].Status = "failed"
+			batch.Items[i].Error = &itemKey
+		}
+		batch.FailedItems = len(batch.Items)
+		return
+	}
+
+	for i := range batch.Items {
+		batch.Items[i].Status = "succeeded"
+		batch.Items[i].TransactionID = &transactions[i].ID
+		s.transactionService.invalidateCacheAsync(ctx, batch.FromAccountID, batch.Items[i].ToAccountID, transactions[i].ID)
+	}
+	batch.SucceededItems = len(batch.Items)
+}
+
+// processBestEffort выполняет переводы по одному через обычный Transfer;
+// ошибка одной позиции не влияет на остальные. Как и all_or_nothing, пакет
+// расходует одну операцию лимита количества: она списывается с первой
+// успешной позиции. Ключ идемпотентности позиции защищает от повторного
+// списания, если перевод прошёл, а статус позиции сохранить не удалось, и
+// пакет перезапускается через Resume или повтор задачи.
+func (s *BatchService) processBestEffort(ctx context.Context, batch *models.TransferBatch) {
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.Status != "pending" {
+			// Позиция обработана до перезапуска пакета
+			continue
+		}
+
+		transaction, err := s.transactionService.Transfer(ctx, batch.UserID, models.TransferRequest{
+			FromAccountID:  batch.FromAccountID,
+			ToAccountID:    item.ToAccountID,
+			Amount:         item.Amount,
+			IdempotencyKey: fmt.Sprintf("transfer-batch:%s:%d", batch.ID, item.Index),
+			BatchItem:      batch.SucceededItems > 0,
+		})
+		if err != nil {
+			key := failureKey(err)
+			item.Status = "failed"
+			item.Error = &key
+			batch.FailedItems++
+		} else {
+			item.Status = "succeeded"
+			item.TransactionID = &transaction.ID
+			batch.SucceededItems++
+		}
+
+		// Прогресс сохраняется сразу, чтобы статус пакета отражал ход выполнения
+		if err := s.batchRepo.UpdateItem(ctx, batch.ID, item); err != nil {
+			utils.LogError("BatchService", "Ошибка сохранения позиции пакета", err)
+		}
+	}
+}
+
+func (s *BatchService) validate(ctx context.Context, userID string, req models.BatchTransferRequest) error {
+	if req.Mode != BatchModeAllOrNothing && req.Mode != BatchModeBestEffort {
+		return ErrInvalidBatchMode
+	}
+
+	if len(req.Items) == 0 {
+		return ErrEmptyBatch
+	}
+
+	if len(req.Items) > MaxBatchItems {
+		return ErrBatchTooLarge
+	}
+
+	for i, item := range req.Items {
+		if err := validateCents(item.Amount); err != nil {
+			return &repository.BatchItemError{Index: i, Err: err}
+		}
+		if item.ToAccountID == req.FromAccountID {
+			return &repository.BatchItemError{Index: i, Err: ErrSelfTransfer}
+		}
+	}
+
+	fromAccount, err := s.accountRepo.GetByID(ctx, req.FromAccountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+
+	if fromAccount.UserID != userID {
+		return ErrUnauthorizedAccess
+	}
+
+	if fromAccount.Status != "active" {
+		return repository.ErrAccountClosed
+	}
+
+	return nil
+}
+
+// transferFee — комиссия за перевод (1%), округлённая до копеек
+func transferFee(amount float64) float64 {
+	return roundMoney(amount * 0.01)
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func newTestBatches(bank *testBank) *BatchService {
+	return NewBatchService(bank.store.Batches(), bank.store.Transactions(), bank.store.Accounts(), bank.transactions, nil)
+}
+
+func batchRequest(from, mode string, items ...models.BatchItemRequest) models.BatchTransferRequest {
+	return models.BatchTransferRequest{FromAccountID: from, Mode: mode, Items: items}
+}
+
+func batchItem(to string, amount float64) models.BatchItemRequest {
+	return models.BatchItemRequest{ToAccountID: to, Amount: amount}
+}
+
+func submitBatch(t *testing.T, batches *BatchService, userID string, req models.BatchTransferRequest) *models.TransferBatch {
+	t.Helper()
+
+	batch, async, err := batches.Submit(context.Background(), userID, req)
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if async {
+		t.Fatal("небольшой пакет поставлен в очередь")
+	}
+	return batch
+}
+
+func assertItem(t *testing.T, batch *models.TransferBatch, index int, status, failure string) {
+	t.Helper()
+
+	item := batch.Items[index]
+	if item.Status != status {
+		t.Errorf("позиция %d: статус %s, ожидался %s", index, item.Status, status)
+	}
+	switch {
+	case failure == "" && item.Error != nil:
+		t.Errorf("позиция %d: ошибка %q", index, *item.Error)
+	case failure != "" && (item.Error == nil || *item.Error != failure):
+		t.Errorf("позиция %d: ошибка %v, ожидалась %q", index, item.Error, failure)
+	}
+	if (status == "succeeded") != (item.TransactionID != nil) {
+		t.Errorf("позиция %d: transaction_id = %v", index, item.TransactionID)
+	}
+}
+
+func TestBatchValidation(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	batches := newTestBatches(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+
+	tests := []struct {
+		name  string
+		req   models.BatchTransferRequest
+		err   error
+		index int
+	}{
+		{"неизвестный режим", batchRequest(alice.ID, "some", batchItem(bob.ID, 10)), ErrInvalidBatchMode, -1},
+		{"пустой пакет", batchRequest(alice.ID, BatchModeBestEffort), ErrEmptyBatch, -1},
+		{"нулевая сумма", batchRequest(alice.ID, BatchModeBestEffort, batchItem(bob.ID, 10), batchItem(bob.ID, 0)), ErrInvalidAmount, 1},
+		{"сумма округляется до нуля", batchRequest(alice.ID, BatchModeAllOrNothing, batchItem(bob.ID, 0.004)), ErrInvalidAmount, 0},
+		{"дробные копейки", batchRequest(alice.ID, BatchModeBestEffort, batchItem(bob.ID, 1), batchItem(bob.ID, 2), batchItem(bob.ID, 10.005)), ErrInvalidAmount, 2},
+		{"перевод самому себе", batchRequest(alice.ID, BatchModeBestEffort, batchItem(alice.ID, 10)), ErrSelfTransfer, 0},
+		{"чужой счёт", batchRequest(bob.ID, BatchModeBestEffort, batchItem(alice.ID, 10)), ErrUnauthorizedAccess, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := batches.Submit(ctx, "alice", tt.req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Submit: %v, ожидалось %v", err, tt.err)
+			}
+			var itemErr *repository.BatchItemError
+			if tt.index >= 0 && (!errors.As(err, &itemErr) || itemErr.Index != tt.index) {
+				t.Errorf("ошибка %v, ожидалась позиция %d", err, tt.index)
+			}
+		})
+	}
+
+	assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100)
+}
+
+func TestBatchAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	batches := newTestBatches(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+	carol := bank.openAccount(t, "carol")
+
+	batch := submitBatch(t, batches, "alice", batchRequest(alice.ID, BatchModeAllOrNothing,
+		batchItem(bob.ID, 30), batchItem(carol.ID, 20)))
+	if batch.Status != "completed" || batch.SucceededItems != 2 || batch.Error != nil {
+		t.Fatalf("пакет = %+v", batch)
+	}
+	assertItem(t, batch, 0, "succeeded", "")
+	assertItem(t, batch, 1, "succeeded", "")
+	assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 49.5)
+	assertMoney(t, "баланс bob", bank.balance(t, bob.ID), 130)
+	assertMoney(t, "баланс carol", bank.balance(t, carol.ID), 120)
+
+	// Ошибка одной позиции откатывает весь пакет
+	if err := bank.accounts.DeleteAccount(ctx, carol.ID, "carol"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	batch = submitBatch(t, batches, "alice", batchRequest(alice.ID, BatchModeAllOrNothing,
+		batchItem(bob.ID, 10), batchItem(carol.ID, 10), batchItem(bob.ID, 5)))
+	if batch.Status != "failed" || batch.FailedItems != 3 || batch.SucceededItems != 0 {
+		t.Fatalf("пакет = %+v", batch)
+	}
+	if batch.Error == nil || *batch.Error != FailureAccountClosed {
+		t.Errorf("ошибка пакета = %v", batch.Error)
+	}
+	assertItem(t, batch, 0, "failed", FailureBatchCancelled)
+	assertItem(t, batch, 1, "failed", FailureAccountClosed)
+	assertItem(t, batch, 2, "failed", FailureBatchCancelled)
+	assertMoney(t, "баланс отправителя после отката", bank.balance(t, alice.ID), 49.5)
+	assertMoney(t, "баланс bob после отката", bank.balance(t, bob.ID), 130)
+
+	// Результат сохранён и читается владельцем
+	stored, err := batches.Get(ctx, "alice", batch.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	assertItem(t, stored, 1, "failed", FailureAccountClosed)
+	if _, err := batches.Get(ctx, "bob", batch.ID); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("Get чужого пакета: %v", err)
+	}
+}
+
+func TestBatchBestEffort(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	batches := newTestBatches(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+
+	// На третью позицию (40 + 0.40 комиссии) денег уже не хватает
+	batch := submitBatch(t, batches, "alice", batchRequest(alice.ID, BatchModeBestEffort,
+		batchItem(bob.ID, 40), batchItem(bob.ID, 40), batchItem(bob.ID, 40), batchItem(bob.ID, 15)))
+	if batch.Status != "partially_completed" || batch.SucceededItems != 3 || batch.FailedItems != 1 {
+		t.Fatalf("пакет = %+v", batch)
+	}
+	assertItem(t, batch, 0, "succeeded", "")
+	assertItem(t, batch, 1, "succeeded", "")
+	assertItem(t, batch, 2, "failed", FailureInsufficientBalance)
+	assertItem(t, batch, 3, "succeeded", "")
+	assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100-80.8-15.15)
+	assertMoney(t, "баланс получателя", bank.balance(t, bob.ID), 195)
+
+	stored, err := batches.Get(ctx, "alice", batch.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Status != "partially_completed" || stored.SucceededItems != 3 || stored.FailedItems != 1 {
+		t.Errorf("сохранённый пакет = %+v", stored)
+	}
+	assertItem(t, stored, 2, "failed", FailureInsufficientBalance)
+}
+
+func TestBatchResumeDoesNotRepeatTransfers(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	batches := newTestBatches(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+
+	// Пакет прервался после проводки первой позиции, но до сохранения
+	// её результата: позиция осталась pending
+	batch, err := bank.store.Batches().Create(ctx, &models.TransferBatch{
+		UserID:        "alice",
+		FromAccountID: alice.ID,
+		Mode:          BatchModeBestEffort,
+		Items:         []models.TransferBatchItem{{ToAccountID: bob.ID, Amount: 30}, {ToAccountID: bob.ID, Amount: 20}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := bank.store.Batches().SetStatus(ctx, batch.ID, "processing"); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	first, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+		FromAccountID:  alice.ID,
+		ToAccountID:    bob.ID,
+		Amount:         30,
+		IdempotencyKey: fmt.Sprintf("transfer-batch:%s:%d", batch.ID, 0),
+	})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	resumed, err := batches.Resume(ctx, batch.ID)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed.Status != "completed" || resumed.SucceededItems != 2 {
+		t.Fatalf("пакет = %+v", resumed)
+	}
+	if id := resumed.Items[0].TransactionID; id == nil || *id != first.ID {
+		t.Errorf("позиция 0 связана с %v, ожидалась уже проведённая %s", id, first.ID)
+	}
+	assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100-30.3-20.2)
+	assertMoney(t, "баланс получателя", bank.balance(t, bob.ID), 150)
+
+	// Завершённый пакет повторно не запускается
+	if _, err := batches.Resume(ctx, batch.ID); !errors.Is(err, ErrBatchNotResumable) {
+		t.Errorf("повторный Resume: %v", err)
+	}
+}
+
+func TestBatchCountsAsOneOperation(t *testing.T) {
+	for _, mode := range []string{BatchModeAllOrNothing, BatchModeBestEffort} {
+		t.Run(mode, func(t *testing.T) {
+			ctx := context.Background()
+			bank := newTestBank(t, nil)
+			batches := newTestBatches(bank)
+			alice := bank.openAccount(t, "alice")
+			bob := bank.openAccount(t, "bob")
+			one := 1
+			bank.store.SetSpendingLimits(repository.LimitScopeUser, "alice", models.SpendingLimits{DailyCountMax: &one})
+
+			batch := submitBatch(t, batches, "alice", batchRequest(alice.ID, mode,
+				batchItem(bob.ID, 10), batchItem(bob.ID, 10), batchItem(bob.ID, 10)))
+			if batch.Status != "completed" {
+				t.Fatalf("пакет при лимите в одну операцию = %+v", batch)
+			}
+
+			// Лимит израсходован пакетом: ни перевод, ни новый пакет не проходят
+			_, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+				FromAccountID: alice.ID, ToAccountID: bob.ID, Amount: 10,
+			})
+			if !errors.Is(err, repository.ErrLimitExceeded) {
+				t.Errorf("перевод после пакета: %v", err)
+			}
+
+			batch = submitBatch(t, batches, "alice", batchRequest(alice.ID, mode,
+				batchItem(bob.ID, 10), batchItem(bob.ID, 10)))
+			if batch.Status != "failed" {
+				t.Fatalf("второй пакет = %+v", batch)
+			}
+			if mode == BatchModeBestEffort {
+				assertItem(t, batch, 0, "failed", FailureLimitExceeded)
+				assertItem(t, batch, 1, "failed", FailureLimitExceeded)
+			} else if batch.Error == nil || *batch.Error != FailureLimitExceeded {
+				t.Errorf("ошибка пакета = %v", batch.Error)
+			}
+			assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100-30.3)
+		})
+	}
+}
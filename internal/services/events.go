@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+)
+
+// EventPublisher доставляет события клиентам потока GET /v1/events.
+// Реализация — events.Broker поверх Redis.
+type EventPublisher interface {
+	Publish(ctx context.Context, userID string, event *models.Event) error
+}
+
+// CombinePublishers возвращает EventPublisher, который передаёт событие
+// каждому из publishers по очереди. Ошибка одного не мешает остальным.
+func CombinePublishers(publishers ...EventPublisher) EventPublisher {
+	return combinedPublisher(publishers)
+}
+
+type combinedPublisher []EventPublisher
+
+func (c combinedPublisher) Publish(ctx context.Context, userID string, event *models.Event) error {
+	var errs []error
+	for _, publisher := range c {
+		if err := publisher.Publish(ctx, userID, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// eventNotifier публикует события об операциях со счетами. Публикация
+// идёт в пуле воркеров после фиксации операции и на её результат не
+// влияет; nil-notifier ничего не публикует.
+type eventNotifier struct {
+	publisher   EventPublisher
+	accountRepo repository.AccountStore
+}
+
+func newEventNotifier(publisher EventPublisher, accountRepo repository.AccountStore) *eventNotifier {
+	if publisher == nil {
+		return nil
+	}
+	return &eventNotifier{publisher: publisher, accountRepo: accountRepo}
+}
+
+// transactionsCompleted отправляет владельцам счетов каждой транзакции
+// transaction.completed, а по каждому счёту — balance.changed. nil среди
+// транзакций пропускаются (возврат без возврата комиссии).
+func (n *eventNotifier) transactionsCompleted(ctx context.Context, pool *worker.WorkerPool, transactions ...*models.Transaction) {
+	if n == nil || len(transactions) == 0 || transactions[0] == nil {
+		return
+	}
+	n.submit(ctx, pool, "events-"+transactions[0].ID, func(jobCtx context.Context) error {
+		for _, transaction := range transactions {
+			if transaction == nil {
+				continue
+			}
+			if err := n.publishTransaction(jobCtx, transaction); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// balancesChanged отправляет balance.changed по счетам без транзакции:
+// холд или его снятие меняют доступный остаток
+func (n *eventNotifier) balancesChanged(ctx context.Context, pool *worker.WorkerPool, accountIDs ...string) {
+	if n == nil || len(accountIDs) == 0 {
+		return
+	}
+	n.submit(ctx, pool, "events-balance-"+accountIDs[0], func(jobCtx context.Context) error {
+		for _, accountID := range accountIDs {
+			account, err := n.accountRepo.GetByID(jobCtx, accountID)
+			if err != nil {
+				return err
+			}
+			if err := n.publishBalance(jobCtx, account, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (n *eventNotifier) publishTransaction(ctx context.Context, transaction *models.Transaction) error {
+	notified := make(map[string]bool)
+	for _, accountID := range []string{transaction.FromAccountID, transaction.ToAccountID} {
+		// Системный счёт банка ничей, о комиссиях клиентам не сообщаем
+		if accountID == "" || accountID == repository.SystemBankAccountID {
+			continue
+		}
+		account, err := n.accountRepo.GetByID(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("счёт %s: %w", accountID, err)
+		}
+
+		if !notified[account.UserID] {
+			notified[account.UserID] = true
+			if err := n.publisher.Publish(ctx, account.UserID, &models.Event{
+				Type:        models.EventTransactionCompleted,
+				Transaction: models.NewTransactionV2(transaction),
+			}); err != nil {
+				return err
+			}
+		}
+		if err := n.publishBalance(ctx, account, transaction.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishBalance отправляет владельцу баланс счёта, прочитанный после операции
+func (n *eventNotifier) publishBalance(ctx context.Context, account *models.Account, transactionID string) error {
+	return n.publisher.Publish(ctx, account.UserID, &models.Event{
+		Type: models.EventBalanceChanged,
+		Balance: &models.BalanceChange{
+			AccountID:        account.ID,
+			Balance:          models.MoneyOf(account.Balance),
+			AvailableBalance: models.MoneyOf(account.AvailableBalance()),
+			TransactionID:    transactionID,
+		},
+	})
+}
+
+// submit ставит публикацию в пул воркеров, а без пула или при полной
+// очереди выполняет сразу. Повторов нет: повтор после частичной публикации
+// прислал бы клиенту дубликаты, а пропуск закроет следующее событие баланса.
+func (n *eventNotifier) submit(ctx context.Context, pool *worker.WorkerPool, jobID string, task func(context.Context) error) {
+	if pool != nil {
+		err := pool.Submit(worker.Job{
+			ID:      jobID,
+			Ctx:     ctx,
+			Task:    task,
+			RetryOn: func(error) bool { return false },
+			OnDone: func(err error) {
+				if err != nil {
+					utils.LogWarning("Events", "Не удалось опубликовать события %s: %v", jobID, err)
+				}
+			},
+		})
+		if err == nil {
+			return
+		}
+		utils.LogWarning("Events", "Worker Pool переполнен, события %s публикуются синхронно", jobID)
+	}
+
+	if err := task(ctx); err != nil {
+		utils.LogWarning("Events", "Не удалось опубликовать события %s: %v", jobID, err)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+
+	"bank-prototype/internal/repository"
+)
+
+// Причины неудачи фоновых операций — позиций пакетов и выполнений
+// регулярных переводов. В базе сохраняется ключ причины, а не текст ошибки:
+// текст на языке журнала, а у внутренних ошибок раскрывает устройство
+// системы. В коды API ключи переводит слой обработчиков.
+const (
+	FailureInsufficientBalance = "insufficient_balance"
+	FailureLimitExceeded       = "spending_limit_exceeded"
+	FailureAccountNotFound     = "account_not_found"
+	FailureAccountClosed       = "account_closed"
+	FailureAccessDenied        = "access_denied"
+	FailureInvalidAmount       = "invalid_amount"
+	FailureSelfTransfer        = "self_transfer"
+	FailureBatchCancelled      = "batch_cancelled"
+	FailureInternal            = "internal_error"
+)
+
+var failureRules = []struct {
+	err error
+	key string
+}{
+	{repository.ErrInsufficientBalance, FailureInsufficientBalance},
+	{repository.ErrLimitExceeded, FailureLimitExceeded},
+	{repository.ErrAccountNotFound, FailureAccountNotFound},
+	{repository.ErrAccountClosed, FailureAccountClosed},
+	{ErrUnauthorizedAccess, FailureAccessDenied},
+	{ErrInvalidAmount, FailureInvalidAmount},
+	{ErrSelfTransfer, FailureSelfTransfer},
+}
+
+// failureKey возвращает ключ причины для ошибки перевода. Неизвестные
+// ошибки сводятся к FailureInternal.
+func failureKey(err error) string {
+	for _, rule := range failureRules {
+		if errors.Is(err, rule.err) {
+			return rule.key
+		}
+	}
+	return FailureInternal
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidLimit      = errors.New("лимит должен быть больше 0")
+	ErrLimitAboveCeiling = errors.New("лимит не может превышать потолок банка")
+)
+
+// SpendingLimitService управляет лимитами расходов. Сама проверка лимитов
+// выполняется в репозитории внутри транзакции перевода.
+type SpendingLimitService struct {
+	limitRepo   *repository.SpendingLimitRepository
+	accountRepo repository.AccountStore
+}
+
+func NewSpendingLimitService(limitRepo *repository.SpendingLimitRepository, accountRepo repository.AccountStore) *SpendingLimitService {
+	return &SpendingLimitService{
+		limitRepo:   limitRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// GetAccountLimits возвращает лимиты счёта и пользователя с оставшимся запасом
+func (s *SpendingLimitService) GetAccountLimits(ctx context.Context, accountID, userID string) (*models.AccountLimitsResponse, error) {
+	ctx, span := tracing.Start(ctx, "SpendingLimitService.GetAccountLimits", attribute.String("account.id", accountID))
+	defer span.End()
+
+	if err := s.verifyOwnership(ctx, accountID, userID); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	state, err := s.limitRepo.GetState(ctx, userID, accountID)
+	if err != nil {
+		utils.LogError("SpendingLimitService", "Ошибка получения лимитов", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return &models.AccountLimitsResponse{
+		AccountID: accountID,
+		SingleMax: state.SingleMax(),
+		Account:   headroom(state.Account, state.AccountUsage),
+		User:      headroom(state.EffectiveUser(), state.UserUsage),
+		Ceilings:  state.Bank,
+	}, nil
+}
+
+// SetAccountLimits задаёт собственные лимиты счёта. nil-поля снимают лимит
+// счёта — тогда действуют только лимиты пользователя и банка.
+func (s *SpendingLimitService) SetAccountLimits(ctx context.Context, accountID, userID string, limits models.SpendingLimits) (*models.AccountLimitsResponse, error) {
+	ctx, span := tracing.Start(ctx, "SpendingLimitService.SetAccountLimits", attribute.String("account.id", accountID))
+	defer span.End()
+
+	if err := s.verifyOwnership(ctx, accountID, userID); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if err := s.validate(ctx, limits); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if err := s.limitRepo.SetAccountLimits(ctx, accountID, limits); err != nil {
+		utils.LogError("SpendingLimitService", "Ошибка сохранения лимитов счёта", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("SpendingLimitService", "Лимиты счёта %s обновлены", accountID)
+
+	return s.GetAccountLimits(ctx, accountID, userID)
+}
+
+// SetUserLimits задаёт лимиты пользователя по всем его счетам
+func (s *SpendingLimitService) SetUserLimits(ctx context.Context, userID string, limits models.SpendingLimits) (*models.SpendingLimits, error) {
+	ctx, span := tracing.Start(ctx, "SpendingLimitService.SetUserLimits", attribute.String("user.id", userID))
+	defer span.End()
+
+	if err := s.validate(ctx, limits); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if err := s.limitRepo.SetUserLimits(ctx, userID, limits); err != nil {
+		utils.LogError("SpendingLimitService", "Ошибка сохранения лимитов пользователя", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("SpendingLimitService", "Лимиты пользователя %s обновлены", userID)
+
+	return &limits, nil
+}
+
+// validate проверяет, что лимиты положительны и не выше потолков банка
+func (s *SpendingLimitService) validate(ctx context.Context, limits models.SpendingLimits) error {
+	ceilings, err := s.limitRepo.GetCeilings(ctx)
+	if err != nil {
+		return err
+	}
+
+	floatLimits := []struct{ value, ceiling *float64 }{
+		{limits.SingleMax, ceilings.SingleMax},
+		{limits.DailyMax, ceilings.DailyMax},
+		{limits.MonthlyMax, ceilings.MonthlyMax},
+	}
+	for _, l := range floatLimits {
+		if l.value == nil {
+			continue
+		}
+		if *l.value <= 0 {
+			return ErrInvalidLimit
+		}
+		if l.ceiling != nil && *l.value > *l.ceiling {
+			return ErrLimitAboveCeiling
+		}
+	}
+
+	if limits.DailyCountMax != nil {
+		if *limits.DailyCountMax <= 0 {
+			return ErrInvalidLimit
+		}
+		if ceilings.DailyCountMax != nil && *limits.DailyCountMax > *ceilings.DailyCountMax {
+			return ErrLimitAboveCeiling
+		}
+	}
+
+	return nil
+}
+
+func (s *SpendingLimitService) verifyOwnership(ctx context.Context, accountID, userID string) error {
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+
+	if account.UserID != userID {
+		return ErrUnauthorizedAccess
+	}
+
+	return nil
+}
+
+func headroom(limits models.SpendingLimits, used models.SpendingUsage) models.LimitHeadroom {
+	h := models.LimitHeadroom{Limits: limits, Used: used}
+
+	if limits.DailyMax != nil {
+		remaining := max(0, roundMoney(*limits.DailyMax-used.Daily))
+		h.DailyRemaining = &remaining
+	}
+	if limits.MonthlyMax != nil {
+		remaining := max(0, roundMoney(*limits.MonthlyMax-used.Monthly))
+		h.MonthlyRemaining = &remaining
+	}
+	if limits.DailyCountMax != nil {
+		remaining := max(0, *limits.DailyCountMax-used.DailyCount)
+		h.DailyCountRemaining = &remaining
+	}
+
+	return h
+}
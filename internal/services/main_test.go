@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"os"
+	"testing"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	// Журнал сервисов в тестах только мешает читать вывод
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testBank — сервисы поверх хранилища в памяти
+type testBank struct {
+	store        *repository.MemoryStore
+	accounts     *AccountService
+	transactions *TransactionService
+}
+
+func newTestBank(t *testing.T, c cache.Cache) *testBank {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	return &testBank{
+		store:        store,
+		accounts:     NewAccountService(store.Accounts(), c),
+		transactions: NewTransactionService(store.Transactions(), store.Accounts(), c),
+	}
+}
+
+// openAccount открывает счёт со стартовым балансом 100
+func (b *testBank) openAccount(t *testing.T, userID string) *models.Account {
+	t.Helper()
+
+	account, err := b.accounts.CreateAccount(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	return account
+}
+
+func (b *testBank) balance(t *testing.T, accountID string) float64 {
+	t.Helper()
+
+	account, err := b.store.Accounts().GetByID(context.Background(), accountID)
+	if err != nil {
+		t.Fatalf("GetByID(%s): %v", accountID, err)
+	}
+	return account.Balance
+}
+
+func assertMoney(t *testing.T, name string, got, want float64) {
+	t.Helper()
+
+	if math.Abs(got-want) > 0.001 {
+		t.Errorf("%s = %.2f, ожидалось %.2f", name, got, want)
+	}
+}
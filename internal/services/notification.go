@@ -0,0 +1,395 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/notifications"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// notificationsLimit — сколько последних уведомлений возвращает Inbox
+	notificationsLimit = 50
+
+	// lowBalanceNotifiedTTL — сколько помнится, что о низком остатке уже
+	// сообщено. Если остаток так и не поднялся выше порога, по истечении
+	// срока следующее списание напомнит о нём снова.
+	lowBalanceNotifiedTTL = 30 * 24 * time.Hour
+)
+
+// NotificationService сообщает пользователям о поступлениях, низком
+// остатке и невыполненных регулярных переводах по каналам, которые они
+// выбрали в настройках. О событиях по счетам он узнаёт как EventPublisher,
+// доставка по каждому каналу идёт отдельной задачей в Worker Pool.
+type NotificationService struct {
+	store       repository.NotificationStore
+	users       repository.UserStore
+	accountRepo repository.AccountStore
+	cache       cache.Cache
+	workerPool  *worker.WorkerPool
+	channels    map[string]notifications.Notifier
+}
+
+// NewNotificationService создаёт сервис с каналом входящих; почта и SMS
+// подключаются через SetChannel
+func NewNotificationService(
+	store repository.NotificationStore,
+	users repository.UserStore,
+	accountRepo repository.AccountStore,
+	c cache.Cache,
+	workerPool *worker.WorkerPool,
+) *NotificationService {
+	utils.LogSuccess("NotificationService", "Инициализирован сервис уведомлений")
+	return &NotificationService{
+		store:       store,
+		users:       users,
+		accountRepo: accountRepo,
+		cache:       c,
+		workerPool:  workerPool,
+		channels: map[string]notifications.Notifier{
+			notifications.ChannelInbox: notifications.NewInboxNotifier(store),
+		},
+	}
+}
+
+// SetChannel подключает канал доставки. Вызывается при запуске, до
+// первых событий.
+func (s *NotificationService) SetChannel(channel string, notifier notifications.Notifier) {
+	s.channels[channel] = notifier
+	utils.LogInfo("NotificationService", "Подключён канал уведомлений: %s", channel)
+}
+
+// Preferences возвращает настройки уведомлений пользователя
+func (s *NotificationService) Preferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.Preferences", attribute.String("user.id", userID))
+	defer span.End()
+
+	preferences, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// UpdatePreferences меняет переданные в req настройки, остальные остаются прежними
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, req models.UpdateNotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.UpdatePreferences", attribute.String("user.id", userID))
+	defer span.End()
+
+	preferences, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value *bool
+		dest  *bool
+	}{
+		{req.EmailEnabled, &preferences.EmailEnabled},
+		{req.SMSEnabled, &preferences.SMSEnabled},
+		{req.InboxEnabled, &preferences.InboxEnabled},
+		{req.IncomingTransfer, &preferences.IncomingTransfer},
+		{req.LowBalance, &preferences.LowBalance},
+		{req.ScheduledTransferFailed, &preferences.ScheduledTransferFailed},
+	} {
+		if field.value != nil {
+			*field.dest = *field.value
+		}
+	}
+	if req.LowBalanceThreshold != nil {
+		preferences.LowBalanceThreshold = *req.LowBalanceThreshold
+	}
+	if req.Language != nil {
+		preferences.Language = *req.Language
+	}
+
+	if err := s.store.SavePreferences(ctx, userID, preferences); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("NotificationService", "Настройки уведомлений пользователя %s обновлены", userID)
+	return &preferences, nil
+}
+
+// Inbox возвращает последние уведомления пользователя, новые первыми
+func (s *NotificationService) Inbox(ctx context.Context, userID string, unreadOnly bool) (*models.NotificationListResponse, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.Inbox", attribute.String("user.id", userID))
+	defer span.End()
+
+	list, err := s.store.ListNotifications(ctx, userID, unreadOnly, notificationsLimit)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	unread, err := s.store.CountUnread(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return &models.NotificationListResponse{
+		Notifications: list,
+		Total:         len(list),
+		Unread:        unread,
+	}, nil
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkRead", attribute.String("user.id", userID))
+	defer span.End()
+
+	if err := s.store.MarkRead(ctx, userID, id); err != nil {
+		if !errors.Is(err, repository.ErrNotificationNotFound) {
+			tracing.Fail(span, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и
+// возвращает их число
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) (int, error) {
+	ctx, span := tracing.Start(ctx, "NotificationService.MarkAllRead", attribute.String("user.id", userID))
+	defer span.End()
+
+	marked, err := s.store.MarkAllRead(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return 0, err
+	}
+	return marked, nil
+}
+
+// Publish получает события по счетам пользователя userID (см.
+// eventNotifier) и превращает их в уведомления. Вызывается из задачи Worker
+// Pool, поэтому ошибки только возвращаются — на операцию они не влияют.
+func (s *NotificationService) Publish(ctx context.Context, userID string, event *models.Event) error {
+	switch event.Type {
+	case models.EventTransactionCompleted:
+		return s.transactionCompleted(ctx, userID, event.Transaction)
+	case models.EventBalanceChanged:
+		return s.balanceChanged(ctx, userID, event.Balance)
+	}
+	return nil
+}
+
+// transactionCompleted сообщает о поступлении на счёт userID со счёта
+// другого клиента. Переводы между своими счетами, пополнения и комиссии
+// уведомлений не порождают.
+func (s *NotificationService) transactionCompleted(ctx context.Context, userID string, transaction *models.TransactionV2) error {
+	if transaction == nil || transaction.Status != "completed" ||
+		transaction.FromAccountID == "" || transaction.FromAccountID == repository.SystemBankAccountID ||
+		transaction.ToAccountID == "" || transaction.ToAccountID == repository.SystemBankAccountID {
+		return nil
+	}
+
+	to, err := s.accountRepo.GetByID(ctx, transaction.ToAccountID)
+	if err != nil {
+		return fmt.Errorf("счёт %s: %w", transaction.ToAccountID, err)
+	}
+	if to.UserID != userID {
+		return nil
+	}
+	from, err := s.accountRepo.GetByID(ctx, transaction.FromAccountID)
+	if err != nil {
+		return fmt.Errorf("счёт %s: %w", transaction.FromAccountID, err)
+	}
+	if from.UserID == userID {
+		return nil
+	}
+
+	return s.notify(ctx, userID, models.NotificationIncomingTransfer, nil, map[string]any{
+		"AccountID": to.ID,
+		"Amount":    transaction.Amount,
+		"Balance":   models.MoneyOf(to.AvailableBalance()),
+	})
+}
+
+// balanceChanged сообщает, когда доступный остаток активного счёта
+// опускается ниже порога из настроек. Сообщение отправляется один раз за
+// пересечение порога: отметка в кеше снимается, когда остаток снова не
+// меньше порога. Без кеша сообщение приходит после каждой операции ниже
+// порога.
+func (s *NotificationService) balanceChanged(ctx context.Context, userID string, change *models.BalanceChange) error {
+	if change == nil {
+		return nil
+	}
+
+	preferences, err := s.store.GetPreferences(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !preferences.LowBalance {
+		return nil
+	}
+
+	available, err := change.AvailableBalance.Float64()
+	if err != nil {
+		return err
+	}
+	notifiedKey := cache.LowBalanceNotifiedKey(change.AccountID)
+	if available >= preferences.LowBalanceThreshold {
+		if s.cache != nil {
+			_ = s.cache.Delete(ctx, notifiedKey)
+		}
+		return nil
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, change.AccountID)
+	if err != nil {
+		return fmt.Errorf("счёт %s: %w", change.AccountID, err)
+	}
+	if account.Status != "active" {
+		return nil
+	}
+
+	if s.cache != nil {
+		if notified, err := s.cache.Exists(ctx, notifiedKey); err == nil && notified {
+			return nil
+		}
+		if err := s.cache.Set(ctx, notifiedKey, change.AccountID, lowBalanceNotifiedTTL); err != nil {
+			utils.LogWarning("NotificationService", "Не удалось отметить уведомление о низком остатке %s: %v", change.AccountID, err)
+		}
+	}
+
+	return s.notify(ctx, userID, models.NotificationLowBalance, &preferences, map[string]any{
+		"AccountID": change.AccountID,
+		"Balance":   change.AvailableBalance,
+		"Threshold": models.MoneyOf(preferences.LowBalanceThreshold),
+	})
+}
+
+// scheduledTransferFailed сообщает владельцу о выполнении регулярного
+// перевода, которое не прошло и больше не повторится: пропущено из-за
+// нехватки средств или завершилось ошибкой без запланированного повтора.
+// Ничего не делает у nil-сервиса.
+func (s *NotificationService) scheduledTransferFailed(ctx context.Context, st *models.ScheduledTransfer, run *models.ScheduledTransferRun) {
+	if s == nil {
+		return
+	}
+	if run.Status != runStatusSkipped && (run.Status != runStatusFailed || st.RetryAt != nil) {
+		return
+	}
+
+	err := s.notify(ctx, st.UserID, models.NotificationScheduledTransferFailed, nil, map[string]any{
+		"Amount":        models.MoneyOf(st.Amount),
+		"FromAccountID": st.FromAccountID,
+		"ToAccountID":   st.ToAccountID,
+		"Skipped":       run.Status == runStatusSkipped,
+		"Paused":        st.Status == scheduleStatusPaused,
+	})
+	if err != nil {
+		utils.LogError("NotificationService", fmt.Sprintf("Ошибка уведомления о регулярном переводе %s", st.ID), err)
+	}
+}
+
+// notify отрисовывает уведомление вида kind и ставит его доставку по каждому
+// включённому каналу. preferences можно не передавать — тогда они читаются.
+func (s *NotificationService) notify(ctx context.Context, userID, kind string, preferences *models.NotificationPreferences, data map[string]any) error {
+	if preferences == nil {
+		stored, err := s.store.GetPreferences(ctx, userID)
+		if err != nil {
+			return err
+		}
+		preferences = &stored
+	}
+	if !preferences.Enabled(kind) {
+		return nil
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	data["Name"] = user.Name
+	if user.DisplayName != "" {
+		data["Name"] = user.DisplayName
+	}
+	rendered, err := notifications.Render(preferences.Language, kind, data)
+	if err != nil {
+		return err
+	}
+
+	msg := notifications.Message{
+		UserID:  user.ID,
+		Name:    user.Name,
+		Email:   user.Email,
+		Phone:   user.Phone,
+		Kind:    kind,
+		Subject: rendered.Subject,
+		Body:    rendered.Body,
+		Short:   rendered.Short,
+	}
+	for _, channel := range []struct {
+		name    string
+		enabled bool
+	}{
+		{notifications.ChannelInbox, preferences.InboxEnabled},
+		{notifications.ChannelEmail, preferences.EmailEnabled},
+		{notifications.ChannelSMS, preferences.SMSEnabled},
+	} {
+		notifier, ok := s.channels[channel.name]
+		if !channel.enabled || !ok {
+			continue
+		}
+		s.deliver(ctx, channel.name, notifier, msg)
+	}
+	return nil
+}
+
+// deliver отправляет сообщение по одному каналу в Worker Pool, а без пула
+// или при полной очереди — сразу. Временные ошибки повторяются, отсутствие
+// адреса и отказ получателя — нет.
+func (s *NotificationService) deliver(ctx context.Context, channel string, notifier notifications.Notifier, msg notifications.Message) {
+	jobID := fmt.Sprintf("notify-%s-%s-%d", msg.Kind, channel, worker.GetCurrentTimeMs())
+	task := func(ctx context.Context) error {
+		return notifier.Notify(ctx, msg)
+	}
+	onDone := func(err error) {
+		switch {
+		case err == nil:
+			utils.LogInfo("NotificationService", "Уведомление %s доставлено пользователю %s (%s)", msg.Kind, msg.UserID, channel)
+		case errors.Is(err, notifications.ErrNoAddress):
+			utils.LogInfo("NotificationService", "У пользователя %s нет адреса для канала %s", msg.UserID, channel)
+		default:
+			utils.LogWarning("NotificationService", "Не удалось доставить уведомление %s: %v", jobID, err)
+		}
+	}
+
+	if s.workerPool != nil {
+		err := s.workerPool.Submit(worker.Job{
+			ID:   jobID,
+			Ctx:  ctx,
+			Task: task,
+			RetryOn: func(err error) bool {
+				return !errors.Is(err, notifications.ErrNoAddress) && !errors.Is(err, notifications.ErrRejected)
+			},
+			OnDone: onDone,
+		})
+		if err == nil {
+			return
+		}
+		utils.LogWarning("NotificationService", "Worker Pool переполнен, уведомление %s доставляется синхронно", jobID)
+	}
+
+	onDone(task(ctx))
+}
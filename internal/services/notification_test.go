@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/notifications"
+	"bank-prototype/internal/notifications/notifytest"
+	"bank-prototype/internal/repository"
+)
+
+// testNotifications — банк в памяти с пользователями alice и bob и
+// уведомлениями через заглушки SMTP и SMS-шлюза
+type testNotifications struct {
+	*testBank
+	service    *NotificationService
+	smtp       *notifytest.SMTPServer
+	gateway    *notifytest.SMSGateway
+	alice, bob *models.User
+}
+
+func newTestNotifications(t *testing.T) *testNotifications {
+	t.Helper()
+
+	c := cache.NewMemoryCache(100)
+	bank := newTestBank(t, c)
+	n := &testNotifications{
+		testBank: bank,
+		smtp:     notifytest.NewSMTPServer(t),
+		gateway:  notifytest.NewSMSGateway(t),
+		alice:    &models.User{Name: "alice", Email: "alice@example.com"},
+		bob:      &models.User{Name: "bob", DisplayName: "Bob", Email: "bob@example.com", Phone: "+79990000002"},
+	}
+	for _, user := range []*models.User{n.alice, n.bob} {
+		if err := bank.store.Users().Create(context.Background(), user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mailer, err := notifications.NewSMTPNotifier(notifications.SMTPConfig{Addr: n.smtp.Addr(), From: "noreply@bank.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sms, err := notifications.NewSMSNotifier(notifications.SMSConfig{URL: n.gateway.URL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.service = NewNotificationService(bank.store.Notifications(), bank.store.Users(), bank.store.Accounts(), c, nil)
+	n.service.SetChannel(notifications.ChannelEmail, mailer)
+	n.service.SetChannel(notifications.ChannelSMS, sms)
+	bank.transactions.SetEventPublisher(CombinePublishers(&recordingPublisher{}, n.service))
+	return n
+}
+
+func (n *testNotifications) transfer(t *testing.T, user *models.User, from, to *models.Account, amount float64) {
+	t.Helper()
+
+	if _, err := n.transactions.Transfer(context.Background(), user.ID, models.TransferRequest{
+		FromAccountID: from.ID, ToAccountID: to.ID, Amount: amount,
+	}); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+}
+
+// kinds возвращает виды уведомлений во входящих пользователя, старые первыми
+func (n *testNotifications) kinds(t *testing.T, user *models.User) string {
+	t.Helper()
+
+	inbox, err := n.service.Inbox(context.Background(), user.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make([]string, 0, len(inbox.Notifications))
+	for i := len(inbox.Notifications) - 1; i >= 0; i-- {
+		kinds = append(kinds, inbox.Notifications[i].Kind)
+	}
+	return strings.Join(kinds, " ")
+}
+
+func TestNotifyIncomingTransfer(t *testing.T) {
+	ctx := context.Background()
+	n := newTestNotifications(t)
+	aliceAccount := n.openAccount(t, n.alice.ID)
+	aliceSavings := n.openAccount(t, n.alice.ID)
+	bobAccount := n.openAccount(t, n.bob.ID)
+
+	sms, language := true, "en"
+	if _, err := n.service.UpdatePreferences(ctx, n.bob.ID, models.UpdateNotificationPreferencesRequest{
+		SMSEnabled: &sms, Language: &language,
+	}); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 50)
+	// Перевод между своими счетами — не поступление
+	n.transfer(t, n.alice, aliceAccount, aliceSavings, 5)
+
+	if got := n.kinds(t, n.bob); got != models.NotificationIncomingTransfer {
+		t.Fatalf("уведомления bob: %q", got)
+	}
+	if got := n.kinds(t, n.alice); got != "" {
+		t.Fatalf("уведомления alice: %q", got)
+	}
+
+	inbox, err := n.service.Inbox(ctx, n.bob.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notification := inbox.Notifications[0]
+	if notification.Subject != "Incoming transfer of 50.00" || !strings.HasPrefix(notification.Body, "Hello, Bob!") ||
+		!strings.Contains(notification.Body, "Available balance: 150.00") {
+		t.Errorf("уведомление: %+v", notification)
+	}
+
+	mails := n.smtp.Mails()
+	if len(mails) != 1 || mails[0].To[0] != "bob@example.com" {
+		t.Fatalf("письма: %+v", mails)
+	}
+	messages := n.gateway.Messages()
+	if len(messages) != 1 || messages[0].To != "+79990000002" || !strings.HasPrefix(messages[0].Text, "Received 50.00") {
+		t.Fatalf("SMS: %+v", messages)
+	}
+
+	// Выключенный вид уведомлений не доставляется ни по одному каналу
+	disabled := false
+	if _, err := n.service.UpdatePreferences(ctx, n.bob.ID, models.UpdateNotificationPreferencesRequest{IncomingTransfer: &disabled}); err != nil {
+		t.Fatal(err)
+	}
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 1)
+	if got := n.kinds(t, n.bob); got != models.NotificationIncomingTransfer {
+		t.Errorf("уведомления bob после отключения: %q", got)
+	}
+	if len(n.smtp.Mails()) != 1 || len(n.gateway.Messages()) != 1 {
+		t.Errorf("после отключения отправлено писем %d, SMS %d", len(n.smtp.Mails()), len(n.gateway.Messages()))
+	}
+}
+
+func TestNotifyLowBalance(t *testing.T) {
+	ctx := context.Background()
+	n := newTestNotifications(t)
+	aliceAccount := n.openAccount(t, n.alice.ID)
+	bobAccount := n.openAccount(t, n.bob.ID)
+
+	threshold, email := 60.0, false
+	if _, err := n.service.UpdatePreferences(ctx, n.alice.ID, models.UpdateNotificationPreferencesRequest{
+		LowBalanceThreshold: &threshold, EmailEnabled: &email,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 30) // 69.70 — выше порога
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 20) // 49.50 — ниже
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 1)  // по-прежнему ниже: без повтора
+	if got := n.kinds(t, n.alice); got != models.NotificationLowBalance {
+		t.Fatalf("уведомления alice: %q", got)
+	}
+
+	// Остаток поднялся выше порога и снова опустился — новое уведомление
+	n.transfer(t, n.bob, bobAccount, aliceAccount, 50)
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 40)
+	want := models.NotificationLowBalance + " " + models.NotificationIncomingTransfer + " " + models.NotificationLowBalance
+	if got := n.kinds(t, n.alice); got != want {
+		t.Fatalf("уведомления alice: %q, ожидалось %q", got, want)
+	}
+
+	inbox, err := n.service.Inbox(ctx, n.alice.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inbox.Unread != 3 || !strings.Contains(inbox.Notifications[0].Body, "порога 60.00") {
+		t.Errorf("входящие: %+v", inbox)
+	}
+	// Почта у alice выключена
+	for _, mail := range n.smtp.Mails() {
+		if mail.To[0] == "alice@example.com" {
+			t.Errorf("письмо alice при выключенной почте")
+		}
+	}
+}
+
+func TestNotificationInbox(t *testing.T) {
+	ctx := context.Background()
+	n := newTestNotifications(t)
+	aliceAccount := n.openAccount(t, n.alice.ID)
+	bobAccount := n.openAccount(t, n.bob.ID)
+
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 1)
+	n.transfer(t, n.alice, aliceAccount, bobAccount, 2)
+
+	inbox, err := n.service.Inbox(ctx, n.bob.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inbox.Total != 2 || inbox.Unread != 2 || inbox.Notifications[0].Subject != "Поступление 2.00" {
+		t.Fatalf("входящие: %+v", inbox)
+	}
+
+	// Чужое уведомление не найти, повторная отметка не ошибка
+	latest := inbox.Notifications[0].ID
+	if err := n.service.MarkRead(ctx, n.alice.ID, latest); !errors.Is(err, repository.ErrNotificationNotFound) {
+		t.Errorf("чужое уведомление: %v, ожидалось ErrNotificationNotFound", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := n.service.MarkRead(ctx, n.bob.ID, latest); err != nil {
+			t.Fatalf("MarkRead: %v", err)
+		}
+	}
+
+	unread, err := n.service.Inbox(ctx, n.bob.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unread.Total != 1 || unread.Unread != 1 || unread.Notifications[0].ID == latest {
+		t.Fatalf("непрочитанные: %+v", unread)
+	}
+
+	marked, err := n.service.MarkAllRead(ctx, n.bob.ID)
+	if err != nil || marked != 1 {
+		t.Fatalf("MarkAllRead = %d, %v", marked, err)
+	}
+	if unread, _ = n.service.Inbox(ctx, n.bob.ID, true); unread.Total != 0 || unread.Unread != 0 {
+		t.Errorf("после MarkAllRead: %+v", unread)
+	}
+}
+
+func TestNotifyScheduledTransferFailed(t *testing.T) {
+	ctx := context.Background()
+	n := newTestNotifications(t)
+	st := &models.ScheduledTransfer{
+		UserID: n.alice.ID, FromAccountID: "a1", ToAccountID: "a2", Amount: 25, Status: scheduleStatusActive,
+	}
+	retryAt := time.Now().Add(time.Hour)
+
+	// Повтор ещё запланирован — уведомлять рано
+	n.service.scheduledTransferFailed(ctx, &models.ScheduledTransfer{UserID: n.alice.ID, RetryAt: &retryAt},
+		&models.ScheduledTransferRun{Status: runStatusFailed})
+	n.service.scheduledTransferFailed(ctx, st, &models.ScheduledTransferRun{Status: runStatusSucceeded})
+	n.service.scheduledTransferFailed(ctx, st, &models.ScheduledTransferRun{Status: runStatusSkipped})
+
+	inbox, err := n.service.Inbox(ctx, n.alice.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inbox.Total != 1 || inbox.Notifications[0].Kind != models.NotificationScheduledTransferFailed ||
+		!strings.Contains(inbox.Notifications[0].Body, "недостаточно средств") {
+		t.Fatalf("входящие: %+v", inbox)
+	}
+	// Без телефона SMS не отправляется, но остальные каналы работают
+	if len(n.smtp.Mails()) != 1 {
+		t.Errorf("писем: %d, ожидалось 1", len(n.smtp.Mails()))
+	}
+
+	// У nil-сервиса уведомлений нет
+	var disabled *NotificationService
+	disabled.scheduledTransferFailed(ctx, st, &models.ScheduledTransferRun{Status: runStatusSkipped})
+}
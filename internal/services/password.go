@@ -0,0 +1,85 @@
+package services
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"bank-prototype/internal/i18n"
+)
+
+// ErrWeakPassword — пароль не соответствует политике паролей
+var ErrWeakPassword = errors.New("пароль не соответствует требованиям")
+
+const (
+	MinPasswordLength = 8
+	// MaxPasswordBytes — bcrypt учитывает только первые 72 байта пароля
+	MaxPasswordBytes = 72
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords — список распространённых паролей из common_passwords.txt
+var commonPasswords = func() map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(commonPasswordsFile, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			passwords[line] = struct{}{}
+		}
+	}
+	return passwords
+}()
+
+// ValidatePassword проверяет пароль пользователя name по политике: от 8
+// символов и не длиннее 72 байт, буквы вместе с цифрами или другими
+// символами, без имени пользователя и не из списка распространённых.
+// Ошибка оборачивает ErrWeakPassword и объясняет, какое правило нарушено.
+func ValidatePassword(password, name string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return i18n.Errorf(ErrWeakPassword, "detail.password_too_short", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordBytes {
+		return i18n.Errorf(ErrWeakPassword, "detail.password_too_long", MaxPasswordBytes)
+	}
+
+	var letters, others bool
+	for _, r := range password {
+		if unicode.IsLetter(r) {
+			letters = true
+		} else {
+			others = true
+		}
+	}
+	if !letters || !others {
+		return i18n.Errorf(ErrWeakPassword, "detail.password_classes")
+	}
+
+	lower := strings.ToLower(password)
+	if name = strings.ToLower(strings.TrimSpace(name)); utf8.RuneCountInString(name) >= 3 && strings.Contains(lower, name) {
+		return i18n.Errorf(ErrWeakPassword, "detail.password_contains_name")
+	}
+
+	if isCommonPassword(lower) {
+		return i18n.Errorf(ErrWeakPassword, "detail.password_common")
+	}
+	return nil
+}
+
+// isCommonPassword проверяет пароль целиком и без цифр и знаков в конце:
+// дописанные к словарному слову год или восклицательный знак пароль не
+// усиливают
+func isCommonPassword(lower string) bool {
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) })
+	if utf8.RuneCountInString(base) < 4 {
+		return false
+	}
+	_, ok := commonPasswords[base]
+	return ok
+}
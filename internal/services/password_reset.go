@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/notifications"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+)
+
+const (
+	// DefaultPasswordResetTTL — сколько действует токен сброса пароля
+	DefaultPasswordResetTTL = 30 * time.Minute
+
+	// passwordResetsPerHour — сколько токенов выпускается одному
+	// пользователю за час; остальные запросы молча игнорируются
+	passwordResetsPerHour = 3
+
+	// resetTokenBytes — длина токена: 256 бит не подобрать перебором
+	resetTokenBytes = 32
+
+	// passwordResetTemplate — шаблон письма со сбросом (см. notifications.Render)
+	passwordResetTemplate = "password_reset"
+)
+
+// PasswordResetService выпускает одноразовые токены сброса пароля и
+// меняет пароль по ним. Ответ на запрос сброса не зависит от того, есть
+// ли такой пользователь, поэтому по нему нельзя перебирать имена и адреса.
+type PasswordResetService struct {
+	users       repository.UserStore
+	resets      repository.PasswordResetStore
+	authService *AuthService
+	notifier    notifications.Notifier
+	workerPool  *worker.WorkerPool
+	ttl         time.Duration
+}
+
+func NewPasswordResetService(
+	users repository.UserStore,
+	resets repository.PasswordResetStore,
+	authService *AuthService,
+	notifier notifications.Notifier,
+	workerPool *worker.WorkerPool,
+	ttl time.Duration,
+) *PasswordResetService {
+	utils.LogSuccess("PasswordResetService", "Инициализирован сервис сброса пароля (срок токена: %v)", ttl)
+	return &PasswordResetService{
+		users:       users,
+		resets:      resets,
+		authService: authService,
+		notifier:    notifier,
+		workerPool:  workerPool,
+		ttl:         ttl,
+	}
+}
+
+// Request ставит выпуск и отправку токена в Worker Pool и сразу
+// возвращается: ни ответ, ни время ответа не выдают, существует ли
+// пользователь login (имя или email). Сообщение пишется на языке lang.
+func (s *PasswordResetService) Request(ctx context.Context, login string, lang i18n.Lang) {
+	login = strings.TrimSpace(login)
+	task := func(ctx context.Context) error {
+		return s.issue(ctx, login, lang)
+	}
+
+	if s.workerPool != nil {
+		err := s.workerPool.Submit(worker.Job{
+			ID:   fmt.Sprintf("password-reset-%d", worker.GetCurrentTimeMs()),
+			Ctx:  ctx,
+			Task: task,
+			OnDone: func(err error) {
+				if err != nil {
+					utils.LogError("PasswordResetService", "Не удалось отправить токен сброса пароля", err)
+				}
+			},
+		})
+		if err == nil {
+			return
+		}
+		utils.LogWarning("PasswordResetService", "Worker Pool переполнен, токен сброса выпускается синхронно")
+	}
+
+	if err := task(ctx); err != nil {
+		utils.LogError("PasswordResetService", "Не удалось отправить токен сброса пароля", err)
+	}
+}
+
+func (s *PasswordResetService) issue(ctx context.Context, login string, lang i18n.Lang) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.issue")
+	defer span.End()
+
+	user, err := s.users.GetByName(ctx, login)
+	if errors.Is(err, repository.ErrUserNotFound) && strings.Contains(login, "@") {
+		user, err = s.users.GetByEmail(ctx, strings.ToLower(login))
+	}
+	if errors.Is(err, repository.ErrUserNotFound) {
+		utils.LogInfo("PasswordResetService", "Запрошен сброс пароля неизвестного пользователя")
+		return nil
+	}
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	issued, err := s.resets.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	if issued >= passwordResetsPerHour {
+		utils.LogWarning("PasswordResetService", "Пользователю %s за час уже выпущено токенов сброса: %d", user.ID, issued)
+		return nil
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	if err := s.resets.Create(ctx, user.ID, hashResetToken(token), time.Now().Add(s.ttl)); err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	rendered, err := notifications.Render(string(lang), passwordResetTemplate, map[string]any{
+		"Name":    user.Name,
+		"Token":   token,
+		"Minutes": int(s.ttl.Minutes()),
+	})
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+	err = s.notifier.Notify(ctx, notifications.Message{
+		UserID:  user.ID,
+		Name:    user.Name,
+		Email:   user.Email,
+		Subject: rendered.Subject,
+		Body:    rendered.Body,
+	})
+	if err != nil {
+		tracing.Fail(span, err)
+		return fmt.Errorf("ошибка доставки токена сброса пользователю %s: %w", user.ID, err)
+	}
+
+	utils.LogSuccess("PasswordResetService", "Токен сброса пароля отправлен пользователю %s", user.ID)
+	return nil
+}
+
+// Confirm устанавливает новый пароль по токену. Токен погашается, вместе с
+// ним — остальные токены сброса пользователя, а все выданные JWT перестают
+// действовать. Слабый пароль токен не расходует.
+func (s *PasswordResetService) Confirm(ctx context.Context, token, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "PasswordResetService.Confirm")
+	defer span.End()
+
+	tokenHash := hashResetToken(token)
+	reset, err := s.resets.GetValid(ctx, tokenHash)
+	if err != nil {
+		if !errors.Is(err, repository.ErrInvalidResetToken) {
+			tracing.Fail(span, err)
+		}
+		return err
+	}
+
+	user, err := s.users.GetByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return repository.ErrInvalidResetToken
+		}
+		tracing.Fail(span, err)
+		return err
+	}
+	if err := ValidatePassword(newPassword, user.Name); err != nil {
+		return err
+	}
+
+	passwordHash, err := s.authService.HashPassword(newPassword)
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	userID, err := s.resets.Consume(ctx, tokenHash, passwordHash)
+	if err != nil {
+		if !errors.Is(err, repository.ErrInvalidResetToken) {
+			tracing.Fail(span, err)
+		}
+		return err
+	}
+	s.authService.revokeSessions(ctx, userID)
+
+	utils.LogSuccess("PasswordResetService", "Пароль пользователя %s сброшен, прежние сессии завершены", userID)
+	return nil
+}
+
+// newResetToken возвращает случайный токен для ссылки сброса
+func newResetToken() (string, error) {
+	buf := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации токена сброса: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashResetToken — SHA-256 токена: у токена 256 бит случайности, поэтому
+// медленный хеш вроде bcrypt здесь не нужен
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/notifications"
+	"bank-prototype/internal/repository"
+)
+
+// capturingNotifier запоминает отправленные сообщения
+type capturingNotifier struct {
+	mu       sync.Mutex
+	messages []notifications.Message
+}
+
+func (n *capturingNotifier) Notify(ctx context.Context, msg notifications.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func (n *capturingNotifier) sent() []notifications.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notifications.Message(nil), n.messages...)
+}
+
+// resetToken извлекает токен из текста последнего сообщения: он стоит
+// отдельной строкой
+func (n *capturingNotifier) resetToken(t *testing.T) string {
+	t.Helper()
+
+	sent := n.sent()
+	if len(sent) == 0 {
+		t.Fatal("сообщение со сбросом пароля не отправлено")
+	}
+	for _, line := range strings.Split(sent[len(sent)-1].Body, "\n") {
+		if len(line) == 43 && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	t.Fatalf("токен не найден в сообщении:\n%s", sent[len(sent)-1].Body)
+	return ""
+}
+
+func newTestPasswordReset(t *testing.T, ttl time.Duration) (*PasswordResetService, *capturingNotifier, *AuthService, *models.User) {
+	t.Helper()
+
+	users, auth, alice, store := newTestUsersStore(t)
+	email := "alice@example.com"
+	if _, err := users.UpdateProfile(context.Background(), alice.ID, models.UpdateProfileRequest{Email: &email}); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := &capturingNotifier{}
+	return NewPasswordResetService(store.Users(), store.PasswordResets(), auth, notifier, nil, ttl), notifier, auth, alice
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	resets, notifier, auth, alice := newTestPasswordReset(t, time.Hour)
+
+	oldToken, err := auth.GenerateToken(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Неизвестный пользователь: ошибки нет, сообщение не отправляется
+	resets.Request(ctx, "nobody", i18n.RU)
+	if got := len(notifier.sent()); got != 0 {
+		t.Fatalf("сообщений для неизвестного пользователя: %d", got)
+	}
+
+	// Запрос по email без учёта регистра
+	resets.Request(ctx, " ALICE@example.com ", i18n.EN)
+	sent := notifier.sent()
+	if len(sent) != 1 || sent[0].UserID != alice.ID || sent[0].Email != "alice@example.com" || sent[0].Subject != "Password reset" {
+		t.Fatalf("сообщение: %+v", sent)
+	}
+	first := notifier.resetToken(t)
+
+	resets.Request(ctx, "alice", i18n.RU)
+	second := notifier.resetToken(t)
+	if first == second {
+		t.Fatal("повторный запрос выдал тот же токен")
+	}
+
+	if err := resets.Confirm(ctx, "not-a-token", "New-password-2"); !errors.Is(err, repository.ErrInvalidResetToken) {
+		t.Errorf("неизвестный токен: %v, ожидалось ErrInvalidResetToken", err)
+	}
+	// Слабый пароль токен не расходует
+	if err := resets.Confirm(ctx, second, "password123"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("слабый пароль: %v, ожидалось ErrWeakPassword", err)
+	}
+	if err := resets.Confirm(ctx, second, "New-password-2"); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	// Токен одноразовый, остальные токены пользователя тоже погашены
+	for _, token := range []string{second, first} {
+		if err := resets.Confirm(ctx, token, "Other-password-3"); !errors.Is(err, repository.ErrInvalidResetToken) {
+			t.Errorf("погашенный токен: %v, ожидалось ErrInvalidResetToken", err)
+		}
+	}
+
+	if _, err := auth.Authenticate(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("JWT до сброса: %v, ожидалось ErrInvalidToken", err)
+	}
+	user, err := resets.users.GetByID(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.CheckPasswordHash("New-password-2", user.PasswordHash); err != nil {
+		t.Errorf("новый пароль не сохранён: %v", err)
+	}
+}
+
+func TestPasswordResetLimits(t *testing.T) {
+	ctx := context.Background()
+
+	// Истёкший токен не принимается
+	resets, notifier, _, _ := newTestPasswordReset(t, time.Nanosecond)
+	resets.Request(ctx, "alice", i18n.RU)
+	token := notifier.resetToken(t)
+	time.Sleep(time.Millisecond)
+	if err := resets.Confirm(ctx, token, "New-password-2"); !errors.Is(err, repository.ErrInvalidResetToken) {
+		t.Errorf("истёкший токен: %v, ожидалось ErrInvalidResetToken", err)
+	}
+
+	// Не больше passwordResetsPerHour сообщений в час одному пользователю
+	resets, notifier, _, _ = newTestPasswordReset(t, time.Hour)
+	for i := 0; i < passwordResetsPerHour+2; i++ {
+		resets.Request(ctx, "alice", i18n.RU)
+	}
+	if got := len(notifier.sent()); got != passwordResetsPerHour {
+		t.Errorf("отправлено сообщений: %d, ожидалось %d", got, passwordResetsPerHour)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	ReconciliationTriggerScheduler = "scheduler"
+	ReconciliationTriggerAdmin     = "admin"
+	ReconciliationTriggerCLI       = "cli"
+
+	// reconciliationReportsLimit — сколько последних отчётов отдаёт List
+	reconciliationReportsLimit = 50
+)
+
+// ReconciliationService сверяет балансы счетов с историей транзакций:
+// баланс каждого счёта должен равняться стартовому депозиту плюс входящие
+// переводы и комиссии минус списания.
+type ReconciliationService struct {
+	repo       *repository.ReconciliationRepository
+	workerPool *worker.WorkerPool
+}
+
+func NewReconciliationService(repo *repository.ReconciliationRepository, workerPool *worker.WorkerPool) *ReconciliationService {
+	utils.LogSuccess("ReconciliationService", "Инициализирован сервис сверки балансов")
+	return &ReconciliationService{
+		repo:       repo,
+		workerPool: workerPool,
+	}
+}
+
+// Run выполняет сверку синхронно и возвращает отчёт с расхождениями
+func (s *ReconciliationService) Run(ctx context.Context, triggeredBy string) (*models.ReconciliationReport, error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.Run",
+		attribute.String("reconciliation.triggered_by", triggeredBy),
+	)
+	defer span.End()
+
+	utils.LogInfo("ReconciliationService", "Запуск сверки балансов (инициатор: %s)", triggeredBy)
+
+	report, err := s.repo.Run(ctx, triggeredBy)
+	if err != nil {
+		utils.LogError("ReconciliationService", "Ошибка сверки балансов", err)
+		tracing.Fail(span, err)
+		return report, err
+	}
+
+	span.SetAttributes(
+		attribute.Int("reconciliation.accounts_checked", report.AccountsChecked),
+		attribute.Int("reconciliation.discrepancies", report.DiscrepancyCount),
+	)
+
+	if report.DiscrepancyCount > 0 {
+		utils.LogWarning("ReconciliationService", "Сверка %s: найдено расхождений %d из %d счетов (сумма: %.2f)",
+			report.ID, report.DiscrepancyCount, report.AccountsChecked, report.TotalDifference)
+	} else {
+		utils.LogSuccess("ReconciliationService", "Сверка %s: расхождений нет (проверено счетов: %d)",
+			report.ID, report.AccountsChecked)
+	}
+
+	return report, nil
+}
+
+// RunScheduled периодически ставит сверку в Worker Pool.
+// Блокирует вызывающую горутину до отмены ctx.
+func (s *ReconciliationService) RunScheduled(ctx context.Context, interval time.Duration) {
+	utils.LogInfo("ReconciliationScheduler", "Плановая сверка балансов запущена (интервал: %v)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.LogInfo("ReconciliationScheduler", "Плановая сверка балансов остановлена")
+			return
+		case <-ticker.C:
+			job := worker.Job{
+				ID:  fmt.Sprintf("reconciliation-%d", worker.GetCurrentTimeMs()),
+				Ctx: ctx,
+				Task: func(ctx context.Context) error {
+					_, err := s.Run(ctx, ReconciliationTriggerScheduler)
+					return err
+				},
+			}
+			if err := s.workerPool.Submit(job); err != nil {
+				utils.LogWarning("ReconciliationScheduler", "Не удалось поставить задачу в очередь: %v", err)
+			}
+		}
+	}
+}
+
+func (s *ReconciliationService) List(ctx context.Context) ([]models.ReconciliationReport, error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.List")
+	defer span.End()
+
+	reports, err := s.repo.List(ctx, reconciliationReportsLimit)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (s *ReconciliationService) Get(ctx context.Context, id string) (*models.ReconciliationReport, error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.Get",
+		attribute.String("reconciliation.report_id", id),
+	)
+	defer span.End()
+
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrRefundOnlyPayments = errors.New("возврат возможен только по платежам")
+	ErrFeeRefundForbidden = errors.New("вернуть комиссию может только администратор")
+)
+
+// Refund оформляет полный или частичный возврат платежа. Инициировать возврат
+// может только получатель платежа; деньги уходят с его счёта плательщику.
+func (s *TransactionService) Refund(ctx context.Context, userID, transactionID string, req models.RefundRequest) (*models.RefundResult, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Refund", attribute.String("transaction.id", transactionID))
+	defer span.End()
+
+	utils.LogInfo("TransactionService", "Возврат по транзакции %s пользователем %s", transactionID, userID)
+
+	if err := validateRefundAmount(req.Amount); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if req.RefundFee {
+		tracing.Fail(span, ErrFeeRefundForbidden)
+		return nil, ErrFeeRefundForbidden
+	}
+
+	original, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if original.Type != "payment" {
+		tracing.Fail(span, ErrRefundOnlyPayments)
+		return nil, ErrRefundOnlyPayments
+	}
+
+	toAccount, err := s.accountRepo.GetByID(ctx, original.ToAccountID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, repository.ErrAccountNotFound
+	}
+
+	if toAccount.UserID != userID {
+		utils.LogWarning("TransactionService", "Попытка возврата чужого платежа %s пользователем %s", transactionID, userID)
+		tracing.Fail(span, ErrUnauthorizedAccess)
+		return nil, ErrUnauthorizedAccess
+	}
+
+	return s.executeRefund(ctx, "refund", transactionID, req)
+}
+
+// Reverse сторнирует перевод или платёж по решению администратора.
+// В отличие от Refund, допускает возврат комиссии с системного счёта банка.
+func (s *TransactionService) Reverse(ctx context.Context, adminID, transactionID string, req models.RefundRequest) (*models.RefundResult, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Reverse", attribute.String("transaction.id", transactionID))
+	defer span.End()
+
+	utils.LogInfo("TransactionService", "Сторнирование транзакции %s администратором %s", transactionID, adminID)
+
+	if err := validateRefundAmount(req.Amount); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	result, err := s.executeRefund(ctx, "reversal", transactionID, req)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// validateRefundAmount проверяет сумму частичного возврата, если она задана
+func validateRefundAmount(amount *float64) error {
+	if amount == nil {
+		return nil
+	}
+	return validateCents(*amount)
+}
+
+// validateCents проверяет, что сумма положительна и выражается в целых
+// копейках. Иначе 0.001 округлилась бы до нуля и создала бы пустую проводку.
+func validateCents(amount float64) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if cents := amount * 100; math.Abs(cents-math.Round(cents)) > 1e-6 {
+		return i18n.Errorf(ErrInvalidAmount, "detail.amount_precision")
+	}
+	return nil
+}
+
+func (s *TransactionService) executeRefund(ctx context.Context, refundType, transactionID string, req models.RefundRequest) (*models.RefundResult, error) {
+	result, err := s.transactionRepo.ExecuteRefund(ctx, repository.RefundParams{
+		OriginalID: transactionID,
+		Type:       refundType,
+		Amount:     req.Amount,
+		RefundFee:  req.RefundFee,
+	})
+	if err != nil {
+		utils.LogError("TransactionService", "Ошибка выполнения возврата", err)
+		return nil, err
+	}
+
+	s.invalidateCacheAsync(ctx, result.Original.FromAccountID, result.Original.ToAccountID, result.Refund.ID)
+	s.events.transactionsCompleted(ctx, s.workerPool, result.Refund, result.FeeRefund)
+
+	utils.LogSuccess("TransactionService", "Возврат %s по транзакции %s выполнен (%.2f)",
+		result.Refund.ID, transactionID, result.Refund.Amount)
+
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultRetentionPeriod — сколько хранятся данные удалённого пользователя:
+// банк обязан хранить сведения об операциях пять лет
+const DefaultRetentionPeriod = 5 * 365 * 24 * time.Hour
+
+// RetentionService окончательно удаляет данные пользователей, у которых
+// истёк срок хранения после удаления
+type RetentionService struct {
+	repo       *repository.UserRepository
+	period     time.Duration
+	workerPool *worker.WorkerPool
+}
+
+func NewRetentionService(repo *repository.UserRepository, period time.Duration, workerPool *worker.WorkerPool) *RetentionService {
+	utils.LogSuccess("RetentionService", "Инициализирован сервис хранения данных (срок: %v)", period)
+	return &RetentionService{
+		repo:       repo,
+		period:     period,
+		workerPool: workerPool,
+	}
+}
+
+// Purge удаляет данные пользователей, удалённых раньше, чем срок хранения назад
+func (s *RetentionService) Purge(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "RetentionService.Purge")
+	defer span.End()
+
+	result, err := s.repo.PurgeDeleted(ctx, time.Now().Add(-s.period))
+	if err != nil {
+		utils.LogError("RetentionService", "Ошибка удаления данных с истёкшим сроком хранения", err)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	span.SetAttributes(
+		attribute.Int("retention.users", result.Users),
+		attribute.Int("retention.accounts", result.Accounts),
+		attribute.Int("retention.transactions", result.Transactions),
+	)
+	if result.Users+result.Accounts+result.Transactions > 0 {
+		utils.LogSuccess("RetentionService", "Удалено по сроку хранения: пользователей %d, счетов %d, транзакций %d",
+			result.Users, result.Accounts, result.Transactions)
+	}
+	return nil
+}
+
+// RunScheduled периодически ставит очистку в Worker Pool.
+// Блокирует вызывающую горутину до отмены ctx.
+func (s *RetentionService) RunScheduled(ctx context.Context, interval time.Duration) {
+	utils.LogInfo("RetentionScheduler", "Очистка данных по сроку хранения запущена (интервал: %v)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.LogInfo("RetentionScheduler", "Очистка данных по сроку хранения остановлена")
+			return
+		case <-ticker.C:
+			job := worker.Job{
+				ID:   fmt.Sprintf("retention-%d", worker.GetCurrentTimeMs()),
+				Ctx:  ctx,
+				Task: s.Purge,
+			}
+			if err := s.workerPool.Submit(job); err != nil {
+				utils.LogWarning("RetentionScheduler", "Не удалось поставить задачу в очередь: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,655 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel/attribute"
+
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+)
+
+var (
+	ErrInvalidSchedule        = errors.New("укажите ровно одно из полей cron_expression или interval_seconds (не менее 60 секунд)")
+	ErrInvalidCronExpression  = errors.New("некорректное cron-выражение")
+	ErrInvalidScheduleWindow  = errors.New("дата окончания должна быть позже даты начала")
+	ErrInvalidTransactionType = errors.New("тип должен быть transfer или payment")
+	ErrInvalidFundsPolicy     = errors.New("on_insufficient_funds должен быть skip или retry")
+	ErrInvalidScheduleStatus  = errors.New("статус можно изменить только на active или paused")
+	ErrScheduleFinished       = errors.New("регулярный перевод завершён или отменён")
+)
+
+const (
+	scheduleStatusActive    = "active"
+	scheduleStatusPaused    = "paused"
+	scheduleStatusCompleted = "completed"
+	scheduleStatusCancelled = "cancelled"
+
+	runStatusSucceeded      = "succeeded"
+	runStatusFailed         = "failed"
+	runStatusSkipped        = "skipped"
+	runStatusRetryScheduled = "retry_scheduled"
+
+	fundsPolicySkip  = "skip"
+	fundsPolicyRetry = "retry"
+
+	defaultRetryIntervalSeconds = 3600
+	defaultMaxRetries           = 3
+	minIntervalSeconds          = 60
+
+	// scheduleClaimBatch — сколько переводов берётся за один проход планировщика
+	scheduleClaimBatch = 100
+	// scheduleLease — на сколько перевод блокируется от повторного запуска
+	scheduleLease = 5 * time.Minute
+	// scheduleRunsLimit — сколько последних выполнений возвращается вместе с переводом
+	scheduleRunsLimit = 20
+)
+
+type ScheduledTransferService struct {
+	repo               repository.ScheduledTransferStore
+	accountRepo        repository.AccountStore
+	transactionService *TransactionService
+	workerPool         *worker.WorkerPool
+	notifications      *NotificationService
+}
+
+func NewScheduledTransferService(
+	repo repository.ScheduledTransferStore,
+	accountRepo repository.AccountStore,
+	transactionService *TransactionService,
+	workerPool *worker.WorkerPool,
+) *ScheduledTransferService {
+	utils.LogSuccess("ScheduledTransferService", "Инициализирован сервис регулярных переводов")
+	return &ScheduledTransferService{
+		repo:               repo,
+		accountRepo:        accountRepo,
+		transactionService: transactionService,
+		workerPool:         workerPool,
+	}
+}
+
+// SetNotifications включает уведомления владельцам о невыполненных переводах
+func (s *ScheduledTransferService) SetNotifications(notifications *NotificationService) {
+	s.notifications = notifications
+}
+
+func (s *ScheduledTransferService) Create(ctx context.Context, userID string, req models.CreateScheduledTransferRequest) (*models.ScheduledTransfer, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.Create", attribute.String("user.id", userID))
+	defer span.End()
+
+	utils.LogInfo("ScheduledTransferService", "Создание регулярного перевода пользователем %s: %s → %s (сумма: %.2f)",
+		userID, req.FromAccountID, req.ToAccountID, req.Amount)
+
+	st := &models.ScheduledTransfer{
+		UserID:               userID,
+		Type:                 req.Type,
+		FromAccountID:        req.FromAccountID,
+		ToAccountID:          req.ToAccountID,
+		Amount:               req.Amount,
+		CronExpression:       req.CronExpression,
+		IntervalSeconds:      req.IntervalSeconds,
+		EndAt:                req.EndAt,
+		MaxOccurrences:       req.MaxOccurrences,
+		OnInsufficientFunds:  req.OnInsufficientFunds,
+		RetryIntervalSeconds: defaultRetryIntervalSeconds,
+		MaxRetries:           defaultMaxRetries,
+		Status:               scheduleStatusActive,
+	}
+
+	if st.Type == "" {
+		st.Type = "transfer"
+	}
+	if st.OnInsufficientFunds == "" {
+		st.OnInsufficientFunds = fundsPolicySkip
+	}
+	if req.RetryIntervalSeconds != nil {
+		st.RetryIntervalSeconds = *req.RetryIntervalSeconds
+	}
+	if req.MaxRetries != nil {
+		st.MaxRetries = *req.MaxRetries
+	}
+
+	st.StartAt = time.Now().UTC()
+	if req.StartAt != nil {
+		st.StartAt = *req.StartAt
+	}
+
+	if err := s.validate(ctx, st); err != nil {
+		utils.LogWarning("ScheduledTransferService", "Регулярный перевод не прошёл проверку: %v", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	// Первое выполнение — ближайшее по расписанию, начиная с start_at
+	next, err := s.firstOccurrence(st)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	st.NextRunAt = next
+
+	created, err := s.repo.Create(ctx, st)
+	if err != nil {
+		utils.LogError("ScheduledTransferService", "Ошибка создания регулярного перевода", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("ScheduledTransferService", "Регулярный перевод %s создан, первое выполнение: %v", created.ID, formatOptionalTime(created.NextRunAt))
+	return created, nil
+}
+
+func (s *ScheduledTransferService) List(ctx context.Context, userID string) ([]models.ScheduledTransfer, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.List", attribute.String("user.id", userID))
+	defer span.End()
+
+	transfers, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		utils.LogError("ScheduledTransferService", "Ошибка получения регулярных переводов", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("ScheduledTransferService", "Найдено %d регулярных переводов пользователя %s", len(transfers), userID)
+	return transfers, nil
+}
+
+// Get возвращает регулярный перевод вместе с последними выполнениями
+func (s *ScheduledTransferService) Get(ctx context.Context, userID, id string) (*models.ScheduledTransfer, []models.ScheduledTransferRun, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.Get", attribute.String("scheduled_transfer.id", id))
+	defer span.End()
+
+	st, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, nil, err
+	}
+
+	runs, err := s.repo.GetRuns(ctx, id, scheduleRunsLimit)
+	if err != nil {
+		utils.LogError("ScheduledTransferService", "Ошибка получения истории выполнений", err)
+		tracing.Fail(span, err)
+		return nil, nil, err
+	}
+
+	return st, runs, nil
+}
+
+func (s *ScheduledTransferService) Update(ctx context.Context, userID, id string, req models.UpdateScheduledTransferRequest) (*models.ScheduledTransfer, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.Update", attribute.String("scheduled_transfer.id", id))
+	defer span.End()
+
+	st, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if st.Status == scheduleStatusCompleted || st.Status == scheduleStatusCancelled {
+		return nil, ErrScheduleFinished
+	}
+
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			return nil, ErrInvalidAmount
+		}
+		st.Amount = *req.Amount
+	}
+	if req.EndAt != nil {
+		if !req.EndAt.After(st.StartAt) {
+			return nil, ErrInvalidScheduleWindow
+		}
+		st.EndAt = req.EndAt
+	}
+	if req.MaxOccurrences != nil {
+		if *req.MaxOccurrences <= 0 {
+			return nil, ErrInvalidSchedule
+		}
+		st.MaxOccurrences = req.MaxOccurrences
+	}
+	if req.OnInsufficientFunds != nil {
+		if *req.OnInsufficientFunds != fundsPolicySkip && *req.OnInsufficientFunds != fundsPolicyRetry {
+			return nil, ErrInvalidFundsPolicy
+		}
+		st.OnInsufficientFunds = *req.OnInsufficientFunds
+	}
+
+	if req.Status != nil {
+		switch *req.Status {
+		case scheduleStatusPaused:
+			st.Status = scheduleStatusPaused
+		case scheduleStatusActive:
+			// После паузы пропущенные выполнения не наверстываются
+			if st.Status == scheduleStatusPaused {
+				st.RetryAt = nil
+				st.RetryCount = 0
+				if st.NextRunAt == nil || st.NextRunAt.Before(time.Now()) {
+					st.NextRunAt = s.nextOccurrenceAfter(st, time.Now())
+				}
+			}
+			st.Status = scheduleStatusActive
+		default:
+			return nil, ErrInvalidScheduleStatus
+		}
+	}
+
+	// Новые end_at / max_occurrences могут завершить расписание
+	if st.NextRunAt != nil && !s.withinLimits(st, *st.NextRunAt, st.Occurrences) {
+		st.NextRunAt = nil
+	}
+	if st.NextRunAt == nil && st.Status == scheduleStatusActive {
+		st.Status = scheduleStatusCompleted
+	}
+
+	if err := s.repo.Update(ctx, st); err != nil {
+		utils.LogError("ScheduledTransferService", "Ошибка обновления регулярного перевода", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("ScheduledTransferService", "Регулярный перевод %s обновлён (статус: %s)", st.ID, st.Status)
+	return st, nil
+}
+
+// Cancel отменяет регулярный перевод. История выполнений сохраняется.
+func (s *ScheduledTransferService) Cancel(ctx context.Context, userID, id string) error {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.Cancel", attribute.String("scheduled_transfer.id", id))
+	defer span.End()
+
+	st, err := s.getOwned(ctx, userID, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return err
+	}
+
+	if st.Status == scheduleStatusCompleted || st.Status == scheduleStatusCancelled {
+		return ErrScheduleFinished
+	}
+
+	st.Status = scheduleStatusCancelled
+	st.NextRunAt = nil
+	st.RetryAt = nil
+
+	if err := s.repo.Update(ctx, st); err != nil {
+		utils.LogError("ScheduledTransferService", "Ошибка отмены регулярного перевода", err)
+		tracing.Fail(span, err)
+		return err
+	}
+
+	utils.LogSuccess("ScheduledTransferService", "Регулярный перевод %s отменён", id)
+	return nil
+}
+
+// ListFailed возвращает переводы, приостановленные после неустранимой ошибки
+func (s *ScheduledTransferService) ListFailed(ctx context.Context, limit int) ([]models.FailedJob, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.ListFailed")
+	defer span.End()
+
+	jobs, err := s.repo.ListFailed(ctx, limit)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Replay возобновляет приостановленный перевод после устранения причины
+// ошибки. Операция администратора: владелец не проверяется, но счета
+// должны снова быть доступны.
+func (s *ScheduledTransferService) Replay(ctx context.Context, id string) (*models.ScheduledTransfer, error) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.Replay", attribute.String("scheduled_transfer.id", id))
+	defer span.End()
+
+	st, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if st.Status != scheduleStatusPaused {
+		tracing.Fail(span, repository.ErrScheduledTransferNotPaused)
+		return nil, repository.ErrScheduledTransferNotPaused
+	}
+
+	if err := s.validate(ctx, st); err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	st, err = s.repo.Reactivate(ctx, id)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	utils.LogSuccess("ScheduledTransferService", "Регулярный перевод %s возобновлён", id)
+	return st, nil
+}
+
+// RunScheduler периодически ставит наступившие выполнения в очередь Worker Pool.
+// Блокируется до отмены ctx.
+func (s *ScheduledTransferService) RunScheduler(ctx context.Context, pollInterval time.Duration) {
+	utils.LogInfo("Scheduler", "Планировщик регулярных переводов запущен (интервал опроса: %v)", pollInterval)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.LogInfo("Scheduler", "Планировщик регулярных переводов остановлен")
+			return
+		case <-ticker.C:
+			s.enqueueDue(ctx)
+		}
+	}
+}
+
+func (s *ScheduledTransferService) enqueueDue(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.enqueueDue")
+	defer span.End()
+
+	due, err := s.repo.ClaimDue(ctx, scheduleClaimBatch, scheduleLease)
+	if err != nil {
+		utils.LogError("Scheduler", "Ошибка выборки регулярных переводов", err)
+		tracing.Fail(span, err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	span.SetAttributes(attribute.Int("scheduled_transfers.due", len(due)))
+	utils.LogInfo("Scheduler", "К выполнению готово регулярных переводов: %d", len(due))
+
+	for i := range due {
+		st := due[i]
+		job := worker.Job{
+			ID:  fmt.Sprintf("scheduled-transfer-%s-%d", st.ID, worker.GetCurrentTimeMs()),
+			Ctx: ctx,
+			Task: func(jobCtx context.Context) error {
+				return s.execute(jobCtx, &st)
+			},
+			// Повтор перевода регулируется политикой расписания, а не пулом
+			RetryOn: func(error) bool { return false },
+		}
+
+		if err := s.workerPool.Submit(job); err != nil {
+			utils.LogWarning("Scheduler", "Не удалось поставить регулярный перевод %s в очередь: %v", st.ID, err)
+			if releaseErr := s.repo.ReleaseLock(ctx, st.ID); releaseErr != nil {
+				utils.LogError("Scheduler", "Ошибка снятия блокировки", releaseErr)
+			}
+		}
+	}
+}
+
+// execute выполняет одно наступившее выполнение и сохраняет его результат
+func (s *ScheduledTransferService) execute(ctx context.Context, st *models.ScheduledTransfer) error {
+	ctx, span := tracing.Start(ctx, "ScheduledTransferService.execute",
+		attribute.String("scheduled_transfer.id", st.ID),
+		attribute.Int("attempt", st.RetryCount+1),
+	)
+	defer span.End()
+
+	scheduledFor := time.Now()
+	if st.NextRunAt != nil {
+		scheduledFor = *st.NextRunAt
+	}
+
+	run := &models.ScheduledTransferRun{
+		ScheduledFor: scheduledFor,
+		Attempt:      st.RetryCount + 1,
+	}
+
+	transaction, err := s.executeTransfer(ctx, st, scheduledFor)
+
+	switch {
+	case err == nil:
+		run.Status = runStatusSucceeded
+		run.TransactionID = &transaction.ID
+		s.advance(st, scheduledFor)
+		utils.LogSuccess("Scheduler", "Регулярный перевод %s выполнен: транзакция %s", st.ID, transaction.ID)
+
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		if st.OnInsufficientFunds == fundsPolicyRetry && st.RetryCount < st.MaxRetries {
+			run.Status = runStatusRetryScheduled
+			s.scheduleRetry(st)
+			utils.LogWarning("Scheduler", "Регулярный перевод %s: недостаточно средств, повтор в %v", st.ID, st.RetryAt.Format(time.RFC3339))
+		} else {
+			run.Status = runStatusSkipped
+			s.advance(st, scheduledFor)
+			utils.LogWarning("Scheduler", "Регулярный перевод %s: недостаточно средств, выполнение пропущено", st.ID)
+		}
+
+	case isPermanentTransferError(err):
+		// Счёт закрыт или больше не принадлежит пользователю — без
+		// вмешательства пользователя следующие выполнения тоже провалятся
+		run.Status = runStatusFailed
+		st.Status = scheduleStatusPaused
+		st.RetryAt = nil
+		utils.LogWarning("Scheduler", "Регулярный перевод %s приостановлен: %v", st.ID, err)
+
+	default:
+		run.Status = runStatusFailed
+		if st.RetryCount < st.MaxRetries {
+			s.scheduleRetry(st)
+		} else {
+			s.advance(st, scheduledFor)
+		}
+		utils.LogError("Scheduler", fmt.Sprintf("Ошибка выполнения регулярного перевода %s", st.ID), err)
+	}
+
+	if err != nil {
+		// Текст ошибки остаётся в журнале, в выполнении — только ключ причины
+		key := failureKey(err)
+		run.Error = &key
+		tracing.Fail(span, err)
+	}
+	span.SetAttributes(attribute.String("run.status", run.Status))
+
+	if finishErr := s.repo.FinishRun(ctx, st, run); finishErr != nil {
+		utils.LogError("Scheduler", "Ошибка сохранения результата регулярного перевода", finishErr)
+		return finishErr
+	}
+	s.notifications.scheduledTransferFailed(ctx, st, run)
+
+	return nil
+}
+
+// executeTransfer проводит одно выполнение расписания. Ключ идемпотентности
+// привязан к паре (перевод, scheduled_for): если процесс упал после проводки,
+// но до FinishRun, то после истечения аренды повтор вернёт ту же транзакцию,
+// и деньги не спишутся дважды.
+func (s *ScheduledTransferService) executeTransfer(ctx context.Context, st *models.ScheduledTransfer, scheduledFor time.Time) (*models.Transaction, error) {
+	key := fmt.Sprintf("scheduled-transfer:%s:%d", st.ID, scheduledFor.Unix())
+
+	if st.Type == "payment" {
+		return s.transactionService.Payment(ctx, st.UserID, models.PaymentRequest{
+			FromAccountID:  st.FromAccountID,
+			ToAccountID:    st.ToAccountID,
+			Amount:         st.Amount,
+			IdempotencyKey: key,
+		})
+	}
+
+	return s.transactionService.Transfer(ctx, st.UserID, models.TransferRequest{
+		FromAccountID:  st.FromAccountID,
+		ToAccountID:    st.ToAccountID,
+		Amount:         st.Amount,
+		IdempotencyKey: key,
+	})
+}
+
+// advance засчитывает выполнение и переходит к следующему по расписанию.
+// Выполнения, пропущенные из-за простоя сервиса, не наверстываются.
+func (s *ScheduledTransferService) advance(st *models.ScheduledTransfer, scheduledFor time.Time) {
+	st.Occurrences++
+	st.RetryCount = 0
+	st.RetryAt = nil
+
+	after := scheduledFor
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+
+	st.NextRunAt = s.nextOccurrenceAfter(st, after)
+	if st.NextRunAt == nil {
+		st.Status = scheduleStatusCompleted
+		utils.LogInfo("Scheduler", "Регулярный перевод %s завершён (выполнений: %d)", st.ID, st.Occurrences)
+	}
+}
+
+func (s *ScheduledTransferService) scheduleRetry(st *models.ScheduledTransfer) {
+	st.RetryCount++
+	retryAt := time.Now().Add(time.Duration(st.RetryIntervalSeconds) * time.Second)
+	st.RetryAt = &retryAt
+}
+
+func (s *ScheduledTransferService) firstOccurrence(st *models.ScheduledTransfer) (*time.Time, error) {
+	if st.IntervalSeconds != nil {
+		first := st.StartAt
+		return &first, nil
+	}
+
+	next := s.nextOccurrenceAfter(st, st.StartAt.Add(-time.Second))
+	if next == nil {
+		return nil, ErrInvalidScheduleWindow
+	}
+	return next, nil
+}
+
+// nextOccurrenceAfter возвращает ближайшее выполнение строго после after
+// или nil, если расписание исчерпано
+func (s *ScheduledTransferService) nextOccurrenceAfter(st *models.ScheduledTransfer, after time.Time) *time.Time {
+	var next time.Time
+
+	if st.IntervalSeconds != nil {
+		interval := time.Duration(*st.IntervalSeconds) * time.Second
+		next = st.StartAt
+		if after.After(next) || after.Equal(next) {
+			steps := after.Sub(st.StartAt)/interval + 1
+			next = st.StartAt.Add(steps * interval)
+		}
+	} else {
+		schedule, err := cron.ParseStandard(*st.CronExpression)
+		if err != nil {
+			return nil
+		}
+		next = schedule.Next(after)
+		if next.IsZero() {
+			return nil
+		}
+	}
+
+	if !s.withinLimits(st, next, st.Occurrences) {
+		return nil
+	}
+	return &next
+}
+
+func (s *ScheduledTransferService) withinLimits(st *models.ScheduledTransfer, at time.Time, occurrences int) bool {
+	if st.EndAt != nil && at.After(*st.EndAt) {
+		return false
+	}
+	if st.MaxOccurrences != nil && occurrences >= *st.MaxOccurrences {
+		return false
+	}
+	return true
+}
+
+func (s *ScheduledTransferService) validate(ctx context.Context, st *models.ScheduledTransfer) error {
+	if st.Type != "transfer" && st.Type != "payment" {
+		return ErrInvalidTransactionType
+	}
+	if st.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if st.FromAccountID == st.ToAccountID {
+		return ErrSelfTransfer
+	}
+
+	hasCron := st.CronExpression != nil && *st.CronExpression != ""
+	hasInterval := st.IntervalSeconds != nil
+	if hasCron == hasInterval {
+		return ErrInvalidSchedule
+	}
+	if hasInterval && *st.IntervalSeconds < minIntervalSeconds {
+		return ErrInvalidSchedule
+	}
+	if hasCron {
+		if _, err := cron.ParseStandard(*st.CronExpression); err != nil {
+			return i18n.Errorf(ErrInvalidCronExpression, "detail.cron_parse", err.Error())
+		}
+	} else {
+		st.CronExpression = nil
+	}
+
+	if st.EndAt != nil && !st.EndAt.After(st.StartAt) {
+		return ErrInvalidScheduleWindow
+	}
+	if st.MaxOccurrences != nil && *st.MaxOccurrences <= 0 {
+		return ErrInvalidSchedule
+	}
+	if st.OnInsufficientFunds != fundsPolicySkip && st.OnInsufficientFunds != fundsPolicyRetry {
+		return ErrInvalidFundsPolicy
+	}
+	if st.RetryIntervalSeconds < minIntervalSeconds || st.MaxRetries < 0 {
+		return ErrInvalidSchedule
+	}
+
+	fromAccount, err := s.accountRepo.GetByID(ctx, st.FromAccountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+	if fromAccount.UserID != st.UserID {
+		return ErrUnauthorizedAccess
+	}
+	if fromAccount.Status != "active" {
+		return repository.ErrAccountClosed
+	}
+
+	toAccount, err := s.accountRepo.GetByID(ctx, st.ToAccountID)
+	if err != nil {
+		return repository.ErrAccountNotFound
+	}
+	if toAccount.Status != "active" {
+		return repository.ErrAccountClosed
+	}
+
+	return nil
+}
+
+func (s *ScheduledTransferService) getOwned(ctx context.Context, userID, id string) (*models.ScheduledTransfer, error) {
+	st, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if st.UserID != userID {
+		utils.LogWarning("ScheduledTransferService", "Попытка доступа к чужому регулярному переводу %s пользователем %s", id, userID)
+		return nil, ErrUnauthorizedAccess
+	}
+
+	return st, nil
+}
+
+func isPermanentTransferError(err error) bool {
+	return errors.Is(err, repository.ErrAccountNotFound) ||
+		errors.Is(err, repository.ErrAccountClosed) ||
+		errors.Is(err, ErrUnauthorizedAccess)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
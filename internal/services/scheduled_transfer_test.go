@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/worker"
+)
+
+func newTestScheduler(bank *testBank) *ScheduledTransferService {
+	return NewScheduledTransferService(bank.store.ScheduledTransfers(), bank.store.Accounts(), bank.transactions, nil)
+}
+
+// scheduleFromPast создаёт регулярный перевод alice, первое выполнение
+// которого уже наступило
+func scheduleFromPast(t *testing.T, scheduler *ScheduledTransferService, from, to string, amount float64, configure func(*models.CreateScheduledTransferRequest)) *models.ScheduledTransfer {
+	t.Helper()
+
+	startAt := time.Now().Add(-2 * time.Hour)
+	cron := "*/30 * * * *"
+	req := models.CreateScheduledTransferRequest{
+		Type:           "transfer",
+		FromAccountID:  from,
+		ToAccountID:    to,
+		Amount:         amount,
+		CronExpression: &cron,
+		StartAt:        &startAt,
+	}
+	if configure != nil {
+		configure(&req)
+	}
+
+	st, err := scheduler.Create(context.Background(), "alice", req)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if st.NextRunAt == nil || st.NextRunAt.After(time.Now()) {
+		t.Fatalf("первое выполнение %v ещё не наступило", st.NextRunAt)
+	}
+	return st
+}
+
+// claimDue выполняет проход планировщика, но не запускает воркеры: задачи
+// остаются в очереди, пока не вызван runQueued
+func claimDue(scheduler *ScheduledTransferService) *worker.WorkerPool {
+	pool := worker.NewWorkerPool(1, 16, 0)
+	scheduler.workerPool = pool
+	scheduler.enqueueDue(context.Background())
+	return pool
+}
+
+// runQueued выполняет задачи из очереди и дожидается их завершения
+func runQueued(t *testing.T, pool *worker.WorkerPool) {
+	t.Helper()
+
+	pool.Start()
+	if err := pool.Shutdown(5 * time.Second); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func runScheduler(t *testing.T, scheduler *ScheduledTransferService) {
+	t.Helper()
+	runQueued(t, claimDue(scheduler))
+}
+
+func getSchedule(t *testing.T, scheduler *ScheduledTransferService, id string) (*models.ScheduledTransfer, []models.ScheduledTransferRun) {
+	t.Helper()
+
+	st, runs, err := scheduler.Get(context.Background(), "alice", id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	return st, runs
+}
+
+func assertRun(t *testing.T, run models.ScheduledTransferRun, status string, attempt int, failure string) {
+	t.Helper()
+
+	if run.Status != status || run.Attempt != attempt {
+		t.Errorf("выполнение: статус %s, попытка %d, ожидались %s и %d", run.Status, run.Attempt, status, attempt)
+	}
+	switch {
+	case failure == "" && run.Error != nil:
+		t.Errorf("выполнение: ошибка %q", *run.Error)
+	case failure != "" && (run.Error == nil || *run.Error != failure):
+		t.Errorf("выполнение: ошибка %v, ожидалась %q", run.Error, failure)
+	}
+}
+
+func TestScheduledTransferCronAdvance(t *testing.T) {
+	bank := newTestBank(t, nil)
+	scheduler := newTestScheduler(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+
+	created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 10, nil)
+	before := time.Now()
+	runScheduler(t, scheduler)
+
+	st, runs := getSchedule(t, scheduler, created.ID)
+	if len(runs) != 1 {
+		t.Fatalf("выполнений %d, ожидалось 1", len(runs))
+	}
+	assertRun(t, runs[0], runStatusSucceeded, 1, "")
+	if !runs[0].ScheduledFor.Equal(*created.NextRunAt) {
+		t.Errorf("scheduled_for = %v, ожидалось %v", runs[0].ScheduledFor, *created.NextRunAt)
+	}
+
+	// Пропущенные за время простоя выполнения не наверстываются: следующее —
+	// ближайшее по cron после текущего момента
+	if st.Status != scheduleStatusActive || st.Occurrences != 1 || st.NextRunAt == nil {
+		t.Fatalf("после выполнения: %+v", st)
+	}
+	next := *st.NextRunAt
+	if !next.After(before) || next.After(before.Add(30*time.Minute)) || next.Minute()%30 != 0 || next.Second() != 0 {
+		t.Errorf("следующее выполнение %v не совпадает с */30 после %v", next, before)
+	}
+	assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 89.9)
+	assertMoney(t, "баланс получателя", bank.balance(t, bob.ID), 110)
+
+	// Повторный проход до срока ничего не выполняет
+	runScheduler(t, scheduler)
+	if _, runs := getSchedule(t, scheduler, created.ID); len(runs) != 1 {
+		t.Errorf("выполнений после повторного прохода %d, ожидалось 1", len(runs))
+	}
+	assertMoney(t, "баланс отправителя после повторного прохода", bank.balance(t, alice.ID), 89.9)
+}
+
+func TestScheduledTransferCompletesAfterMaxOccurrences(t *testing.T) {
+	bank := newTestBank(t, nil)
+	scheduler := newTestScheduler(bank)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+
+	created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 10, func(req *models.CreateScheduledTransferRequest) {
+		once := 1
+		req.MaxOccurrences = &once
+	})
+	runScheduler(t, scheduler)
+
+	st, _ := getSchedule(t, scheduler, created.ID)
+	if st.Status != scheduleStatusCompleted || st.NextRunAt != nil || st.Occurrences != 1 {
+		t.Errorf("после последнего выполнения: статус %s, next_run_at %v, выполнений %d", st.Status, st.NextRunAt, st.Occurrences)
+	}
+}
+
+func TestScheduledTransferInsufficientFunds(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skip", func(t *testing.T) {
+		bank := newTestBank(t, nil)
+		scheduler := newTestScheduler(bank)
+		alice := bank.openAccount(t, "alice")
+		bob := bank.openAccount(t, "bob")
+
+		created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 200, nil)
+		runScheduler(t, scheduler)
+
+		st, runs := getSchedule(t, scheduler, created.ID)
+		assertRun(t, runs[0], runStatusSkipped, 1, FailureInsufficientBalance)
+		if st.Status != scheduleStatusActive || st.Occurrences != 1 || st.RetryAt != nil {
+			t.Errorf("после пропуска: статус %s, выполнений %d, retry_at %v", st.Status, st.Occurrences, st.RetryAt)
+		}
+		if st.NextRunAt == nil || !st.NextRunAt.After(time.Now()) {
+			t.Errorf("следующее выполнение %v не перенесено вперёд", st.NextRunAt)
+		}
+		assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		bank := newTestBank(t, nil)
+		scheduler := newTestScheduler(bank)
+		alice := bank.openAccount(t, "alice")
+		bob := bank.openAccount(t, "bob")
+
+		created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 200, func(req *models.CreateScheduledTransferRequest) {
+			retries := 1
+			req.OnInsufficientFunds = fundsPolicyRetry
+			req.MaxRetries = &retries
+		})
+		runScheduler(t, scheduler)
+
+		st, runs := getSchedule(t, scheduler, created.ID)
+		assertRun(t, runs[0], runStatusRetryScheduled, 1, FailureInsufficientBalance)
+		if st.Occurrences != 0 || st.RetryCount != 1 || st.RetryAt == nil || !st.RetryAt.After(time.Now()) {
+			t.Fatalf("после нехватки средств: выполнений %d, повторов %d, retry_at %v", st.Occurrences, st.RetryCount, st.RetryAt)
+		}
+		if !st.NextRunAt.Equal(*created.NextRunAt) {
+			t.Errorf("next_run_at сдвинут до исчерпания повторов: %v", st.NextRunAt)
+		}
+
+		// Время повтора наступило, но повторы исчерпаны — выполнение пропускается
+		past := time.Now().Add(-time.Second)
+		st.RetryAt = &past
+		if err := bank.store.ScheduledTransfers().Update(ctx, st); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		runScheduler(t, scheduler)
+
+		st, runs = getSchedule(t, scheduler, created.ID)
+		if len(runs) != 2 {
+			t.Fatalf("выполнений %d, ожидалось 2", len(runs))
+		}
+		assertRun(t, runs[0], runStatusSkipped, 2, FailureInsufficientBalance)
+		if !runs[0].ScheduledFor.Equal(*created.NextRunAt) {
+			t.Errorf("повтор относится к %v, ожидалось %v", runs[0].ScheduledFor, *created.NextRunAt)
+		}
+		if st.Status != scheduleStatusActive || st.Occurrences != 1 || st.RetryCount != 0 || st.RetryAt != nil {
+			t.Errorf("после исчерпания повторов: %+v", st)
+		}
+		assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 100)
+	})
+
+	t.Run("закрытый счёт получателя приостанавливает перевод", func(t *testing.T) {
+		bank := newTestBank(t, nil)
+		scheduler := newTestScheduler(bank)
+		alice := bank.openAccount(t, "alice")
+		bob := bank.openAccount(t, "bob")
+
+		created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 10, nil)
+		if err := bank.store.Accounts().UpdateStatus(ctx, bob.ID, "closed"); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+		runScheduler(t, scheduler)
+
+		st, runs := getSchedule(t, scheduler, created.ID)
+		assertRun(t, runs[0], runStatusFailed, 1, FailureAccountClosed)
+		if st.Status != scheduleStatusPaused {
+			t.Errorf("статус %s, ожидался paused", st.Status)
+		}
+
+		failed, err := scheduler.ListFailed(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListFailed: %v", err)
+		}
+		if len(failed) != 1 || failed[0].ID != created.ID || failed[0].Error == nil || *failed[0].Error != FailureAccountClosed {
+			t.Errorf("неудачные переводы: %+v", failed)
+		}
+	})
+}
+
+// TestScheduledTransferChangedDuringRun проверяет, что пауза или отмена,
+// сделанные, пока выполнение уже взято в работу, не перезаписываются его
+// результатом, и перевод больше не выполняется
+func TestScheduledTransferChangedDuringRun(t *testing.T) {
+	ctx := context.Background()
+	paused := scheduleStatusPaused
+
+	tests := []struct {
+		name   string
+		change func(*ScheduledTransferService, string) error
+		status string
+	}{
+		{"пауза", func(s *ScheduledTransferService, id string) error {
+			_, err := s.Update(ctx, "alice", id, models.UpdateScheduledTransferRequest{Status: &paused})
+			return err
+		}, scheduleStatusPaused},
+		{"отмена", func(s *ScheduledTransferService, id string) error {
+			return s.Cancel(ctx, "alice", id)
+		}, scheduleStatusCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bank := newTestBank(t, nil)
+			scheduler := newTestScheduler(bank)
+			alice := bank.openAccount(t, "alice")
+			bob := bank.openAccount(t, "bob")
+			created := scheduleFromPast(t, scheduler, alice.ID, bob.ID, 10, nil)
+
+			pool := claimDue(scheduler)
+			if err := tt.change(scheduler, created.ID); err != nil {
+				t.Fatalf("изменение во время выполнения: %v", err)
+			}
+			runQueued(t, pool)
+
+			st, runs := getSchedule(t, scheduler, created.ID)
+			if st.Status != tt.status {
+				t.Errorf("статус %s, ожидался %s", st.Status, tt.status)
+			}
+			// Выполнение, взятое до изменения, завершается и попадает в историю
+			if len(runs) != 1 {
+				t.Fatalf("выполнений %d, ожидалось 1", len(runs))
+			}
+			assertRun(t, runs[0], runStatusSucceeded, 1, "")
+			assertMoney(t, "баланс отправителя", bank.balance(t, alice.ID), 89.9)
+
+			// Следующие проходы перевод не берут
+			past := time.Now().Add(-time.Second)
+			st.NextRunAt = &past
+			if err := bank.store.ScheduledTransfers().Update(ctx, st); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			runScheduler(t, scheduler)
+			if _, runs := getSchedule(t, scheduler, created.ID); len(runs) != 1 {
+				t.Errorf("после изменения выполнений %d, ожидалось 1", len(runs))
+			}
+			assertMoney(t, "баланс отправителя после прохода", bank.balance(t, alice.ID), 89.9)
+		})
+	}
+}
@@ -8,8 +8,11 @@ import (
 	"bank-prototype/internal/cache"
 	"bank-prototype/internal/models"
 	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
 	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -18,27 +21,18 @@ var (
 )
 
 type TransactionService struct {
-	transactionRepo *repository.TransactionRepository
-	accountRepo     *repository.AccountRepository
-	cache           *cache.RedisCache
+	transactionRepo repository.TransactionStore
+	accountRepo     repository.AccountStore
+	cache           cache.Cache
 	workerPool      *worker.WorkerPool
+	events          *eventNotifier
 }
 
+// NewTransactionService создаёт сервис транзакций; cache может быть nil
 func NewTransactionService(
-	transactionRepo *repository.TransactionRepository,
-	accountRepo *repository.AccountRepository,
-) *TransactionService {
-	return &TransactionService{
-		transactionRepo: transactionRepo,
-		accountRepo:     accountRepo,
-		cache:           nil,
-	}
-}
-
-func NewTransactionServiceWithCache(
-	transactionRepo *repository.TransactionRepository,
-	accountRepo *repository.AccountRepository,
-	cache *cache.RedisCache,
+	transactionRepo repository.TransactionStore,
+	accountRepo repository.AccountStore,
+	cache cache.Cache,
 ) *TransactionService {
 	return &TransactionService{
 		transactionRepo: transactionRepo,
@@ -53,12 +47,29 @@ func (s *TransactionService) SetWorkerPool(pool *worker.WorkerPool) {
 	utils.LogSuccess("TransactionService", "Worker Pool подключен к сервису транзакций")
 }
 
+// SetEventPublisher подключает публикацию событий о проведённых транзакциях
+func (s *TransactionService) SetEventPublisher(publisher EventPublisher) {
+	s.events = newEventNotifier(publisher, s.accountRepo)
+}
+
 func (s *TransactionService) Transfer(ctx context.Context, userID string, req models.TransferRequest) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Transfer",
+		attribute.String("account.from", req.FromAccountID),
+		attribute.String("account.to", req.ToAccountID),
+		attribute.Float64("amount", req.Amount),
+	)
+	defer span.End()
+
 	utils.LogInfo("TransactionService", fmt.Sprintf("Перевод от пользователя %s: %s → %s (сумма: %.2f)",
 		userID, req.FromAccountID, req.ToAccountID, req.Amount))
 
+	if existing, err := s.findExecuted(ctx, req.IdempotencyKey); existing != nil || err != nil {
+		return existing, err
+	}
+
 	if err := s.validateTransfer(ctx, userID, req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
 		utils.LogError("TransactionService", "Ошибка валидации перевода", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -76,14 +87,21 @@ func (s *TransactionService) Transfer(ctx context.Context, userID string, req mo
 		feeAmount,
 		1,
 		"transfer",
+		repository.TransferOptions{IdempotencyKey: req.IdempotencyKey, BatchItem: req.BatchItem},
 	)
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		// Параллельный повтор успел провести перевод раньше
+		return s.findExecuted(ctx, req.IdempotencyKey)
+	}
 
 	if err != nil {
 		utils.LogError("TransactionService", "Ошибка выполнения перевода", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
 	s.invalidateCacheAsync(ctx, req.FromAccountID, req.ToAccountID, transaction.ID)
+	s.events.transactionsCompleted(ctx, s.workerPool, transaction)
 
 	utils.LogSuccess("TransactionService", fmt.Sprintf("Перевод %s успешно выполнен", transaction.ID))
 
@@ -91,11 +109,23 @@ func (s *TransactionService) Transfer(ctx context.Context, userID string, req mo
 }
 
 func (s *TransactionService) Payment(ctx context.Context, userID string, req models.PaymentRequest) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.Payment",
+		attribute.String("account.from", req.FromAccountID),
+		attribute.String("account.to", req.ToAccountID),
+		attribute.Float64("amount", req.Amount),
+	)
+	defer span.End()
+
 	utils.LogInfo("TransactionService", fmt.Sprintf("Платёж от пользователя %s: %s → %s (сумма: %.2f)",
 		userID, req.FromAccountID, req.ToAccountID, req.Amount))
 
+	if existing, err := s.findExecuted(ctx, req.IdempotencyKey); existing != nil || err != nil {
+		return existing, err
+	}
+
 	if err := s.validateTransfer(ctx, userID, req.FromAccountID, req.ToAccountID, req.Amount); err != nil {
 		utils.LogError("TransactionService", "Ошибка валидации платежа", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -113,28 +143,57 @@ func (s *TransactionService) Payment(ctx context.Context, userID string, req mod
 		feeAmount,
 		3,
 		"payment",
+		repository.TransferOptions{IdempotencyKey: req.IdempotencyKey},
 	)
+	if errors.Is(err, repository.ErrDuplicateTransaction) {
+		return s.findExecuted(ctx, req.IdempotencyKey)
+	}
 
 	if err != nil {
 		utils.LogError("TransactionService", "Ошибка выполнения платежа", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
 	if s.cache != nil {
 		_ = s.cache.Delete(ctx,
-			cache.AccountBalanceKey(req.FromAccountID),
-			cache.AccountBalanceKey(req.ToAccountID),
-			cache.AccountBalanceKey(repository.SystemBankAccountID),
+			cache.AccountVersionKey(req.FromAccountID),
+			cache.AccountVersionKey(req.ToAccountID),
+			cache.AccountVersionKey(repository.SystemBankAccountID),
 		)
 		utils.LogInfo("Cache", fmt.Sprintf("Инвалидирован кеш балансов счетов: %s, %s, system", req.FromAccountID, req.ToAccountID))
 	}
+	s.events.transactionsCompleted(ctx, s.workerPool, transaction)
 
 	utils.LogSuccess("TransactionService", fmt.Sprintf("Платёж %s успешно выполнен", transaction.ID))
 
 	return transaction, nil
 }
 
+// findExecuted возвращает транзакцию, уже проведённую с ключом
+// идемпотентности key, или nil, если её нет. Повтор не проводится заново,
+// и события о нём не публикуются.
+func (s *TransactionService) findExecuted(ctx context.Context, key string) (*models.Transaction, error) {
+	if key == "" {
+		return nil, nil
+	}
+
+	transaction, err := s.transactionRepo.GetByIdempotencyKey(ctx, key)
+	if errors.Is(err, repository.ErrTransactionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("TransactionService", "Транзакция с ключом %s уже проведена: %s", key, transaction.ID)
+	return transaction, nil
+}
+
 func (s *TransactionService) GetTransactionHistory(ctx context.Context, userID string, accountID *string) ([]models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTransactionHistory", attribute.String("user.id", userID))
+	defer span.End()
+
 	if accountID != nil {
 		utils.LogInfo("TransactionService", fmt.Sprintf("Получение истории транзакций по счёту %s", *accountID))
 
@@ -151,6 +210,7 @@ func (s *TransactionService) GetTransactionHistory(ctx context.Context, userID s
 		transactions, err := s.transactionRepo.GetByAccountID(ctx, *accountID)
 		if err != nil {
 			utils.LogError("TransactionService", "Ошибка получения транзакций", err)
+			tracing.Fail(span, err)
 			return nil, err
 		}
 
@@ -163,6 +223,7 @@ func (s *TransactionService) GetTransactionHistory(ctx context.Context, userID s
 	transactions, err := s.transactionRepo.GetByUserID(ctx, userID)
 	if err != nil {
 		utils.LogError("TransactionService", "Ошибка получения транзакций пользователя", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -171,11 +232,15 @@ func (s *TransactionService) GetTransactionHistory(ctx context.Context, userID s
 }
 
 func (s *TransactionService) GetTransactionByID(ctx context.Context, userID, transactionID string) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.GetTransactionByID", attribute.String("transaction.id", transactionID))
+	defer span.End()
+
 	utils.LogInfo("TransactionService", fmt.Sprintf("Получение транзакции %s пользователем %s", transactionID, userID))
 
 	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
 	if err != nil {
 		utils.LogError("TransactionService", "Транзакция не найдена", err)
+		tracing.Fail(span, err)
 		return nil, err
 	}
 
@@ -199,11 +264,43 @@ func (s *TransactionService) GetTransactionByID(ctx context.Context, userID, tra
 		return nil, ErrUnauthorizedAccess
 	}
 
+	refunds, err := s.transactionRepo.GetByOriginalID(ctx, transactionID)
+	if err != nil {
+		utils.LogError("TransactionService", "Ошибка получения возвратов", err)
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	transaction.Refunds = refunds
+
 	utils.LogSuccess("TransactionService", fmt.Sprintf("Транзакция %s получена", transactionID))
 	return transaction, nil
 }
 
+// LookupTransaction возвращает транзакцию вместе с возвратами без проверки
+// владельца — для инструментов администратора
+func (s *TransactionService) LookupTransaction(ctx context.Context, transactionID string) (*models.Transaction, error) {
+	ctx, span := tracing.Start(ctx, "TransactionService.LookupTransaction", attribute.String("transaction.id", transactionID))
+	defer span.End()
+
+	transaction, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	refunds, err := s.transactionRepo.GetByOriginalID(ctx, transactionID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	transaction.Refunds = refunds
+
+	return transaction, nil
+}
+
 func (s *TransactionService) validateTransfer(ctx context.Context, userID, fromAccountID, toAccountID string, amount float64) error {
+	ctx, span := tracing.Start(ctx, "TransactionService.validateTransfer", attribute.String("account.from", fromAccountID))
+	defer span.End()
 
 	if amount <= 0 {
 		return ErrInvalidAmount
@@ -247,12 +344,13 @@ func (s *TransactionService) invalidateCacheAsync(ctx context.Context, fromAccou
 	// Если Worker Pool доступен, используем его для асинхронной обработки
 	if s.workerPool != nil {
 		job := worker.Job{
-			ID: fmt.Sprintf("cache-invalidate-%s", transactionID),
-			Task: func() error {
-				return s.cache.Delete(ctx,
-					cache.AccountBalanceKey(fromAccountID),
-					cache.AccountBalanceKey(toAccountID),
-					cache.AccountBalanceKey(repository.SystemBankAccountID),
+			ID:  fmt.Sprintf("cache-invalidate-%s", transactionID),
+			Ctx: ctx,
+			Task: func(jobCtx context.Context) error {
+				return s.cache.Delete(jobCtx,
+					cache.AccountVersionKey(fromAccountID),
+					cache.AccountVersionKey(toAccountID),
+					cache.AccountVersionKey(repository.SystemBankAccountID),
 				)
 			},
 		}
@@ -262,9 +360,9 @@ func (s *TransactionService) invalidateCacheAsync(ctx context.Context, fromAccou
 			// Если очередь переполнена, выполняем синхронно
 			utils.LogWarning("TransactionService", "Worker Pool переполнен, инвалидация кеша выполняется синхронно")
 			_ = s.cache.Delete(ctx,
-				cache.AccountBalanceKey(fromAccountID),
-				cache.AccountBalanceKey(toAccountID),
-				cache.AccountBalanceKey(repository.SystemBankAccountID),
+				cache.AccountVersionKey(fromAccountID),
+				cache.AccountVersionKey(toAccountID),
+				cache.AccountVersionKey(repository.SystemBankAccountID),
 			)
 		} else {
 			utils.LogDebug("TransactionService", "Инвалидация кеша добавлена в Worker Pool для транзакции %s", transactionID)
@@ -272,9 +370,9 @@ func (s *TransactionService) invalidateCacheAsync(ctx context.Context, fromAccou
 	} else {
 		// Если Worker Pool недоступен, выполняем синхронно
 		_ = s.cache.Delete(ctx,
-			cache.AccountBalanceKey(fromAccountID),
-			cache.AccountBalanceKey(toAccountID),
-			cache.AccountBalanceKey(repository.SystemBankAccountID),
+			cache.AccountVersionKey(fromAccountID),
+			cache.AccountVersionKey(toAccountID),
+			cache.AccountVersionKey(repository.SystemBankAccountID),
 		)
 		utils.LogInfo("Cache", fmt.Sprintf("Инвалидирован кеш балансов счетов: %s, %s, system", fromAccountID, toAccountID))
 	}
@@ -9,9 +9,7 @@ import (
 	"fmt"
 )
 
-func (s *TransactionService) CreateTransaction(userID string, req models.TransactionRequest) (*models.Transaction, error) {
-	ctx := context.Background()
-
+func (s *TransactionService) CreateTransaction(ctx context.Context, userID string, req models.TransactionRequest) (*models.Transaction, error) {
 	utils.LogInfo("TransactionService", fmt.Sprintf("Создание транзакции: тип=%s, от=%s, к=%s, сумма=%.2f",
 		req.Type, req.FromAccountID, req.ToAccountID, req.Amount))
 
@@ -49,7 +47,7 @@ func (s *TransactionService) CreateTransaction(userID string, req models.Transac
 }
 
 // CreateTransactionAsync - Асинхронное создание транзакции через Worker Pool
-func (s *TransactionService) CreateTransactionAsync(userID string, req models.TransactionRequest) error {
+func (s *TransactionService) CreateTransactionAsync(ctx context.Context, userID string, req models.TransactionRequest) error {
 	if s.workerPool == nil {
 		return errors.New("worker pool не инициализирован")
 	}
@@ -57,9 +55,10 @@ func (s *TransactionService) CreateTransactionAsync(userID string, req models.Tr
 	transactionID := fmt.Sprintf("tx-%s-%d", userID, worker.GetCurrentTimeMs())
 
 	job := worker.Job{
-		ID: transactionID,
-		Task: func() error {
-			_, err := s.CreateTransaction(userID, req)
+		ID:  transactionID,
+		Ctx: ctx,
+		Task: func(jobCtx context.Context) error {
+			_, err := s.CreateTransaction(jobCtx, userID, req)
 			return err
 		},
 	}
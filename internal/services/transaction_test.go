@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func TestTransferMovesMoneyAndFee(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	transaction, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+		FromAccountID: from.ID, ToAccountID: to.ID, Amount: 50,
+	})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	if transaction.Type != "transfer" || transaction.Status != "completed" || transaction.FeePercent != 1 {
+		t.Errorf("транзакция = %+v", transaction)
+	}
+	assertMoney(t, "комиссия", transaction.FeeAmount, 0.5)
+	assertMoney(t, "списано", transaction.TotalDebit, 50.5)
+
+	assertMoney(t, "баланс отправителя", bank.balance(t, from.ID), 49.5)
+	assertMoney(t, "баланс получателя", bank.balance(t, to.ID), 150)
+	assertMoney(t, "баланс системного счёта", bank.balance(t, repository.SystemBankAccountID), 0.5)
+
+	history, err := bank.transactions.GetTransactionHistory(ctx, "bob", &to.ID)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].ID != transaction.ID {
+		t.Errorf("история получателя = %+v", history)
+	}
+}
+
+func TestTransferValidation(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	alice := bank.openAccount(t, "alice")
+	bob := bank.openAccount(t, "bob")
+	closed := bank.openAccount(t, "bob")
+	if err := bank.accounts.DeleteAccount(ctx, closed.ID, "bob"); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	bank.store.PutAccount(models.Account{
+		ID: "13000000000001", UserID: "alice", Balance: 100, HeldAmount: 60, Status: "active",
+	})
+
+	tests := []struct {
+		name    string
+		userID  string
+		from    string
+		to      string
+		amount  float64
+		wantErr error
+	}{
+		{"нулевая сумма", "alice", alice.ID, bob.ID, 0, ErrInvalidAmount},
+		{"отрицательная сумма", "alice", alice.ID, bob.ID, -5, ErrInvalidAmount},
+		{"на свой же счёт", "alice", alice.ID, alice.ID, 10, ErrSelfTransfer},
+		{"с чужого счёта", "bob", alice.ID, bob.ID, 10, ErrUnauthorizedAccess},
+		{"несуществующий получатель", "alice", alice.ID, "13999999999999", 10, repository.ErrAccountNotFound},
+		{"на закрытый счёт", "alice", alice.ID, closed.ID, 10, repository.ErrAccountClosed},
+		{"с закрытого счёта", "bob", closed.ID, bob.ID, 10, repository.ErrAccountClosed},
+		{"не хватает с учётом комиссии", "alice", alice.ID, bob.ID, 100, repository.ErrInsufficientBalance},
+		{"средства под холдом", "alice", "13000000000001", bob.ID, 50, repository.ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bank.transactions.Transfer(ctx, tt.userID, models.TransferRequest{
+				FromAccountID: tt.from, ToAccountID: tt.to, Amount: tt.amount,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Transfer: %v, ожидалось %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Ни одна неудачная операция не изменила балансы
+	assertMoney(t, "баланс alice", bank.balance(t, alice.ID), 100)
+	assertMoney(t, "баланс bob", bank.balance(t, bob.ID), 100)
+	assertMoney(t, "баланс под холдом", bank.balance(t, "13000000000001"), 100)
+}
+
+func TestTransferIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	publisher := &recordingPublisher{}
+	bank := newTestBank(t, nil)
+	bank.transactions.SetEventPublisher(publisher)
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	req := models.TransferRequest{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10, IdempotencyKey: "scheduled:1"}
+	first, err := bank.transactions.Transfer(ctx, "alice", req)
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	published := len(publisher.events)
+
+	// Повтор после сбоя возвращает ту же транзакцию без второго списания и событий
+	again, err := bank.transactions.Transfer(ctx, "alice", req)
+	if err != nil {
+		t.Fatalf("повтор Transfer: %v", err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("повтор провёл новую транзакцию %s, ожидалась %s", again.ID, first.ID)
+	}
+	assertMoney(t, "баланс отправителя", bank.balance(t, from.ID), 89.9)
+	if got := len(publisher.events); got != published {
+		t.Errorf("событий после повтора: %d, ожидалось %d", got, published)
+	}
+
+	// Проводка с тем же ключом в обход сервиса отклоняется хранилищем
+	if _, err := bank.store.Transactions().ExecuteTransfer(ctx, from.ID, to.ID, 10, 0.1, 1, "transfer", repository.TransferOptions{IdempotencyKey: "scheduled:1"}); !errors.Is(err, repository.ErrDuplicateTransaction) {
+		t.Errorf("повторная проводка: %v, ожидалось ErrDuplicateTransaction", err)
+	}
+
+	// Другой ключ — новое выполнение
+	req.IdempotencyKey = "scheduled:2"
+	if next, err := bank.transactions.Transfer(ctx, "alice", req); err != nil || next.ID == first.ID {
+		t.Errorf("перевод с новым ключом: %+v, %v", next, err)
+	}
+	assertMoney(t, "баланс отправителя", bank.balance(t, from.ID), 79.8)
+}
+
+func TestConcurrentTransfersNeverOverdraw(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	const workers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+				FromAccountID: from.ID, ToAccountID: to.ID, Amount: 10,
+			})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if !errors.Is(err, repository.ErrInsufficientBalance) {
+				t.Errorf("Transfer: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 100 / 10.10 — проходят ровно 9 переводов
+	if succeeded != 9 {
+		t.Errorf("успешных переводов: %d, ожидалось 9", succeeded)
+	}
+	assertMoney(t, "баланс отправителя", bank.balance(t, from.ID), 100-9*10.1)
+	assertMoney(t, "баланс получателя", bank.balance(t, to.ID), 100+9*10)
+
+	total := bank.balance(t, from.ID) + bank.balance(t, to.ID) + bank.balance(t, repository.SystemBankAccountID)
+	assertMoney(t, "сумма балансов", total, 200)
+}
+
+func TestPaymentRefund(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	payer := bank.openAccount(t, "alice")
+	merchant := bank.openAccount(t, "shop")
+
+	payment, err := bank.transactions.Payment(ctx, "alice", models.PaymentRequest{
+		FromAccountID: payer.ID, ToAccountID: merchant.ID, Amount: 50,
+	})
+	if err != nil {
+		t.Fatalf("Payment: %v", err)
+	}
+	assertMoney(t, "комиссия платежа", payment.FeeAmount, 1.5)
+
+	if _, err := bank.transactions.Refund(ctx, "alice", payment.ID, models.RefundRequest{}); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("возврат плательщиком: %v, ожидалось ErrUnauthorizedAccess", err)
+	}
+	if _, err := bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{RefundFee: true}); !errors.Is(err, ErrFeeRefundForbidden) {
+		t.Errorf("возврат комиссии получателем: %v, ожидалось ErrFeeRefundForbidden", err)
+	}
+
+	tooMuch := 60.0
+	if _, err := bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{Amount: &tooMuch}); !errors.Is(err, repository.ErrRefundExceedsAmount) {
+		t.Errorf("возврат больше суммы: %v, ожидалось ErrRefundExceedsAmount", err)
+	}
+
+	// Доли копейки не округляются до пустого возврата
+	for _, amount := range []float64{0.001, 10.005} {
+		if _, err := bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{Amount: &amount}); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("возврат %v: %v, ожидалось ErrInvalidAmount", amount, err)
+		}
+		if _, err := bank.transactions.Reverse(ctx, "admin", payment.ID, models.RefundRequest{Amount: &amount}); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("сторнирование %v: %v, ожидалось ErrInvalidAmount", amount, err)
+		}
+	}
+
+	partial := 20.0
+	result, err := bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{Amount: &partial})
+	if err != nil {
+		t.Fatalf("частичный возврат: %v", err)
+	}
+	if result.Original.Status != "partially_refunded" {
+		t.Errorf("статус после частичного возврата = %s", result.Original.Status)
+	}
+	assertMoney(t, "баланс плательщика", bank.balance(t, payer.ID), 100-51.5+20)
+	assertMoney(t, "баланс получателя", bank.balance(t, merchant.ID), 130)
+
+	result, err = bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{})
+	if err != nil {
+		t.Fatalf("возврат остатка: %v", err)
+	}
+	if result.Original.Status != "refunded" {
+		t.Errorf("статус после полного возврата = %s", result.Original.Status)
+	}
+	assertMoney(t, "возвращено", result.Refund.Amount, 30)
+
+	if _, err := bank.transactions.Refund(ctx, "shop", payment.ID, models.RefundRequest{}); !errors.Is(err, repository.ErrTransactionNotRefundable) {
+		t.Errorf("повторный возврат: %v, ожидалось ErrTransactionNotRefundable", err)
+	}
+
+	transaction, err := bank.transactions.GetTransactionByID(ctx, "alice", payment.ID)
+	if err != nil {
+		t.Fatalf("GetTransactionByID: %v", err)
+	}
+	if len(transaction.Refunds) != 2 {
+		t.Errorf("возвратов в транзакции: %d, ожидалось 2", len(transaction.Refunds))
+	}
+
+	if _, err := bank.transactions.GetTransactionByID(ctx, "mallory", payment.ID); !errors.Is(err, ErrUnauthorizedAccess) {
+		t.Errorf("GetTransactionByID посторонним: %v, ожидалось ErrUnauthorizedAccess", err)
+	}
+}
+
+func TestReverseReturnsFee(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	transfer, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+		FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40,
+	})
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	if _, err := bank.transactions.Refund(ctx, "bob", transfer.ID, models.RefundRequest{}); !errors.Is(err, ErrRefundOnlyPayments) {
+		t.Errorf("Refund перевода: %v, ожидалось ErrRefundOnlyPayments", err)
+	}
+
+	result, err := bank.transactions.Reverse(ctx, "admin", transfer.ID, models.RefundRequest{RefundFee: true})
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if result.FeeRefund == nil {
+		t.Fatal("комиссия не возвращена")
+	}
+	assertMoney(t, "возврат комиссии", result.FeeRefund.Amount, 0.4)
+
+	assertMoney(t, "баланс отправителя", bank.balance(t, from.ID), 100)
+	assertMoney(t, "баланс получателя", bank.balance(t, to.ID), 100)
+	assertMoney(t, "баланс системного счёта", bank.balance(t, repository.SystemBankAccountID), 0)
+}
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, userID string, event *models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := userID + " " + event.Type
+	if event.Balance != nil {
+		entry += " " + event.Balance.AccountID + "=" + string(event.Balance.Balance)
+	}
+	p.events = append(p.events, entry)
+	return nil
+}
+
+func TestTransferPublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	bank := newTestBank(t, nil)
+	publisher := &recordingPublisher{}
+	bank.transactions.SetEventPublisher(publisher)
+	from := bank.openAccount(t, "alice")
+	to := bank.openAccount(t, "bob")
+
+	if _, err := bank.transactions.Transfer(ctx, "alice", models.TransferRequest{
+		FromAccountID: from.ID, ToAccountID: to.ID, Amount: 50,
+	}); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	// Без пула воркеров события публикуются сразу; о комиссии на
+	// системном счёте никто не уведомляется
+	want := []string{
+		"alice transaction.completed",
+		"alice balance.changed " + from.ID + "=49.50",
+		"bob transaction.completed",
+		"bob balance.changed " + to.ID + "=150.00",
+	}
+	if strings.Join(publisher.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("события:\n%s\nожидались:\n%s", strings.Join(publisher.events, "\n"), strings.Join(want, "\n"))
+	}
+}
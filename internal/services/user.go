@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/i18n"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+	"bank-prototype/internal/tracing"
+	"bank-prototype/internal/utils"
+	"bank-prototype/internal/worker"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrWrongPassword        = errors.New("неверный текущий пароль")
+	ErrInvalidEmail         = errors.New("неверный адрес электронной почты")
+	ErrInvalidPhone         = errors.New("неверный номер телефона")
+	ErrBalanceNotZero       = errors.New("на счетах пользователя остались средства")
+	ErrInvalidPayoutAccount = errors.New("остатки нельзя перевести на собственный счёт")
+)
+
+// phonePattern — номер в формате E.164: плюс, код страны и до 15 цифр
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// UserService — профиль текущего пользователя, смена пароля и удаление
+type UserService struct {
+	users       repository.UserStore
+	accountRepo repository.AccountStore
+	authService *AuthService
+	cache       cache.Cache
+	workerPool  *worker.WorkerPool
+	events      *eventNotifier
+}
+
+func NewUserService(users repository.UserStore, accountRepo repository.AccountStore, authService *AuthService, c cache.Cache, workerPool *worker.WorkerPool) *UserService {
+	return &UserService{
+		users:       users,
+		accountRepo: accountRepo,
+		authService: authService,
+		cache:       c,
+		workerPool:  workerPool,
+	}
+}
+
+// SetEventPublisher включает события о выплатах остатков при удалении
+func (s *UserService) SetEventPublisher(publisher EventPublisher) {
+	s.events = newEventNotifier(publisher, s.accountRepo)
+}
+
+// Profile возвращает профиль пользователя
+func (s *UserService) Profile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	ctx, span := tracing.Start(ctx, "UserService.Profile", attribute.String("user.id", userID))
+	defer span.End()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	return newUserProfile(user), nil
+}
+
+// UpdateProfile меняет заданные в req поля профиля. Email приводится к
+// нижнему регистру, из телефона убираются пробелы, дефисы и скобки.
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.UserProfile, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateProfile", attribute.String("user.id", userID))
+	defer span.End()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Email != nil {
+		if user.Email, err = normalizeEmail(*req.Email); err != nil {
+			return nil, err
+		}
+	}
+	if req.Phone != nil {
+		if user.Phone, err = normalizePhone(*req.Phone); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.users.UpdateProfile(ctx, user); err != nil {
+		if !errors.Is(err, repository.ErrEmailTaken) && !errors.Is(err, repository.ErrPhoneTaken) {
+			tracing.Fail(span, err)
+		}
+		return nil, err
+	}
+
+	utils.LogSuccess("UserService", "Профиль пользователя %s обновлён", userID)
+	return newUserProfile(user), nil
+}
+
+// ChangePassword меняет пароль после проверки текущего и завершает все
+// сессии пользователя. Возвращает токен новой сессии, чтобы клиент, сменивший
+// пароль, остался в системе.
+func (s *UserService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword", attribute.String("user.id", userID))
+	defer span.End()
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return "", err
+	}
+
+	if err := s.authService.CheckPasswordHash(currentPassword, user.PasswordHash); err != nil {
+		utils.LogWarning("UserService", "Неверный текущий пароль пользователя %s", userID)
+		return "", ErrWrongPassword
+	}
+	if newPassword == currentPassword {
+		return "", i18n.Errorf(ErrWeakPassword, "detail.password_unchanged")
+	}
+	if err := ValidatePassword(newPassword, user.Name); err != nil {
+		return "", err
+	}
+
+	passwordHash, err := s.authService.HashPassword(newPassword)
+	if err != nil {
+		tracing.Fail(span, err)
+		return "", err
+	}
+
+	user.SessionVersion, err = s.users.UpdatePassword(ctx, userID, passwordHash)
+	if err != nil {
+		tracing.Fail(span, err)
+		return "", err
+	}
+	s.authService.revokeSessions(ctx, userID)
+
+	token, err := s.authService.GenerateToken(user)
+	if err != nil {
+		tracing.Fail(span, err)
+		return "", fmt.Errorf("пароль изменён, но токен не выдан: %w", err)
+	}
+
+	utils.LogSuccess("UserService", "Пароль пользователя %s изменён, прежние сессии завершены", userID)
+	return token, nil
+}
+
+// DeleteUser удаляет пользователя без потери учётных данных банка: счета
+// закрываются, остатки переводятся на payoutAccountID (чужой счёт в банке)
+// в пределах лимитов расходов, учётная запись обезличивается, а транзакции
+// хранятся весь срок хранения (см. RetentionService). Без счёта выплаты все
+// балансы должны быть нулевыми. Выплата на внешний счёт не поддерживается.
+func (s *UserService) DeleteUser(ctx context.Context, userID, payoutAccountID string) (*models.UserDeletion, error) {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser", attribute.String("user.id", userID))
+	defer span.End()
+
+	utils.LogInfo("UserService", "Удаление пользователя %s", userID)
+
+	// Предварительная проверка даёт понятную ошибку; репозиторий повторяет
+	// её под блокировкой счетов
+	accounts, err := s.accountRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	var remaining float64
+	for _, account := range accounts {
+		switch {
+		case account.Status == "closed":
+			continue
+		case account.Status == "frozen":
+			utils.LogWarning("UserService", "Пользователь %s не удалён: счёт %s заморожен", userID, account.ID)
+			return nil, ErrAccountFrozen
+		case account.HeldAmount > 0:
+			utils.LogWarning("UserService", "Пользователь %s не удалён: на счёте %s зарезервировано %.2f", userID, account.ID, account.HeldAmount)
+			return nil, ErrAccountHasHolds
+		}
+		remaining += account.Balance
+	}
+
+	var payout *models.Account
+	if payoutAccountID != "" {
+		if payout, err = s.accountRepo.GetByID(ctx, payoutAccountID); err != nil {
+			return nil, err
+		}
+		if payout.UserID == userID {
+			return nil, ErrInvalidPayoutAccount
+		}
+	} else if remaining > 0 {
+		utils.LogWarning("UserService", "Пользователь %s не удалён: остаток %.2f без счёта выплаты", userID, remaining)
+		return nil, i18n.Errorf(ErrBalanceNotZero, "detail.balance_not_zero", remaining)
+	}
+
+	deletion, err := s.users.SoftDelete(ctx, userID, payoutAccountID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrAccountStatusChanged) && !errors.Is(err, repository.ErrAccountClosed) &&
+			!errors.Is(err, repository.ErrLimitExceeded) {
+			tracing.Fail(span, err)
+		}
+		return nil, err
+	}
+
+	s.authService.revokeSessions(ctx, userID)
+	if s.cache != nil {
+		keys := []string{cache.UserAccountsKey(userID)}
+		for _, accountID := range deletion.ClosedAccounts {
+			keys = append(keys, cache.AccountVersionKey(accountID))
+		}
+		if payout != nil {
+			keys = append(keys, cache.AccountVersionKey(payout.ID), cache.UserAccountsKey(payout.UserID))
+		}
+		if err := s.cache.Delete(ctx, keys...); err != nil {
+			utils.LogWarning("Cache", "Не удалось инвалидировать кеш: %v", err)
+		}
+	}
+
+	payouts := make([]*models.Transaction, len(deletion.Payouts))
+	for i := range deletion.Payouts {
+		payouts[i] = &deletion.Payouts[i]
+	}
+	s.events.transactionsCompleted(ctx, s.workerPool, payouts...)
+
+	utils.LogSuccess("UserService", "Пользователь %s удалён и обезличен (счетов закрыто: %d, выплат: %d)",
+		userID, len(deletion.ClosedAccounts), len(deletion.Payouts))
+	return deletion, nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", nil
+	}
+
+	// ParseAddress принимает и "Имя <a@b.c>", поэтому адрес должен совпасть целиком
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+func normalizePhone(phone string) (string, error) {
+	phone = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, phone)
+	if phone == "" {
+		return "", nil
+	}
+
+	if !phonePattern.MatchString(phone) {
+		return "", ErrInvalidPhone
+	}
+	return phone, nil
+}
+
+func newUserProfile(user *models.User) *models.UserProfile {
+	return &models.UserProfile{
+		ID:          user.ID,
+		Name:        user.Name,
+		DisplayName: user.DisplayName,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		Role:        user.Role,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bank-prototype/internal/cache"
+	"bank-prototype/internal/models"
+	"bank-prototype/internal/repository"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{"Integration-Pass-2024!", true},
+		{"correct horse battery 9", true},
+		{"Пароль-из-кириллицы-7", true},
+		{"short1", false},
+		{"onlyletterslong", false},
+		{"1234567890123", false},
+		{"Password1", false},
+		{"Qwerty2024!", false},
+		{"zaq12wsx", false},
+		{"xx-alice-2024", false},
+		{string(make([]byte, MaxPasswordBytes)) + "a1", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePassword(tt.password, "Alice")
+		if tt.ok && err != nil {
+			t.Errorf("ValidatePassword(%q) = %v, ожидался успех", tt.password, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("ValidatePassword(%q) = %v, ожидалось ErrWeakPassword", tt.password, err)
+		}
+	}
+}
+
+// newTestUsers создаёт пользователя alice в хранилище в памяти
+func newTestUsers(t *testing.T) (*UserService, *AuthService, *models.User) {
+	t.Helper()
+
+	users, auth, alice, _ := newTestUsersStore(t)
+	return users, auth, alice
+}
+
+func newTestUsersStore(t *testing.T) (*UserService, *AuthService, *models.User, *repository.MemoryStore) {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	c := cache.NewMemoryCache(100)
+	auth := NewAuthService("test-secret", time.Hour)
+	auth.SetUserStore(store.Users(), c)
+
+	hash, err := auth.HashPassword("Old-password-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Name: "alice", PasswordHash: hash}
+	if err := store.Users().Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return NewUserService(store.Users(), store.Accounts(), auth, c, nil), auth, user, store
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	users, _, alice := newTestUsers(t)
+
+	email, phone, name := " Alice@Example.COM ", "+7 (916) 123-45-67", "Алиса"
+	profile, err := users.UpdateProfile(ctx, alice.ID, models.UpdateProfileRequest{DisplayName: &name, Email: &email, Phone: &phone})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if profile.Email != "alice@example.com" || profile.Phone != "+79161234567" || profile.DisplayName != "Алиса" {
+		t.Fatalf("профиль: %+v", profile)
+	}
+
+	// Поле без значения не меняется, пустая строка очищает
+	empty := ""
+	profile, err = users.UpdateProfile(ctx, alice.ID, models.UpdateProfileRequest{Phone: &empty})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if profile.Phone != "" || profile.Email != "alice@example.com" {
+		t.Fatalf("после очистки телефона: %+v", profile)
+	}
+
+	for _, bad := range []string{"alice", "Alice <alice@example.com>", "alice@localhost"} {
+		if _, err := users.UpdateProfile(ctx, alice.ID, models.UpdateProfileRequest{Email: &bad}); !errors.Is(err, ErrInvalidEmail) {
+			t.Errorf("email %q: %v, ожидалось ErrInvalidEmail", bad, err)
+		}
+	}
+	for _, bad := range []string{"89161234567", "+0123456789", "+7916abc4567"} {
+		if _, err := users.UpdateProfile(ctx, alice.ID, models.UpdateProfileRequest{Phone: &bad}); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("телефон %q: %v, ожидалось ErrInvalidPhone", bad, err)
+		}
+	}
+
+	// Email уникален без учёта регистра
+	bob := &models.User{Name: "bob", PasswordHash: "-"}
+	if err := users.users.Create(ctx, bob); err != nil {
+		t.Fatal(err)
+	}
+	taken := "ALICE@example.com"
+	if _, err := users.UpdateProfile(ctx, bob.ID, models.UpdateProfileRequest{Email: &taken}); !errors.Is(err, repository.ErrEmailTaken) {
+		t.Errorf("занятый email: %v, ожидалось ErrEmailTaken", err)
+	}
+}
+
+func TestChangePasswordRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	users, auth, alice := newTestUsers(t)
+
+	oldToken, err := auth.GenerateToken(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.Authenticate(ctx, oldToken); err != nil {
+		t.Fatalf("Authenticate до смены пароля: %v", err)
+	}
+
+	if _, err := users.ChangePassword(ctx, alice.ID, "wrong-password-1", "New-password-2"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("неверный текущий пароль: %v, ожидалось ErrWrongPassword", err)
+	}
+	if _, err := users.ChangePassword(ctx, alice.ID, "Old-password-1", "password123"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("слабый пароль: %v, ожидалось ErrWeakPassword", err)
+	}
+	if _, err := users.ChangePassword(ctx, alice.ID, "Old-password-1", "Old-password-1"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("тот же пароль: %v, ожидалось ErrWeakPassword", err)
+	}
+
+	newToken, err := users.ChangePassword(ctx, alice.ID, "Old-password-1", "New-password-2")
+	if err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	if _, err := auth.Authenticate(ctx, oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("старый токен: %v, ожидалось ErrInvalidToken", err)
+	}
+	if claims, err := auth.Authenticate(ctx, newToken); err != nil || claims.UserID != alice.ID {
+		t.Errorf("новый токен: %+v, %v", claims, err)
+	}
+
+	user, err := users.users.GetByID(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.CheckPasswordHash("New-password-2", user.PasswordHash); err != nil {
+		t.Errorf("новый пароль не сохранён: %v", err)
+	}
+}
+
+func TestAuthenticateUsesCurrentRole(t *testing.T) {
+	ctx := context.Background()
+	_, auth, alice, store := newTestUsersStore(t)
+
+	if err := store.Users().SetRole(ctx, alice.Name, models.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := store.Users().GetByID(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.GenerateToken(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := auth.Authenticate(ctx, token); err != nil || claims.Role != models.RoleAdmin {
+		t.Fatalf("до смены роли: %+v, %v", claims, err)
+	}
+
+	// Роль снята: токен с ролью admin действует ещё сутки, но права
+	// пропадают, как только истекает запись в кеше сессий
+	if err := store.Users().SetRole(ctx, alice.Name, models.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := auth.Authenticate(ctx, token); err != nil || claims.Role != models.RoleAdmin {
+		t.Fatalf("в пределах TTL кеша: %+v, %v", claims, err)
+	}
+	if err := auth.cache.Delete(ctx, cache.UserSessionKey(alice.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := auth.Authenticate(ctx, token); err != nil || claims.Role != models.RoleUser {
+		t.Errorf("после истечения кеша: %+v, %v, ожидалась роль user", claims, err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	users, auth, alice, store := newTestUsersStore(t)
+
+	store.PutAccount(models.Account{ID: "10000000000001", UserID: alice.ID, Balance: 70, Status: "active"})
+	store.PutAccount(models.Account{ID: "10000000000002", UserID: alice.ID, Balance: 30, Status: "active"})
+	store.PutAccount(models.Account{ID: "20000000000001", UserID: "bob", Balance: 100, Status: "active"})
+
+	token, err := auth.GenerateToken(alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Без счёта выплаты остаток не даёт удалить пользователя
+	if _, err := users.DeleteUser(ctx, alice.ID, ""); !errors.Is(err, ErrBalanceNotZero) {
+		t.Fatalf("без счёта выплаты: %v, ожидалось ErrBalanceNotZero", err)
+	}
+	if _, err := users.DeleteUser(ctx, alice.ID, "10000000000002"); !errors.Is(err, ErrInvalidPayoutAccount) {
+		t.Fatalf("выплата на свой счёт: %v, ожидалось ErrInvalidPayoutAccount", err)
+	}
+
+	store.PutAccount(models.Account{ID: "10000000000002", UserID: alice.ID, Balance: 30, HeldAmount: 10, Status: "active"})
+	if _, err := users.DeleteUser(ctx, alice.ID, "20000000000001"); !errors.Is(err, ErrAccountHasHolds) {
+		t.Fatalf("счёт с холдом: %v, ожидалось ErrAccountHasHolds", err)
+	}
+	store.PutAccount(models.Account{ID: "10000000000002", UserID: alice.ID, Balance: 30, Status: "active"})
+
+	deletion, err := users.DeleteUser(ctx, alice.ID, "20000000000001")
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if len(deletion.Payouts) != 2 || len(deletion.ClosedAccounts) != 2 {
+		t.Fatalf("удаление: %+v", deletion)
+	}
+	for _, payout := range deletion.Payouts {
+		if payout.Type != "payout" || payout.ToAccountID != "20000000000001" {
+			t.Errorf("выплата: %+v", payout)
+		}
+	}
+
+	bob, err := store.Accounts().GetByID(ctx, "20000000000001")
+	if err != nil || bob.Balance != 200 {
+		t.Fatalf("счёт выплаты: %+v, %v", bob, err)
+	}
+	for _, id := range deletion.ClosedAccounts {
+		account, err := store.Accounts().GetByID(ctx, id)
+		if err != nil || account.Status != "closed" || account.Balance != 0 {
+			t.Errorf("счёт %s: %+v, %v", id, account, err)
+		}
+	}
+
+	if _, err := auth.Authenticate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("токен удалённого пользователя: %v, ожидалось ErrInvalidToken", err)
+	}
+	if _, err := users.DeleteUser(ctx, alice.ID, ""); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("повторное удаление: %v, ожидалось ErrUserNotFound", err)
+	}
+}
+
+// TestDeleteUserPayoutLimits проверяет, что выплата остатков на чужой счёт
+// ограничена лимитами расходов, как перевод, и отказ ничего не меняет
+func TestDeleteUserPayoutLimits(t *testing.T) {
+	ctx := context.Background()
+	users, _, alice, store := newTestUsersStore(t)
+
+	store.PutAccount(models.Account{ID: "10000000000001", UserID: alice.ID, Balance: 70, Status: "active"})
+	store.PutAccount(models.Account{ID: "10000000000002", UserID: alice.ID, Balance: 30, Status: "active"})
+	store.PutAccount(models.Account{ID: "20000000000001", UserID: "bob", Balance: 100, Status: "active"})
+
+	limit := func(v float64) *float64 { return &v }
+	count := func(v int) *int { return &v }
+	tests := []struct {
+		name   string
+		scope  string
+		owner  string
+		limits models.SpendingLimits
+	}{
+		{"лимит одной операции счёта", repository.LimitScopeAccount, "10000000000001", models.SpendingLimits{SingleMax: limit(50)}},
+		{"дневной лимит пользователя по сумме выплат", repository.LimitScopeUser, alice.ID, models.SpendingLimits{DailyMax: limit(90)}},
+		{"потолок банка по числу операций", repository.LimitScopeBank, "", models.SpendingLimits{DailyCountMax: count(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store.SetSpendingLimits(tt.scope, tt.owner, tt.limits)
+			defer store.SetSpendingLimits(tt.scope, tt.owner, models.SpendingLimits{})
+
+			if _, err := users.DeleteUser(ctx, alice.ID, "20000000000001"); !errors.Is(err, repository.ErrLimitExceeded) {
+				t.Fatalf("DeleteUser: %v, ожидалось ErrLimitExceeded", err)
+			}
+			for id, want := range map[string]float64{"10000000000001": 70, "10000000000002": 30, "20000000000001": 100} {
+				account, err := store.Accounts().GetByID(ctx, id)
+				if err != nil || account.Status != "active" || account.Balance != want {
+					t.Errorf("счёт %s после отказа: %+v, %v", id, account, err)
+				}
+			}
+		})
+	}
+
+	// В пределах лимитов удаление проходит
+	store.SetSpendingLimits(repository.LimitScopeUser, alice.ID, models.SpendingLimits{DailyMax: limit(100), DailyCountMax: count(2)})
+	deletion, err := users.DeleteUser(ctx, alice.ID, "20000000000001")
+	if err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if len(deletion.Payouts) != 2 {
+		t.Errorf("выплат: %d, ожидалось 2", len(deletion.Payouts))
+	}
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDContextKey — ключ идентификатора запроса в context.Context
+// вызова gRPC
+type requestIDContextKey struct{}
+
+// metadataCarrier адаптирует метаданные gRPC к propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UnaryServerInterceptor открывает серверный спан на каждый вызов gRPC —
+// аналог Middleware для HTTP. Контекст трассировки и x-request-id
+// берутся из метаданных.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, finish := startRPC(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	finish(err)
+	return resp, err
+}
+
+// StreamServerInterceptor — то же для потоковых вызовов
+func StreamServerInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, finish := startRPC(stream.Context(), info.FullMethod)
+	err := handler(srv, ServerStream(stream, ctx))
+	finish(err)
+	return err
+}
+
+// ServerStream подменяет контекст потока: обработчик и следующие
+// перехватчики получают ctx через stream.Context()
+func ServerStream(stream grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &serverStream{ServerStream: stream, ctx: ctx}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// RequestIDFromContext возвращает идентификатор вызова gRPC. Он уходит
+// клиенту в заголовке x-request-id и в подробностях ошибки.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// startRPC открывает спан вызова fullMethod (/bank.v1.AccountService/GetAccount)
+// и возвращает функцию, которая закрывает его с кодом ответа
+func startRPC(ctx context.Context, fullMethod string) (context.Context, func(error)) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	parent := otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	spanCtx, span := Tracer().Start(
+		parent,
+		"gRPC "+fullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		),
+	)
+
+	requestID := newRequestID(metadataCarrier(md).Get(RequestIDHeader), span)
+	spanCtx = context.WithValue(spanCtx, requestIDContextKey{}, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+	span.SetAttributes(attribute.String("rpc.request.id", requestID))
+
+	return spanCtx, func(err error) {
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		// Ошибками сервера считаются те же коды, что и в семантике OpenTelemetry;
+		// NotFound или InvalidArgument — ошибка клиента, спан остаётся успешным
+		switch code {
+		case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+	}
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
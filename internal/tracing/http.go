@@ -0,0 +1,147 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestContextKey — ключ, под которым в fasthttp.RequestCtx хранится
+// контекст со спаном запроса
+const requestContextKey = "trace_ctx"
+
+const (
+	// RequestIDHeader — заголовок с идентификатором запроса
+	RequestIDHeader = "X-Request-ID"
+
+	requestIDKey = "request_id"
+
+	// maxRequestIDLength ограничивает идентификатор, пришедший от клиента
+	maxRequestIDLength = 128
+)
+
+// requestHeaderCarrier адаптирует заголовки fasthttp к propagation.TextMapCarrier
+type requestHeaderCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c requestHeaderCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c requestHeaderCarrier) Set(key, value string) {
+	c.header.Set(key, value)
+}
+
+func (c requestHeaderCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range c.header.All() {
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+// Middleware открывает серверный спан на каждый HTTP-запрос.
+// Входящий контекст трассировки (traceparent) извлекается из заголовков.
+// Спан называется по методу, а после выбора маршрута — по его шаблону
+// (см. SetRoute): конкретный путь с идентификаторами есть только в url.path,
+// чтобы число имён спанов не росло с числом счетов.
+func Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		method := string(ctx.Method())
+		path := string(ctx.Path())
+
+		parent := otel.GetTextMapPropagator().Extract(ctx, requestHeaderCarrier{header: &ctx.Request.Header})
+
+		spanCtx, span := Tracer().Start(
+			parent,
+			"HTTP "+method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", method),
+				attribute.String("url.path", path),
+				attribute.String("client.address", ctx.RemoteIP().String()),
+			),
+		)
+		defer span.End()
+
+		ctx.SetUserValue(requestContextKey, spanCtx)
+
+		requestID := newRequestID(string(ctx.Request.Header.Peek(RequestIDHeader)), span)
+		ctx.SetUserValue(requestIDKey, requestID)
+		ctx.Response.Header.Set(RequestIDHeader, requestID)
+		span.SetAttributes(attribute.String("http.request.id", requestID))
+
+		next(ctx)
+
+		status := ctx.Response.StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if userID, ok := ctx.UserValue("user_id").(string); ok {
+			span.SetAttributes(attribute.String("enduser.id", userID))
+		}
+		if status >= fasthttp.StatusInternalServerError {
+			span.SetStatus(codes.Error, fasthttp.StatusMessage(status))
+		}
+	}
+}
+
+// SetRoute сообщает шаблон маршрута запроса, например /v1/accounts/{id}.
+// Его вызывает роутер, когда путь сопоставлен с маршрутом.
+func SetRoute(ctx *fasthttp.RequestCtx, route string) {
+	span := trace.SpanFromContext(Context(ctx))
+	span.SetName("HTTP " + string(ctx.Method()) + " " + route)
+	span.SetAttributes(attribute.String("http.route", route))
+}
+
+// Context возвращает контекст со спаном текущего HTTP-запроса.
+// Его нужно передавать в сервисы вместо самого RequestCtx, чтобы
+// спаны сервисов, SQL и Redis попадали в трейс запроса.
+func Context(ctx *fasthttp.RequestCtx) context.Context {
+	if spanCtx, ok := ctx.UserValue(requestContextKey).(context.Context); ok {
+		return spanCtx
+	}
+	return ctx
+}
+
+// RequestID возвращает идентификатор текущего HTTP-запроса. Он попадает
+// в заголовок X-Request-ID ответа и в тела ошибок, по нему запрос
+// находится в журнале и трейсах.
+func RequestID(ctx *fasthttp.RequestCtx) string {
+	requestID, _ := ctx.UserValue(requestIDKey).(string)
+	return requestID
+}
+
+// newRequestID берёт X-Request-ID клиента или балансировщика, иначе
+// идентификатор трейса, чтобы запрос и трейс находились по одному значению.
+// Без трейсинга идентификатор генерируется.
+func newRequestID(incoming string, span trace.Span) string {
+	if validRequestID(incoming) {
+		return incoming
+	}
+	if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return uuid.NewString()
+}
+
+// validRequestID допускает только печатные ASCII-символы, чтобы значение
+// клиента нельзя было использовать для подделки строк журнала
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+var _ propagation.TextMapCarrier = requestHeaderCarrier{}
@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddlewareSpanName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	routed := Middleware(func(ctx *fasthttp.RequestCtx) {
+		SetRoute(ctx, "/v1/accounts/{id}")
+	})
+	unrouted := Middleware(func(ctx *fasthttp.RequestCtx) {})
+
+	for _, handler := range []fasthttp.RequestHandler{routed, unrouted} {
+		var ctx fasthttp.RequestCtx
+		ctx.Request.Header.SetMethod("GET")
+		ctx.Request.SetRequestURI("/v1/accounts/13579246801234")
+		handler(&ctx)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("спанов: %d, ожидалось 2", len(spans))
+	}
+	// В имени — шаблон маршрута, конкретный путь — только в атрибуте
+	if got := spans[0].Name(); got != "HTTP GET /v1/accounts/{id}" {
+		t.Errorf("имя спана: %q", got)
+	}
+	if got := spans[1].Name(); got != "HTTP GET" {
+		t.Errorf("имя спана без маршрута: %q", got)
+	}
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "url.path" && attr.Value.AsString() != "/v1/accounts/13579246801234" {
+			t.Errorf("url.path = %q", attr.Value.AsString())
+		}
+	}
+}
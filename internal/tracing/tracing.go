@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"bank-prototype/internal/utils"
+)
+
+const instrumentationName = "bank-prototype"
+
+// Init настраивает глобальный TracerProvider.
+//
+// Экспортёр выбирается переменной OTEL_TRACES_EXPORTER:
+//   - "otlp"   — отправка по OTLP/HTTP (адрес берётся из OTEL_EXPORTER_OTLP_ENDPOINT);
+//   - "stdout" — вывод спанов в консоль, удобно локально и в тестах;
+//   - "none"   — трейсинг выключен.
+//
+// Если переменная не задана, используется OTLP при заданном
+// OTEL_EXPORTER_OTLP_ENDPOINT, иначе трейсинг выключен.
+// Возвращает функцию, которую нужно вызвать при остановке сервиса.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporterName := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER"))
+	if exporterName == "" {
+		exporterName = "none"
+		if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+			exporterName = "otlp"
+		}
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch exporterName {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none":
+		utils.LogInfo("Tracing", "Трейсинг выключен (OTEL_TRACES_EXPORTER=none)")
+		return func(context.Context) error { return nil }, nil
+	default:
+		utils.LogWarning("Tracing", "Неизвестный экспортёр %q, трейсинг выключен", exporterName)
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(newResource(serviceName)),
+	)
+	otel.SetTracerProvider(provider)
+
+	utils.LogSuccess("Tracing", "Трейсинг включён (экспортёр: %s)", exporterName)
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трейсер приложения
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start открывает дочерний спан с указанными атрибутами
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail отмечает спан как завершившийся ошибкой
+func Fail(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func newResource(serviceName string) *resource.Resource {
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", serviceName)),
+	)
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
@@ -7,13 +7,18 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"bank-prototype/internal/tracing"
 	"bank-prototype/internal/utils"
 )
 
 // Job представляет задачу для выполнения
 type Job struct {
 	ID      string
-	Task    func() error
+	Ctx     context.Context // Контекст источника задачи: из него берётся трейс для связи спанов
+	Task    func(context.Context) error
 	RetryOn func(error) bool // Функция для определения, нужна ли повторная попытка
 	OnDone  func(error)      // Callback после завершения
 }
@@ -37,6 +42,7 @@ type PoolStats struct {
 	FailedJobs    int64
 	ActiveWorkers int
 	QueuedJobs    int
+	QueueCapacity int
 }
 
 func NewWorkerPool(workers int, queueSize int, maxRetries int) *WorkerPool {
@@ -50,6 +56,7 @@ func NewWorkerPool(workers int, queueSize int, maxRetries int) *WorkerPool {
 		maxRetries: maxRetries,
 		stats: PoolStats{
 			ActiveWorkers: workers,
+			QueueCapacity: queueSize,
 		},
 	}
 
@@ -102,6 +109,24 @@ func (p *WorkerPool) executeJob(workerID int, job Job) {
 	startTime := time.Now()
 	var err error
 
+	// Спан задачи начинает новый трейс и ссылается на спан запроса,
+	// который поставил задачу в очередь: к моменту выполнения он уже завершён
+	spanOpts := []trace.SpanStartOption{
+		trace.WithNewRoot(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("worker.job.id", job.ID),
+			attribute.Int("worker.id", workerID),
+		),
+	}
+	if job.Ctx != nil {
+		if link := trace.LinkFromContext(job.Ctx); link.SpanContext.IsValid() {
+			spanOpts = append(spanOpts, trace.WithLinks(link))
+		}
+	}
+	jobCtx, span := tracing.Tracer().Start(p.ctx, "worker.job", spanOpts...)
+	defer span.End()
+
 	// Попытки выполнения с повторами
 	for attempt := 0; attempt <= p.maxRetries; attempt++ {
 		if attempt > 0 {
@@ -109,7 +134,8 @@ func (p *WorkerPool) executeJob(workerID int, job Job) {
 			time.Sleep(time.Millisecond * time.Duration(100*attempt)) // Экспоненциальная задержка
 		}
 
-		err = job.Task()
+		span.SetAttributes(attribute.Int("worker.job.attempt", attempt+1))
+		err = job.Task(jobCtx)
 
 		if err == nil {
 			// Успешное выполнение
@@ -136,6 +162,7 @@ func (p *WorkerPool) executeJob(workerID int, job Job) {
 	p.mu.Unlock()
 
 	duration := time.Since(startTime)
+	tracing.Fail(span, err)
 	utils.LogError("WorkerPool", fmt.Sprintf("Воркер #%d: задача %s провалилась после %v", workerID, job.ID, duration), err)
 
 	if job.OnDone != nil {
@@ -210,6 +237,14 @@ func (p *WorkerPool) GetStats() PoolStats {
 	return stats
 }
 
+// Saturation возвращает заполненность очереди от 0 до 1
+func (s PoolStats) Saturation() float64 {
+	if s.QueueCapacity == 0 {
+		return 0
+	}
+	return float64(s.QueuedJobs) / float64(s.QueueCapacity)
+}
+
 // updateStats обновляет статистику пула
 func (p *WorkerPool) updateStats(completed int64, queued int) {
 	p.mu.Lock()
@@ -1,3 +1,29 @@
-DROP TABLE IF EXISTS transactions;
-DROP TABLE IF EXISTS accounts;
+-- Откат возможен, только пока все номера счетов остаются UUID: счета с
+-- 14-значными номерами, открытые после миграции, не преобразуются, и
+-- миграция завершится ошибкой вместо потери данных.
+DROP INDEX IF EXISTS idx_accounts_status;
+DROP INDEX IF EXISTS idx_accounts_user_id;
 
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+
+ALTER TABLE transactions DROP CONSTRAINT transactions_from_account_id_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_to_account_id_fkey;
+ALTER TABLE transactions DROP CONSTRAINT transactions_fee_account_id_fkey;
+
+UPDATE accounts SET id = '00000000-0000-0000-0000-000000000001' WHERE id = '00000000000001';
+UPDATE transactions SET from_account_id = '00000000-0000-0000-0000-000000000001' WHERE from_account_id = '00000000000001';
+UPDATE transactions SET to_account_id = '00000000-0000-0000-0000-000000000001' WHERE to_account_id = '00000000000001';
+UPDATE transactions SET fee_account_id = '00000000-0000-0000-0000-000000000001' WHERE fee_account_id = '00000000000001';
+
+ALTER TABLE accounts ALTER COLUMN id TYPE UUID USING id::uuid;
+ALTER TABLE accounts ALTER COLUMN id SET DEFAULT uuid_generate_v4();
+
+ALTER TABLE transactions
+    ALTER COLUMN from_account_id TYPE UUID USING from_account_id::uuid,
+    ALTER COLUMN to_account_id TYPE UUID USING to_account_id::uuid,
+    ALTER COLUMN fee_account_id TYPE UUID USING fee_account_id::uuid;
+
+ALTER TABLE transactions
+    ADD CONSTRAINT transactions_from_account_id_fkey FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+    ADD CONSTRAINT transactions_to_account_id_fkey FOREIGN KEY (to_account_id) REFERENCES accounts(id),
+    ADD CONSTRAINT transactions_fee_account_id_fkey FOREIGN KEY (fee_account_id) REFERENCES accounts(id);
@@ -0,0 +1,49 @@
+-- Регулярные (запланированные) переводы и платежи
+CREATE TABLE scheduled_transfers (
+                                     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     type TEXT NOT NULL CHECK (type IN ('transfer', 'payment')),
+                                     from_account_id TEXT NOT NULL REFERENCES accounts(id),
+                                     to_account_id TEXT NOT NULL REFERENCES accounts(id),
+                                     amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+                                     -- Расписание: либо cron-выражение, либо интервал в секундах
+                                     cron_expression TEXT,
+                                     interval_seconds BIGINT CHECK (interval_seconds >= 60),
+                                     start_at TIMESTAMPTZ NOT NULL,
+                                     end_at TIMESTAMPTZ,
+                                     max_occurrences INT CHECK (max_occurrences > 0),
+                                     occurrences INT NOT NULL DEFAULT 0,
+                                     -- Время очередного запланированного выполнения
+                                     next_run_at TIMESTAMPTZ,
+                                     -- Поведение при нехватке средств: пропустить выполнение или повторить позже
+                                     on_insufficient_funds TEXT NOT NULL DEFAULT 'skip' CHECK (on_insufficient_funds IN ('skip', 'retry')),
+                                     retry_interval_seconds INT NOT NULL DEFAULT 3600 CHECK (retry_interval_seconds >= 60),
+                                     max_retries INT NOT NULL DEFAULT 3 CHECK (max_retries >= 0),
+                                     retry_count INT NOT NULL DEFAULT 0,
+                                     retry_at TIMESTAMPTZ,
+                                     -- Аренда выполнения: защищает от повторного запуска на нескольких экземплярах API
+                                     locked_until TIMESTAMPTZ,
+                                     status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'completed', 'cancelled')),
+                                     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                     updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                     CHECK ((cron_expression IS NULL) <> (interval_seconds IS NULL)),
+                                     CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_scheduled_transfers_user_id ON scheduled_transfers(user_id);
+CREATE INDEX idx_scheduled_transfers_due ON scheduled_transfers((COALESCE(retry_at, next_run_at))) WHERE status = 'active';
+
+
+-- История выполнений регулярных переводов
+CREATE TABLE scheduled_transfer_runs (
+                                         id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                         scheduled_transfer_id UUID NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+                                         scheduled_for TIMESTAMPTZ NOT NULL,
+                                         attempt INT NOT NULL DEFAULT 1,
+                                         status TEXT NOT NULL CHECK (status IN ('succeeded', 'failed', 'skipped', 'retry_scheduled')),
+                                         transaction_id UUID REFERENCES transactions(id),
+                                         error TEXT,
+                                         executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scheduled_transfer_runs_schedule ON scheduled_transfer_runs(scheduled_transfer_id, executed_at DESC);
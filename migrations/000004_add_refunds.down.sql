@@ -0,0 +1,19 @@
+DELETE FROM transactions WHERE type IN ('refund', 'reversal', 'fee_refund');
+
+DROP INDEX IF EXISTS idx_tx_original;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_refund_limit_check;
+ALTER TABLE transactions DROP COLUMN IF EXISTS refunded_fee_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS original_transaction_id;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+UPDATE transactions SET status = 'completed' WHERE status IN ('partially_refunded', 'refunded');
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_fee_percent_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_fee_percent_check CHECK (fee_percent IN (1, 3));
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (type IN ('transfer', 'payment'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
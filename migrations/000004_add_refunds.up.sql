@@ -0,0 +1,26 @@
+-- Роли пользователей: администраторы могут сторнировать любые транзакции
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+-- Компенсирующие транзакции:
+--   refund     — возврат получателем платежа плательщику
+--   reversal   — сторнирование транзакции администратором
+--   fee_refund — возврат комиссии с системного счёта
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('transfer', 'payment', 'refund', 'reversal', 'fee_refund'));
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_fee_percent_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_fee_percent_check
+    CHECK (fee_percent IN (0, 1, 3));
+
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('pending', 'completed', 'partially_refunded', 'refunded'));
+
+-- Связь компенсирующей транзакции с исходной и учёт уже возвращённых сумм
+ALTER TABLE transactions ADD COLUMN original_transaction_id UUID REFERENCES transactions(id);
+ALTER TABLE transactions ADD COLUMN refunded_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN refunded_fee_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD CONSTRAINT transactions_refund_limit_check
+    CHECK (refunded_amount <= amount AND refunded_fee_amount <= fee_amount);
+
+CREATE INDEX idx_tx_original ON transactions(original_transaction_id) WHERE original_transaction_id IS NOT NULL;
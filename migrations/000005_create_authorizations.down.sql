@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS authorizations;
+
+ALTER TABLE accounts DROP COLUMN IF EXISTS held_amount;
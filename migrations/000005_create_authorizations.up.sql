@@ -0,0 +1,26 @@
+-- Сумма, зарезервированная активными авторизациями.
+-- Доступный остаток счёта = balance - held_amount
+ALTER TABLE accounts ADD COLUMN held_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (held_amount >= 0);
+
+-- Авторизации (холды): резервирование средств под платёж с последующим
+-- списанием (capture), отменой (release) или истечением срока (expired)
+CREATE TABLE authorizations (
+                                id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                from_account_id TEXT NOT NULL REFERENCES accounts(id),
+                                to_account_id TEXT NOT NULL REFERENCES accounts(id),
+                                -- Сумма платежа и зарезервированная сумма (платёж + комиссия 3%)
+                                amount DECIMAL(15,2) NOT NULL CHECK (amount > 0),
+                                held_amount DECIMAL(15,2) NOT NULL CHECK (held_amount >= amount),
+                                captured_amount DECIMAL(15,2) NOT NULL DEFAULT 0 CHECK (captured_amount <= amount),
+                                transaction_id UUID REFERENCES transactions(id),
+                                status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'captured', 'released', 'expired')),
+                                expires_at TIMESTAMPTZ NOT NULL,
+                                created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                CHECK (from_account_id <> to_account_id)
+);
+
+CREATE INDEX idx_authorizations_user_id ON authorizations(user_id);
+CREATE INDEX idx_authorizations_from_account ON authorizations(from_account_id);
+CREATE INDEX idx_authorizations_expires ON authorizations(expires_at) WHERE status = 'active';
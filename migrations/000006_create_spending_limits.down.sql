@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tx_from_created;
+DROP TABLE IF EXISTS spending_limits;
@@ -0,0 +1,32 @@
+-- Лимиты расходов. Строка scope = 'bank' задаёт потолки банка, выше которых
+-- пользователь не может поднять свои лимиты; строки 'user' и 'account' —
+-- лимиты, установленные пользователем. NULL — собственный лимит не задан.
+CREATE TABLE spending_limits (
+                                 id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                 scope TEXT NOT NULL CHECK (scope IN ('bank', 'user', 'account')),
+                                 user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                 account_id TEXT REFERENCES accounts(id),
+                                 -- Максимальная сумма одной операции
+                                 single_max DECIMAL(15,2) CHECK (single_max > 0),
+                                 -- Исходящие операции за текущие сутки и месяц (UTC)
+                                 daily_max DECIMAL(15,2) CHECK (daily_max > 0),
+                                 monthly_max DECIMAL(15,2) CHECK (monthly_max > 0),
+                                 daily_count_max INT CHECK (daily_count_max > 0),
+                                 updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                 CHECK (
+                                     (scope = 'bank' AND user_id IS NULL AND account_id IS NULL) OR
+                                     (scope = 'user' AND user_id IS NOT NULL AND account_id IS NULL) OR
+                                     (scope = 'account' AND user_id IS NULL AND account_id IS NOT NULL)
+                                 )
+);
+
+CREATE UNIQUE INDEX idx_spending_limits_bank ON spending_limits(scope) WHERE scope = 'bank';
+CREATE UNIQUE INDEX idx_spending_limits_user ON spending_limits(user_id) WHERE scope = 'user';
+CREATE UNIQUE INDEX idx_spending_limits_account ON spending_limits(account_id) WHERE scope = 'account';
+
+-- Для подсчёта исходящих операций счёта за период
+CREATE INDEX idx_tx_from_created ON transactions(from_account_id, created_at);
+
+-- Потолки банка по умолчанию
+INSERT INTO spending_limits (scope, single_max, daily_max, monthly_max, daily_count_max)
+VALUES ('bank', 50000.00, 100000.00, 500000.00, 50);
@@ -0,0 +1,30 @@
+-- Пакетные переводы (выплаты зарплаты и т.п.) с одного счёта
+CREATE TABLE transfer_batches (
+                                  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  from_account_id TEXT NOT NULL REFERENCES accounts(id),
+                                  -- all_or_nothing — все переводы в одной DB-транзакции, best_effort — каждый отдельно
+                                  mode TEXT NOT NULL CHECK (mode IN ('all_or_nothing', 'best_effort')),
+                                  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'partially_completed', 'failed')),
+                                  total_items INT NOT NULL CHECK (total_items > 0),
+                                  succeeded_items INT NOT NULL DEFAULT 0,
+                                  failed_items INT NOT NULL DEFAULT 0,
+                                  total_amount DECIMAL(15,2) NOT NULL,
+                                  error TEXT,
+                                  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                  completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_transfer_batches_user_id ON transfer_batches(user_id);
+
+CREATE TABLE transfer_batch_items (
+                                      batch_id UUID NOT NULL REFERENCES transfer_batches(id) ON DELETE CASCADE,
+                                      item_index INT NOT NULL,
+                                      to_account_id TEXT NOT NULL,
+                                      amount DECIMAL(15,2) NOT NULL,
+                                      reference TEXT,
+                                      status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+                                      transaction_id UUID REFERENCES transactions(id),
+                                      error TEXT,
+                                      PRIMARY KEY (batch_id, item_index)
+);
@@ -0,0 +1,26 @@
+-- Отчёты сверки балансов счетов с историей транзакций
+CREATE TABLE reconciliation_reports (
+                                        id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                        -- Кто запустил сверку: scheduler, admin или cli
+                                        triggered_by TEXT NOT NULL,
+                                        status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'completed', 'failed')),
+                                        accounts_checked INT NOT NULL DEFAULT 0,
+                                        discrepancy_count INT NOT NULL DEFAULT 0,
+                                        -- Сумма расхождений (фактический баланс минус ожидаемый) по всем счетам
+                                        total_difference DECIMAL(15,2) NOT NULL DEFAULT 0,
+                                        error TEXT,
+                                        started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+                                        finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_reconciliation_reports_started ON reconciliation_reports(started_at DESC);
+
+CREATE TABLE reconciliation_discrepancies (
+                                              report_id UUID NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+                                              account_id TEXT NOT NULL REFERENCES accounts(id),
+                                              account_status TEXT NOT NULL,
+                                              expected_balance DECIMAL(15,2) NOT NULL,
+                                              actual_balance DECIMAL(15,2) NOT NULL,
+                                              difference DECIMAL(15,2) NOT NULL,
+                                              PRIMARY KEY (report_id, account_id)
+);
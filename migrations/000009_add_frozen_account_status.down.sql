@@ -0,0 +1,4 @@
+UPDATE accounts SET status = 'active' WHERE status = 'frozen';
+
+ALTER TABLE accounts DROP CONSTRAINT accounts_status_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_status_check CHECK (status IN ('active', 'closed'));
@@ -0,0 +1,3 @@
+-- Заморозка счёта администратором: операции по счёту запрещены, баланс сохраняется
+ALTER TABLE accounts DROP CONSTRAINT accounts_status_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_status_check CHECK (status IN ('active', 'frozen', 'closed'));
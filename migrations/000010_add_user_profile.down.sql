@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS users_phone_key;
+DROP INDEX IF EXISTS users_email_key;
+
+ALTER TABLE users DROP COLUMN session_version;
+ALTER TABLE users DROP COLUMN updated_at;
+ALTER TABLE users DROP COLUMN phone;
+ALTER TABLE users DROP COLUMN email;
+ALTER TABLE users DROP COLUMN display_name;
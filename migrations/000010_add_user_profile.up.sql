@@ -0,0 +1,14 @@
+-- Профиль пользователя: отображаемое имя и контакты. Email хранится в
+-- нижнем регистре, телефон — в формате E.164, поэтому уникальность
+-- проверяется простым индексом.
+ALTER TABLE users ADD COLUMN display_name TEXT;
+ALTER TABLE users ADD COLUMN email TEXT;
+ALTER TABLE users ADD COLUMN phone TEXT;
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- Версия сессий: входит в JWT, при смене пароля увеличивается, и токены,
+-- выданные раньше, перестают приниматься
+ALTER TABLE users ADD COLUMN session_version INT NOT NULL DEFAULT 0;
+
+CREATE UNIQUE INDEX users_email_key ON users(email) WHERE email IS NOT NULL;
+CREATE UNIQUE INDEX users_phone_key ON users(phone) WHERE phone IS NOT NULL;
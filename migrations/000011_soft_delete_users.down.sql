@@ -0,0 +1,11 @@
+ALTER TABLE accounts DROP COLUMN purged_balance;
+
+UPDATE transactions SET type = 'transfer' WHERE type = 'payout';
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('transfer', 'payment', 'refund', 'reversal', 'fee_refund'));
+
+DROP INDEX IF EXISTS idx_users_deleted;
+
+ALTER TABLE users DROP COLUMN deleted_at;
@@ -0,0 +1,16 @@
+-- Мягкое удаление пользователей. Удалённый пользователь обезличивается
+-- (имя заменяется на deleted-<id>, контакты и пароль стираются), счета
+-- закрываются, а история транзакций хранится весь срок хранения
+-- (DATA_RETENTION_PERIOD) и только потом удаляется.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deleted ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- payout — перевод остатков со счетов удаляемого пользователя на счёт выплаты
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('transfer', 'payment', 'refund', 'reversal', 'fee_refund', 'payout'));
+
+-- Итог транзакций счёта, удалённых по истечении срока хранения: сверка
+-- балансов прибавляет его к ожидаемому балансу вместо удалённой истории
+ALTER TABLE accounts ADD COLUMN purged_balance DECIMAL(15,2) NOT NULL DEFAULT 0;
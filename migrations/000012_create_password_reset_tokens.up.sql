@@ -0,0 +1,12 @@
+-- Одноразовые токены сброса пароля. Хранится только SHA-256 токена:
+-- утечка таблицы не даёт сбросить чужой пароль
+CREATE TABLE password_reset_tokens (
+                                       id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                       user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                       token_hash TEXT NOT NULL UNIQUE,
+                                       expires_at TIMESTAMPTZ NOT NULL,
+                                       used_at TIMESTAMPTZ,
+                                       created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user ON password_reset_tokens(user_id, created_at);
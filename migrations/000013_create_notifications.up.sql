@@ -0,0 +1,28 @@
+-- Настройки уведомлений пользователя: каналы доставки, виды уведомлений,
+-- порог низкого остатка и язык. Нет строки — действуют значения по умолчанию
+CREATE TABLE notification_preferences (
+                                          user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                          email_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+                                          sms_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+                                          inbox_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+                                          incoming_transfer BOOLEAN NOT NULL DEFAULT TRUE,
+                                          low_balance BOOLEAN NOT NULL DEFAULT TRUE,
+                                          low_balance_threshold DECIMAL(15,2) NOT NULL DEFAULT 10 CHECK (low_balance_threshold >= 0),
+                                          scheduled_transfer_failed BOOLEAN NOT NULL DEFAULT TRUE,
+                                          language TEXT NOT NULL DEFAULT 'ru' CHECK (language IN ('ru', 'en')),
+                                          updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Уведомления в приложении (входящие)
+CREATE TABLE notifications (
+                               id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                               user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               kind TEXT NOT NULL,
+                               subject TEXT NOT NULL,
+                               body TEXT NOT NULL,
+                               read_at TIMESTAMPTZ,
+                               created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
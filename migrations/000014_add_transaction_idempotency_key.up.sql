@@ -0,0 +1,7 @@
+-- Ключ идемпотентности проводки. Его задают внутренние вызовы, которые могут
+-- повториться после сбоя: выполнение регулярного перевода и позиция пакета.
+-- Повторная проводка с тем же ключом нарушает уникальность и откатывается,
+-- поэтому деньги списываются не больше одного раза.
+ALTER TABLE transactions ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX idx_transactions_idempotency_key ON transactions(idempotency_key) WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,9 @@
+// Package migrations содержит SQL-миграции схемы базы данных. Файлы
+// встраиваются в бинарник, поэтому для запуска API и утилит каталог
+// migrations рядом с исполняемым файлом не нужен.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS